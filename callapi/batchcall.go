@@ -0,0 +1,375 @@
+package callapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	ethereum "github.com/fsn-dev/fsn-go-sdk/efsn"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common/hexutil"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/ethclient"
+)
+
+// DefaultRPCBatchSize default max requests in one batch call
+const DefaultRPCBatchSize = 100
+
+var errBatchRejected = errors.New("batch request rejected by server")
+
+// BatchRequest json rpc request in batch call
+type BatchRequest struct {
+	Method string
+	Params []interface{}
+
+	// do the request by the dialed client on gateways which are not http,
+	// nil means the request is only sent to http gateways
+	clientCall func(ctx context.Context, client *ethclient.Client) (interface{}, error)
+}
+
+// BatchResult json rpc result of batch request,
+// Error is set if the request failed (other requests are not affected)
+type BatchResult struct {
+	Result json.RawMessage
+	Error  error
+}
+
+// SetRPCBatchSize set max requests in one batch call (0 means disable batching)
+func (c *APICaller) SetRPCBatchSize(size int) {
+	c.rpcBatchSize = size
+}
+
+// GetRPCBatchSize get max requests in one batch call
+func (c *APICaller) GetRPCBatchSize() int {
+	return c.rpcBatchSize
+}
+
+func (c *APICaller) isBatchRejected(url string) bool {
+	c.batchMu.Lock()
+	defer c.batchMu.Unlock()
+	return c.batchRejectedURLs[url]
+}
+
+func (c *APICaller) setBatchRejected(url string) {
+	c.batchMu.Lock()
+	defer c.batchMu.Unlock()
+	if c.batchRejectedURLs == nil {
+		c.batchRejectedURLs = make(map[string]bool)
+	}
+	c.batchRejectedURLs[url] = true
+}
+
+// BatchCall call json rpc requests in batches of rpc batch size, try every server until success.
+// the sdk does not expose batch call of its rpc client, so batches are posted to http servers
+// by our own http client, and fallback to sequential calls on servers which reject batch request.
+// on websocket and ipc servers requests are called one by one by the dialed client,
+// if all of them support it (see BatchRequest), otherwise these servers are skipped.
+func (c *APICaller) BatchCall(reqs []BatchRequest) ([]BatchResult, error) {
+	results := make([]BatchResult, len(reqs))
+	batchSize := c.rpcBatchSize
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+	for start := 0; start < len(reqs); start += batchSize {
+		end := start + batchSize
+		if end > len(reqs) {
+			end = len(reqs)
+		}
+		err := c.batchCallChunk(reqs[start:end], results[start:end])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+func (c *APICaller) batchCallChunk(reqs []BatchRequest, results []BatchResult) (err error) {
+	err = errNoHTTPServer
+	clients, urls := c.getClients()
+	for _, i := range c.clientOrder(urls, false) {
+		url := urls[i]
+		if c.coolDownLeft(url) > 0 {
+			continue
+		}
+		if !isHTTPURL(strings.ToLower(url)) {
+			if !canCallByClient(reqs) {
+				continue
+			}
+			start := time.Now()
+			err = c.doClientCalls(clients[i], reqs, results)
+			c.recordClientResult(url, err, time.Since(start))
+			c.checkConnection(i, url, err)
+			if err == nil {
+				return nil
+			}
+			c.checkRateLimit(url, err)
+			continue
+		}
+		if len(reqs) > 1 && !c.isBatchRejected(url) {
+			start := time.Now()
+			err = doBatchRPCCall(c.context, url, reqs, results)
+			c.recordClientResult(url, err, time.Since(start))
+			if err == nil {
+				return nil
+			}
+			if !errors.Is(err, errBatchRejected) {
+				c.checkRateLimit(url, err)
+				continue
+			}
+			log.Warn("[callapi] server reject batch call, fallback to sequential calls", "server", RedactURL(url), "err", err)
+			c.setBatchRejected(url)
+		}
+		start := time.Now()
+		err = doSequentialRPCCall(c.context, url, reqs, results)
+		c.recordClientResult(url, err, time.Since(start))
+		if err == nil {
+			return nil
+		}
+		c.checkRateLimit(url, err)
+	}
+	return err
+}
+
+func canCallByClient(reqs []BatchRequest) bool {
+	for _, req := range reqs {
+		if req.clientCall == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// doClientCalls call requests one by one by client, reverted call is an error of the request
+func (c *APICaller) doClientCalls(client *ethclient.Client, reqs []BatchRequest, results []BatchResult) error {
+	for i, req := range reqs {
+		ctx, cancel := c.callContext()
+		result, err := req.clientCall(ctx, client)
+		cancel()
+		if err != nil {
+			if !IsRevertError(err) {
+				return err
+			}
+			results[i] = BatchResult{Error: err}
+			continue
+		}
+		data, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		results[i] = BatchResult{Result: data}
+	}
+	return nil
+}
+
+func doSequentialRPCCall(ctx context.Context, url string, reqs []BatchRequest, results []BatchResult) error {
+	for i, req := range reqs {
+		var result json.RawMessage
+		err := doRPCCall(ctx, url, &result, req.Method, req.Params)
+		var rpcErr *RPCError
+		if err != nil && !errors.As(err, &rpcErr) {
+			return err
+		}
+		results[i] = BatchResult{Result: result, Error: err}
+	}
+	return nil
+}
+
+func doBatchRPCCall(ctx context.Context, url string, reqs []BatchRequest, results []BatchResult) error {
+	batch := make([]*jsonrpcRequest, len(reqs))
+	for i, req := range reqs {
+		params := req.Params
+		if params == nil {
+			params = []interface{}{}
+		}
+		batch[i] = &jsonrpcRequest{
+			Version: "2.0",
+			ID:      i + 1,
+			Method:  req.Method,
+			Params:  params,
+		}
+	}
+	reqData, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+	resp, err := postJSON(ctx, url, reqData)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return newRateLimitError(resp)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w, http status %v", errBatchRejected, resp.Status)
+	}
+	var respData json.RawMessage
+	err = json.NewDecoder(resp.Body).Decode(&respData)
+	if err != nil {
+		return err
+	}
+	respData = bytes.TrimSpace(respData)
+	if len(respData) == 0 || respData[0] != '[' {
+		return fmt.Errorf("%w, response is not an array", errBatchRejected)
+	}
+	var batchResp []*jsonrpcResponse
+	err = json.Unmarshal(respData, &batchResp)
+	if err != nil {
+		return err
+	}
+	if len(batchResp) != len(reqs) {
+		return fmt.Errorf("%w, got %v responses for %v requests", errBatchRejected, len(batchResp), len(reqs))
+	}
+	for _, rpcResp := range batchResp {
+		index := rpcResp.ID - 1
+		if index < 0 || index >= len(reqs) {
+			return fmt.Errorf("%w, unknown response id %v", errBatchRejected, rpcResp.ID)
+		}
+		if rpcResp.Error != nil {
+			results[index] = BatchResult{Error: rpcResp.Error}
+		} else {
+			results[index] = BatchResult{Result: rpcResp.Result}
+		}
+	}
+	return nil
+}
+
+func toBlockNumArg(number *big.Int) string {
+	if number == nil {
+		return "latest"
+	}
+	return hexutil.EncodeBig(number)
+}
+
+func newCallRequest(contract common.Address, data []byte, blockNumber *big.Int) BatchRequest {
+	callArgs := map[string]interface{}{
+		"to":   contract,
+		"data": hexutil.Bytes(data),
+	}
+	return BatchRequest{
+		Method: "eth_call",
+		Params: []interface{}{callArgs, toBlockNumArg(blockNumber)},
+		clientCall: func(ctx context.Context, client *ethclient.Client) (interface{}, error) {
+			res, err := client.CallContract(ctx, ethereum.CallMsg{To: &contract, Data: data}, blockNumber)
+			return hexutil.Bytes(res), err
+		},
+	}
+}
+
+func unmarshalBatchBigInts(results []BatchResult, parse func(json.RawMessage) (*big.Int, error)) []*big.Int {
+	values := make([]*big.Int, len(results))
+	for i, result := range results {
+		if result.Error != nil {
+			continue
+		}
+		value, err := parse(result.Result)
+		if err == nil {
+			values[i] = value
+		}
+	}
+	return values
+}
+
+// BatchGetTokenBalances get token balances of accounts in batch call,
+// or in multicall if multicall address is set. value is nil if the read of the account failed
+func (c *APICaller) BatchGetTokenBalances(token common.Address, accounts []common.Address, blockNumber *big.Int) ([]*big.Int, error) {
+	if c.multicall != nil {
+		return c.multicallGetTokenBalances(token, accounts, blockNumber)
+	}
+	balanceOfFuncHash := common.FromHex("0x70a08231")
+	reqs := make([]BatchRequest, len(accounts))
+	for i, account := range accounts {
+		reqs[i] = newCallRequest(token, packBytes(balanceOfFuncHash, account.Bytes()), blockNumber)
+	}
+	results, err := c.BatchCall(reqs)
+	if err != nil {
+		log.Warn("[callapi] BatchGetTokenBalances error", "token", token.String(), "accounts", len(accounts), "blockNumber", blockNumber, "err", err)
+		return nil, err
+	}
+	return unmarshalBatchBigInts(results, func(data json.RawMessage) (*big.Int, error) {
+		var res hexutil.Bytes
+		if err := json.Unmarshal(data, &res); err != nil {
+			return nil, err
+		}
+		if len(res) < 32 {
+			return nil, fmt.Errorf("wrong balanceOf result length %v", len(res))
+		}
+		return common.GetBigInt(res, 0, 32), nil
+	}), nil
+}
+
+// BatchGetCoinBalances get coin balances of accounts in batch call,
+// value is nil if the read of the account failed
+func (c *APICaller) BatchGetCoinBalances(accounts []common.Address, blockNumber *big.Int) ([]*big.Int, error) {
+	reqs := make([]BatchRequest, len(accounts))
+	for i, account := range accounts {
+		account := account
+		reqs[i] = BatchRequest{
+			Method: "eth_getBalance",
+			Params: []interface{}{account, toBlockNumArg(blockNumber)},
+			clientCall: func(ctx context.Context, client *ethclient.Client) (interface{}, error) {
+				balance, err := client.BalanceAt(ctx, account, blockNumber)
+				return (*hexutil.Big)(balance), err
+			},
+		}
+	}
+	results, err := c.BatchCall(reqs)
+	if err != nil {
+		log.Warn("[callapi] BatchGetCoinBalances error", "accounts", len(accounts), "blockNumber", blockNumber, "err", err)
+		return nil, err
+	}
+	return unmarshalBatchBigInts(results, func(data json.RawMessage) (*big.Int, error) {
+		var res hexutil.Big
+		if err := json.Unmarshal(data, &res); err != nil {
+			return nil, err
+		}
+		return res.ToInt(), nil
+	}), nil
+}
+
+// Erc20Metadata erc20 name, symbol and decimals
+type Erc20Metadata struct {
+	Name     string
+	Symbol   string
+	Decimals uint8
+}
+
+// BatchGetErc20Metadata get erc20 name, symbol and decimals in one batch call
+func (c *APICaller) BatchGetErc20Metadata(erc20 common.Address) (*Erc20Metadata, error) {
+	reqs := []BatchRequest{
+		newCallRequest(erc20, common.FromHex("0x06fdde03"), nil),
+		newCallRequest(erc20, common.FromHex("0x95d89b41"), nil),
+		newCallRequest(erc20, common.FromHex("0x313ce567"), nil),
+	}
+	results, err := c.BatchCall(reqs)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]hexutil.Bytes, len(results))
+	for i, result := range results {
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if err = json.Unmarshal(result.Result, &res[i]); err != nil {
+			return nil, err
+		}
+	}
+	metadata := &Erc20Metadata{}
+	if metadata.Name, err = UnpackABIEncodedStringOrBytes32(res[0]); err != nil {
+		return nil, err
+	}
+	if metadata.Symbol, err = UnpackABIEncodedStringOrBytes32(res[1]); err != nil {
+		return nil, err
+	}
+	if len(res[2]) < 32 {
+		return nil, fmt.Errorf("wrong decimals result length %v", len(res[2]))
+	}
+	metadata.Decimals = uint8(common.GetBigInt(res[2], 0, 32).Uint64())
+	return metadata, nil
+}
@@ -0,0 +1,59 @@
+package callapi
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+func TestBatchCallOfIPCClient(t *testing.T) {
+	dir, err := ioutil.TempDir("", "callapi-batchcall")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "node.ipc")
+	reverted := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	newIPCStub(t, path, func(method string, params []json.RawMessage) (interface{}, error) {
+		switch method {
+		case "eth_getBalance":
+			var account common.Address
+			if err := json.Unmarshal(params[0], &account); err != nil {
+				return nil, err
+			}
+			if account == reverted {
+				return nil, errors.New("execution reverted")
+			}
+			return "0x64", nil
+		case "eth_call":
+			return "0x" + common.Bytes2Hex(common.LeftPadBytes([]byte{7}, 32)), nil
+		}
+		return nil, errors.New("method not supported")
+	})
+
+	c := newStubCaller(t, 1, path)
+	account := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	balances, err := c.BatchGetCoinBalances([]common.Address{account, reverted}, nil)
+	if err != nil {
+		t.Fatalf("batch get coin balances of ipc client failed: %v", err)
+	}
+	if balances[0] == nil || balances[0].Uint64() != 100 || balances[1] != nil {
+		t.Fatalf("got balances %v, want [100 <nil>]", balances)
+	}
+	tokenBalances, err := c.BatchGetTokenBalances(common.Address{}, []common.Address{account}, nil)
+	if err != nil {
+		t.Fatalf("batch get token balances of ipc client failed: %v", err)
+	}
+	if tokenBalances[0] == nil || tokenBalances[0].Uint64() != 7 {
+		t.Fatalf("got token balances %v, want [7]", tokenBalances)
+	}
+
+	if _, err = c.BatchCall([]BatchRequest{{Method: "eth_blockNumber"}}); !errors.Is(err, errNoHTTPServer) {
+		t.Fatalf("request without client call should skip ipc client, got %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package callapi
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/ethclient"
+)
+
+// BroadcastError every client rejected the broadcast, errors are in the order of URLs
+type BroadcastError struct {
+	URLs   []string
+	Errors []error
+}
+
+func (e *BroadcastError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = fmt.Sprintf("%v: %v", RedactURL(e.URLs[i]), err)
+	}
+	return "rejected by all clients, " + strings.Join(msgs, "; ")
+}
+
+// broadcastClients call every client not in rate limit cool down concurrently,
+// succeed if at least one client accepts. rejections of other clients are only logged,
+// known tx and nonce too low are expected there as the tx may have been gossiped to them.
+// if all clients rejected, the error of the only client or a BroadcastError is returned.
+func (c *APICaller) broadcastClients(call func(ctx context.Context, client *ethclient.Client) error) error {
+	clients, urls := c.getClients()
+	var indexes []int
+	for _, i := range c.clientOrder(urls, false) {
+		if c.coolDownLeft(urls[i]) <= 0 {
+			indexes = append(indexes, i)
+		}
+	}
+	if len(indexes) == 0 {
+		// all clients are rate limited, wait cool down and send one by one
+		return c.callClients(func(client *ethclient.Client) error {
+			return call(c.context, client)
+		})
+	}
+
+	errs := make([]error, len(indexes))
+	var wg sync.WaitGroup
+	for j, i := range indexes {
+		wg.Add(1)
+		go func(j, i int) {
+			defer wg.Done()
+			ctx, cancel := c.callContext()
+			defer cancel()
+			start := time.Now()
+			err := call(ctx, clients[i])
+			c.recordClientResult(urls[i], err, time.Since(start))
+			c.checkConnection(i, urls[i], err)
+			c.checkRateLimit(urls[i], err)
+			errs[j] = err
+		}(j, i)
+	}
+	wg.Wait()
+
+	accepted := 0
+	for _, err := range errs {
+		if err == nil {
+			accepted++
+		}
+	}
+	if accepted == 0 {
+		if len(errs) == 1 {
+			return errs[0]
+		}
+		rejectedURLs := make([]string, len(indexes))
+		for j, i := range indexes {
+			rejectedURLs[j] = urls[i]
+		}
+		return &BroadcastError{URLs: rejectedURLs, Errors: errs}
+	}
+	for j, err := range errs {
+		if err != nil && !IsKnownTxError(err) && !IsNonceTooLowError(err) {
+			log.Warn("[callapi] broadcast rejected by client", "server", RedactURL(urls[indexes[j]]), "accepted", accepted, "clients", len(indexes), "err", err)
+		}
+	}
+	return nil
+}
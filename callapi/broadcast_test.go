@@ -0,0 +1,64 @@
+package callapi
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+func sendTxStub(t *testing.T, sendErr error) *rpcStub {
+	return newRPCStub(t, func(method string, params []json.RawMessage) (interface{}, error) {
+		if method != "eth_sendRawTransaction" {
+			return nil, errors.New("method not supported")
+		}
+		if sendErr != nil {
+			return nil, sendErr
+		}
+		return common.Hash{}, nil
+	})
+}
+
+func TestSendTransactionBroadcastOneAccepts(t *testing.T) {
+	accepting := sendTxStub(t, nil)
+	rejecting := sendTxStub(t, errors.New("insufficient funds for gas * price + value"))
+	logs := captureLog(t)
+
+	c := newStubCaller(t, 1, accepting.URL, rejecting.URL)
+	if err := c.SendTransaction(newSignedTestTx(t)); err != nil {
+		t.Fatalf("send should succeed when one gateway accepts, got %v", err)
+	}
+	for _, stub := range []*rpcStub{accepting, rejecting} {
+		if count := stub.callCount("eth_sendRawTransaction"); count != 1 {
+			t.Errorf("gateway %v got %v sends, want 1", stub.URL, count)
+		}
+	}
+	output := logs.String()
+	if !strings.Contains(output, "broadcast rejected by client") || !strings.Contains(output, "insufficient funds") {
+		t.Errorf("rejection is not logged, log: %v", output)
+	}
+	if !strings.Contains(output, rejecting.URL) || strings.Contains(output, accepting.URL) {
+		t.Errorf("only the rejecting gateway should be logged, log: %v", output)
+	}
+}
+
+func TestSendTransactionBroadcastAllReject(t *testing.T) {
+	first := sendTxStub(t, errors.New("insufficient funds for gas * price + value"))
+	second := sendTxStub(t, errors.New("gas price too low"))
+	captureLog(t)
+
+	c := newStubCaller(t, 1, first.URL, second.URL)
+	err := c.SendTransaction(newSignedTestTx(t))
+	var broadcastErr *BroadcastError
+	if !errors.As(err, &broadcastErr) {
+		t.Fatalf("want BroadcastError, got %v", err)
+	}
+	if len(broadcastErr.Errors) != 2 {
+		t.Fatalf("want errors of 2 gateways, got %v", len(broadcastErr.Errors))
+	}
+	if !strings.Contains(err.Error(), "insufficient funds") || !strings.Contains(err.Error(), "gas price too low") {
+		t.Errorf("error should contain errors of all gateways, got %v", err)
+	}
+}
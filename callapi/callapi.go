@@ -2,7 +2,10 @@ package callapi
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"math/big"
+	"sync"
 	"time"
 
 	"github.com/anyswap/ANYToken-distribution/log"
@@ -15,47 +18,295 @@ import (
 // APICaller encapsulate ethclient
 type APICaller struct {
 	clients          []*ethclient.Client
+	urls             []string // server URL of clients
+	clientsMu        sync.RWMutex
+	clientCursor     uint32 // first client of next call, advanced atomically
 	context          context.Context
 	rpcRetryCount    int
 	rpcRetryInterval time.Duration
+
+	// timeout of every read or broadcast call to one client (0 means no timeout),
+	// a hung client is given up and the next client is tried
+	callTimeout time.Duration
+
+	// nil means the default policy of retry count and interval
+	retryPolicy     RetryPolicy
+	loopRetryPolicy RetryPolicy
+
+	// all clients must be connected to the same chain
+	chainID                 *big.Int
+	dropMismatchChainClient bool
+
+	errorBudget *ErrorBudget
+
+	dialRetry *DialRetry
+
+	// max requests in one json rpc batch call (0 means disable batching)
+	rpcBatchSize      int
+	batchMu           sync.Mutex
+	batchRejectedURLs map[string]bool
+
+	// Multicall2 contract to aggregate contract reads (nil means disabled)
+	multicall *common.Address
+
+	// cool down of rate limited servers
+	rateLimitCoolDown time.Duration
+	coolDown          coolDownState
+
+	// recent results and latency of every server, failing ones are tried last
+	health clientHealthState
+
+	// broken persistent connections are re-dialed in background
+	reconnect reconnectState
+
+	// read calls race all clients and take the fastest success
+	parallelRead bool
+
+	// retry sending tx rejected by full mempool
+	mempoolFullRetries int
+	mempoolFullBackoff time.Duration
+
+	// gateway endpoints with weight and role, keyed by URL
+	endpoints  map[string]*Endpoint
+	endpointMu sync.Mutex
+
+	// cache of block number at time (unix seconds)
+	timeBlockMu    sync.Mutex
+	timeBlockCache map[int64]*big.Int
+}
+
+// healthCheckTimeout timeout of checking client health
+const healthCheckTimeout = 10 * time.Second
+
+// DefaultCallTimeout default timeout of every read call to one client
+const DefaultCallTimeout = 30 * time.Second
+
+// ErrChainIDMismatch clients are connected to different chains
+var ErrChainIDMismatch = errors.New("chain ID mismatch between clients")
+
+// ErrDialServerFailed dial server failed after all retries
+var ErrDialServerFailed = errors.New("dial server failed")
+
+// DialRetry retry of dialing every server URL
+type DialRetry struct {
+	Count    int           // attempts of every server URL
+	Interval time.Duration // interval between attempts
+	Timeout  time.Duration // connect timeout of every attempt
+}
+
+// DefaultDialRetry default dial retry
+var DefaultDialRetry = &DialRetry{
+	Count:    5,
+	Interval: 3 * time.Second,
+	Timeout:  10 * time.Second,
 }
 
 // NewDefaultAPICaller new default API caller
 func NewDefaultAPICaller() *APICaller {
+	return NewDefaultAPICallerWithContext(context.Background())
+}
+
+// NewDefaultAPICallerWithContext new default API caller,
+// all rpc calls are cancelled when parent context is done
+func NewDefaultAPICallerWithContext(ctx context.Context) *APICaller {
 	return &APICaller{
-		context:          context.Background(),
-		rpcRetryCount:    3,
-		rpcRetryInterval: 1 * time.Second,
+		context:           ctx,
+		rpcRetryCount:     3,
+		rpcRetryInterval:  1 * time.Second,
+		callTimeout:       DefaultCallTimeout,
+		rpcBatchSize:      DefaultRPCBatchSize,
+		rateLimitCoolDown: DefaultRateLimitCoolDown,
+
+		mempoolFullRetries: DefaultMempoolFullRetries,
+		mempoolFullBackoff: DefaultMempoolFullBackoff,
+	}
+}
+
+// APICallerOption option of NewAPICaller
+type APICallerOption func(c *APICaller)
+
+// WithCallTimeout set timeout of every read call to one client (0 means no timeout),
+// default is DefaultCallTimeout
+func WithCallTimeout(timeout time.Duration) APICallerOption {
+	return func(c *APICaller) {
+		c.callTimeout = timeout
 	}
 }
 
 // NewAPICaller new API caller
-func NewAPICaller(ctx context.Context, retryCount int, retryInterval time.Duration) *APICaller {
-	return &APICaller{
-		context:          ctx,
-		rpcRetryCount:    retryCount,
-		rpcRetryInterval: retryInterval,
+func NewAPICaller(ctx context.Context, retryCount int, retryInterval time.Duration, options ...APICallerOption) *APICaller {
+	c := &APICaller{
+		context:           ctx,
+		rpcRetryCount:     retryCount,
+		rpcRetryInterval:  retryInterval,
+		callTimeout:       DefaultCallTimeout,
+		rpcBatchSize:      DefaultRPCBatchSize,
+		rateLimitCoolDown: DefaultRateLimitCoolDown,
+
+		mempoolFullRetries: DefaultMempoolFullRetries,
+		mempoolFullBackoff: DefaultMempoolFullBackoff,
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// Context get context of rpc calls
+func (c *APICaller) Context() context.Context {
+	return c.context
+}
+
+// SetCallTimeout set timeout of every read call to one client (0 means no timeout)
+func (c *APICaller) SetCallTimeout(timeout time.Duration) {
+	c.callTimeout = timeout
+}
+
+// callContext context of one read or broadcast call to one client, it's cancelled after call timeout
+func (c *APICaller) callContext() (context.Context, context.CancelFunc) {
+	if c.callTimeout > 0 {
+		return context.WithTimeout(c.context, c.callTimeout)
 	}
+	return context.WithCancel(c.context)
 }
 
 // DialServer dial server and assign client
 func (c *APICaller) DialServer(serverURL []string) (err error) {
+	if len(serverURL) == 0 {
+		return errors.New("empty server URL")
+	}
+	c.CloseClient() // when redial
+	c.clientsMu.Lock()
+	c.clients = nil
+	c.urls = nil
+	c.clientsMu.Unlock()
 	var client *ethclient.Client
 	for _, url := range serverURL {
-		client, err = ethclient.Dial(url)
+		client, err = c.dialWithRetry(url)
 		if err != nil {
-			log.Error("[callapi] client connection error", "server", url, "err", err)
-			return err
+			log.Error("[callapi] client connection error", "server", RedactURL(url), "err", err)
+			return fmt.Errorf("%w %v. %v", ErrDialServerFailed, RedactURL(url), err)
 		}
-		log.Info("[callapi] client connection succeed", "server", url)
+		log.Info("[callapi] client connection succeed", "server", RedactURL(url))
+		c.clientsMu.Lock()
 		c.clients = append(c.clients, client)
+		c.urls = append(c.urls, url)
+		c.clientsMu.Unlock()
+	}
+	err = c.checkChainID(serverURL)
+	if err != nil {
+		return err
 	}
 	c.LoopGetLatestBlockHeader()
 	return nil
 }
 
+// SetDialRetry set retry of dialing every server URL
+func (c *APICaller) SetDialRetry(dialRetry *DialRetry) {
+	c.dialRetry = dialRetry
+}
+
+func (c *APICaller) dialWithRetry(url string) (client *ethclient.Client, err error) {
+	dialRetry := c.dialRetry
+	if dialRetry == nil {
+		dialRetry = DefaultDialRetry
+	}
+	dialURL, err := getDialURL(url)
+	if err != nil {
+		return nil, err
+	}
+	for i := 1; ; i++ {
+		ctx := c.context
+		var cancel context.CancelFunc
+		if dialRetry.Timeout > 0 {
+			ctx, cancel = context.WithTimeout(c.context, dialRetry.Timeout)
+		}
+		client, err = ethclient.DialContext(ctx, dialURL)
+		if cancel != nil {
+			cancel()
+		}
+		if err == nil || i >= dialRetry.Count {
+			return client, err
+		}
+		if c.context.Err() != nil {
+			return nil, err
+		}
+		log.Warn("[callapi] dial server failed, retry later", "server", RedactURL(url), "attempt", i, "maxAttempts", dialRetry.Count, "err", err)
+		time.Sleep(dialRetry.Interval)
+	}
+}
+
+// SetDropMismatchChainClient drop clients with mismatched chain ID
+// instead of refusing to start when dial server
+func (c *APICaller) SetDropMismatchChainClient(drop bool) {
+	c.dropMismatchChainClient = drop
+}
+
+// checkChainID query chain ID from every client and pin to the chain ID
+// of the first client (the primary gateway), so we never send on wrong chain
+func (c *APICaller) checkChainID(serverURL []string) (err error) {
+	clients, urls := c.getClients()
+	chainIDs := make([]*big.Int, len(clients))
+	for i, client := range clients {
+		err = c.retryCall(func() (errf error) {
+			chainIDs[i], errf = client.NetworkID(c.context)
+			return errf
+		})
+		if err != nil {
+			log.Error("[callapi] get chain ID error", "server", RedactURL(serverURL[i]), "err", err)
+			return err
+		}
+	}
+	c.chainID = chainIDs[0]
+	matchClients := make([]*ethclient.Client, 0, len(clients))
+	matchURLs := make([]string, 0, len(urls))
+	for i, client := range clients {
+		if chainIDs[i].Cmp(c.chainID) == 0 {
+			matchClients = append(matchClients, client)
+			matchURLs = append(matchURLs, urls[i])
+			continue
+		}
+		if !c.dropMismatchChainClient {
+			log.Error("[callapi] client chain ID mismatch", "server", RedactURL(serverURL[i]), "chainID", chainIDs[i], "want", c.chainID)
+			return ErrChainIDMismatch
+		}
+		log.Warn("[callapi] drop client with mismatched chain ID", "server", RedactURL(serverURL[i]), "chainID", chainIDs[i], "want", c.chainID)
+		client.Close()
+	}
+	c.clientsMu.Lock()
+	c.clients = matchClients
+	c.urls = matchURLs
+	c.clientsMu.Unlock()
+	log.Info("[callapi] check clients chain ID succeed", "chainID", c.chainID, "clients", len(matchClients))
+	return nil
+}
+
+// SetErrorBudget set rpc failures budget shared across a whole run
+func (c *APICaller) SetErrorBudget(budget *ErrorBudget) {
+	c.errorBudget = budget
+}
+
+// CheckErrorBudget return ErrSystemicOutage if rpc failures budget is exhausted
+func (c *APICaller) CheckErrorBudget() error {
+	if c.errorBudget == nil {
+		return nil
+	}
+	return c.errorBudget.Check()
+}
+
+// recordResult record call result to error budget. reverted call and nonce too low are
+// answers of healthy nodes to bad requests, not rpc failures, and are not recorded
+func (c *APICaller) recordResult(err error) {
+	if c.errorBudget == nil || IsRevertError(err) || IsNonceTooLowError(err) {
+		return
+	}
+	c.errorBudget.Record(err != nil)
+}
+
 // CloseClient close client
 func (c *APICaller) CloseClient() {
+	c.clientsMu.RLock()
+	defer c.clientsMu.RUnlock()
 	for _, client := range c.clients {
 		if client != nil {
 			client.Close()
@@ -65,12 +316,7 @@ func (c *APICaller) CloseClient() {
 
 // GetCoinBalance get coin balance
 func (c *APICaller) GetCoinBalance(account common.Address, blockNumber *big.Int) (balance *big.Int, err error) {
-	for i := 0; i < c.rpcRetryCount; i++ {
-		balance, err = c.BalanceAt(account, blockNumber)
-		if err == nil {
-			break
-		}
-	}
+	balance, err = c.BalanceAt(account, blockNumber)
 	if err != nil {
 		log.Warn("[callapi] GetCoinBalance error", "account", account.String(), "blockNumber", blockNumber, "err", err)
 		return nil, err
@@ -129,124 +375,190 @@ func (c *APICaller) GetLiquidityBalance(exchange, account common.Address, blockN
 }
 
 // GetExchangeTokenAddress get exchange's token address
-func (c *APICaller) GetExchangeTokenAddress(exchange common.Address) common.Address {
+func (c *APICaller) GetExchangeTokenAddress(exchange common.Address) (common.Address, error) {
 	tokenAddressFuncHash := common.FromHex("0x9d76ea58")
 	res, err := c.CallContract(exchange, tokenAddressFuncHash, nil)
 	if err != nil {
-		return common.Address{}
+		return common.Address{}, err
 	}
-	return common.BytesToAddress(common.GetData(res, 0, 32))
+	return common.BytesToAddress(common.GetData(res, 0, 32)), nil
+}
+
+// GetExchangeTokenAddressOrZero get exchange's token address, return zero address if error
+func (c *APICaller) GetExchangeTokenAddressOrZero(exchange common.Address) common.Address {
+	token, _ := c.GetExchangeTokenAddress(exchange)
+	return token
 }
 
 // GetExchangeFactoryAddress get exchange's factory address
-func (c *APICaller) GetExchangeFactoryAddress(exchange common.Address) common.Address {
+func (c *APICaller) GetExchangeFactoryAddress(exchange common.Address) (common.Address, error) {
 	factoryAddress := common.FromHex("0x966dae0e")
 	res, err := c.CallContract(exchange, factoryAddress, nil)
 	if err != nil {
-		return common.Address{}
+		return common.Address{}, err
 	}
-	return common.BytesToAddress(common.GetData(res, 0, 32))
+	return common.BytesToAddress(common.GetData(res, 0, 32)), nil
 }
 
-// BalanceAt get account balance
+// GetExchangeFactoryAddressOrZero get exchange's factory address, return zero address if error
+func (c *APICaller) GetExchangeFactoryAddressOrZero(exchange common.Address) common.Address {
+	factory, _ := c.GetExchangeFactoryAddress(exchange)
+	return factory
+}
+
+// BalanceAt get account balance, retry all clients by retry policy
 func (c *APICaller) BalanceAt(account common.Address, blockNumber *big.Int) (balance *big.Int, err error) {
-	for _, client := range c.clients {
-		balance, err = client.BalanceAt(c.context, account, blockNumber)
-		if err == nil {
-			return
+	defer func() { c.recordResult(err) }()
+	err = c.retryCall(func() error {
+		result, errf := c.readClientsAt(blockNumber, func(ctx context.Context, client *ethclient.Client) (interface{}, error) {
+			return client.BalanceAt(ctx, account, blockNumber)
+		})
+		if errf == nil {
+			balance = result.(*big.Int)
 		}
-	}
+		return errf
+	})
 	return
 }
 
-// GetAccountNonce get account nonce
+// IsContract is address a contract (has code at latest block)
+func (c *APICaller) IsContract(address common.Address) (isContract bool, err error) {
+	defer func() { c.recordResult(err) }()
+	err = c.callClients(func(client *ethclient.Client) (errf error) {
+		code, errf := client.CodeAt(c.context, address, nil)
+		isContract = len(code) > 0
+		return errf
+	})
+	return
+}
+
+// GetAccountNonce get account nonce, retry all clients by retry policy
 func (c *APICaller) GetAccountNonce(account common.Address) (nonce uint64, err error) {
-	for _, client := range c.clients {
-		nonce, err = client.PendingNonceAt(c.context, account)
-		if err == nil {
-			return
-		}
-	}
+	defer func() { c.recordResult(err) }()
+	err = c.retryClients(func(client *ethclient.Client) (errf error) {
+		nonce, errf = client.PendingNonceAt(c.context, account)
+		return errf
+	})
+	return
+}
+
+// GetAccountConfirmedNonce get account nonce of latest block
+func (c *APICaller) GetAccountConfirmedNonce(account common.Address) (nonce uint64, err error) {
+	defer func() { c.recordResult(err) }()
+	err = c.callClients(func(client *ethclient.Client) (errf error) {
+		nonce, errf = client.NonceAt(c.context, account, nil)
+		return errf
+	})
 	return
 }
 
-// SendTransaction send signed tx
+// SendTransaction broadcast signed tx to all clients, succeed if any client accepts it.
+// retry all clients by retry policy if all rejected, and retry later if it is rejected by full mempool.
+// known tx and nonce too low are not retried, known tx of a retry is the tx of a former attempt
+// which reached the node and is success. known tx of the first attempt is returned to the caller.
 func (c *APICaller) SendTransaction(tx *types.Transaction) (err error) {
-	for _, client := range c.clients {
-		err = client.SendTransaction(c.context, tx)
-		if err == nil {
-			return
-		}
-	}
+	defer func() { c.recordResult(err) }()
+	attempt := 0
+	err = c.retryMempoolFull(func() error {
+		return c.retryCallUnless(func() error {
+			attempt++
+			errf := c.broadcastClients(func(ctx context.Context, client *ethclient.Client) error {
+				return client.SendTransaction(ctx, tx)
+			})
+			if attempt > 1 && IsKnownTxError(errf) {
+				return nil
+			}
+			return errf
+		}, isFinalSendError)
+	})
 	return
 }
 
-// GetChainID get chain ID, also known as network ID
+// isFinalSendError send errors which fail again by resending the same tx
+func isFinalSendError(err error) bool {
+	return IsKnownTxError(err) || IsNonceTooLowError(err)
+}
+
+// GetChainID get chain ID, also known as network ID, retry all clients by retry policy
 func (c *APICaller) GetChainID() (chainID *big.Int, err error) {
-	for _, client := range c.clients {
-		chainID, err = client.NetworkID(c.context)
-		if err == nil {
-			return
-		}
+	if c.chainID != nil {
+		return new(big.Int).Set(c.chainID), nil
 	}
+	defer func() { c.recordResult(err) }()
+	err = c.retryClients(func(client *ethclient.Client) (errf error) {
+		chainID, errf = client.NetworkID(c.context)
+		return errf
+	})
 	return
 }
 
-// SuggestGasPrice suggest gas price
+// SuggestGasPrice suggest gas price, retry all clients by retry policy
 func (c *APICaller) SuggestGasPrice() (gasPrice *big.Int, err error) {
-	for _, client := range c.clients {
-		gasPrice, err = client.SuggestGasPrice(c.context)
-		if err == nil {
-			return
-		}
-	}
+	defer func() { c.recordResult(err) }()
+	err = c.retryClients(func(client *ethclient.Client) (errf error) {
+		gasPrice, errf = client.SuggestGasPrice(c.context)
+		return errf
+	})
 	return
 }
 
 // SyncProgress get sync process
 func (c *APICaller) SyncProgress() (progress *ethereum.SyncProgress, err error) {
-	for _, client := range c.clients {
-		progress, err = client.SyncProgress(c.context)
-		if err == nil {
-			return
-		}
-	}
+	defer func() { c.recordResult(err) }()
+	err = c.callClients(func(client *ethclient.Client) (errf error) {
+		progress, errf = client.SyncProgress(c.context)
+		return errf
+	})
 	return
 }
 
 // DoCall call contract
 func (c *APICaller) DoCall(msg *ethereum.CallMsg, blockNumber *big.Int) (res []byte, err error) {
-	for _, client := range c.clients {
-		res, err = client.CallContract(c.context, *msg, blockNumber)
-		if err == nil {
-			return
-		}
+	defer func() { c.recordResult(err) }()
+	result, err := c.readClientsAt(blockNumber, func(ctx context.Context, client *ethclient.Client) (interface{}, error) {
+		return client.CallContract(ctx, *msg, blockNumber)
+	})
+	if err == nil {
+		res = result.([]byte)
 	}
 	return
 }
 
+// EstimateGas estimate gas of message
+func (c *APICaller) EstimateGas(msg *ethereum.CallMsg) (gas uint64, err error) {
+	defer func() { c.recordResult(err) }()
+	err = c.callClients(func(client *ethclient.Client) (errf error) {
+		gas, errf = client.EstimateGas(c.context, *msg)
+		return errf
+	})
+	return
+}
+
 // HeaderByNumber get header by number
 func (c *APICaller) HeaderByNumber(blockNumber *big.Int) (header *types.Header, err error) {
-	for _, client := range c.clients {
-		header, err = client.HeaderByNumber(c.context, blockNumber)
-		if err == nil {
-			return
-		}
+	defer func() { c.recordResult(err) }()
+	// header is not state, archive clients are not preferred
+	result, err := c.readClientsAt(nil, func(ctx context.Context, client *ethclient.Client) (interface{}, error) {
+		return client.HeaderByNumber(ctx, blockNumber)
+	})
+	if err == nil {
+		header = result.(*types.Header)
 	}
 	return
 }
 
 // GetSyncProgress get full node syncing state
 func (c *APICaller) GetSyncProgress() *ethereum.SyncProgress {
-	for {
-		progress, err := c.SyncProgress()
-		if err == nil {
-			log.Info("call eth_syncing success", "progress", progress)
-			return progress
+	var progress *ethereum.SyncProgress
+	c.loopUntilSuccess(func() (err error) {
+		progress, err = c.SyncProgress()
+		if err != nil {
+			log.Warn("call eth_syncing failed", "err", err)
 		}
-		log.Warn("call eth_syncing failed", "err", err)
-		time.Sleep(c.rpcRetryInterval)
-	}
+		return err
+	})
+	log.Info("call eth_syncing success", "progress", progress)
+	return progress
 }
 
 // CallContract common call contract
@@ -255,33 +567,32 @@ func (c *APICaller) CallContract(contract common.Address, data []byte, blockNumb
 		To:   &contract,
 		Data: data,
 	}
-	for i := 0; i < c.rpcRetryCount; i++ {
-		res, err = c.DoCall(msg, blockNumber)
-		if err == nil {
-			break
+	err = c.retryCall(func() (errf error) {
+		res, errf = c.DoCall(msg, blockNumber)
+		if errf != nil {
+			log.Error("[callapi] CallContract error", "contract", contract.String(), "blockNumber", blockNumber, "err", errf)
 		}
-		log.Error("[callapi] CallContract error", "contract", contract.String(), "blockNumber", blockNumber, "err", err)
-		time.Sleep(c.rpcRetryInterval)
-	}
+		return errf
+	})
 	return res, err
 }
 
-// GetErc20Name erc20
+// GetErc20Name erc20, support string and bytes32 name
 func (c *APICaller) GetErc20Name(erc20 common.Address) (string, error) {
 	res, err := c.CallContract(erc20, common.FromHex("0x06fdde03"), nil)
 	if err != nil {
 		return "", err
 	}
-	return UnpackABIEncodedStringInIndex(res, 0)
+	return UnpackABIEncodedStringOrBytes32(res)
 }
 
-// GetErc20Symbol erc20
+// GetErc20Symbol erc20, support string and bytes32 symbol
 func (c *APICaller) GetErc20Symbol(erc20 common.Address) (string, error) {
 	res, err := c.CallContract(erc20, common.FromHex("0x95d89b41"), nil)
 	if err != nil {
 		return "", err
 	}
-	return UnpackABIEncodedStringInIndex(res, 0)
+	return UnpackABIEncodedStringOrBytes32(res)
 }
 
 // GetErc20Decimals erc20
@@ -301,3 +612,52 @@ func (c *APICaller) GetErc20TotalSupply(erc20 common.Address, blockNumber *big.I
 	}
 	return common.GetBigInt(res, 0, 32), nil
 }
+
+// GetErc20Allowance erc20 allowance of owner to spender
+func (c *APICaller) GetErc20Allowance(erc20, owner, spender common.Address, blockNumber *big.Int) (*big.Int, error) {
+	allowanceFuncHash := common.FromHex("0xdd62ed3e")
+	data := packBytes(allowanceFuncHash, owner.Bytes(), spender.Bytes())
+	res, err := c.CallContract(erc20, data, blockNumber)
+	if err != nil {
+		log.Warn("[callapi] GetErc20Allowance error", "erc20", erc20.String(), "owner", owner.String(), "spender", spender.String(), "blockNumber", blockNumber, "err", err)
+		return nil, err
+	}
+	return common.GetBigInt(res, 0, 32), nil
+}
+
+// GetHealthyClientCount get count of clients which can get latest block header
+// in time, rate limited clients in cool down are not healthy
+func (c *APICaller) GetHealthyClientCount() (count int) {
+	clients, urls := c.getClients()
+	for i, url := range urls {
+		if c.coolDownLeft(url) > 0 {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(c.context, healthCheckTimeout)
+		_, err := clients[i].HeaderByNumber(ctx, nil)
+		cancel()
+		c.checkConnection(i, url, err)
+		if err != nil {
+			log.Warn("[callapi] client is not healthy", "server", RedactURL(url), "err", err)
+			continue
+		}
+		count++
+	}
+	return count
+}
+
+// CallBool call contract view function which returns bool
+func (c *APICaller) CallBool(contract common.Address, data []byte) (bool, error) {
+	res, err := c.CallContract(contract, data, nil)
+	if err != nil {
+		return false, err
+	}
+	if len(res) < 32 {
+		return false, fmt.Errorf("wrong bool result length %v", len(res))
+	}
+	value := common.GetBigInt(res, 0, 32)
+	if value.Cmp(big.NewInt(1)) > 0 {
+		return false, fmt.Errorf("wrong bool result %v", value)
+	}
+	return value.Sign() != 0, nil
+}
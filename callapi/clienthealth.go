@@ -0,0 +1,188 @@
+package callapi
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	ethereum "github.com/fsn-dev/fsn-go-sdk/efsn"
+)
+
+const (
+	// DefaultDemoteFailures consecutive failures of a client to be deprioritized as failing
+	DefaultDemoteFailures = 3
+
+	// DefaultRecoverSuccesses consecutive successes of a failing client to be tried in turn again
+	DefaultRecoverSuccesses = 3
+
+	// healthWindow count of recent results of every client in health statistics
+	healthWindow = 100
+
+	// healthProbeInterval every this number of calls failing clients are tried first,
+	// so they get calls to prove they are recovered
+	healthProbeInterval = 10
+)
+
+// ClientHealth health statistics of a client over its recent results
+type ClientHealth struct {
+	URL                  string // redacted server URL
+	Successes            int
+	Errors               int
+	ConsecutiveSuccesses int
+	ConsecutiveFailures  int
+	AvgLatency           time.Duration
+	Failing              bool // deprioritized until recovered by consecutive successes
+}
+
+type clientResult struct {
+	success bool
+	latency time.Duration
+}
+
+type clientHealthRecord struct {
+	results              []clientResult // ring buffer of recent results
+	next                 int
+	consecutiveSuccesses int
+	consecutiveFailures  int
+	failing              bool
+}
+
+type clientHealthState struct {
+	mu               sync.Mutex
+	records          map[string]*clientHealthRecord // key is server URL
+	demoteFailures   int
+	recoverSuccesses int
+	probeCounter     uint32
+}
+
+// SetDemoteFailures set consecutive failures of a client to be deprioritized as failing
+func (c *APICaller) SetDemoteFailures(failures int) {
+	c.health.mu.Lock()
+	c.health.demoteFailures = failures
+	c.health.mu.Unlock()
+}
+
+// SetRecoverSuccesses set consecutive successes of a failing client to be tried in turn again
+func (c *APICaller) SetRecoverSuccesses(successes int) {
+	c.health.mu.Lock()
+	c.health.recoverSuccesses = successes
+	c.health.mu.Unlock()
+}
+
+// isClientFailure errors which are answers of a healthy server are not failures of the client
+func isClientFailure(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, ethereum.NotFound) || errors.Is(err, errBatchRejected) {
+		return false
+	}
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		return false
+	}
+	return !strings.Contains(strings.ToLower(err.Error()), "execution reverted")
+}
+
+// recordClientResult record result and latency of a call to server url.
+// a client of consecutive failures of demoteFailures is deprioritized
+// until it has consecutive successes of recoverSuccesses
+func (c *APICaller) recordClientResult(url string, err error, latency time.Duration) {
+	if err != nil && !isClientFailure(err) {
+		if errors.Is(err, context.Canceled) {
+			return // cancelled by caller, not a result of the server
+		}
+		err = nil
+	}
+	c.health.mu.Lock()
+	defer c.health.mu.Unlock()
+	if c.health.records == nil {
+		c.health.records = make(map[string]*clientHealthRecord)
+	}
+	record, exist := c.health.records[url]
+	if !exist {
+		record = &clientHealthRecord{}
+		c.health.records[url] = record
+	}
+	result := clientResult{success: err == nil, latency: latency}
+	if len(record.results) < healthWindow {
+		record.results = append(record.results, result)
+	} else {
+		record.results[record.next] = result
+		record.next = (record.next + 1) % healthWindow
+	}
+	if err != nil {
+		record.consecutiveSuccesses = 0
+		record.consecutiveFailures++
+		demoteFailures := c.health.demoteFailures
+		if demoteFailures <= 0 {
+			demoteFailures = DefaultDemoteFailures
+		}
+		if record.consecutiveFailures >= demoteFailures {
+			record.failing = true
+		}
+		return
+	}
+	record.consecutiveFailures = 0
+	record.consecutiveSuccesses++
+	recoverSuccesses := c.health.recoverSuccesses
+	if recoverSuccesses <= 0 {
+		recoverSuccesses = DefaultRecoverSuccesses
+	}
+	if record.failing && record.consecutiveSuccesses >= recoverSuccesses {
+		record.failing = false
+	}
+}
+
+// orderByHealth move failing clients after the others, keep the relative order otherwise.
+// every healthProbeInterval calls failing clients are moved before the others instead to probe them
+func (c *APICaller) orderByHealth(order []int, urls []string) []int {
+	isProbe := atomic.AddUint32(&c.health.probeCounter, 1)%healthProbeInterval == 0
+	c.health.mu.Lock()
+	defer c.health.mu.Unlock()
+	healthy := make([]int, 0, len(order))
+	var failing []int
+	for _, i := range order {
+		if record := c.health.records[urls[i]]; record != nil && record.failing {
+			failing = append(failing, i)
+			continue
+		}
+		healthy = append(healthy, i)
+	}
+	if isProbe {
+		return append(failing, healthy...)
+	}
+	return append(healthy, failing...)
+}
+
+// ClientStats health statistics of every client over its recent results
+func (c *APICaller) ClientStats() []*ClientHealth {
+	_, urls := c.getClients()
+	c.health.mu.Lock()
+	defer c.health.mu.Unlock()
+	stats := make([]*ClientHealth, len(urls))
+	for i, url := range urls {
+		stat := &ClientHealth{URL: RedactURL(url)}
+		stats[i] = stat
+		record := c.health.records[url]
+		if record == nil {
+			continue
+		}
+		var totalLatency time.Duration
+		for _, result := range record.results {
+			if result.success {
+				stat.Successes++
+			} else {
+				stat.Errors++
+			}
+			totalLatency += result.latency
+		}
+		if len(record.results) > 0 {
+			stat.AvgLatency = totalLatency / time.Duration(len(record.results))
+		}
+		stat.ConsecutiveSuccesses = record.consecutiveSuccesses
+		stat.ConsecutiveFailures = record.consecutiveFailures
+		stat.Failing = record.failing
+	}
+	return stats
+}
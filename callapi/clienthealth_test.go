@@ -0,0 +1,75 @@
+package callapi
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestClientDemoteAndRecover(t *testing.T) {
+	c := newOrderTestCaller(nil, nil)
+	url := c.urls[0]
+	errConn := errors.New("connection refused")
+	isFailing := func() bool { return c.ClientStats()[0].Failing }
+
+	for i := 1; i < DefaultDemoteFailures; i++ {
+		c.recordClientResult(url, errConn, time.Millisecond)
+	}
+	c.recordClientResult(url, nil, time.Millisecond)
+	c.recordClientResult(url, errConn, time.Millisecond)
+	if isFailing() {
+		t.Fatal("client should not be demoted by failures which are not consecutive")
+	}
+	for i := 1; i < DefaultDemoteFailures; i++ {
+		c.recordClientResult(url, errConn, time.Millisecond)
+	}
+	if !isFailing() {
+		t.Fatalf("client should be demoted after %v consecutive failures", DefaultDemoteFailures)
+	}
+	for i := 0; i < 100; i++ {
+		if order := c.clientOrder(c.urls, false); order[0] != 1 && i%healthProbeInterval != healthProbeInterval-1 {
+			t.Fatalf("failing client should be tried last, got order %v at call %v", order, i)
+		}
+	}
+
+	for i := 1; i < DefaultRecoverSuccesses; i++ {
+		c.recordClientResult(url, nil, time.Millisecond)
+	}
+	if !isFailing() {
+		t.Fatal("client should not recover before consecutive successes")
+	}
+	c.recordClientResult(url, nil, time.Millisecond)
+	if isFailing() {
+		t.Fatalf("client should recover after %v consecutive successes", DefaultRecoverSuccesses)
+	}
+}
+
+func TestClientOrderIgnoresLatency(t *testing.T) {
+	const calls = 20000
+	recordLatencies := func(c *APICaller) {
+		for i, url := range c.urls {
+			for j := 0; j < 10; j++ {
+				c.recordClientResult(url, nil, time.Duration(1+50*i)*time.Millisecond)
+			}
+		}
+	}
+
+	c := newOrderTestCaller(nil, nil, nil)
+	recordLatencies(c)
+	for i, count := range countFirstClients(c, 300) {
+		if count != 100 {
+			t.Errorf("client %v is first in %v of 300 calls, want 100 by round-robin", i, count)
+		}
+	}
+
+	weights := []uint64{1, 2, 5}
+	c = newOrderTestCaller(&Endpoint{Weight: weights[0]}, &Endpoint{Weight: weights[1]}, &Endpoint{Weight: weights[2]})
+	recordLatencies(c)
+	for i, count := range countFirstClients(c, calls) {
+		want := float64(weights[i]) / 8
+		if got := float64(count) / calls; math.Abs(got-want) > 0.02 {
+			t.Errorf("client %v of weight %v is first in %.3f of calls, want %.3f", i, weights[i], got, want)
+		}
+	}
+}
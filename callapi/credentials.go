@@ -0,0 +1,132 @@
+package callapi
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"github.com/anyswap/ANYToken-distribution/log"
+)
+
+// Credential credential of a gateway, keep it in a secrets file apart from
+// the gateway list. it's sent as 'Authorization' header of requests to URL
+type Credential struct {
+	URL         string
+	BearerToken string
+	Username    string
+	Password    string
+}
+
+// CredentialsFileConfig gateway secrets file
+type CredentialsFileConfig struct {
+	Credentials []*Credential `toml:"Credential"`
+}
+
+var (
+	credentialsMu     sync.RWMutex
+	credentialHeaders map[string]http.Header // keyed by credentialKey
+)
+
+// LoadCredentialsFile load gateway credentials from toml file
+func LoadCredentialsFile(fileName string) ([]*Credential, error) {
+	credentialsConfig := &CredentialsFileConfig{}
+	if _, err := toml.DecodeFile(fileName, credentialsConfig); err != nil {
+		return nil, err
+	}
+	if len(credentialsConfig.Credentials) == 0 {
+		return nil, fmt.Errorf("no credentials in gateway secrets file %v", fileName)
+	}
+	return credentialsConfig.Credentials, nil
+}
+
+// SetCredentials inject credentials as headers into http requests to their gateways,
+// both of dialed clients (through a local proxy) and of raw rpc calls.
+// websocket gateways are not supported.
+func SetCredentials(credentials []*Credential) error {
+	headers := make(map[string]http.Header, len(credentials))
+	for _, cred := range credentials {
+		u, err := url.Parse(cred.URL)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("wrong credential URL '%v'", RedactURL(cred.URL))
+		}
+		if !isHTTPURL(strings.ToLower(cred.URL)) {
+			return fmt.Errorf("credential of non http URL '%v' is not supported", RedactURL(cred.URL))
+		}
+		header := make(http.Header)
+		switch {
+		case cred.BearerToken != "" && cred.Username != "":
+			return fmt.Errorf("credential of '%v' has both bearer token and basic auth", RedactURL(cred.URL))
+		case cred.BearerToken != "":
+			header.Set("Authorization", "Bearer "+cred.BearerToken)
+		case cred.Username != "":
+			auth := base64.StdEncoding.EncodeToString([]byte(cred.Username + ":" + cred.Password))
+			header.Set("Authorization", "Basic "+auth)
+		default:
+			return fmt.Errorf("credential of '%v' has neither bearer token nor username", RedactURL(cred.URL))
+		}
+		key := credentialKey(u)
+		if _, exist := headers[key]; exist {
+			return fmt.Errorf("duplicate credential of '%v'", RedactURL(cred.URL))
+		}
+		headers[key] = header
+	}
+
+	credentialsMu.Lock()
+	defer credentialsMu.Unlock()
+	credentialHeaders = headers
+	log.Info("[callapi] set gateway credentials", "count", len(headers))
+	return nil
+}
+
+// credentialKey scheme, host and path of URL, without credentials, query and trailing slash
+func credentialKey(u *url.URL) string {
+	return strings.ToLower(u.Scheme) + "://" + strings.ToLower(u.Host) + strings.TrimSuffix(u.Path, "/")
+}
+
+func getCredentialHeader(u *url.URL) http.Header {
+	credentialsMu.RLock()
+	defer credentialsMu.RUnlock()
+	return credentialHeaders[credentialKey(u)]
+}
+
+// credentialTransport add headers of credential to requests to its gateway
+type credentialTransport struct {
+	base http.RoundTripper // nil means http.DefaultTransport
+}
+
+func (t *credentialTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	header := getCredentialHeader(req.URL)
+	if header == nil {
+		return base.RoundTrip(req)
+	}
+	// must not modify the request, clone it before adding headers
+	req = req.Clone(req.Context())
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	return base.RoundTrip(req)
+}
+
+// RedactURL remove user info and query (which may contain api keys) of URL for logging
+func RedactURL(rawurl string) string {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return "<unparsable URL>"
+	}
+	if u.User == nil && u.RawQuery == "" {
+		return rawurl
+	}
+	u.User = nil
+	if u.RawQuery != "" {
+		u.RawQuery = "redacted"
+	}
+	return u.String()
+}
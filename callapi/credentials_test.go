@@ -0,0 +1,70 @@
+package callapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+func TestSetCredentials(t *testing.T) {
+	var mu sync.Mutex
+	var auths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		auths = append(auths, r.Header.Get("Authorization"))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x1"}`))
+	}))
+	defer server.Close()
+
+	defaultTransport := http.DefaultTransport
+	err := SetCredentials([]*Credential{{URL: server.URL + "/", BearerToken: "secret"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		credentialsMu.Lock()
+		credentialHeaders = nil
+		credentialsMu.Unlock()
+	})
+	if http.DefaultTransport != defaultTransport {
+		t.Fatal("default transport should not be replaced")
+	}
+
+	c := NewAPICaller(context.Background(), 1, time.Millisecond, WithCallTimeout(5*time.Second))
+	client, err := c.dialWithRetry(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if _, err = client.BalanceAt(context.Background(), common.Address{}, nil); err != nil {
+		t.Fatalf("call dialed client failed: %v", err)
+	}
+	var result string
+	if err = doRPCCall(context.Background(), server.URL, &result, "eth_getBalance", nil); err != nil {
+		t.Fatalf("raw rpc call failed: %v", err)
+	}
+	resp, err := http.Post(server.URL, "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"Bearer secret", "Bearer secret", ""}
+	if len(auths) != len(want) {
+		t.Fatalf("got %v requests, want %v", len(auths), len(want))
+	}
+	for i, auth := range auths {
+		if auth != want[i] {
+			t.Errorf("request %v: got authorization %q, want %q", i, auth, want[i])
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package callapi
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// gateway roles
+const (
+	RolePrimary = "primary"
+	RoleArchive = "archive"
+)
+
+// Endpoint gateway server with weight in client selection and role
+type Endpoint struct {
+	URL    string
+	Weight uint64 // relative weight of selecting it first (0 means 1)
+	Role   string // primary (default) or archive
+}
+
+var endpointRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+
+// DialEndpoints dial gateway endpoints. clients are tried in weighted random order,
+// and archive clients are tried first for state queries at history block
+func (c *APICaller) DialEndpoints(endpoints []*Endpoint) error {
+	urls := make([]string, len(endpoints))
+	c.endpoints = make(map[string]*Endpoint, len(endpoints))
+	for i, endpoint := range endpoints {
+		urls[i] = endpoint.URL
+		c.endpoints[endpoint.URL] = endpoint
+	}
+	return c.DialServer(urls)
+}
+
+func (c *APICaller) getEndpoint(url string) *Endpoint {
+	if endpoint, exist := c.endpoints[url]; exist {
+		return endpoint
+	}
+	return &Endpoint{URL: url}
+}
+
+// clientOrder indexes of clients in the order of trying. without endpoints config
+// it's the dialing order rotated by a cursor advanced every call, so the load is
+// spread over all clients. otherwise clients of the preferred role are tried first,
+// and clients of the same role are ordered by weighted random sampling.
+// in both cases recently failing clients are tried after the others.
+func (c *APICaller) clientOrder(urls []string, archive bool) []int {
+	order := make([]int, len(urls))
+	if len(c.endpoints) == 0 {
+		if len(order) == 0 {
+			return order
+		}
+		start := int(atomic.AddUint32(&c.clientCursor, 1)-1) % len(order)
+		for i := range order {
+			order[i] = (start + i) % len(order)
+		}
+		return c.orderByHealth(order, urls)
+	}
+	for i := range order {
+		order[i] = i
+	}
+	preferRole := RolePrimary
+	if archive {
+		preferRole = RoleArchive
+	}
+	keys := make([]float64, len(order))
+	preferred := make([]bool, len(order))
+	c.endpointMu.Lock()
+	for i := range order {
+		endpoint := c.getEndpoint(urls[i])
+		weight := endpoint.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		// smaller key is selected first, with probability proportional to weight
+		keys[i] = -math.Log(1-endpointRand.Float64()) / float64(weight)
+		role := endpoint.Role
+		if role == "" {
+			role = RolePrimary
+		}
+		preferred[i] = role == preferRole
+	}
+	c.endpointMu.Unlock()
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		if preferred[a] != preferred[b] {
+			return preferred[a]
+		}
+		return keys[a] < keys[b]
+	})
+	return c.orderByHealth(order, urls)
+}
+
+// orderedURLs server URLs in the order of trying
+func (c *APICaller) orderedURLs() []string {
+	_, urls := c.getClients()
+	order := c.clientOrder(urls, false)
+	ordered := make([]string, len(order))
+	for i, idx := range order {
+		ordered[i] = urls[idx]
+	}
+	return ordered
+}
@@ -0,0 +1,77 @@
+package callapi
+
+import (
+	"math"
+	"testing"
+
+	"github.com/fsn-dev/fsn-go-sdk/efsn/ethclient"
+)
+
+func newOrderTestCaller(endpoints ...*Endpoint) *APICaller {
+	c := NewDefaultAPICaller()
+	c.clients = make([]*ethclient.Client, len(endpoints))
+	c.urls = make([]string, len(endpoints))
+	if endpoints[0] != nil {
+		c.endpoints = make(map[string]*Endpoint, len(endpoints))
+	}
+	for i, endpoint := range endpoints {
+		c.urls[i] = string(rune('a' + i))
+		if endpoint != nil {
+			endpoint.URL = c.urls[i]
+			c.endpoints[endpoint.URL] = endpoint
+		}
+	}
+	return c
+}
+
+func countFirstClients(c *APICaller, calls int) []int {
+	counts := make([]int, len(c.clients))
+	for i := 0; i < calls; i++ {
+		counts[c.clientOrder(c.urls, false)[0]]++
+	}
+	return counts
+}
+
+func TestClientOrderWeighted(t *testing.T) {
+	const calls = 20000
+	weights := []uint64{1, 2, 5, 0} // 0 means 1
+	endpoints := make([]*Endpoint, len(weights))
+	var sum float64
+	for i, weight := range weights {
+		endpoints[i] = &Endpoint{Weight: weight}
+		sum += math.Max(float64(weight), 1)
+	}
+	c := newOrderTestCaller(endpoints...)
+	for i, count := range countFirstClients(c, calls) {
+		want := math.Max(float64(weights[i]), 1) / sum
+		if got := float64(count) / calls; math.Abs(got-want) > 0.02 {
+			t.Errorf("client %v of weight %v is first in %.3f of calls, want %.3f", i, weights[i], got, want)
+		}
+	}
+}
+
+func TestClientOrderPreferRole(t *testing.T) {
+	c := newOrderTestCaller(
+		&Endpoint{Weight: 100},
+		&Endpoint{Weight: 1, Role: RoleArchive},
+		&Endpoint{Weight: 1, Role: RolePrimary},
+	)
+	for i := 0; i < 100; i++ {
+		if first := c.clientOrder(c.urls, true)[0]; first != 1 {
+			t.Fatalf("archive client should be first for history state, got %v", first)
+		}
+		if order := c.clientOrder(c.urls, false); order[2] != 1 {
+			t.Fatalf("archive client should be last for latest state, got %v", order)
+		}
+	}
+}
+
+func TestClientOrderRoundRobin(t *testing.T) {
+	const calls = 300
+	c := newOrderTestCaller(nil, nil, nil)
+	for i, count := range countFirstClients(c, calls) {
+		if count != calls/3 {
+			t.Errorf("client %v is first in %v of %v calls, want %v", i, count, calls, calls/3)
+		}
+	}
+}
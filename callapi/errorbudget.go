@@ -0,0 +1,103 @@
+package callapi
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrSystemicOutage too many rpc failures, maybe all nodes are out of service
+var ErrSystemicOutage = errors.New("systemic outage")
+
+// ErrorBudget rpc failures budget shared across a whole run
+type ErrorBudget struct {
+	MaxFailures    int           // abort when failures in window reach it (0 means no limit)
+	MaxFailureRate float64       // abort when failure rate in window reach it (0 means no limit)
+	MinSamples     int           // minimum calls in window before checking failure rate
+	Window         time.Duration // sliding window (0 means the whole run)
+
+	mu       sync.Mutex
+	records  []budgetRecord // calls in sliding window
+	total    int            // calls of the whole run if there is no window
+	failures int
+}
+
+type budgetRecord struct {
+	timestamp time.Time
+	failed    bool
+}
+
+// NewErrorBudget new error budget
+func NewErrorBudget(maxFailures int, maxFailureRate float64, window time.Duration) *ErrorBudget {
+	minSamples := maxFailures
+	if minSamples < 10 {
+		minSamples = 10
+	}
+	return &ErrorBudget{
+		MaxFailures:    maxFailures,
+		MaxFailureRate: maxFailureRate,
+		MinSamples:     minSamples,
+		Window:         window,
+	}
+}
+
+// Record record rpc call result
+func (b *ErrorBudget) Record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.Window <= 0 {
+		b.total++
+		if failed {
+			b.failures++
+		}
+		return
+	}
+	now := time.Now()
+	b.evict(now)
+	b.records = append(b.records, budgetRecord{timestamp: now, failed: failed})
+}
+
+func (b *ErrorBudget) evict(now time.Time) {
+	if b.Window <= 0 {
+		return
+	}
+	i := 0
+	for ; i < len(b.records); i++ {
+		if now.Sub(b.records[i].timestamp) <= b.Window {
+			break
+		}
+	}
+	b.records = b.records[i:]
+}
+
+// Stat get total calls and failures in window
+func (b *ErrorBudget) Stat() (total, failures int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.Window <= 0 {
+		return b.total, b.failures
+	}
+	b.evict(time.Now())
+	for _, record := range b.records {
+		if record.failed {
+			failures++
+		}
+	}
+	return len(b.records), failures
+}
+
+// Check return ErrSystemicOutage if budget is exhausted
+func (b *ErrorBudget) Check() error {
+	total, failures := b.Stat()
+	if b.MaxFailures > 0 && failures >= b.MaxFailures {
+		return fmt.Errorf("%w: %v rpc failures (max %v) in window %v", ErrSystemicOutage, failures, b.MaxFailures, b.Window)
+	}
+	if b.MaxFailureRate > 0 && total >= b.MinSamples {
+		rate := float64(failures) / float64(total)
+		if rate >= b.MaxFailureRate {
+			return fmt.Errorf("%w: rpc failure rate %.2f (max %.2f, %v/%v) in window %v", ErrSystemicOutage, rate, b.MaxFailureRate, failures, total, b.Window)
+		}
+	}
+	return nil
+}
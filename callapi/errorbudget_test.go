@@ -0,0 +1,60 @@
+package callapi
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestErrorBudgetWholeRun(t *testing.T) {
+	budget := NewErrorBudget(3, 0, 0)
+	for i := 0; i < 1000; i++ {
+		budget.Record(false)
+	}
+	budget.Record(true)
+	budget.Record(true)
+	if total, failures := budget.Stat(); total != 1002 || failures != 2 {
+		t.Fatalf("got total %v failures %v, want 1002 and 2", total, failures)
+	}
+	if len(budget.records) != 0 {
+		t.Fatalf("records should not grow without window, got %v", len(budget.records))
+	}
+	if err := budget.Check(); err != nil {
+		t.Fatalf("budget should not be exhausted, got %v", err)
+	}
+	budget.Record(true)
+	if err := budget.Check(); !errors.Is(err, ErrSystemicOutage) {
+		t.Fatalf("want systemic outage, got %v", err)
+	}
+}
+
+func TestErrorBudgetWindow(t *testing.T) {
+	budget := NewErrorBudget(2, 0, 50*time.Millisecond)
+	budget.Record(true)
+	budget.Record(true)
+	if err := budget.Check(); !errors.Is(err, ErrSystemicOutage) {
+		t.Fatalf("want systemic outage, got %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	budget.Record(false)
+	if total, failures := budget.Stat(); total != 1 || failures != 0 {
+		t.Fatalf("failures out of window should be evicted, got total %v failures %v", total, failures)
+	}
+}
+
+func TestRecordResultSkipsRequestErrors(t *testing.T) {
+	c := NewAPICaller(context.Background(), 1, 0, WithCallTimeout(0))
+	budget := NewErrorBudget(1, 0, 0)
+	c.SetErrorBudget(budget)
+	c.recordResult(errors.New("execution reverted: transfer amount exceeds balance"))
+	c.recordResult(errors.New("nonce too low"))
+	if total, _ := budget.Stat(); total != 0 {
+		t.Fatalf("revert and nonce too low should not be recorded, got %v records", total)
+	}
+	c.recordResult(nil)
+	c.recordResult(errors.New("connection refused"))
+	if total, failures := budget.Stat(); total != 2 || failures != 1 {
+		t.Fatalf("got total %v failures %v, want 2 and 1", total, failures)
+	}
+}
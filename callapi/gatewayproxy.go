@@ -0,0 +1,60 @@
+package callapi
+
+import (
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+)
+
+// the sdk dials http gateways with an http client of its own, so requests of dialed
+// clients to gateways with credentials (or all gateways if rpc tracing is enabled)
+// are sent through a local proxy owned by the process, which forwards them by
+// gatewayTransport, like raw rpc calls.
+var (
+	gatewayProxiesMu sync.Mutex
+	gatewayProxies   = make(map[string]string) // gateway URL -> proxy URL
+)
+
+// getDialURL URL to dial the gateway, which is a local proxy if the gateway needs one
+func getDialURL(rawurl string) (string, error) {
+	if !isHTTPURL(strings.ToLower(rawurl)) {
+		return rawurl, nil
+	}
+	target, err := url.Parse(rawurl)
+	if err != nil || (getCredentialHeader(target) == nil && !rpcTracingEnabled) {
+		return rawurl, nil
+	}
+
+	gatewayProxiesMu.Lock()
+	defer gatewayProxiesMu.Unlock()
+	if proxyURL, exist := gatewayProxies[rawurl]; exist {
+		return proxyURL, nil
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	proxy := &httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			req.URL.Scheme = target.Scheme
+			req.URL.Host = target.Host
+			req.URL.Path = target.Path
+			req.URL.RawQuery = target.RawQuery
+			req.Host = target.Host
+		},
+		Transport: gatewayTransport,
+	}
+	go func() {
+		err := http.Serve(listener, proxy)
+		log.Warn("[callapi] gateway proxy stopped", "gateway", RedactURL(rawurl), "err", err)
+	}()
+	proxyURL := "http://" + listener.Addr().String()
+	gatewayProxies[rawurl] = proxyURL
+	log.Info("[callapi] start gateway proxy", "gateway", RedactURL(rawurl), "proxy", proxyURL)
+	return proxyURL, nil
+}
@@ -2,7 +2,6 @@ package callapi
 
 import (
 	"math/big"
-	"time"
 
 	"github.com/anyswap/ANYToken-distribution/log"
 	ethereum "github.com/fsn-dev/fsn-go-sdk/efsn"
@@ -12,31 +11,46 @@ import (
 
 // LoopGetBlockHeader loop get block header
 func (c *APICaller) LoopGetBlockHeader(blockNumber *big.Int) *types.Header {
-	for {
-		header, err := c.HeaderByNumber(blockNumber)
-		if err == nil {
-			return header
+	var header *types.Header
+	c.loopUntilSuccess(func() (err error) {
+		header, err = c.HeaderByNumber(blockNumber)
+		if err != nil {
+			log.Error("[callapi] get block header failed.", "blockNumber", blockNumber, "err", err)
 		}
-		log.Error("[callapi] get block header failed.", "blockNumber", blockNumber, "err", err)
-		time.Sleep(c.rpcRetryInterval)
-	}
+		return err
+	})
+	return header
 }
 
 // LoopGetLatestBlockHeader loop get latest block header
 func (c *APICaller) LoopGetLatestBlockHeader() *types.Header {
-	for {
-		header, err := c.HeaderByNumber(nil)
-		if err == nil {
-			log.Info("[callapi] get latest block header succeed.",
-				"number", header.Number,
-				"hash", header.Hash().String(),
-				"timestamp", header.Time,
-			)
-			return header
+	var header *types.Header
+	c.loopUntilSuccess(func() (err error) {
+		header, err = c.HeaderByNumber(nil)
+		if err != nil {
+			log.Error("[callapi] get latest block header failed.", "err", err)
 		}
-		log.Error("[callapi] get latest block header failed.", "err", err)
-		time.Sleep(c.rpcRetryInterval)
-	}
+		return err
+	})
+	log.Info("[callapi] get latest block header succeed.",
+		"number", header.Number,
+		"hash", header.Hash().String(),
+		"timestamp", header.Time,
+	)
+	return header
+}
+
+// LoopGetBlockHash loop get block hash of block at height number
+func (c *APICaller) LoopGetBlockHash(number uint64) common.Hash {
+	var blockHash common.Hash
+	c.loopUntilSuccess(func() (err error) {
+		blockHash, err = c.GetBlockHashByNumber(number)
+		if err != nil {
+			log.Error("[callapi] get block hash failed.", "number", number, "err", err)
+		}
+		return err
+	})
+	return blockHash
 }
 
 // LoopGetExchangeLiquidity get exchange liquidity
@@ -47,30 +61,26 @@ func (c *APICaller) LoopGetExchangeLiquidity(exchangeAddr common.Address, blockN
 // LoopGetTokenTotalSupply get token total supply
 func (c *APICaller) LoopGetTokenTotalSupply(address common.Address, blockNumber *big.Int) *big.Int {
 	var totalSupply *big.Int
-	var err error
-	for {
+	c.loopUntilSuccess(func() (err error) {
 		totalSupply, err = c.GetTokenTotalSupply(address, blockNumber)
-		if err == nil {
-			break
+		if err != nil {
+			log.Error("[callapi] GetTokenTotalSupply error", "address", address.String(), "err", err)
 		}
-		log.Error("[callapi] GetTokenTotalSupply error", "address", address.String(), "err", err)
-		time.Sleep(c.rpcRetryInterval)
-	}
+		return err
+	})
 	return totalSupply
 }
 
 // LoopGetCoinBalance get coin balance
 func (c *APICaller) LoopGetCoinBalance(address common.Address, blockNumber *big.Int) *big.Int {
 	var fsnBalance *big.Int
-	var err error
-	for {
+	c.loopUntilSuccess(func() (err error) {
 		fsnBalance, err = c.GetCoinBalance(address, blockNumber)
-		if err == nil {
-			break
+		if err != nil {
+			log.Error("[callapi] GetCoinBalance error", "address", address.String(), "err", err)
 		}
-		log.Error("[callapi] GetCoinBalance error", "address", address.String(), "err", err)
-		time.Sleep(c.rpcRetryInterval)
-	}
+		return err
+	})
 	return fsnBalance
 }
 
@@ -87,15 +97,13 @@ func (c *APICaller) LoopGetLiquidityBalance(exchange, account common.Address, bl
 // LoopGetTokenBalance get account token balance
 func (c *APICaller) LoopGetTokenBalance(tokenAddr, account common.Address, blockNumber *big.Int) *big.Int {
 	var tokenBalance *big.Int
-	var err error
-	for {
+	c.loopUntilSuccess(func() (err error) {
 		tokenBalance, err = c.GetTokenBalance(tokenAddr, account, blockNumber)
-		if err == nil {
-			break
+		if err != nil {
+			log.Error("[callapi] GetTokenBalance error", "token", tokenAddr.String(), "account", account.String(), "err", err)
 		}
-		log.Error("[callapi] GetTokenBalance error", "token", tokenAddr.String(), "account", account.String(), "err", err)
-		time.Sleep(c.rpcRetryInterval)
-	}
+		return err
+	})
 	return tokenBalance
 }
 
@@ -112,7 +120,6 @@ func (c *APICaller) LoopGetFactoryToken(factory, exchange common.Address) common
 func (c *APICaller) loopGetFactoryExcahngeOrToken(factory, address common.Address, isGetExchange bool) common.Address {
 	var (
 		res []byte
-		err error
 
 		getExchangeFuncHash = common.FromHex("0x06f2bf62")
 		getTokenFuncHash    = common.FromHex("0x59770438")
@@ -128,14 +135,13 @@ func (c *APICaller) loopGetFactoryExcahngeOrToken(factory, address common.Addres
 		To:   &factory,
 		Data: data,
 	}
-	for {
+	c.loopUntilSuccess(func() (err error) {
 		res, err = c.DoCall(msg, nil)
-		if err == nil {
-			break
+		if err != nil {
+			log.Error("[callapi] GetFactoryExcahngeOrToken error", "factory", factory.String(), "address", address.String(), "isGetExchange", isGetExchange, "err", err)
 		}
-		log.Error("[callapi] GetFactoryExcahngeOrToken error", "factory", factory.String(), "address", address.String(), "isGetExchange", isGetExchange, "err", err)
-		time.Sleep(c.rpcRetryInterval)
-	}
+		return err
+	})
 	return common.BytesToAddress(common.GetData(res, 0, 32))
 }
 
@@ -143,7 +149,6 @@ func (c *APICaller) loopGetFactoryExcahngeOrToken(factory, address common.Addres
 func (c *APICaller) LoopGetFactoryTokenCount(factory common.Address) uint64 {
 	var (
 		res []byte
-		err error
 
 		getTokenCountFuncHash = common.FromHex("0x9f181b5e")
 	)
@@ -151,14 +156,13 @@ func (c *APICaller) LoopGetFactoryTokenCount(factory common.Address) uint64 {
 		To:   &factory,
 		Data: getTokenCountFuncHash,
 	}
-	for i := 0; i < c.rpcRetryCount; i++ {
+	_ = c.retryCall(func() (err error) {
 		res, err = c.DoCall(msg, nil)
-		if err == nil {
-			break
+		if err != nil {
+			log.Error("[callapi] GetFactoryTokenCount error", "factory", factory.String(), "err", err)
 		}
-		log.Error("[callapi] GetFactoryTokenCount error", "factory", factory.String(), "err", err)
-		time.Sleep(c.rpcRetryInterval)
-	}
+		return err
+	})
 	return new(big.Int).SetBytes(common.GetData(res, 0, 32)).Uint64()
 }
 
@@ -166,7 +170,6 @@ func (c *APICaller) LoopGetFactoryTokenCount(factory common.Address) uint64 {
 func (c *APICaller) LoopGetFactoryTokenWithID(factory common.Address, id uint64) common.Address {
 	var (
 		res []byte
-		err error
 
 		getTokenWithIDFuncHash = common.FromHex("0xaa65a6c0")
 	)
@@ -177,13 +180,12 @@ func (c *APICaller) LoopGetFactoryTokenWithID(factory common.Address, id uint64)
 		To:   &factory,
 		Data: data,
 	}
-	for i := 0; i < c.rpcRetryCount; i++ {
+	_ = c.retryCall(func() (err error) {
 		res, err = c.DoCall(msg, nil)
-		if err == nil {
-			break
+		if err != nil {
+			log.Error("[callapi] GetFactoryTokenWithID error", "factory", factory.String(), "id", id, "err", err)
 		}
-		log.Error("[callapi] GetFactoryTokenWithID error", "factory", factory.String(), "id", id, "err", err)
-		time.Sleep(c.rpcRetryInterval)
-	}
+		return err
+	})
 	return common.BytesToAddress(common.GetData(res, 0, 32))
 }
@@ -0,0 +1,72 @@
+package callapi
+
+import (
+	"strings"
+	"time"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+)
+
+const (
+	// DefaultMempoolFullRetries default retries of sending tx rejected by full mempool
+	DefaultMempoolFullRetries = 5
+
+	// DefaultMempoolFullBackoff default first backoff of retrying full mempool, doubled every retry
+	DefaultMempoolFullBackoff = 15 * time.Second
+
+	// maxMempoolFullBackoff cap of doubled backoff
+	maxMempoolFullBackoff = 2 * time.Minute
+)
+
+// node specific error strings of full mempool, they are transient
+// and usually clear within a block or two
+var mempoolFullErrorStrings = []string{
+	"txpool is full",
+	"transaction pool is full",
+	"mempool is full",
+	"mempool limit reached",
+	"txpool limit reached",
+}
+
+// IsMempoolFullError is transient mempool (txpool) full error
+func IsMempoolFullError(err error) bool {
+	if err == nil {
+		return false
+	}
+	errStr := strings.ToLower(err.Error())
+	for _, str := range mempoolFullErrorStrings {
+		if strings.Contains(errStr, str) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetMempoolFullRetry set retries (0 means no retry) and first backoff
+// of sending tx rejected by full mempool of all clients
+func (c *APICaller) SetMempoolFullRetry(retries int, backoff time.Duration) {
+	c.mempoolFullRetries = retries
+	c.mempoolFullBackoff = backoff
+}
+
+// retryMempoolFull call send and retry with doubled backoff while all clients reject it
+// by full mempool. resending the same signed tx is safe, it's either accepted once or known.
+func (c *APICaller) retryMempoolFull(send func() error) (err error) {
+	backoff := c.mempoolFullBackoff
+	for retry := 0; ; retry++ {
+		err = send()
+		if !IsMempoolFullError(err) || retry >= c.mempoolFullRetries {
+			return err
+		}
+		log.Warn("[callapi] mempool is full, retry sending tx later", "retry", retry+1, "maxRetries", c.mempoolFullRetries, "backoff", backoff, "err", err)
+		select {
+		case <-c.context.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > maxMempoolFullBackoff {
+			backoff = maxMempoolFullBackoff
+		}
+	}
+}
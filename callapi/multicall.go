@@ -0,0 +1,179 @@
+package callapi
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+// tryAggregate(bool,(address,bytes)[]) of Multicall2
+var tryAggregateFuncHash = common.FromHex("0xbce38bd7")
+
+// CallEntry contract call in multicall
+type CallEntry struct {
+	Target common.Address
+	Data   []byte
+}
+
+// CallEntryResult result of contract call in multicall,
+// Success is false if the call reverted
+type CallEntryResult struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// SetMulticallAddress set Multicall2 contract address (nil means disable multicall)
+func (c *APICaller) SetMulticallAddress(multicall *common.Address) {
+	c.multicall = multicall
+}
+
+// GetMulticallAddress get Multicall2 contract address (nil if multicall is disabled)
+func (c *APICaller) GetMulticallAddress() *common.Address {
+	return c.multicall
+}
+
+// CallBatchContract call contracts by Multicall2 tryAggregate in chunks of rpc batch size,
+// fallback to sequential CallContract if multicall address is not set.
+// a reverted call does not fail others, it's result has Success of false.
+func (c *APICaller) CallBatchContract(calls []CallEntry, blockNumber *big.Int) ([]*CallEntryResult, error) {
+	results := make([]*CallEntryResult, len(calls))
+	if c.multicall == nil {
+		for i, call := range calls {
+			res, err := c.CallContract(call.Target, call.Data, blockNumber)
+			if err != nil && !IsRevertError(err) {
+				return nil, err
+			}
+			results[i] = &CallEntryResult{Success: err == nil, ReturnData: res}
+		}
+		return results, nil
+	}
+	chunkSize := c.rpcBatchSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultRPCBatchSize
+	}
+	for start := 0; start < len(calls); start += chunkSize {
+		end := start + chunkSize
+		if end > len(calls) {
+			end = len(calls)
+		}
+		res, err := c.CallContract(*c.multicall, packTryAggregate(calls[start:end]), blockNumber)
+		if err != nil {
+			return nil, err
+		}
+		chunkResults, err := unpackTryAggregateResults(res)
+		if err != nil {
+			return nil, err
+		}
+		if len(chunkResults) != end-start {
+			return nil, fmt.Errorf("multicall returns %v results for %v calls", len(chunkResults), end-start)
+		}
+		copy(results[start:end], chunkResults)
+	}
+	return results, nil
+}
+
+func padTo32(length int) int {
+	return (length + 31) / 32 * 32
+}
+
+func wordOf(value int) []byte {
+	return common.LeftPadBytes(big.NewInt(int64(value)).Bytes(), 32)
+}
+
+// packTryAggregate abi encode tryAggregate(false, calls)
+func packTryAggregate(calls []CallEntry) []byte {
+	data := packBytes(tryAggregateFuncHash, wordOf(0), wordOf(64), wordOf(len(calls)))
+	// tuples are dynamic, heads are offsets relative to the first head
+	offset := 32 * len(calls)
+	for _, call := range calls {
+		data = append(data, wordOf(offset)...)
+		offset += 96 + padTo32(len(call.Data))
+	}
+	for _, call := range calls {
+		data = append(data, common.LeftPadBytes(call.Target.Bytes(), 32)...)
+		data = append(data, wordOf(64)...)
+		data = append(data, wordOf(len(call.Data))...)
+		data = append(data, common.RightPadBytes(call.Data, padTo32(len(call.Data)))...)
+	}
+	return data
+}
+
+func readWord(data []byte, pos uint64) (uint64, error) {
+	if uint64(len(data)) < pos+32 {
+		return 0, errAccessDataOverflow
+	}
+	value, overflow := common.GetUint64(data, pos, 32)
+	if overflow {
+		return 0, errAccessDataOverflow
+	}
+	return value, nil
+}
+
+// unpackTryAggregateResults abi decode (bool success, bytes returnData)[]
+func unpackTryAggregateResults(data []byte) ([]*CallEntryResult, error) {
+	arrayPos, err := readWord(data, 0)
+	if err != nil {
+		return nil, err
+	}
+	count, err := readWord(data, arrayPos)
+	if err != nil {
+		return nil, err
+	}
+	headsPos := arrayPos + 32
+	if count > uint64(len(data))/32 {
+		return nil, errAccessDataOverflow
+	}
+	results := make([]*CallEntryResult, count)
+	for i := uint64(0); i < count; i++ {
+		tupleOffset, err := readWord(data, headsPos+i*32)
+		if err != nil {
+			return nil, err
+		}
+		tuplePos := headsPos + tupleOffset
+		success, err := readWord(data, tuplePos)
+		if err != nil {
+			return nil, err
+		}
+		bytesOffset, err := readWord(data, tuplePos+32)
+		if err != nil {
+			return nil, err
+		}
+		bytesPos := tuplePos + bytesOffset
+		length, err := readWord(data, bytesPos)
+		if err != nil {
+			return nil, err
+		}
+		if uint64(len(data)) < bytesPos+32+length {
+			return nil, errAccessDataOverflow
+		}
+		results[i] = &CallEntryResult{
+			Success:    success != 0,
+			ReturnData: common.CopyBytes(data[bytesPos+32 : bytesPos+32+length]),
+		}
+	}
+	return results, nil
+}
+
+// multicallGetTokenBalances get token balances of accounts by multicall,
+// value is nil if the read of the account failed
+func (c *APICaller) multicallGetTokenBalances(token common.Address, accounts []common.Address, blockNumber *big.Int) ([]*big.Int, error) {
+	balanceOfFuncHash := common.FromHex("0x70a08231")
+	calls := make([]CallEntry, len(accounts))
+	for i, account := range accounts {
+		calls[i] = CallEntry{Target: token, Data: packBytes(balanceOfFuncHash, account.Bytes())}
+	}
+	results, err := c.CallBatchContract(calls, blockNumber)
+	if err != nil {
+		log.Warn("[callapi] multicall get token balances error", "token", token.String(), "accounts", len(accounts), "blockNumber", blockNumber, "err", err)
+		return nil, err
+	}
+	values := make([]*big.Int, len(results))
+	for i, result := range results {
+		if result.Success && len(result.ReturnData) >= 32 {
+			values[i] = common.GetBigInt(result.ReturnData, 0, 32)
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,71 @@
+package callapi
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/fsn-dev/fsn-go-sdk/efsn/ethclient"
+)
+
+// SetParallelRead set whether read calls (DoCall, BalanceAt, HeaderByNumber) are sent
+// to all clients concurrently and take the fastest successful response,
+// instead of trying clients one by one
+func (c *APICaller) SetParallelRead(parallel bool) {
+	c.parallelRead = parallel
+}
+
+type readResult struct {
+	index  int
+	result interface{}
+	err    error
+}
+
+// readClientsAt read from clients for state at blockNumber. in parallel read mode all clients
+// not in rate limit cool down are called concurrently, the first success is returned and
+// the others are cancelled. error is returned only if all clients failed.
+// every call is given up after call timeout, then the next client is tried in serial mode.
+func (c *APICaller) readClientsAt(blockNumber *big.Int, call func(ctx context.Context, client *ethclient.Client) (interface{}, error)) (interface{}, error) {
+	clients, urls := c.getClients()
+	var indexes []int
+	if c.parallelRead {
+		for i := range clients {
+			if c.coolDownLeft(urls[i]) <= 0 {
+				indexes = append(indexes, i)
+			}
+		}
+	}
+	if len(indexes) <= 1 {
+		var result interface{}
+		err := c.callClientsAt(blockNumber, func(client *ethclient.Client) (errf error) {
+			ctx, cancel := c.callContext()
+			defer cancel()
+			result, errf = call(ctx, client)
+			return errf
+		})
+		return result, err
+	}
+
+	ctx, cancel := c.callContext()
+	defer cancel()
+	results := make(chan *readResult, len(indexes)) // buffered, so losers never block
+	for _, i := range indexes {
+		go func(i int) {
+			start := time.Now()
+			result, err := call(ctx, clients[i])
+			c.recordClientResult(urls[i], err, time.Since(start))
+			c.checkConnection(i, urls[i], err)
+			results <- &readResult{index: i, result: result, err: err}
+		}(i)
+	}
+	var err error
+	for range indexes {
+		res := <-results
+		if res.err == nil {
+			return res.result, nil
+		}
+		c.checkRateLimit(urls[res.index], res.err)
+		err = res.err
+	}
+	return nil, err
+}
@@ -0,0 +1,49 @@
+package callapi
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+func balanceStub(b *testing.B, delay time.Duration) *rpcStub {
+	return newRPCStub(b, func(method string, params []json.RawMessage) (interface{}, error) {
+		if method != "eth_getBalance" {
+			return nil, errors.New("method not supported")
+		}
+		time.Sleep(delay)
+		return "0x1", nil
+	})
+}
+
+// BenchmarkParallelRead read balance with a slow primary gateway and a fast secondary one
+func BenchmarkParallelRead(b *testing.B) {
+	primary := balanceStub(b, 20*time.Millisecond)
+	secondary := balanceStub(b, 0)
+	account := common.HexToAddress("0x1111111111111111111111111111111111111111")
+
+	for _, parallel := range []bool{false, true} {
+		name := "serial"
+		if parallel {
+			name = "parallel"
+		}
+		b.Run(name, func(b *testing.B) {
+			c := newStubCaller(b, 1, primary.URL, secondary.URL)
+			// the slow primary is always tried first in serial mode
+			c.endpoints = map[string]*Endpoint{
+				primary.URL:   {URL: primary.URL, Role: RolePrimary},
+				secondary.URL: {URL: secondary.URL, Role: RoleArchive},
+			}
+			c.SetParallelRead(parallel)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := c.BalanceAt(account, nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package callapi
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/ethclient"
+)
+
+const (
+	// DefaultRateLimitCoolDown default cool down of rate limited server
+	DefaultRateLimitCoolDown = 10 * time.Second
+
+	// maxRateLimitCoolDown cap of Retry-After hint
+	maxRateLimitCoolDown = 5 * time.Minute
+)
+
+// provider specific error strings of rate limit
+var rateLimitErrorStrings = []string{
+	"too many requests",
+	"rate limit",
+	"ratelimit",
+	"request limit",
+}
+
+// RateLimitError rate limit response with optional Retry-After hint
+type RateLimitError struct {
+	RetryAfter time.Duration
+	Status     string
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limited, http status %v, retry after %v", e.Status, e.RetryAfter)
+}
+
+func newRateLimitError(resp *http.Response) *RateLimitError {
+	return &RateLimitError{
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		Status:     resp.Status,
+	}
+}
+
+// parseRetryAfter parse Retry-After header of delay seconds or http date
+func parseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseUint(value, 10, 64); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
+
+// isRateLimitError check if err is rate limit response, and get its retry after hint
+func isRateLimitError(err error) (isRateLimit bool, retryAfter time.Duration) {
+	if err == nil {
+		return false, 0
+	}
+	var rateLimitErr *RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		return true, rateLimitErr.RetryAfter
+	}
+	errStr := strings.ToLower(err.Error())
+	for _, str := range rateLimitErrorStrings {
+		if strings.Contains(errStr, str) {
+			return true, 0
+		}
+	}
+	return false, 0
+}
+
+// SetRateLimitCoolDown set cool down of rate limited server if it has no Retry-After hint
+func (c *APICaller) SetRateLimitCoolDown(coolDown time.Duration) {
+	c.rateLimitCoolDown = coolDown
+}
+
+type coolDownState struct {
+	mu    sync.Mutex
+	until map[string]time.Time
+}
+
+// coolDownLeft get left cool down time of server
+func (c *APICaller) coolDownLeft(url string) time.Duration {
+	c.coolDown.mu.Lock()
+	defer c.coolDown.mu.Unlock()
+	return time.Until(c.coolDown.until[url])
+}
+
+// checkRateLimit start cool down of server if err is rate limit response
+func (c *APICaller) checkRateLimit(url string, err error) bool {
+	isRateLimit, retryAfter := isRateLimitError(err)
+	if !isRateLimit {
+		return false
+	}
+	if retryAfter <= 0 {
+		retryAfter = c.rateLimitCoolDown
+	}
+	if retryAfter > maxRateLimitCoolDown {
+		retryAfter = maxRateLimitCoolDown
+	}
+	c.coolDown.mu.Lock()
+	if c.coolDown.until == nil {
+		c.coolDown.until = make(map[string]time.Time)
+	}
+	c.coolDown.until[url] = time.Now().Add(retryAfter)
+	c.coolDown.mu.Unlock()
+	log.Warn("[callapi] server is rate limited, cool down", "server", RedactURL(url), "coolDown", retryAfter, "err", err)
+	return true
+}
+
+// waitCoolDown wait server cool down finished, return false if context is done
+func (c *APICaller) waitCoolDown(url string) bool {
+	wait := c.coolDownLeft(url)
+	if wait <= 0 {
+		return true
+	}
+	log.Info("[callapi] wait rate limited server cool down", "server", RedactURL(url), "wait", wait)
+	select {
+	case <-time.After(wait):
+		return true
+	case <-c.context.Done():
+		return false
+	}
+}
+
+// callClients call every client until success. rate limited clients are
+// cooled down and skipped, and retried after cool down if all others failed
+func (c *APICaller) callClients(call func(client *ethclient.Client) error) (err error) {
+	return c.callClientsInOrder(false, call)
+}
+
+// callClientsAt call clients for state at blockNumber, prefer archive clients if it's not latest
+func (c *APICaller) callClientsAt(blockNumber *big.Int, call func(client *ethclient.Client) error) (err error) {
+	return c.callClientsInOrder(blockNumber != nil, call)
+}
+
+func (c *APICaller) callClientsInOrder(archive bool, call func(client *ethclient.Client) error) (err error) {
+	clients, urls := c.getClients()
+	var limited []int
+	for _, i := range c.clientOrder(urls, archive) {
+		if c.coolDownLeft(urls[i]) > 0 {
+			limited = append(limited, i)
+			continue
+		}
+		start := time.Now()
+		err = call(clients[i])
+		c.recordClientResult(urls[i], err, time.Since(start))
+		c.checkConnection(i, urls[i], err)
+		if err == nil {
+			return nil
+		}
+		if c.checkRateLimit(urls[i], err) {
+			limited = append(limited, i)
+		}
+	}
+	for _, i := range limited {
+		if !c.waitCoolDown(urls[i]) {
+			return c.context.Err()
+		}
+		start := time.Now()
+		err = call(clients[i])
+		c.recordClientResult(urls[i], err, time.Since(start))
+		c.checkConnection(i, urls[i], err)
+		if err == nil {
+			return nil
+		}
+		c.checkRateLimit(urls[i], err)
+	}
+	return err
+}
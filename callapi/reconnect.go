@@ -0,0 +1,125 @@
+package callapi
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/ethclient"
+)
+
+// reconnectErrorThreshold consecutive connection errors of a client to re-dial its server
+const reconnectErrorThreshold = 3
+
+// connection level error strings, the persistent (websocket or ipc) connection is broken
+var connectionErrorStrings = []string{
+	"client is closed",
+	"connection lost",
+	"connection refused",
+	"connection reset",
+	"broken pipe",
+	"use of closed network connection",
+	"eof",
+}
+
+type reconnectState struct {
+	mu         sync.Mutex
+	connErrors map[string]int  // consecutive connection errors, key is server URL
+	redialing  map[string]bool // servers being re-dialed in background
+}
+
+// isConnectionError is connection level error (not error response of server like contract revert)
+func isConnectionError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	errStr := strings.ToLower(err.Error())
+	for _, str := range connectionErrorStrings {
+		if strings.Contains(errStr, str) {
+			return true
+		}
+	}
+	return false
+}
+
+// getClients snapshot of clients and their server URLs, clients are swapped
+// by DialServer and reconnecting, indexes are only valid in a snapshot
+func (c *APICaller) getClients() (clients []*ethclient.Client, urls []string) {
+	c.clientsMu.RLock()
+	defer c.clientsMu.RUnlock()
+	clients = append(clients, c.clients...)
+	urls = append(urls, c.urls...)
+	return clients, urls
+}
+
+// checkConnection count consecutive connection errors of client at index,
+// and re-dial its server in background if it reaches reconnectErrorThreshold.
+// http clients are not re-dialed, every request makes a new connection if needed.
+func (c *APICaller) checkConnection(i int, url string, err error) {
+	if isHTTPURL(strings.ToLower(url)) {
+		return
+	}
+	c.reconnect.mu.Lock()
+	defer c.reconnect.mu.Unlock()
+	if !isConnectionError(err) {
+		delete(c.reconnect.connErrors, url)
+		return
+	}
+	if c.reconnect.connErrors == nil {
+		c.reconnect.connErrors = make(map[string]int)
+		c.reconnect.redialing = make(map[string]bool)
+	}
+	c.reconnect.connErrors[url]++
+	if c.reconnect.connErrors[url] < reconnectErrorThreshold || c.reconnect.redialing[url] {
+		return
+	}
+	c.reconnect.redialing[url] = true
+	log.Warn("[callapi] connection of server is broken, reconnect in background", "server", RedactURL(url), "errors", c.reconnect.connErrors[url], "err", err)
+	go c.redial(i, url)
+}
+
+// redial dial server again and swap in the new client if it's still connected to our chain
+func (c *APICaller) redial(i int, url string) {
+	defer func() {
+		c.reconnect.mu.Lock()
+		delete(c.reconnect.redialing, url)
+		c.reconnect.mu.Unlock()
+	}()
+	client, err := c.dialWithRetry(url)
+	if err != nil {
+		log.Warn("[callapi] reconnect server failed", "server", RedactURL(url), "err", err)
+		return
+	}
+	chainID, err := client.NetworkID(c.context)
+	if err == nil && c.chainID != nil && chainID.Cmp(c.chainID) != 0 {
+		err = ErrChainIDMismatch
+	}
+	if err != nil {
+		log.Warn("[callapi] check chain ID of reconnected server failed", "server", RedactURL(url), "chainID", chainID, "want", c.chainID, "err", err)
+		client.Close()
+		return
+	}
+
+	c.clientsMu.Lock()
+	if i >= len(c.urls) || c.urls[i] != url { // clients are re-dialed by DialServer
+		c.clientsMu.Unlock()
+		client.Close()
+		return
+	}
+	oldClient := c.clients[i]
+	c.clients[i] = client
+	c.clientsMu.Unlock()
+	oldClient.Close()
+
+	c.reconnect.mu.Lock()
+	delete(c.reconnect.connErrors, url)
+	c.reconnect.mu.Unlock()
+	log.Info("[callapi] reconnect server succeed", "server", RedactURL(url))
+}
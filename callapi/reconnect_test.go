@@ -0,0 +1,129 @@
+package callapi
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/ethclient"
+)
+
+// newIPCStub json rpc server on unix socket at path, connections are persistent like ipc of node
+func newIPCStub(t *testing.T, path string, handler stubHandler) {
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	stub := &rpcStub{handler: handler, calls: make(map[string]int)}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				decoder, encoder := json.NewDecoder(conn), json.NewEncoder(conn)
+				for {
+					var req stubRequest
+					if decoder.Decode(&req) != nil {
+						return
+					}
+					if encoder.Encode(stub.handle(&req)) != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+}
+
+func TestReconnectClosedClient(t *testing.T) {
+	dir, err := ioutil.TempDir("", "callapi-reconnect")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "node.ipc")
+	newIPCStub(t, path, func(method string, params []json.RawMessage) (interface{}, error) {
+		switch method {
+		case "net_version":
+			return "1", nil
+		case "eth_getBalance":
+			return "0x1", nil
+		}
+		return nil, errors.New("method not supported")
+	})
+	captureLog(t)
+
+	c := newStubCaller(t, 1, path)
+	c.SetDialRetry(&DialRetry{Count: 1, Timeout: time.Second})
+	account := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	if _, err = c.BalanceAt(account, nil); err != nil {
+		t.Fatalf("balance of connected client failed: %v", err)
+	}
+
+	firstClient := func() *ethclient.Client {
+		clients, _ := c.getClients()
+		return clients[0]
+	}
+
+	// fake a broken connection
+	oldClient := firstClient()
+	oldClient.Close()
+	for i := 0; i < reconnectErrorThreshold; i++ {
+		_, err = c.BalanceAt(account, nil)
+		if !isConnectionError(err) {
+			t.Fatalf("want connection error of closed client, got %v", err)
+		}
+	}
+
+	// health checks read clients concurrently with the swap
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				c.GetHealthyClientCount()
+			}
+		}
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for firstClient() == oldClient {
+		if time.Now().After(deadline) {
+			t.Fatal("closed client is not reconnected")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	close(stop)
+	wg.Wait()
+
+	if _, err = c.BalanceAt(account, nil); err != nil {
+		t.Fatalf("balance of reconnected client failed: %v", err)
+	}
+}
+
+func TestNoReconnectOfHTTPClient(t *testing.T) {
+	c := newStubCaller(t, 1, "HTTP://127.0.0.1:1")
+	for i := 0; i < reconnectErrorThreshold; i++ {
+		c.checkConnection(0, c.urls[0], errors.New("connection refused"))
+	}
+	c.reconnect.mu.Lock()
+	defer c.reconnect.mu.Unlock()
+	if len(c.reconnect.redialing) != 0 || len(c.reconnect.connErrors) != 0 {
+		t.Fatal("http client should not be re-dialed")
+	}
+}
@@ -0,0 +1,203 @@
+package callapi
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/ethclient"
+)
+
+// retry policy names
+const (
+	RetryPolicyDefault  = "default"
+	RetryPolicyFailFast = "failfast"
+)
+
+// RetryPolicy decide whether and after how long to retry a failed call,
+// attempt is the number of calls already made (starts from 1)
+type RetryPolicy interface {
+	ShouldRetry(err error, attempt int) (retry bool, delay time.Duration)
+}
+
+// FixedRetryPolicy retry any error with fixed interval,
+// MaxAttempts of 0 means retry forever
+type FixedRetryPolicy struct {
+	MaxAttempts int
+	Interval    time.Duration
+}
+
+// ShouldRetry impl RetryPolicy
+func (p *FixedRetryPolicy) ShouldRetry(err error, attempt int) (retry bool, delay time.Duration) {
+	if p.MaxAttempts > 0 && attempt >= p.MaxAttempts {
+		return false, 0
+	}
+	return true, p.Interval
+}
+
+// FailFastRetryPolicy do not retry errors which will fail again (revert, nonce too low),
+// and wait at least the Retry-After hint of rate limited errors, otherwise follow Base
+type FailFastRetryPolicy struct {
+	Base RetryPolicy
+}
+
+// ShouldRetry impl RetryPolicy
+func (p *FailFastRetryPolicy) ShouldRetry(err error, attempt int) (retry bool, delay time.Duration) {
+	if IsRevertError(err) || IsNonceTooLowError(err) {
+		return false, 0
+	}
+	retry, delay = p.Base.ShouldRetry(err, attempt)
+	if isRateLimit, retryAfter := isRateLimitError(err); retry && isRateLimit && retryAfter > delay {
+		delay = retryAfter
+	}
+	return retry, delay
+}
+
+// IsRevertError is execution reverted error
+func IsRevertError(err error) bool {
+	return err != nil && strings.Contains(strings.ToLower(err.Error()), "execution reverted")
+}
+
+// IsNonceTooLowError is nonce too low error
+func IsNonceTooLowError(err error) bool {
+	return err != nil && strings.Contains(strings.ToLower(err.Error()), "nonce too low")
+}
+
+// IsKnownTxError identical tx is already in mempool of node
+func IsKnownTxError(err error) bool {
+	if err == nil {
+		return false
+	}
+	errStr := strings.ToLower(err.Error())
+	return strings.Contains(errStr, "already known") || strings.Contains(errStr, "known transaction")
+}
+
+// IsValidRetryPolicy is valid retry policy name
+func IsValidRetryPolicy(name string) bool {
+	switch name {
+	case RetryPolicyDefault, RetryPolicyFailFast:
+		return true
+	default:
+		return false
+	}
+}
+
+// UseRetryPolicy use retry policy of name for calls and loops
+func (c *APICaller) UseRetryPolicy(name string) error {
+	switch name {
+	case RetryPolicyDefault:
+		c.retryPolicy, c.loopRetryPolicy = nil, nil
+	case RetryPolicyFailFast:
+		c.retryPolicy = &FailFastRetryPolicy{Base: c.defaultRetryPolicy()}
+		c.loopRetryPolicy = &FailFastRetryPolicy{Base: c.defaultLoopRetryPolicy()}
+	default:
+		return fmt.Errorf("unknown retry policy '%v'", name)
+	}
+	return nil
+}
+
+// SetRetryPolicy set retry policy of calls which give up after limited attempts
+func (c *APICaller) SetRetryPolicy(policy RetryPolicy) {
+	c.retryPolicy = policy
+}
+
+// SetLoopRetryPolicy set retry policy of loops which retry until success
+func (c *APICaller) SetLoopRetryPolicy(policy RetryPolicy) {
+	c.loopRetryPolicy = policy
+}
+
+// GetRetryPolicy get retry policy of calls which give up after limited attempts
+func (c *APICaller) GetRetryPolicy() RetryPolicy {
+	if c.retryPolicy != nil {
+		return c.retryPolicy
+	}
+	return c.defaultRetryPolicy()
+}
+
+// GetLoopRetryPolicy get retry policy of loops which retry until success
+func (c *APICaller) GetLoopRetryPolicy() RetryPolicy {
+	if c.loopRetryPolicy != nil {
+		return c.loopRetryPolicy
+	}
+	return c.defaultLoopRetryPolicy()
+}
+
+func (c *APICaller) defaultRetryPolicy() RetryPolicy {
+	return &FixedRetryPolicy{MaxAttempts: c.rpcRetryCount, Interval: c.rpcRetryInterval}
+}
+
+func (c *APICaller) defaultLoopRetryPolicy() RetryPolicy {
+	return &FixedRetryPolicy{Interval: c.rpcRetryInterval}
+}
+
+// retryCall call f and retry by retry policy
+func (c *APICaller) retryCall(f func() error) (err error) {
+	return c.retryCallUnless(f, nil)
+}
+
+// retryCallUnless call f and retry by retry policy, errors of noRetry (if not nil) are not retried
+func (c *APICaller) retryCallUnless(f func() error, noRetry func(error) bool) (err error) {
+	policy := c.GetRetryPolicy()
+	for attempt := 1; ; attempt++ {
+		if err = f(); err == nil {
+			return nil
+		}
+		if noRetry != nil && noRetry(err) {
+			return err
+		}
+		retry, delay := policy.ShouldRetry(err, attempt)
+		if !retry {
+			return err
+		}
+		time.Sleep(delay)
+	}
+}
+
+// retryClients call clients until success, and retry the whole client list by retry policy
+func (c *APICaller) retryClients(call func(client *ethclient.Client) error) error {
+	return c.retryCall(func() error {
+		return c.callClients(call)
+	})
+}
+
+// LoopRetry call f and retry by loop retry policy until success,
+// stop if the policy gives up or abort (if not nil) returns error before a retry
+func (c *APICaller) LoopRetry(f, abort func() error) (err error) {
+	policy := c.GetLoopRetryPolicy()
+	for attempt := 1; ; attempt++ {
+		if err = f(); err == nil {
+			return nil
+		}
+		if abort != nil {
+			if erra := abort(); erra != nil {
+				return erra
+			}
+		}
+		retry, delay := policy.ShouldRetry(err, attempt)
+		if !retry {
+			return err
+		}
+		time.Sleep(delay)
+	}
+}
+
+// loopUntilSuccess call f until success, as the caller can not handle failure
+// the loop never gives up and only takes retry delay from the loop retry policy
+func (c *APICaller) loopUntilSuccess(f func() error) {
+	policy := c.GetLoopRetryPolicy()
+	for attempt := 1; ; attempt++ {
+		err := f()
+		if err == nil {
+			return
+		}
+		retry, delay := policy.ShouldRetry(err, attempt)
+		if !retry {
+			log.Warn("[callapi] retry policy gives up, but the call must succeed, keep retrying", "attempt", attempt, "err", err)
+		}
+		if delay <= 0 {
+			delay = c.rpcRetryInterval
+		}
+		time.Sleep(delay)
+	}
+}
@@ -0,0 +1,114 @@
+package callapi
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+// failingStub answer method with failErr for the first fails calls, then with result
+func failingStub(t *testing.T, method string, fails int, failErr error, result interface{}) *rpcStub {
+	count := 0
+	return newRPCStub(t, func(m string, params []json.RawMessage) (interface{}, error) {
+		if m != method {
+			return nil, errors.New("method not supported")
+		}
+		count++
+		if count <= fails {
+			return nil, failErr
+		}
+		return result, nil
+	})
+}
+
+func TestRetryClientsRespectRetryCount(t *testing.T) {
+	const retryCount = 3
+	account := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	calls := []struct {
+		name   string
+		method string
+		result interface{}
+		call   func(c *APICaller) error
+	}{
+		{"BalanceAt", "eth_getBalance", "0x1", func(c *APICaller) error {
+			_, err := c.BalanceAt(account, nil)
+			return err
+		}},
+		{"GetAccountNonce", "eth_getTransactionCount", "0x5", func(c *APICaller) error {
+			_, err := c.GetAccountNonce(account)
+			return err
+		}},
+		{"SuggestGasPrice", "eth_gasPrice", "0x3b9aca00", func(c *APICaller) error {
+			_, err := c.SuggestGasPrice()
+			return err
+		}},
+		{"GetChainID", "net_version", "1", func(c *APICaller) error {
+			c.chainID = nil // not pinned by dialing
+			_, err := c.GetChainID()
+			return err
+		}},
+		{"SendTransaction", "eth_sendRawTransaction", common.Hash{}, func(c *APICaller) error {
+			return c.SendTransaction(newSignedTestTx(t))
+		}},
+	}
+	for _, call := range calls {
+		for _, fails := range []int{0, retryCount - 1, retryCount, retryCount + 2} {
+			stub := failingStub(t, call.method, fails, errors.New("internal error"), call.result)
+			c := newStubCaller(t, retryCount, stub.URL)
+			err := call.call(c)
+
+			wantAttempts := fails + 1
+			if fails >= retryCount {
+				wantAttempts = retryCount
+			}
+			if attempts := stub.callCount(call.method); attempts != wantAttempts {
+				t.Errorf("%v with %v failures: got %v attempts, want %v", call.name, fails, attempts, wantAttempts)
+			}
+			if fails < retryCount && err != nil {
+				t.Errorf("%v with %v failures: unexpected error %v", call.name, fails, err)
+			}
+			if fails >= retryCount && (err == nil || !strings.Contains(err.Error(), "internal error")) {
+				t.Errorf("%v with %v failures: want final error 'internal error', got %v", call.name, fails, err)
+			}
+		}
+	}
+}
+
+func TestSendTransactionFinalErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		failErrs     []string
+		wantAttempts int
+		wantErr      string
+	}{
+		{name: "known tx of first attempt is returned", failErrs: []string{"already known"}, wantAttempts: 1, wantErr: "already known"},
+		{name: "known tx of retry is success", failErrs: []string{"internal error", "already known"}, wantAttempts: 2},
+		{name: "nonce too low is not retried", failErrs: []string{"nonce too low"}, wantAttempts: 1, wantErr: "nonce too low"},
+		{name: "nonce too low of retry is not retried", failErrs: []string{"internal error", "nonce too low"}, wantAttempts: 2, wantErr: "nonce too low"},
+	}
+	for _, test := range tests {
+		count := 0
+		failErrs := test.failErrs
+		stub := newRPCStub(t, func(method string, params []json.RawMessage) (interface{}, error) {
+			count++
+			if count <= len(failErrs) {
+				return nil, errors.New(failErrs[count-1])
+			}
+			return common.Hash{}, nil
+		})
+		c := newStubCaller(t, 3, stub.URL)
+		err := c.SendTransaction(newSignedTestTx(t))
+		if attempts := stub.callCount("eth_sendRawTransaction"); attempts != test.wantAttempts {
+			t.Errorf("%v: got %v attempts, want %v", test.name, attempts, test.wantAttempts)
+		}
+		switch {
+		case test.wantErr == "" && err != nil:
+			t.Errorf("%v: unexpected error %v", test.name, err)
+		case test.wantErr != "" && (err == nil || !strings.Contains(err.Error(), test.wantErr)):
+			t.Errorf("%v: want error '%v', got %v", test.name, test.wantErr, err)
+		}
+	}
+}
@@ -0,0 +1,342 @@
+package callapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	ethereum "github.com/fsn-dev/fsn-go-sdk/efsn"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common/hexutil"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/core/types"
+)
+
+var (
+	errNoHTTPServer = errors.New("no http server URL to do rpc call")
+
+	// gatewayTransport transport of http requests to gateways, of both raw rpc calls and
+	// dialed clients (see getDialURL), it's wrapped by rpcTraceTransport if tracing is enabled
+	gatewayTransport http.RoundTripper = &credentialTransport{}
+
+	rpcHTTPClient = &http.Client{Timeout: 30 * time.Second, Transport: gatewayTransport}
+)
+
+type jsonrpcRequest struct {
+	Version string        `json:"jsonrpc"`
+	ID      int           `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type jsonrpcResponse struct {
+	Version string          `json:"jsonrpc"`
+	ID      int             `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// RPCError json rpc error returned by server
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("rpc error %v: %v", e.Code, e.Message)
+}
+
+// RPCCall call json rpc method which is not supported by ethclient.
+// only http servers are used, try every server until success
+func (c *APICaller) RPCCall(result interface{}, method string, params ...interface{}) (err error) {
+	err = errNoHTTPServer
+	for _, url := range c.orderedURLs() {
+		if !isHTTPURL(url) || c.coolDownLeft(url) > 0 {
+			continue
+		}
+		start := time.Now()
+		err = doRPCCall(c.context, url, result, method, params)
+		c.recordClientResult(url, err, time.Since(start))
+		if err == nil {
+			return nil
+		}
+		c.checkRateLimit(url, err)
+		var rpcErr *RPCError
+		if errors.As(err, &rpcErr) {
+			return err // server side error, no need to try other servers
+		}
+	}
+	return err
+}
+
+func isHTTPURL(url string) bool {
+	return strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://")
+}
+
+func doRPCCall(ctx context.Context, url string, result interface{}, method string, params []interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	reqData, err := json.Marshal(&jsonrpcRequest{
+		Version: "2.0",
+		ID:      1,
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return err
+	}
+	resp, err := postJSON(ctx, url, reqData)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return newRateLimitError(resp)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("rpc call %v failed, http status %v", method, resp.Status)
+	}
+	var rpcResp jsonrpcResponse
+	err = json.NewDecoder(resp.Body).Decode(&rpcResp)
+	if err != nil {
+		return err
+	}
+	if rpcResp.Error != nil {
+		return rpcResp.Error
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(rpcResp.Result, result)
+}
+
+// TxPoolStatus txpool_status result
+type TxPoolStatus struct {
+	Pending uint64
+	Queued  uint64
+}
+
+// GetTxPoolStatus call txpool_status
+func (c *APICaller) GetTxPoolStatus() (*TxPoolStatus, error) {
+	var result struct {
+		Pending hexutil.Uint64 `json:"pending"`
+		Queued  hexutil.Uint64 `json:"queued"`
+	}
+	err := c.RPCCall(&result, "txpool_status")
+	if err != nil {
+		return nil, err
+	}
+	return &TxPoolStatus{
+		Pending: uint64(result.Pending),
+		Queued:  uint64(result.Queued),
+	}, nil
+}
+
+// RPCReceipt transaction receipt with block info
+// (types.Receipt of sdk does not contain block info)
+type RPCReceipt struct {
+	TxHash            common.Hash     `json:"transactionHash"`
+	BlockNumber       *hexutil.Big    `json:"blockNumber"`
+	BlockHash         common.Hash     `json:"blockHash"`
+	From              common.Address  `json:"from"`
+	To                *common.Address `json:"to"`
+	Status            *hexutil.Uint64 `json:"status"`
+	GasUsed           *hexutil.Uint64 `json:"gasUsed"`
+	EffectiveGasPrice *hexutil.Big    `json:"effectiveGasPrice,omitempty"`
+	Logs              []*types.Log    `json:"logs"`
+}
+
+// IsSuccess is receipt status successful
+func (r *RPCReceipt) IsSuccess() bool {
+	return r.Status != nil && uint64(*r.Status) == types.ReceiptStatusSuccessful
+}
+
+// GetTransactionReceipt call eth_getTransactionReceipt,
+// return ethereum.NotFound if transaction is not mined yet
+func (c *APICaller) GetTransactionReceipt(txHash common.Hash) (receipt *RPCReceipt, err error) {
+	err = c.RPCCall(&receipt, "eth_getTransactionReceipt", txHash)
+	if err == nil && receipt == nil {
+		return nil, ethereum.NotFound
+	}
+	return receipt, err
+}
+
+// RPCBlockTxs block header fields and transaction hashes
+type RPCBlockTxs struct {
+	Hash         common.Hash    `json:"hash"`
+	Number       *hexutil.Big   `json:"number"`
+	Timestamp    hexutil.Uint64 `json:"timestamp"`
+	ReceiptsRoot common.Hash    `json:"receiptsRoot"`
+	Transactions []common.Hash  `json:"transactions"`
+}
+
+// GetBlockTxsByHash call eth_getBlockByHash without full transactions
+func (c *APICaller) GetBlockTxsByHash(blockHash common.Hash) (block *RPCBlockTxs, err error) {
+	err = c.RPCCall(&block, "eth_getBlockByHash", blockHash, false)
+	if err == nil && block == nil {
+		return nil, ethereum.NotFound
+	}
+	return block, err
+}
+
+// GetBlockHashByNumber get block hash reported by node of block at height number
+func (c *APICaller) GetBlockHashByNumber(number uint64) (blockHash common.Hash, err error) {
+	var block *struct {
+		Hash common.Hash `json:"hash"`
+	}
+	err = c.RPCCall(&block, "eth_getBlockByNumber", hexutil.Uint64(number), false)
+	if err == nil && block == nil {
+		return blockHash, ethereum.NotFound
+	}
+	if err != nil {
+		return blockHash, err
+	}
+	return block.Hash, nil
+}
+
+// RPCConsensusReceipt consensus fields of receipt, which are encoded in receipts trie
+type RPCConsensusReceipt struct {
+	TxHash            common.Hash     `json:"transactionHash"`
+	TxIndex           hexutil.Uint64  `json:"transactionIndex"`
+	Type              hexutil.Uint64  `json:"type"`
+	Root              hexutil.Bytes   `json:"root"` // post state root before byzantium
+	Status            *hexutil.Uint64 `json:"status"`
+	CumulativeGasUsed hexutil.Uint64  `json:"cumulativeGasUsed"`
+	Bloom             hexutil.Bytes   `json:"logsBloom"`
+	Logs              []*types.Log    `json:"logs"`
+}
+
+// GetBlockReceipts call eth_getBlockReceipts, which is not supported by old nodes
+func (c *APICaller) GetBlockReceipts(blockHash common.Hash) (receipts []*RPCConsensusReceipt, err error) {
+	err = c.RPCCall(&receipts, "eth_getBlockReceipts", blockHash)
+	return receipts, err
+}
+
+// GetConsensusReceipt call eth_getTransactionReceipt for consensus fields
+func (c *APICaller) GetConsensusReceipt(txHash common.Hash) (receipt *RPCConsensusReceipt, err error) {
+	err = c.RPCCall(&receipt, "eth_getTransactionReceipt", txHash)
+	if err == nil && receipt == nil {
+		return nil, ethereum.NotFound
+	}
+	return receipt, err
+}
+
+// GetLatestBaseFee get base fee of latest block by eth_getBlockByNumber,
+// return nil if chain does not support EIP-1559 (header has no baseFeePerGas)
+func (c *APICaller) GetLatestBaseFee() (*big.Int, error) {
+	var header struct {
+		BaseFee *hexutil.Big `json:"baseFeePerGas"`
+	}
+	err := c.RPCCall(&header, "eth_getBlockByNumber", "latest", false)
+	if err != nil {
+		return nil, err
+	}
+	if header.BaseFee == nil {
+		return nil, nil
+	}
+	return header.BaseFee.ToInt(), nil
+}
+
+// FeeHistory eth_feeHistory result
+type FeeHistory struct {
+	OldestBlock  *hexutil.Big     `json:"oldestBlock"`
+	Reward       [][]*hexutil.Big `json:"reward,omitempty"`
+	BaseFee      []*hexutil.Big   `json:"baseFeePerGas,omitempty"` // has one more item of the next block
+	GasUsedRatio []float64        `json:"gasUsedRatio"`
+}
+
+// GetFeeHistory call eth_feeHistory of latest blockCount blocks,
+// reward of every block is the priority fees at rewardPercentiles of its transactions weighted by gas used
+func (c *APICaller) GetFeeHistory(blockCount uint64, rewardPercentiles []float64) (*FeeHistory, error) {
+	var result FeeHistory
+	err := c.RPCCall(&result, "eth_feeHistory", hexutil.Uint64(blockCount), "latest", rewardPercentiles)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// IsMethodNotSupported is rpc error of calling method which is not supported by node
+func IsMethodNotSupported(err error) bool {
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	if rpcErr.Code == -32601 {
+		return true
+	}
+	msg := strings.ToLower(rpcErr.Message)
+	return strings.Contains(msg, "not found") || strings.Contains(msg, "does not exist") || strings.Contains(msg, "not supported")
+}
+
+// SimulatedCall call result of eth_simulateV1
+type SimulatedCall struct {
+	Status     hexutil.Uint64 `json:"status"`
+	ReturnData hexutil.Bytes  `json:"returnData"`
+	GasUsed    hexutil.Uint64 `json:"gasUsed"`
+	Error      *RPCError      `json:"error,omitempty"`
+}
+
+// IsSuccess is simulated call successful
+func (r *SimulatedCall) IsSuccess() bool {
+	return uint64(r.Status) == types.ReceiptStatusSuccessful
+}
+
+type simulatedCallArgs struct {
+	From  common.Address  `json:"from"`
+	To    *common.Address `json:"to"`
+	Gas   *hexutil.Uint64 `json:"gas,omitempty"`
+	Value *hexutil.Big    `json:"value,omitempty"`
+	Data  hexutil.Bytes   `json:"input,omitempty"`
+}
+
+// SimulateCalls call eth_simulateV1 to execute calls in sequence in one block
+// on top of latest state, so every call sees state changes of calls before it.
+// validation is off, nonce and gas fee of sender are not checked.
+func (c *APICaller) SimulateCalls(msgs []*ethereum.CallMsg) ([]*SimulatedCall, error) {
+	calls := make([]*simulatedCallArgs, len(msgs))
+	for i, msg := range msgs {
+		call := &simulatedCallArgs{From: msg.From, To: msg.To, Data: msg.Data}
+		if msg.Gas != 0 {
+			gas := hexutil.Uint64(msg.Gas)
+			call.Gas = &gas
+		}
+		if msg.Value != nil {
+			call.Value = (*hexutil.Big)(msg.Value)
+		}
+		calls[i] = call
+	}
+	simOpts := map[string]interface{}{
+		"blockStateCalls": []interface{}{
+			map[string]interface{}{"calls": calls},
+		},
+		"validation": false,
+	}
+	var blocks []struct {
+		Calls []*SimulatedCall `json:"calls"`
+	}
+	err := c.RPCCall(&blocks, "eth_simulateV1", simOpts, "latest")
+	if err != nil {
+		return nil, err
+	}
+	if len(blocks) != 1 || len(blocks[0].Calls) != len(msgs) {
+		return nil, fmt.Errorf("eth_simulateV1 returns wrong count of results")
+	}
+	return blocks[0].Calls, nil
+}
+
+// postJSON post json request which is cancelled with ctx
+func postJSON(ctx context.Context, url string, reqData []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(reqData))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return rpcHTTPClient.Do(req)
+}
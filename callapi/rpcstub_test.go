@@ -0,0 +1,143 @@
+package callapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/core/types"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/crypto"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/ethclient"
+)
+
+// stubHandler answer a json rpc call, a non nil error is returned as rpc error
+type stubHandler func(method string, params []json.RawMessage) (interface{}, error)
+
+// rpcStub json rpc http server which counts calls of every method
+type rpcStub struct {
+	*httptest.Server
+	handler stubHandler
+
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+type stubRequest struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type stubResponse struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+func newRPCStub(t testing.TB, handler stubHandler) *rpcStub {
+	stub := &rpcStub{handler: handler, calls: make(map[string]int)}
+	stub.Server = httptest.NewServer(http.HandlerFunc(stub.serveHTTP))
+	t.Cleanup(stub.Close)
+	return stub
+}
+
+func (s *rpcStub) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var resp interface{}
+	if len(raw) > 0 && raw[0] == '[' {
+		var reqs []*stubRequest
+		if err := json.Unmarshal(raw, &reqs); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		resps := make([]*stubResponse, len(reqs))
+		for i, req := range reqs {
+			resps[i] = s.handle(req)
+		}
+		resp = resps
+	} else {
+		var req stubRequest
+		if err := json.Unmarshal(raw, &req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		resp = s.handle(&req)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (s *rpcStub) handle(req *stubRequest) *stubResponse {
+	s.mu.Lock()
+	s.calls[req.Method]++
+	s.mu.Unlock()
+	resp := &stubResponse{Version: "2.0", ID: req.ID}
+	result, err := s.handler(req.Method, req.Params)
+	if err != nil {
+		resp.Error = &RPCError{Code: -32000, Message: err.Error()}
+	} else {
+		resp.Result = result
+	}
+	return resp
+}
+
+func (s *rpcStub) callCount(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[method]
+}
+
+// newStubCaller api caller of clients connected to urls without dialing checks,
+// retry by retryCount attempts with a short interval
+func newStubCaller(t testing.TB, retryCount int, urls ...string) *APICaller {
+	c := NewDefaultAPICallerWithContext(context.Background())
+	c.rpcRetryCount = retryCount
+	c.rpcRetryInterval = 10 * time.Millisecond
+	for _, url := range urls {
+		client, err := ethclient.Dial(url)
+		if err != nil {
+			t.Fatalf("dial %v failed: %v", url, err)
+		}
+		c.clients = append(c.clients, client)
+		c.urls = append(c.urls, url)
+	}
+	c.chainID = big.NewInt(1)
+	t.Cleanup(c.CloseClient)
+	return c
+}
+
+func newSignedTestTx(t *testing.T) *types.Transaction {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	to := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	tx := types.NewTransaction(0, to, big.NewInt(1), 21000, big.NewInt(1), nil)
+	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(big.NewInt(1)), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signedTx
+}
+
+// captureLog capture log output of the test
+func captureLog(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stdout) })
+	return &buf
+}
@@ -2,7 +2,6 @@ package callapi
 
 import (
 	"math/big"
-	"time"
 
 	"github.com/anyswap/ANYToken-distribution/log"
 	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
@@ -34,11 +33,10 @@ func (c *APICaller) GetRegisteredNodeID(stakeContract, account common.Address, b
 
 // LoopGetStakeAmount loop get stake amount
 func (c *APICaller) LoopGetStakeAmount(stakeContract, account common.Address, blockNumber *big.Int) *big.Int {
-	for {
-		stakeAmount, err := c.GetStakeAmount(stakeContract, account, blockNumber)
-		if err == nil {
-			return stakeAmount
-		}
-		time.Sleep(c.rpcRetryInterval)
-	}
+	var stakeAmount *big.Int
+	c.loopUntilSuccess(func() (err error) {
+		stakeAmount, err = c.GetStakeAmount(stakeContract, account, blockNumber)
+		return err
+	})
+	return stakeAmount
 }
@@ -0,0 +1,99 @@
+package callapi
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+var (
+	errTimeInFuture      = errors.New("time is later than latest block")
+	errTimeBeforeGenesis = errors.New("time is earlier than genesis block")
+)
+
+func (c *APICaller) getCachedBlockAtTime(timestamp int64) (*big.Int, bool) {
+	c.timeBlockMu.Lock()
+	defer c.timeBlockMu.Unlock()
+	number, exist := c.timeBlockCache[timestamp]
+	return number, exist
+}
+
+func (c *APICaller) setCachedBlockAtTime(timestamp int64, number *big.Int) {
+	c.timeBlockMu.Lock()
+	defer c.timeBlockMu.Unlock()
+	if c.timeBlockCache == nil {
+		c.timeBlockCache = make(map[int64]*big.Int)
+	}
+	c.timeBlockCache[timestamp] = number
+}
+
+// GetBlockNumberAtTime get number of the last block whose timestamp is not after t,
+// state of this block is the state at time t. result is cached by t in seconds
+func (c *APICaller) GetBlockNumberAtTime(t time.Time) (*big.Int, error) {
+	timestamp := t.Unix()
+	if number, exist := c.getCachedBlockAtTime(timestamp); exist {
+		return new(big.Int).Set(number), nil
+	}
+	latest, err := c.HeaderByNumber(nil)
+	if err != nil {
+		return nil, err
+	}
+	if latest.Time.Int64() < timestamp {
+		return nil, errTimeInFuture
+	}
+	// find the first block whose timestamp is after t, the previous one is the result
+	var searchErr error
+	count := int(latest.Number.Int64()) + 1
+	index := sort.Search(count, func(i int) bool {
+		if searchErr != nil {
+			return true
+		}
+		header, errf := c.HeaderByNumber(big.NewInt(int64(i)))
+		if errf != nil {
+			searchErr = errf
+			return true
+		}
+		return header.Time.Int64() > timestamp
+	})
+	if searchErr != nil {
+		return nil, searchErr
+	}
+	if index == 0 {
+		return nil, errTimeBeforeGenesis
+	}
+	number := big.NewInt(int64(index - 1))
+	c.setCachedBlockAtTime(timestamp, number)
+	log.Info("[callapi] get block number at time success", "time", t, "timestamp", timestamp, "blockNumber", number)
+	return new(big.Int).Set(number), nil
+}
+
+// GetTokenBalanceAtTime get token balance of account at time t
+func (c *APICaller) GetTokenBalanceAtTime(token, account common.Address, t time.Time) (*big.Int, error) {
+	blockNumber, err := c.GetBlockNumberAtTime(t)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetTokenBalance(token, account, blockNumber)
+}
+
+// GetCoinBalanceAtTime get coin balance of account at time t
+func (c *APICaller) GetCoinBalanceAtTime(account common.Address, t time.Time) (*big.Int, error) {
+	blockNumber, err := c.GetBlockNumberAtTime(t)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetCoinBalance(account, blockNumber)
+}
+
+// GetLiquidityBalanceAtTime get liquidity balance of account at time t
+func (c *APICaller) GetLiquidityBalanceAtTime(exchange, account common.Address, t time.Time) (*big.Int, error) {
+	blockNumber, err := c.GetBlockNumberAtTime(t)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetLiquidityBalance(exchange, account, blockNumber)
+}
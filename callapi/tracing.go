@@ -0,0 +1,82 @@
+package callapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/anyswap/ANYToken-distribution/tracing"
+)
+
+var rpcTracingEnabled bool
+
+// EnableRPCTracing trace every json rpc http request to gateways as client span of
+// current span, both of dialed clients and of raw rpc calls (see gatewayTransport).
+// it must be called before dialing. websocket and ipc gateways are not traced.
+func EnableRPCTracing() {
+	if rpcTracingEnabled || !tracing.Enabled() {
+		return
+	}
+	gatewayTransport = &rpcTraceTransport{base: gatewayTransport}
+	rpcHTTPClient.Transport = gatewayTransport
+	rpcTracingEnabled = true
+}
+
+// rpcTraceTransport start a span of rpc method for every request
+type rpcTraceTransport struct {
+	base http.RoundTripper
+}
+
+func (t *rpcTraceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req, method := readRPCMethod(req)
+	span := tracing.StartClientSpan("rpc "+method, "server", RedactURL(req.URL.String()))
+	resp, err := t.base.RoundTrip(req)
+	if err == nil && resp.StatusCode != http.StatusOK {
+		span.SetAttributes("httpStatus", resp.StatusCode)
+		span.End(errors.New(resp.Status))
+	} else {
+		span.End(err)
+	}
+	return resp, err
+}
+
+// readRPCMethod get json rpc method from request body, batch request is named 'batch(<count>)'.
+// the sdk does not set GetBody of request, in which case body is read and
+// a clone of request with the read body is returned, as request must not be modified.
+func readRPCMethod(req *http.Request) (*http.Request, string) {
+	var data []byte
+	var err error
+	switch {
+	case req.GetBody != nil:
+		var body io.ReadCloser
+		if body, err = req.GetBody(); err == nil {
+			data, err = ioutil.ReadAll(body)
+			body.Close()
+		}
+	case req.Body != nil:
+		data, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		req = req.Clone(req.Context())
+		req.Body = ioutil.NopCloser(bytes.NewReader(data))
+	}
+	if err != nil || len(data) == 0 {
+		return req, "unknown"
+	}
+	var batch []struct {
+		Method string `json:"method"`
+	}
+	if err = json.Unmarshal(data, &batch); err == nil {
+		return req, fmt.Sprintf("batch(%d)", len(batch))
+	}
+	var single struct {
+		Method string `json:"method"`
+	}
+	if err = json.Unmarshal(data, &single); err != nil || single.Method == "" {
+		return req, "unknown"
+	}
+	return req, single.Method
+}
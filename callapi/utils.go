@@ -1,8 +1,10 @@
 package callapi
 
 import (
+	"bytes"
 	"errors"
 	"math/big"
+	"unicode/utf8"
 
 	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
 )
@@ -47,3 +49,20 @@ func UnpackABIEncodedStringInIndex(data []byte, index uint64) (string, error) {
 	}
 	return UnpackABIEncodedString(data, offset)
 }
+
+// UnpackABIEncodedStringOrBytes32 parse abi encoded string, or right padded bytes32
+// returned by non-standard tokens (eg. name and symbol of MKR)
+func UnpackABIEncodedStringOrBytes32(data []byte) (string, error) {
+	str, err := UnpackABIEncodedStringInIndex(data, 0)
+	if err == nil {
+		return str, nil
+	}
+	if len(data) != 32 {
+		return "", err
+	}
+	bs := bytes.TrimRight(data, "\x00")
+	if !utf8.Valid(bs) {
+		return "", err
+	}
+	return string(bs), nil
+}
@@ -0,0 +1,47 @@
+package callapi
+
+import (
+	"testing"
+
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+func TestUnpackABIEncodedStringOrBytes32(t *testing.T) {
+	abiString := func(s string) []byte {
+		return append(common.LeftPadBytes([]byte{32}, 32), PackStringToABIEncoded(s)...)
+	}
+	tests := []struct {
+		name    string
+		data    []byte
+		want    string
+		wantErr bool
+	}{
+		{name: "abi string", data: abiString("Uniswap V1"), want: "Uniswap V1"},
+		{name: "abi empty string", data: abiString(""), want: ""},
+		{name: "abi string of 32 bytes", data: abiString("abcdefghijklmnopqrstuvwxyz012345"), want: "abcdefghijklmnopqrstuvwxyz012345"},
+		{name: "bytes32 with trailing zeros", data: common.RightPadBytes([]byte("MKR"), 32), want: "MKR"},
+		{name: "bytes32 name", data: common.RightPadBytes([]byte("Maker"), 32), want: "Maker"},
+		{name: "bytes32 all zeros", data: make([]byte, 32), want: ""},
+		{name: "bytes32 invalid utf8", data: common.RightPadBytes([]byte{0xff, 0xfe}, 32), wantErr: true},
+		{name: "empty", data: nil, wantErr: true},
+		{name: "short", data: []byte{1, 2, 3}, wantErr: true},
+		{name: "offset out of range", data: append(common.LeftPadBytes([]byte{64}, 32), make([]byte, 32)...), wantErr: true},
+		{name: "length out of range", data: append(common.LeftPadBytes([]byte{32}, 32), common.LeftPadBytes([]byte{100}, 32)...), wantErr: true},
+	}
+	for _, test := range tests {
+		got, err := UnpackABIEncodedStringOrBytes32(test.data)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%v: want error, got %q", test.name, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: unexpected error %v", test.name, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%v: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/anyswap/ANYToken-distribution/cmd/utils"
+	"github.com/anyswap/ANYToken-distribution/distributer"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	aggregateCommand = &cli.Command{
+		Action:    aggregate,
+		Name:      "aggregate",
+		Usage:     "aggregate output files of multiple runs into one report",
+		ArgsUsage: " ",
+		Description: `
+aggregate output files (of any output format) of multiple runs,
+report total rewards per reward token and per recipient of each token.
+reward token is read from title of output file, files without reward token info
+use '--rewardToken' option, or are reported under 'unknown' token.
+report format is csv (default) or json, written to stdout if no output file.
+`,
+		Flags: []cli.Flag{
+			utils.InputFileSliceFlag,
+			utils.RewardTokenFlag,
+			utils.OutputFileFlag,
+			utils.OutputFormatFlag,
+		},
+	}
+)
+
+func aggregate(ctx *cli.Context) error {
+	inputFiles := ctx.StringSlice(utils.InputFileSliceFlag.Name)
+	if len(inputFiles) == 0 {
+		return fmt.Errorf("must specify input files")
+	}
+	format := distributer.OutputFormatCSV
+	if ctx.IsSet(utils.OutputFormatFlag.Name) {
+		format = ctx.String(utils.OutputFormatFlag.Name)
+	}
+
+	report, err := distributer.AggregateRewardFiles(inputFiles, ctx.String(utils.RewardTokenFlag.Name))
+	if err != nil {
+		return err
+	}
+
+	output := os.Stdout
+	if outputFile := ctx.String(utils.OutputFileFlag.Name); outputFile != "" {
+		output, err = os.OpenFile(outputFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			return err
+		}
+		defer output.Close()
+	}
+	return distributer.WriteAggregateReport(output, report, format)
+}
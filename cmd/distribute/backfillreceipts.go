@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/anyswap/ANYToken-distribution/cmd/utils"
+	"github.com/anyswap/ANYToken-distribution/distributer"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	backfillReceiptsCommand = &cli.Command{
+		Action:    backfillReceipts,
+		Name:      "backfillreceipts",
+		Usage:     "backfill transaction receipts of sended rewards",
+		ArgsUsage: " ",
+		Description: `
+append status,gasUsed,block columns of transaction receipt to every line
+of output file of sendrewards (legacy format), other columns are kept as is.
+status is success, failed, or notfound if receipt is not found (dropped transaction).
+line without txhash is kept as is.
+the input file is rewritten unless '--output' is specified.
+`,
+		Flags: []cli.Flag{
+			utils.GatewayFlag,
+			utils.GatewayFileFlag,
+			utils.DropMismatchChainClientFlag,
+			utils.DialRetriesFlag,
+			utils.DialRetryIntervalFlag,
+			utils.DialTimeoutFlag,
+			utils.InputFileFlag,
+			utils.OutputFileFlag,
+		},
+	}
+)
+
+func backfillReceipts(ctx *cli.Context) error {
+	serverURL := ctx.StringSlice(utils.GatewayFlag.Name)
+	if len(serverURL) == 0 && !ctx.IsSet(utils.GatewayFileFlag.Name) {
+		return fmt.Errorf("must specify gateway URL")
+	}
+	bopt := &distributer.BackfillOption{
+		InputFile:  ctx.String(utils.InputFileFlag.Name),
+		OutputFile: ctx.String(utils.OutputFileFlag.Name),
+	}
+	if bopt.InputFile == "" {
+		return fmt.Errorf("must specify input file")
+	}
+
+	capi := utils.InitAppWithURL(ctx, serverURL, false)
+	distributer.SetAPICaller(capi)
+	defer capi.CloseClient()
+
+	result, err := distributer.BackfillReceipts(bopt)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("records: %v, success: %v, failed: %v, notfound: %v, without txhash: %v\n",
+		result.Records, result.Success, result.Failed, result.NotFound, result.NoTxHash)
+	return nil
+}
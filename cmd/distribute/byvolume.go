@@ -28,17 +28,57 @@ distribute rewards by volume
 			utils.ExchangeSliceFlag,
 			utils.WeightSliceFlag,
 			utils.InputFileSliceFlag,
+			utils.BurnAddressSliceFlag,
+			utils.SkipBurnAddressesFlag,
+			utils.BlocklistFlag,
+			utils.SkipBlocklistedFlag,
 			utils.OutputFileSliceFlag,
+			utils.OutputStdoutFlag,
+			utils.OutputFormatFlag,
+			utils.VerboseOutputFlag,
+			utils.MerkleOutputFlag,
+			utils.SignOutputFlag,
+			utils.ReplayLogFlag,
 			utils.SenderFlag,
 			utils.KeyStoreFileFlag,
 			utils.PasswordFileFlag,
+			utils.MnemonicEnvFlag,
+			utils.DerivationPathFlag,
 			utils.GasLimitFlag,
+			utils.EstimateGasFlag,
+			utils.GasBufferFlag,
+			utils.CacheGasEstimateFlag,
 			utils.GasPriceFlag,
+			utils.TxTypeFlag,
+			utils.GasPriceStrategyFlag,
+			utils.GasPricePercentileFlag,
+			utils.GasPriceBlocksFlag,
+			utils.MaxGasSpendFlag,
+			utils.FailOnKnownTxFlag,
+			utils.MinGasPriceFlag,
+			utils.AbortOnLowGasPriceFlag,
+			utils.ReplaceBumpPercentFlag,
+			utils.MaxReplaceBumpPercentFlag,
+			utils.MaxBumpGasPriceFlag,
+			utils.WaitAtBumpCeilingFlag,
+			utils.SenderLockDirFlag,
+			utils.SenderLockWaitFlag,
+			utils.ExpectedFactoryFlag,
+			utils.PausedSelectorFlag,
+			utils.RevalidateIntervalFlag,
+			utils.BlacklistSelectorFlag,
+			utils.SweepToFlag,
+			utils.SweepReserveFlag,
 			utils.AccountNonceFlag,
+			utils.FixedNonceFlag,
+			utils.StrictNonceFlag,
 			utils.SaveDBFlag,
 			utils.DryRunFlag,
+			utils.EmitUnsignedJSONFlag,
+			utils.MultisigOutputFlag,
 			utils.BatchCountFlag,
 			utils.BatchIntervalFlag,
+			utils.AdaptiveThrottleFlag,
 			utils.UseTimeMeasurementFlag,
 			utils.PercentageWeightFlag,
 		},
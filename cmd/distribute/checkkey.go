@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/anyswap/ANYToken-distribution/cmd/utils"
+	"github.com/anyswap/ANYToken-distribution/distributer"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	checkKeyCommand = &cli.Command{
+		Action:    checkKey,
+		Name:      "checkkey",
+		Usage:     "decrypt and verify signing key without running a distribution",
+		ArgsUsage: " ",
+		Description: `
+decrypt keystore with password file (or derive key from mnemonic), print the derived address,
+and check it matches '--sender' if specified. exit nonzero on decryption failure or mismatch.
+this command is offline, it does not touch the chain.
+`,
+		Flags: []cli.Flag{
+			utils.SenderFlag,
+			utils.KeyStoreFileFlag,
+			utils.PasswordFileFlag,
+			utils.MnemonicEnvFlag,
+			utils.DerivationPathFlag,
+		},
+	}
+)
+
+func checkKey(ctx *cli.Context) error {
+	args := &distributer.BuildTxArgs{
+		Sender:         ctx.String(utils.SenderFlag.Name),
+		KeystoreFile:   ctx.String(utils.KeyStoreFileFlag.Name),
+		PasswordFile:   ctx.String(utils.PasswordFileFlag.Name),
+		MnemonicEnv:    ctx.String(utils.MnemonicEnvFlag.Name),
+		DerivationPath: ctx.String(utils.DerivationPathFlag.Name),
+	}
+	address, err := distributer.CheckKey(args)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("key is OK, address %v\n", address.String())
+	return nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/anyswap/ANYToken-distribution/cmd/utils"
+	"github.com/anyswap/ANYToken-distribution/distributer"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	exportPendingCommand = &cli.Command{
+		Action:    exportPending,
+		Name:      "exportpending",
+		Usage:     "export not sended accounts of a partial run to a new input file",
+		ArgsUsage: " ",
+		Description: `
+compare input file of sendrewards with its partial output file,
+write accounts and rewards which are not sended yet to a new input file.
+title line of the input file is kept, so the new file can be used by sendrewards directly.
+`,
+		Flags: []cli.Flag{
+			utils.InputFileFlag,
+			utils.OutputFileFlag,
+			utils.PendingFileFlag,
+		},
+	}
+)
+
+func exportPending(ctx *cli.Context) error {
+	inputFile := ctx.String(utils.InputFileFlag.Name)
+	outputFile := ctx.String(utils.OutputFileFlag.Name)
+	pendingFile := ctx.String(utils.PendingFileFlag.Name)
+	if inputFile == "" || outputFile == "" || pendingFile == "" {
+		return fmt.Errorf("must specify input file, output file and pending file")
+	}
+	pending, err := distributer.ExportPendingInputFile(inputFile, outputFile, pendingFile)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("exported %v pending accounts, total reward %v, to %v\n", len(pending), pending.CalcTotalReward(), pendingFile)
+	return nil
+}
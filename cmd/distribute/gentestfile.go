@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/anyswap/ANYToken-distribution/cmd/utils"
+	"github.com/anyswap/ANYToken-distribution/distributer"
+	"github.com/anyswap/ANYToken-distribution/tools"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	genTestFileCommand = &cli.Command{
+		Action:    genTestFile,
+		Name:      "gentestfile",
+		Usage:     "generate test reward file of synthetic recipients",
+		ArgsUsage: " ",
+		Description: `
+generate input file of sendrewards with '--count' synthetic recipients,
+rewards are in range ['--minReward', '--maxReward'].
+recipients are random, or derived from '--seed' to be reproducible.
+title line is generated from reward type, reward token, exchange, start and end options,
+so the file can be used by sendrewards (eg. in dry run) and selftest on devnet directly.
+`,
+		Flags: []cli.Flag{
+			utils.OutputFileFlag,
+			utils.TestCountFlag,
+			utils.MinRewardFlag,
+			utils.MaxRewardFlag,
+			utils.TestSeedFlag,
+			utils.RewardTyepFlag,
+			utils.RewardTokenFlag,
+			utils.ExchangeFlag,
+			utils.StartHeightFlag,
+			utils.EndHeightFlag,
+		},
+	}
+)
+
+func genTestFile(ctx *cli.Context) error {
+	outputFile := ctx.String(utils.OutputFileFlag.Name)
+	if outputFile == "" {
+		return fmt.Errorf("must specify output file")
+	}
+	minReward, err := tools.GetBigIntFromString(ctx.String(utils.MinRewardFlag.Name))
+	if err != nil {
+		return fmt.Errorf("wrong min reward. %v", err)
+	}
+	maxReward := minReward
+	if ctx.IsSet(utils.MaxRewardFlag.Name) {
+		maxReward, err = tools.GetBigIntFromString(ctx.String(utils.MaxRewardFlag.Name))
+		if err != nil {
+			return fmt.Errorf("wrong max reward. %v", err)
+		}
+	}
+	topt := &distributer.TestFileOption{
+		Count:       ctx.Int(utils.TestCountFlag.Name),
+		MinReward:   minReward,
+		MaxReward:   maxReward,
+		Seed:        ctx.String(utils.TestSeedFlag.Name),
+		RewardType:  ctx.String(utils.RewardTyepFlag.Name),
+		RewardToken: ctx.String(utils.RewardTokenFlag.Name),
+		Exchange:    ctx.String(utils.ExchangeFlag.Name),
+		StartHeight: ctx.Uint64(utils.StartHeightFlag.Name),
+		EndHeight:   ctx.Uint64(utils.EndHeightFlag.Name),
+	}
+	totalReward, err := distributer.GenerateTestRewardFile(outputFile, topt)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("generated %v recipients, total reward %v, to %v\n", topt.Count, totalReward, outputFile)
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 
 	"github.com/anyswap/ANYToken-distribution/cmd/utils"
+	"github.com/anyswap/ANYToken-distribution/tracing"
 	"github.com/anyswap/ANYToken-distribution/worker"
 	"github.com/urfave/cli/v2"
 )
@@ -28,6 +29,23 @@ func initApp() {
 		sendRewardsCommand,
 		importRewardsCommand,
 		insertAccountCommand,
+		replayCommand,
+		prefetchBalancesCommand,
+		verifyCommand,
+		selfTestCommand,
+		aggregateCommand,
+		statusCommand,
+		exportPendingCommand,
+		genTestFileCommand,
+		validateCommand,
+		obligationsCommand,
+		sendMultiChainCommand,
+		verifyOutputSigCommand,
+		mergeInputCommand,
+		backfillReceiptsCommand,
+		simulateBundleCommand,
+		checkKeyCommand,
+		scheduleCommand,
 		utils.LicenseCommand,
 		utils.VersionCommand,
 	}
@@ -36,7 +54,25 @@ func initApp() {
 		utils.SyncFromFlag,
 		utils.SyncToFlag,
 		utils.OverwriteFlag,
+		utils.SyncMigrateFlag,
+		utils.SyncResetFlag,
+		utils.SyncChunkSizeFlag,
 		utils.OnlySyncAccountFlag,
+		utils.GatewaySecretsFileFlag,
+		utils.DropMismatchChainClientFlag,
+		utils.DialRetriesFlag,
+		utils.DialRetryIntervalFlag,
+		utils.DialTimeoutFlag,
+		utils.CallTimeoutFlag,
+		utils.RPCBatchSizeFlag,
+		utils.MulticallFlag,
+		utils.RateLimitCoolDownFlag,
+		utils.ParallelReadFlag,
+		utils.ClientDemoteFailuresFlag,
+		utils.ClientRecoverSuccessesFlag,
+		utils.MempoolFullRetriesFlag,
+		utils.MempoolFullBackoffFlag,
+		utils.RetryPolicyFlag,
 		utils.VerbosityFlag,
 		utils.LogFileFlag,
 		utils.LogRotationFlag,
@@ -48,7 +84,9 @@ func initApp() {
 
 func main() {
 	initApp()
-	if err := app.Run(os.Args); err != nil {
+	err := app.Run(os.Args)
+	tracing.Shutdown()
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
@@ -62,6 +100,6 @@ func distribute(ctx *cli.Context) error {
 	capi := utils.InitApp(ctx, true)
 	defer capi.CloseClient()
 
-	worker.StartWork(capi, ctx.Bool(utils.OnlySyncAccountFlag.Name))
+	worker.StartWork(ctx.Context, capi, ctx.Bool(utils.OnlySyncAccountFlag.Name))
 	return nil
 }
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/anyswap/ANYToken-distribution/cmd/utils"
+	"github.com/anyswap/ANYToken-distribution/distributer"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	mergeInputCommand = &cli.Command{
+		Action:    mergeInput,
+		Name:      "mergeinput",
+		Usage:     "merge duplicate accounts of input file",
+		ArgsUsage: " ",
+		Description: `
+merge duplicate accounts of input file by summing their rewards,
+output is sorted by account with line format: <address> <rewards>
+input file larger than '--externalMergeThreshold' (or any with '--externalMerge')
+is merged on disk by external sort, with at most '--mergeChunkSize' recipients in memory.
+both ways have the same output. lines with per recipient options can not be merged.
+`,
+		Flags: []cli.Flag{
+			utils.InputFileFlag,
+			utils.OutputFileFlag,
+			utils.ExternalMergeThresholdFlag,
+			utils.ExternalMergeFlag,
+			utils.MergeChunkSizeFlag,
+		},
+	}
+)
+
+func mergeInput(ctx *cli.Context) error {
+	utils.SetLogger(ctx)
+	mopt := &distributer.MergeOption{
+		InputFile:         ctx.String(utils.InputFileFlag.Name),
+		OutputFile:        ctx.String(utils.OutputFileFlag.Name),
+		ExternalThreshold: ctx.Int64(utils.ExternalMergeThresholdFlag.Name),
+		ForceExternal:     ctx.Bool(utils.ExternalMergeFlag.Name),
+		ChunkSize:         ctx.Int(utils.MergeChunkSizeFlag.Name),
+	}
+	result, err := distributer.MergeInputFile(mopt)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("lines: %v, recipients: %v, total reward: %v, external: %v\n", result.Lines, result.Recipients, result.TotalReward, result.External)
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/anyswap/ANYToken-distribution/cmd/utils"
+	"github.com/anyswap/ANYToken-distribution/distributer"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	obligationsCommand = &cli.Command{
+		Action:    obligations,
+		Name:      "obligations",
+		Usage:     "report accrued but not sended rewards and whether they are funded",
+		ArgsUsage: " ",
+		Description: `
+sum rewards in database which have no reward tx yet per reward token,
+and compare with current balances of sender and treasury to flag underfunding.
+this command is read only, and returns error if any reward token is underfunded.
+`,
+		Flags: []cli.Flag{
+			utils.SenderFlag,
+			utils.TreasuryFlag,
+		},
+	}
+)
+
+func obligations(ctx *cli.Context) error {
+	oopt := &distributer.ObligationsOption{
+		Sender:   ctx.String(utils.SenderFlag.Name),
+		Treasury: ctx.String(utils.TreasuryFlag.Name),
+	}
+	if oopt.Sender == "" && oopt.Treasury == "" {
+		return fmt.Errorf("must specify sender or treasury")
+	}
+	capi := utils.InitApp(ctx, true)
+	distributer.SetAPICaller(capi)
+	defer capi.CloseClient()
+
+	result, err := distributer.GetObligations(oopt)
+	if err != nil {
+		return err
+	}
+	if underfunded := distributer.PrintObligations(oopt, result); underfunded > 0 {
+		return fmt.Errorf("%v reward token(s) underfunded", underfunded)
+	}
+	return nil
+}
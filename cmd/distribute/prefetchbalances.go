@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/anyswap/ANYToken-distribution/cmd/utils"
+	"github.com/anyswap/ANYToken-distribution/distributer"
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	prefetchBalancesCommand = &cli.Command{
+		Action:    prefetchBalances,
+		Name:      "prefetchbalances",
+		Usage:     "prefetch and cache liquidity balances of accounts",
+		ArgsUsage: " ",
+		Description: `
+snapshot liquidity balances of accounts at sample height (default latest block),
+and write to cache file which can be loaded by '--balanceCache' option
+`,
+		Flags: []cli.Flag{
+			utils.ExchangeSliceFlag,
+			utils.InputFileSliceFlag,
+			utils.SampleFlag,
+			utils.OutputFileFlag,
+			utils.RPCBatchSizeFlag,
+			utils.MulticallFlag,
+		},
+	}
+)
+
+func prefetchBalances(ctx *cli.Context) error {
+	cacheFile := ctx.String(utils.OutputFileFlag.Name)
+	if cacheFile == "" {
+		return fmt.Errorf("must specify output cache file")
+	}
+	capi := utils.InitApp(ctx, true)
+	distributer.SetAPICaller(capi)
+	defer capi.CloseClient()
+
+	opt := &distributer.Option{
+		Exchanges:    ctx.StringSlice(utils.ExchangeSliceFlag.Name),
+		InputFiles:   ctx.StringSlice(utils.InputFileSliceFlag.Name),
+		SampleHeight: ctx.Uint64(utils.SampleFlag.Name),
+	}
+	if len(opt.Exchanges) == 0 {
+		return fmt.Errorf("must specify exchanges")
+	}
+	if len(opt.InputFiles) != 0 && len(opt.InputFiles) != len(opt.Exchanges) {
+		return fmt.Errorf("count of exchanges and input files is not equal")
+	}
+	log.Info("start prefetch balances", "exchanges", opt.Exchanges, "inputs", opt.InputFiles, "sample", opt.SampleHeight)
+	return distributer.PrefetchBalances(opt, cacheFile)
+}
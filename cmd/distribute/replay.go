@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/anyswap/ANYToken-distribution/cmd/utils"
+	"github.com/anyswap/ANYToken-distribution/distributer"
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	replayCommand = &cli.Command{
+		Action:    replay,
+		Name:      "replay",
+		Usage:     "verify replay log of a past sending run",
+		ArgsUsage: " ",
+		Description: `
+verify internal consistency of replay log (totals, nonce sequence) without touching the chain
+`,
+		Flags: []cli.Flag{
+			utils.ReplayLogFlag,
+		},
+	}
+)
+
+func replay(ctx *cli.Context) error {
+	replayLogFile := ctx.String(utils.ReplayLogFlag.Name)
+	if replayLogFile == "" {
+		return fmt.Errorf("must specify replay log file")
+	}
+	rlog, err := distributer.LoadReplayLog(replayLogFile)
+	if err != nil {
+		return err
+	}
+	log.Info("load replay log success", "file", replayLogFile,
+		"chainID", rlog.ChainID, "sender", rlog.Sender, "dryrun", rlog.DryRun,
+		"startNonce", rlog.StartNonce, "records", len(rlog.Records), "totalSended", rlog.TotalSended)
+	return rlog.Verify()
+}
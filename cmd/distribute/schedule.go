@@ -0,0 +1,107 @@
+package main
+
+import (
+	"time"
+
+	"github.com/anyswap/ANYToken-distribution/cmd/utils"
+	"github.com/anyswap/ANYToken-distribution/distributer"
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/anyswap/ANYToken-distribution/tools"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	scheduleCommand = &cli.Command{
+		Action:    schedule,
+		Name:      "schedule",
+		Usage:     "distribute rewards continuously on schedule",
+		ArgsUsage: " ",
+		Description: `
+distribute rewards by liquidity or volume (--rewardType) every '--scheduleInterval' seconds.
+the first run starts immediately. every run pulls accounts from database,
+its window starts from the end of the last completed run (or '--start' if none)
+and ends at the latest stable height, and '--rewards' are distributed in every run.
+a run is skipped (or queued if '--queueOverlap') if the previous run has not finished.
+interrupt or terminate signal stops scheduling after the running run finishes.
+`,
+		Flags: []cli.Flag{
+			utils.RewardTyepFlag,
+			utils.ScheduleIntervalFlag,
+			utils.QueueOverlapFlag,
+			utils.RewardTokenFlag,
+			utils.TotalRewardsFlag,
+			utils.StartHeightFlag,
+			utils.StableHeightFlag,
+			utils.StepCountFlag,
+			utils.StepRewardFlag,
+			utils.ExchangeSliceFlag,
+			utils.WeightSliceFlag,
+			utils.PercentageWeightFlag,
+			utils.BurnAddressSliceFlag,
+			utils.SkipBurnAddressesFlag,
+			utils.BlocklistFlag,
+			utils.SkipBlocklistedFlag,
+			utils.OutputFormatFlag,
+			utils.VerboseOutputFlag,
+			utils.MerkleOutputFlag,
+			utils.SignOutputFlag,
+			utils.SenderFlag,
+			utils.KeyStoreFileFlag,
+			utils.PasswordFileFlag,
+			utils.MnemonicEnvFlag,
+			utils.DerivationPathFlag,
+			utils.GasLimitFlag,
+			utils.EstimateGasFlag,
+			utils.GasBufferFlag,
+			utils.CacheGasEstimateFlag,
+			utils.GasPriceFlag,
+			utils.TxTypeFlag,
+			utils.GasPriceStrategyFlag,
+			utils.GasPricePercentileFlag,
+			utils.GasPriceBlocksFlag,
+			utils.MaxGasSpendFlag,
+			utils.FailOnKnownTxFlag,
+			utils.MinGasPriceFlag,
+			utils.AbortOnLowGasPriceFlag,
+			utils.ReplaceBumpPercentFlag,
+			utils.MaxReplaceBumpPercentFlag,
+			utils.MaxBumpGasPriceFlag,
+			utils.WaitAtBumpCeilingFlag,
+			utils.SenderLockDirFlag,
+			utils.SenderLockWaitFlag,
+			utils.ExpectedFactoryFlag,
+			utils.PausedSelectorFlag,
+			utils.RevalidateIntervalFlag,
+			utils.BlacklistSelectorFlag,
+			utils.SaveDBFlag,
+			utils.DryRunFlag,
+			utils.BatchCountFlag,
+			utils.BatchIntervalFlag,
+			utils.AdaptiveThrottleFlag,
+			utils.UseTimeMeasurementFlag,
+			utils.ArchiveModeFlag,
+		},
+	}
+)
+
+func schedule(ctx *cli.Context) error {
+	capi := utils.InitApp(ctx, true)
+	defer capi.CloseClient()
+	distributer.SetAPICaller(capi)
+
+	opt, err := newOption(ctx)
+	if err != nil {
+		log.Fatalf("get option error: %v", err)
+	}
+	log.Println("get option success.", tools.ToJSONString(opt, !log.JSONFormat))
+
+	sopt := &distributer.ScheduleOption{
+		Template:     opt,
+		Interval:     time.Duration(ctx.Uint64(utils.ScheduleIntervalFlag.Name)) * time.Second,
+		QueueOverlap: ctx.Bool(utils.QueueOverlapFlag.Name),
+	}
+
+	sigCtx, cancel := utils.WithSignalCancel(ctx.Context)
+	defer cancel()
+	return sopt.RunSchedule(sigCtx)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/anyswap/ANYToken-distribution/cmd/utils"
+	"github.com/anyswap/ANYToken-distribution/distributer"
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/anyswap/ANYToken-distribution/tools"
+	"github.com/urfave/cli/v2"
+)
+
+const selfTestDefaultTotalReward = 3
+
+var (
+	selfTestCommand = &cli.Command{
+		Action:    selfTest,
+		Name:      "selftest",
+		Usage:     "self test the whole pipeline against a devnet",
+		ArgsUsage: " ",
+		Description: `
+send a small distribution of a provided test token (or native coin if not specified)
+to some random recipients on devnet, then confirm receipts and verify balances.
+NOTE: deploying test token is not supported, please deploy one and specify by '--rewardToken'.
+`,
+		Flags: []cli.Flag{
+			utils.GatewayFlag,
+			utils.GatewayFileFlag,
+			utils.DropMismatchChainClientFlag,
+			utils.DialRetriesFlag,
+			utils.DialRetryIntervalFlag,
+			utils.DialTimeoutFlag,
+			utils.RewardTokenFlag,
+			utils.TotalRewardsFlag,
+			utils.SenderFlag,
+			utils.KeyStoreFileFlag,
+			utils.PasswordFileFlag,
+			utils.MnemonicEnvFlag,
+			utils.DerivationPathFlag,
+			utils.GasLimitFlag,
+			utils.GasPriceFlag,
+		},
+	}
+)
+
+func selfTest(ctx *cli.Context) error {
+	serverURL := ctx.StringSlice(utils.GatewayFlag.Name)
+	if len(serverURL) == 0 && !ctx.IsSet(utils.GatewayFileFlag.Name) {
+		return fmt.Errorf("must specify gateway URL")
+	}
+
+	capi := utils.InitAppWithURL(ctx, serverURL, false)
+	distributer.SetAPICaller(capi)
+	defer capi.CloseClient()
+
+	args, err := getBuildTxArgs(ctx)
+	if err != nil {
+		log.Fatalf("get build tx args error: %v", err)
+	}
+
+	totalReward := big.NewInt(selfTestDefaultTotalReward)
+	if ctx.IsSet(utils.TotalRewardsFlag.Name) {
+		totalReward, err = tools.GetBigIntFromString(ctx.String(utils.TotalRewardsFlag.Name))
+		if err != nil {
+			return err
+		}
+	}
+	opt := &distributer.Option{
+		BuildTxArgs: args,
+		RewardToken: ctx.String(utils.RewardTokenFlag.Name),
+	}
+
+	report := distributer.SelfTest(opt, totalReward)
+	fmt.Print(report.String())
+	if !report.Passed() {
+		return fmt.Errorf("selftest failed")
+	}
+	return nil
+}
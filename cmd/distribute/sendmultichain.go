@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/anyswap/ANYToken-distribution/cmd/utils"
+	"github.com/anyswap/ANYToken-distribution/distributer"
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/anyswap/ANYToken-distribution/params"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	sendMultiChainCommand = &cli.Command{
+		Action:    sendMultiChain,
+		Name:      "sendmultichain",
+		Usage:     "send rewards on multiple chains in one run",
+		ArgsUsage: " ",
+		Description: `
+send rewards of input file with line format: <address> <rewards> chain=<name>
+chains are specified in '--chainsFile' (toml) with [[Chain]] tables of:
+Name, ChainID, APIAddress, RewardToken (empty means native coin) and GasPrice (optional).
+every chain has its own gateways, chain ID check, balance check and nonce sequence,
+all chains are checked before any transfer is sent.
+output line format is: <address>,<rewards>,<txhash>,chain=<name>
+`,
+		Flags: []cli.Flag{
+			utils.ChainsFileFlag,
+			utils.DropMismatchChainClientFlag,
+			utils.DialRetriesFlag,
+			utils.DialRetryIntervalFlag,
+			utils.DialTimeoutFlag,
+			utils.InputFileFlag,
+			utils.OutputFileFlag,
+			utils.DustRewardFlag,
+			utils.SenderFlag,
+			utils.KeyStoreFileFlag,
+			utils.PasswordFileFlag,
+			utils.MnemonicEnvFlag,
+			utils.DerivationPathFlag,
+			utils.GasLimitFlag,
+			utils.EstimateGasFlag,
+			utils.GasBufferFlag,
+			utils.CacheGasEstimateFlag,
+			utils.TxTypeFlag,
+			utils.GasPriceStrategyFlag,
+			utils.GasPricePercentileFlag,
+			utils.GasPriceBlocksFlag,
+			utils.MaxGasSpendFlag,
+			utils.FailOnKnownTxFlag,
+			utils.MinGasPriceFlag,
+			utils.AbortOnLowGasPriceFlag,
+			utils.ReplaceBumpPercentFlag,
+			utils.MaxReplaceBumpPercentFlag,
+			utils.MaxBumpGasPriceFlag,
+			utils.WaitAtBumpCeilingFlag,
+			utils.MaxNonceGapFlag,
+			utils.DryRunFlag,
+		},
+	}
+)
+
+func sendMultiChain(ctx *cli.Context) error {
+	utils.SetLogger(ctx)
+
+	chainsFile := ctx.String(utils.ChainsFileFlag.Name)
+	if chainsFile == "" {
+		return fmt.Errorf("must specify chains file")
+	}
+	chains, err := params.LoadChainsFile(chainsFile)
+	if err != nil {
+		return fmt.Errorf("load chains file failed. %v", err)
+	}
+
+	args, err := newBuildTxArgs(ctx)
+	if err != nil {
+		return err
+	}
+	if err = setConfigParams(ctx); err != nil {
+		return err
+	}
+
+	mopt := &distributer.MultiChainOption{
+		InputFile:   ctx.String(utils.InputFileFlag.Name),
+		OutputFile:  ctx.String(utils.OutputFileFlag.Name),
+		DryRun:      ctx.Bool(utils.DryRunFlag.Name),
+		BuildTxArgs: args,
+	}
+	for _, chain := range chains {
+		log.Info("[multichain] dial chain", "chain", chain.Name, "chainID", chain.ChainID, "gateway", chain.APIAddress)
+		capi := utils.DialChain(ctx, chain.APIAddress)
+		defer capi.CloseClient()
+		mopt.Chains = append(mopt.Chains, &distributer.ChainTarget{
+			Config: chain,
+			Caller: capi,
+		})
+	}
+	return mopt.SendMultiChainRewards()
+}
@@ -3,10 +3,15 @@ package main
 import (
 	"fmt"
 	"math/big"
+	"time"
 
+	"github.com/anyswap/ANYToken-distribution/callapi"
 	"github.com/anyswap/ANYToken-distribution/cmd/utils"
 	"github.com/anyswap/ANYToken-distribution/distributer"
 	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/anyswap/ANYToken-distribution/tools"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common/hexutil"
 	"github.com/urfave/cli/v2"
 )
 
@@ -18,9 +23,21 @@ var (
 		ArgsUsage: " ",
 		Description: `
 send rewards batchly according to verified input file with line format: <address> <rewards>
+with --inputWeights, <rewards> is weight in basis points of --totalPool
+with --verifyAfterEach, transfers are sent strictly one at a time in lock-step:
+every transfer must be confirmed successfully (by --confirmations/--confirmTiers,
+at least 1 block) before the next is sent, and the first failure aborts sending.
+--batchCount and --batchInterval are not applied in this mode.
+with --continueOnError, failed sends are deferred instead of aborting, the rest of
+the recipients are sent, and the deferred ones are retried once at the end of each file.
 `,
 		Flags: []cli.Flag{
 			utils.GatewayFlag,
+			utils.GatewayFileFlag,
+			utils.DropMismatchChainClientFlag,
+			utils.DialRetriesFlag,
+			utils.DialRetryIntervalFlag,
+			utils.DialTimeoutFlag,
 			utils.RewardTyepFlag,
 			utils.DustRewardFlag,
 			utils.ExchangeSliceFlag,
@@ -28,25 +45,91 @@ send rewards batchly according to verified input file with line format: <address
 			utils.StartHeightFlag,
 			utils.EndHeightFlag,
 			utils.InputFileSliceFlag,
+			utils.BurnAddressSliceFlag,
+			utils.SkipBurnAddressesFlag,
+			utils.BlocklistFlag,
+			utils.SkipBlocklistedFlag,
+			utils.EligibilityContractFlag,
+			utils.EligibilitySelectorFlag,
+			utils.SkipIneligibleFlag,
 			utils.OutputFileSliceFlag,
+			utils.OutputStdoutFlag,
+			utils.OutputFormatFlag,
+			utils.VerboseOutputFlag,
+			utils.MerkleOutputFlag,
+			utils.SignOutputFlag,
+			utils.SplitOutputFlag,
+			utils.SkipReasonsFlag,
+			utils.ReplayLogFlag,
+			utils.PreflightReportFlag,
 			utils.SenderFlag,
 			utils.KeyStoreFileFlag,
 			utils.PasswordFileFlag,
+			utils.MnemonicEnvFlag,
+			utils.DerivationPathFlag,
 			utils.GasLimitFlag,
+			utils.EstimateGasFlag,
+			utils.GasBufferFlag,
+			utils.CacheGasEstimateFlag,
 			utils.GasPriceFlag,
+			utils.TxTypeFlag,
+			utils.GasPriceStrategyFlag,
+			utils.GasPricePercentileFlag,
+			utils.GasPriceBlocksFlag,
+			utils.MaxGasSpendFlag,
+			utils.FailOnKnownTxFlag,
+			utils.MinGasPriceFlag,
+			utils.AbortOnLowGasPriceFlag,
+			utils.ReplaceBumpPercentFlag,
+			utils.MaxReplaceBumpPercentFlag,
+			utils.MaxBumpGasPriceFlag,
+			utils.WaitAtBumpCeilingFlag,
+			utils.SenderLockDirFlag,
+			utils.SenderLockWaitFlag,
+			utils.MaxSupplyPercentFlag,
+			utils.AbortOnSupplyRatioFlag,
+			utils.ExpectedFactoryFlag,
+			utils.PausedSelectorFlag,
+			utils.RevalidateIntervalFlag,
+			utils.BlacklistSelectorFlag,
+			utils.SweepToFlag,
+			utils.SweepReserveFlag,
 			utils.AccountNonceFlag,
+			utils.FixedNonceFlag,
+			utils.StrictNonceFlag,
+			utils.MaxNonceGapFlag,
 			utils.SaveDBFlag,
 			utils.DryRunFlag,
+			utils.EmitUnsignedJSONFlag,
+			utils.MultisigOutputFlag,
+			utils.ConfirmFlag,
+			utils.VerifyAfterEachFlag,
+			utils.ContinueOnErrorFlag,
+			utils.ConfirmationsFlag,
+			utils.ConfirmTiersFlag,
+			utils.ConfirmTimeoutFlag,
 			utils.BatchCountFlag,
 			utils.BatchIntervalFlag,
+			utils.AdaptiveThrottleFlag,
 			utils.ScalingValueFlag,
+			utils.InputWeightsFlag,
+			utils.TotalPoolFlag,
+			utils.AssertTotalSliceFlag,
+			utils.AssertToleranceFlag,
+			utils.ExpectedCommitmentSliceFlag,
+			utils.RequireUniqueFlag,
+			utils.PaidMismatchPolicyFlag,
+			utils.MinClientsFlag,
+			utils.MaxRPCFailuresFlag,
+			utils.MaxRPCFailureRateFlag,
+			utils.RPCFailureWindowFlag,
 		},
 	}
 )
 
 func sendRewards(ctx *cli.Context) error {
 	serverURL := ctx.StringSlice(utils.GatewayFlag.Name)
-	if len(serverURL) == 0 {
+	if len(serverURL) == 0 && !ctx.IsSet(utils.GatewayFileFlag.Name) {
 		return fmt.Errorf("must specify gateway URL")
 	}
 	rewardType := ctx.String(utils.RewardTyepFlag.Name)
@@ -58,6 +141,10 @@ func sendRewards(ctx *cli.Context) error {
 	capi := utils.InitAppWithURL(ctx, serverURL, withConfigFile)
 	distributer.SetAPICaller(capi)
 
+	if budget := getErrorBudget(ctx); budget != nil {
+		capi.SetErrorBudget(budget)
+	}
+
 	opt, err := getOptionAndTxArgs(ctx)
 	if err != nil {
 		log.Fatalf("get option error: %v", err)
@@ -65,10 +152,64 @@ func sendRewards(ctx *cli.Context) error {
 
 	opt.ScalingNumerator, opt.ScalingDenominator = getScalingValue(ctx.String(utils.ScalingValueFlag.Name))
 
+	opt.VerifyAfterEach = ctx.Bool(utils.VerifyAfterEachFlag.Name)
+	opt.ContinueOnError = ctx.Bool(utils.ContinueOnErrorFlag.Name)
+	opt.Confirm, opt.ConfirmTimeout, err = getConfirmPolicy(ctx)
+	if err != nil {
+		log.Fatalf("get confirm policy error: %v", err)
+	}
+
+	opt.InputWeights = ctx.Bool(utils.InputWeightsFlag.Name)
+	if ctx.IsSet(utils.TotalPoolFlag.Name) {
+		opt.TotalPool, err = tools.GetBigIntFromString(ctx.String(utils.TotalPoolFlag.Name))
+		if err != nil {
+			log.Fatalf("wrong total pool: %v", err)
+		}
+	}
+
+	for _, totalStr := range ctx.StringSlice(utils.AssertTotalSliceFlag.Name) {
+		total, errf := tools.GetBigIntFromString(totalStr)
+		if errf != nil {
+			log.Fatalf("wrong assert total '%v': %v", totalStr, errf)
+		}
+		opt.AssertTotals = append(opt.AssertTotals, total)
+	}
+	for _, commitmentStr := range ctx.StringSlice(utils.ExpectedCommitmentSliceFlag.Name) {
+		commitment, errf := hexutil.Decode(commitmentStr)
+		if errf != nil || len(commitment) != common.HashLength {
+			log.Fatalf("wrong expected commitment '%v', must be 32 bytes hex", commitmentStr)
+		}
+		opt.ExpectedCommitments = append(opt.ExpectedCommitments, common.BytesToHash(commitment))
+	}
+	opt.RequireUnique = ctx.Bool(utils.RequireUniqueFlag.Name)
+	opt.PreflightReportFile = ctx.String(utils.PreflightReportFlag.Name)
+	if ctx.Bool(utils.SkipReasonsFlag.Name) {
+		opt.SkipReasons = true
+		opt.SplitOutput = true
+	}
+	opt.AssertTolerance, err = tools.GetBigIntFromString(ctx.String(utils.AssertToleranceFlag.Name))
+	if err != nil || opt.AssertTolerance.Sign() < 0 {
+		log.Fatalf("wrong assert tolerance '%v'", ctx.String(utils.AssertToleranceFlag.Name))
+	}
+
 	defer capi.CloseClient()
 	return opt.SendRewardsFromFile()
 }
 
+func getErrorBudget(ctx *cli.Context) *callapi.ErrorBudget {
+	maxFailures := ctx.Int(utils.MaxRPCFailuresFlag.Name)
+	maxFailureRate := ctx.Float64(utils.MaxRPCFailureRateFlag.Name)
+	if maxFailures <= 0 && maxFailureRate <= 0 {
+		return nil
+	}
+	if maxFailureRate > 1 {
+		log.Fatalf("wrong max rpc failure rate '%v'", maxFailureRate)
+	}
+	window := time.Duration(ctx.Uint64(utils.RPCFailureWindowFlag.Name)) * time.Second
+	log.Info("set rpc failures budget", "maxFailures", maxFailures, "maxFailureRate", maxFailureRate, "window", window)
+	return callapi.NewErrorBudget(maxFailures, maxFailureRate, window)
+}
+
 func getScalingValue(scalingStr string) (numerator, denominator *big.Int) {
 	if scalingStr == "" {
 		return
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/anyswap/ANYToken-distribution/cmd/utils"
+	"github.com/anyswap/ANYToken-distribution/distributer"
+	"github.com/anyswap/ANYToken-distribution/tools"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	simulateBundleCommand = &cli.Command{
+		Action:    simulateBundle,
+		Name:      "simulatebundle",
+		Usage:     "simulate all transfers of reward file in one request",
+		ArgsUsage: " ",
+		Description: `
+simulate all non dust transfers of reward file in sequence by 'eth_simulateV1',
+every transfer sees state changes of transfers before it, so cumulative issues
+like sender running out of balance partway are caught.
+on nodes without 'eth_simulateV1' every transfer is simulated separately,
+and cumulative rewards are checked against balance of sender.
+exit with error and report index of the first failed transfer if any transfer fails.
+`,
+		Flags: []cli.Flag{
+			utils.GatewayFlag,
+			utils.GatewayFileFlag,
+			utils.DropMismatchChainClientFlag,
+			utils.DialRetriesFlag,
+			utils.DialRetryIntervalFlag,
+			utils.DialTimeoutFlag,
+			utils.InputFileFlag,
+			utils.RewardTokenFlag,
+			utils.SenderFlag,
+			utils.GasLimitFlag,
+		},
+	}
+)
+
+func simulateBundle(ctx *cli.Context) error {
+	serverURL := ctx.StringSlice(utils.GatewayFlag.Name)
+	if len(serverURL) == 0 && !ctx.IsSet(utils.GatewayFileFlag.Name) {
+		return fmt.Errorf("must specify gateway URL")
+	}
+	sopt := &distributer.SimulateBundleOption{
+		InputFile:   ctx.String(utils.InputFileFlag.Name),
+		RewardToken: ctx.String(utils.RewardTokenFlag.Name),
+	}
+	if sopt.InputFile == "" {
+		return fmt.Errorf("must specify input file")
+	}
+	sender := ctx.String(utils.SenderFlag.Name)
+	if !common.IsHexAddress(sender) {
+		return fmt.Errorf("wrong sender '%v'", sender)
+	}
+	sopt.Sender = common.HexToAddress(sender)
+	if ctx.IsSet(utils.GasLimitFlag.Name) {
+		gasLimit, err := tools.GetBigIntFromString(ctx.String(utils.GasLimitFlag.Name))
+		if err != nil {
+			return err
+		}
+		sopt.GasLimit = gasLimit.Uint64()
+	}
+
+	capi := utils.InitAppWithURL(ctx, serverURL, false)
+	distributer.SetAPICaller(capi)
+	defer capi.CloseClient()
+
+	result, err := distributer.SimulateBundle(sopt)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("method: %v, transfers: %v, total reward: %v, gas used: %v\n",
+		result.Method, result.Transfers, result.TotalReward, result.GasUsed)
+	if result.FailedIndex >= 0 {
+		return fmt.Errorf("transfer %v to %v will fail. %v", result.FailedIndex, result.FailedAccount, result.FailedReason)
+	}
+	return nil
+}
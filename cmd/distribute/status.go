@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/anyswap/ANYToken-distribution/cmd/utils"
+	"github.com/anyswap/ANYToken-distribution/distributer"
+	"github.com/anyswap/ANYToken-distribution/tools"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	statusCommand = &cli.Command{
+		Action:    status,
+		Name:      "status",
+		Usage:     "show cost to complete of partially sended rewards",
+		ArgsUsage: " ",
+		Description: `
+compare input file of sendrewards with its partial output file,
+report left recipients, left rewards and gas cost to complete at current gas price.
+if sender is specified, also report whether sender balances need top up.
+this command is read only.
+`,
+		Flags: []cli.Flag{
+			utils.GatewayFlag,
+			utils.GatewayFileFlag,
+			utils.DropMismatchChainClientFlag,
+			utils.DialRetriesFlag,
+			utils.DialRetryIntervalFlag,
+			utils.DialTimeoutFlag,
+			utils.InputFileFlag,
+			utils.OutputFileFlag,
+			utils.RewardTokenFlag,
+			utils.SenderFlag,
+			utils.DustRewardFlag,
+			utils.GasLimitFlag,
+			utils.GasPriceFlag,
+		},
+	}
+)
+
+func status(ctx *cli.Context) error {
+	serverURL := ctx.StringSlice(utils.GatewayFlag.Name)
+	if len(serverURL) == 0 && !ctx.IsSet(utils.GatewayFileFlag.Name) {
+		return fmt.Errorf("must specify gateway URL")
+	}
+	sopt := &distributer.StatusOption{
+		InputFile:   ctx.String(utils.InputFileFlag.Name),
+		OutputFile:  ctx.String(utils.OutputFileFlag.Name),
+		RewardToken: ctx.String(utils.RewardTokenFlag.Name),
+		Sender:      ctx.String(utils.SenderFlag.Name),
+	}
+	if sopt.InputFile == "" || sopt.OutputFile == "" {
+		return fmt.Errorf("must specify input file and output file")
+	}
+	if ctx.IsSet(utils.GasLimitFlag.Name) {
+		gasLimit, err := tools.GetBigIntFromString(ctx.String(utils.GasLimitFlag.Name))
+		if err != nil {
+			return err
+		}
+		gasLimitValue := gasLimit.Uint64()
+		sopt.GasLimit = &gasLimitValue
+	}
+	if ctx.IsSet(utils.GasPriceFlag.Name) {
+		gasPrice, err := tools.GetBigIntFromString(ctx.String(utils.GasPriceFlag.Name))
+		if err != nil {
+			return err
+		}
+		sopt.GasPrice = gasPrice
+	}
+	capi := utils.InitAppWithURL(ctx, serverURL, false)
+	distributer.SetAPICaller(capi)
+	defer capi.CloseClient()
+
+	if err := setConfigParams(ctx); err != nil {
+		return err
+	}
+
+	runStatus, err := distributer.GetRunStatus(sopt)
+	if err != nil {
+		return err
+	}
+	distributer.PrintRunStatus(sopt, runStatus)
+	return nil
+}
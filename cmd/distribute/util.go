@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math/big"
 	"regexp"
+	"time"
 
 	"github.com/anyswap/ANYToken-distribution/cmd/utils"
 	"github.com/anyswap/ANYToken-distribution/distributer"
@@ -16,6 +17,22 @@ import (
 var blankOrCommaSepRegexp = regexp.MustCompile(`[\s,]+`) // blank or comma separated
 
 func getOptionAndTxArgs(ctx *cli.Context) (*distributer.Option, error) {
+	opt, err := newOption(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = opt.CheckBasic()
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("get option success.", tools.ToJSONString(opt, !log.JSONFormat))
+	return opt, nil
+}
+
+// newOption get option and build tx args from flags without checking option
+func newOption(ctx *cli.Context) (*distributer.Option, error) {
 	var rewards *big.Int
 	if ctx.IsSet(utils.TotalRewardsFlag.Name) {
 		rewardsiBig, err := tools.GetBigIntFromString(ctx.String(utils.TotalRewardsFlag.Name))
@@ -66,16 +83,65 @@ func getOptionAndTxArgs(ctx *cli.Context) (*distributer.Option, error) {
 		Weights:            weights,
 		InputFiles:         ctx.StringSlice(utils.InputFileSliceFlag.Name),
 		OutputFiles:        ctx.StringSlice(utils.OutputFileSliceFlag.Name),
+		OutputToStdout:     ctx.Bool(utils.OutputStdoutFlag.Name),
+		OutputFormat:       ctx.String(utils.OutputFormatFlag.Name),
+		VerboseOutput:      ctx.Bool(utils.VerboseOutputFlag.Name),
+		SplitOutput:        ctx.Bool(utils.SplitOutputFlag.Name),
+		ReplayLogFile:      ctx.String(utils.ReplayLogFlag.Name),
+		BalanceCacheFile:   ctx.String(utils.BalanceCacheFlag.Name),
+		BurnAddresses:      ctx.StringSlice(utils.BurnAddressSliceFlag.Name),
+		SkipBurnAddresses:  ctx.Bool(utils.SkipBurnAddressesFlag.Name),
+		BlocklistFile:      ctx.String(utils.BlocklistFlag.Name),
+		SkipBlocklisted:    ctx.Bool(utils.SkipBlocklistedFlag.Name),
 		SampleHeight:       ctx.Uint64(utils.SampleFlag.Name),
 		SaveDB:             ctx.Bool(utils.SaveDBFlag.Name),
 		DryRun:             ctx.Bool(utils.DryRunFlag.Name),
 		BatchCount:         ctx.Uint64(utils.BatchCountFlag.Name),
 		BatchInterval:      ctx.Uint64(utils.BatchIntervalFlag.Name),
+		AdaptiveThrottle:   ctx.Bool(utils.AdaptiveThrottleFlag.Name),
+		ConfirmBeforeSend:  ctx.Bool(utils.ConfirmFlag.Name),
+		MinClients:         ctx.Int(utils.MinClientsFlag.Name),
 		UseTimeMeasurement: ctx.Bool(utils.UseTimeMeasurementFlag.Name),
 		ArchiveMode:        ctx.Bool(utils.ArchiveModeFlag.Name),
+		SampleInterval:     ctx.Uint64(utils.SampleIntervalFlag.Name),
+		SnapshotWorkers:    ctx.Int(utils.SnapshotWorkersFlag.Name),
 		WeightIsPercentage: ctx.Bool(utils.PercentageWeightFlag.Name),
 	}
 
+	opt.EligibilityContract = ctx.String(utils.EligibilityContractFlag.Name)
+	opt.EligibilitySelector = ctx.String(utils.EligibilitySelectorFlag.Name)
+	opt.SkipIneligible = ctx.Bool(utils.SkipIneligibleFlag.Name)
+
+	opt.MerkleOutput = ctx.Bool(utils.MerkleOutputFlag.Name)
+	opt.SignOutput = ctx.Bool(utils.SignOutputFlag.Name)
+	opt.MaxSupplyPercent = ctx.Float64(utils.MaxSupplyPercentFlag.Name)
+	opt.AbortOnSupplyRatio = ctx.Bool(utils.AbortOnSupplyRatioFlag.Name)
+	opt.ExpectedFactory = ctx.String(utils.ExpectedFactoryFlag.Name)
+	opt.SenderLockDir = ctx.String(utils.SenderLockDirFlag.Name)
+	opt.SenderLockWait = time.Duration(ctx.Uint64(utils.SenderLockWaitFlag.Name)) * time.Second
+	opt.PausedSelector = ctx.String(utils.PausedSelectorFlag.Name)
+	opt.RevalidateInterval = ctx.Uint64(utils.RevalidateIntervalFlag.Name)
+	opt.BlacklistSelector = ctx.String(utils.BlacklistSelectorFlag.Name)
+	opt.PaidMismatchPolicy = ctx.String(utils.PaidMismatchPolicyFlag.Name)
+
+	opt.SweepTo = ctx.String(utils.SweepToFlag.Name)
+	if ctx.IsSet(utils.SweepReserveFlag.Name) {
+		opt.SweepReserve, err = tools.GetBigIntFromString(ctx.String(utils.SweepReserveFlag.Name))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	opt.CombineExchanges = ctx.Bool(utils.CombineExchangesFlag.Name)
+	opt.SnapshotProvenance = ctx.Bool(utils.SnapshotProvenanceFlag.Name)
+
+	for _, height := range ctx.Int64Slice(utils.SnapshotHeightsFlag.Name) {
+		if height < 0 {
+			return nil, fmt.Errorf("wrong snapshot height %v", height)
+		}
+		opt.SnapshotHeights = append(opt.SnapshotHeights, uint64(height))
+	}
+
 	if ctx.IsSet(utils.RewardTyepFlag.Name) {
 		err = opt.SetByWhat(ctx.String(utils.RewardTyepFlag.Name))
 		if err != nil {
@@ -83,16 +149,25 @@ func getOptionAndTxArgs(ctx *cli.Context) (*distributer.Option, error) {
 		}
 	}
 
-	err = opt.CheckBasic()
+	return opt, nil
+}
+
+func getBuildTxArgs(ctx *cli.Context) (*distributer.BuildTxArgs, error) {
+	args, err := newBuildTxArgs(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	log.Println("get option success.", tools.ToJSONString(opt, !log.JSONFormat))
-	return opt, nil
+	dryRun := ctx.Bool(utils.DryRunFlag.Name)
+	if err := args.Check(dryRun); err != nil {
+		return nil, err
+	}
+
+	return args, nil
 }
 
-func getBuildTxArgs(ctx *cli.Context) (*distributer.BuildTxArgs, error) {
+// newBuildTxArgs get build tx args from flags without checking them
+func newBuildTxArgs(ctx *cli.Context) (*distributer.BuildTxArgs, error) {
 	var (
 		gasPrice    *big.Int
 		gasLimitPtr *uint64
@@ -125,6 +200,39 @@ func getBuildTxArgs(ctx *cli.Context) (*distributer.BuildTxArgs, error) {
 		noncePtr = &nonce
 	}
 
+	var maxGasSpend *big.Int
+	if ctx.IsSet(utils.MaxGasSpendFlag.Name) {
+		maxGasSpendBig, errf := tools.GetBigIntFromString(ctx.String(utils.MaxGasSpendFlag.Name))
+		if errf != nil {
+			return nil, errf
+		}
+		maxGasSpend = maxGasSpendBig
+	}
+
+	var minGasPrice *big.Int
+	if ctx.IsSet(utils.MinGasPriceFlag.Name) {
+		minGasPriceBig, errf := tools.GetBigIntFromString(ctx.String(utils.MinGasPriceFlag.Name))
+		if errf != nil {
+			return nil, errf
+		}
+		if minGasPriceBig.Sign() <= 0 {
+			return nil, fmt.Errorf("min gas price must be positive")
+		}
+		minGasPrice = minGasPriceBig
+	}
+
+	var maxBumpGasPrice *big.Int
+	if ctx.IsSet(utils.MaxBumpGasPriceFlag.Name) {
+		maxBumpGasPriceBig, errf := tools.GetBigIntFromString(ctx.String(utils.MaxBumpGasPriceFlag.Name))
+		if errf != nil {
+			return nil, errf
+		}
+		if maxBumpGasPriceBig.Sign() <= 0 {
+			return nil, fmt.Errorf("max bump gas price must be positive")
+		}
+		maxBumpGasPrice = maxBumpGasPriceBig
+	}
+
 	args := &distributer.BuildTxArgs{
 		Sender:       ctx.String(utils.SenderFlag.Name),
 		KeystoreFile: ctx.String(utils.KeyStoreFileFlag.Name),
@@ -132,13 +240,34 @@ func getBuildTxArgs(ctx *cli.Context) (*distributer.BuildTxArgs, error) {
 		Nonce:        noncePtr,
 		GasLimit:     gasLimitPtr,
 		GasPrice:     gasPrice,
-	}
 
-	dryRun := ctx.Bool(utils.DryRunFlag.Name)
-	if err := args.Check(dryRun); err != nil {
-		return nil, err
-	}
+		MnemonicEnv:    ctx.String(utils.MnemonicEnvFlag.Name),
+		DerivationPath: ctx.String(utils.DerivationPathFlag.Name),
+		MaxGasSpend:    maxGasSpend,
+		MinGasPrice:    minGasPrice,
+		MaxNonceGap:    ctx.Uint64(utils.MaxNonceGapFlag.Name),
+		FixedNonce:     ctx.Bool(utils.FixedNonceFlag.Name),
+		StrictNonce:    ctx.Bool(utils.StrictNonceFlag.Name),
+		FailOnKnownTx:  ctx.Bool(utils.FailOnKnownTxFlag.Name),
+
+		AbortOnLowGasPrice: ctx.Bool(utils.AbortOnLowGasPriceFlag.Name),
+		UnsignedTxFile:     ctx.String(utils.EmitUnsignedJSONFlag.Name),
+		MultisigOutput:     ctx.String(utils.MultisigOutputFlag.Name),
+		TxType:             ctx.String(utils.TxTypeFlag.Name),
+
+		GasPriceStrategy:   ctx.String(utils.GasPriceStrategyFlag.Name),
+		GasPricePercentile: ctx.Float64(utils.GasPricePercentileFlag.Name),
+		GasPriceBlocks:     ctx.Uint64(utils.GasPriceBlocksFlag.Name),
 
+		ReplaceBumpPercent:    ctx.Uint64(utils.ReplaceBumpPercentFlag.Name),
+		MaxReplaceBumpPercent: ctx.Uint64(utils.MaxReplaceBumpPercentFlag.Name),
+		MaxBumpGasPrice:       maxBumpGasPrice,
+		WaitAtBumpCeiling:     ctx.Bool(utils.WaitAtBumpCeilingFlag.Name),
+
+		EstimateGas:      ctx.Bool(utils.EstimateGasFlag.Name),
+		GasBufferPercent: ctx.Uint64(utils.GasBufferFlag.Name),
+		CacheGasEstimate: ctx.Bool(utils.CacheGasEstimateFlag.Name),
+	}
 	return args, nil
 }
 
@@ -152,3 +281,21 @@ func setConfigParams(ctx *cli.Context) error {
 	}
 	return nil
 }
+
+// getConfirmPolicy get confirm policy from flags, return nil policy if not specified
+func getConfirmPolicy(ctx *cli.Context) (*distributer.ConfirmPolicy, time.Duration, error) {
+	confirmTiers, err := distributer.ParseConfirmTiers(ctx.StringSlice(utils.ConfirmTiersFlag.Name))
+	if err != nil {
+		return nil, 0, err
+	}
+	timeout := time.Duration(ctx.Uint64(utils.ConfirmTimeoutFlag.Name)) * time.Second
+	confirmations := ctx.Uint64(utils.ConfirmationsFlag.Name)
+	if confirmations == 0 && len(confirmTiers) == 0 {
+		return nil, timeout, nil
+	}
+	policy := &distributer.ConfirmPolicy{
+		Confirmations: confirmations,
+		Tiers:         confirmTiers,
+	}
+	return policy, timeout, nil
+}
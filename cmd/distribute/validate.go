@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/anyswap/ANYToken-distribution/cmd/utils"
+	"github.com/anyswap/ANYToken-distribution/distributer"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	validateCommand = &cli.Command{
+		Action:    validate,
+		Name:      "validate",
+		Usage:     "validate reward file offline",
+		ArgsUsage: " ",
+		Description: `
+validate structure of reward file without connecting to any node:
+title line, addresses, rewards, shares, gas overrides and duplicate accounts.
+every problem is reported with its line number, and total reward is computed.
+exit with error if any problem is found, so it can be used as a CI gate.
+if no problem is found, the canonical commitment of (account, amount) pairs is printed,
+which can be approved and checked by 'sendrewards --expectedCommitment'.
+`,
+		Flags: []cli.Flag{
+			utils.InputFileFlag,
+			utils.RewardTyepFlag,
+			utils.RewardTokenFlag,
+			utils.AllowDuplicateFlag,
+		},
+	}
+)
+
+func validate(ctx *cli.Context) error {
+	inputFile := ctx.String(utils.InputFileFlag.Name)
+	if inputFile == "" {
+		return fmt.Errorf("must specify input file")
+	}
+	vopt := &distributer.ValidateOption{
+		InputFile:      inputFile,
+		RewardType:     ctx.String(utils.RewardTyepFlag.Name),
+		RewardToken:    ctx.String(utils.RewardTokenFlag.Name),
+		AllowDuplicate: ctx.Bool(utils.AllowDuplicateFlag.Name),
+	}
+	res, err := distributer.ValidateRewardFile(vopt)
+	if err != nil {
+		return err
+	}
+	for _, problem := range res.Problems {
+		fmt.Printf("%v:%v: %v\n", inputFile, problem.Line, problem.Message)
+	}
+	fmt.Printf("recipients %v, total reward %v, problems %v\n", res.Recipients, res.TotalReward, len(res.Problems))
+	if len(res.Problems) != 0 {
+		return fmt.Errorf("validate %v failed with %v problems", inputFile, len(res.Problems))
+	}
+	commitment, err := distributer.GetRewardsCommitmentOfFile(inputFile)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("commitment %v\n", commitment.String())
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/anyswap/ANYToken-distribution/cmd/utils"
+	"github.com/anyswap/ANYToken-distribution/distributer"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	verifyCommand = &cli.Command{
+		Action:    verify,
+		Name:      "verify",
+		Usage:     "verify sended rewards",
+		ArgsUsage: " ",
+		Description: `
+verify every recipient in output file of sendrewards received reward.
+by default check Transfer log of reward token in transaction receipt,
+or check recipient's balance delta between the block before and the block of reward transaction.
+optionally wait every transaction confirmed by '--confirmations' blocks before verify,
+larger transfers can require more confirmations by '--confirmTiers'.
+with '--receiptProofs', write a proof file of every verified transfer for the recipient,
+which contains the receipt and its merkle proof of inclusion in the block's receipts trie.
+`,
+		Flags: []cli.Flag{
+			utils.GatewayFlag,
+			utils.GatewayFileFlag,
+			utils.DropMismatchChainClientFlag,
+			utils.DialRetriesFlag,
+			utils.DialRetryIntervalFlag,
+			utils.DialTimeoutFlag,
+			utils.InputFileFlag,
+			utils.RewardTokenFlag,
+			utils.SenderFlag,
+			utils.VerifyByBalanceDeltaFlag,
+			utils.ConfirmationsFlag,
+			utils.ConfirmTiersFlag,
+			utils.ConfirmTimeoutFlag,
+			utils.ReceiptProofsFlag,
+		},
+	}
+)
+
+func verify(ctx *cli.Context) error {
+	serverURL := ctx.StringSlice(utils.GatewayFlag.Name)
+	if len(serverURL) == 0 && !ctx.IsSet(utils.GatewayFileFlag.Name) {
+		return fmt.Errorf("must specify gateway URL")
+	}
+	vopt := &distributer.VerifyOption{
+		InputFile:      ctx.String(utils.InputFileFlag.Name),
+		RewardToken:    ctx.String(utils.RewardTokenFlag.Name),
+		Sender:         ctx.String(utils.SenderFlag.Name),
+		ByBalanceDelta: ctx.Bool(utils.VerifyByBalanceDeltaFlag.Name),
+
+		ReceiptProofDir: ctx.String(utils.ReceiptProofsFlag.Name),
+	}
+	if vopt.InputFile == "" {
+		return fmt.Errorf("must specify input file")
+	}
+	var err error
+	vopt.Confirm, vopt.ConfirmTimeout, err = getConfirmPolicy(ctx)
+	if err != nil {
+		return err
+	}
+
+	capi := utils.InitAppWithURL(ctx, serverURL, false)
+	distributer.SetAPICaller(capi)
+	defer capi.CloseClient()
+
+	return distributer.VerifyRewardsFromFile(vopt)
+}
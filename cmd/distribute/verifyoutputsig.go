@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/anyswap/ANYToken-distribution/cmd/utils"
+	"github.com/anyswap/ANYToken-distribution/distributer"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	verifyOutputSigCommand = &cli.Command{
+		Action:    verifyOutputSig,
+		Name:      "verifyoutputsig",
+		Usage:     "verify detached signature of output file",
+		ArgsUsage: " ",
+		Description: `
+verify output file is not altered after the run which signed it by '--signOutput',
+and is signed by the signer in signature file (and by '--sender' if specified).
+this command is offline and read only.
+`,
+		Flags: []cli.Flag{
+			utils.OutputFileFlag,
+			utils.SignatureFileFlag,
+			utils.SenderFlag,
+		},
+	}
+)
+
+func verifyOutputSig(ctx *cli.Context) error {
+	ofile := ctx.String(utils.OutputFileFlag.Name)
+	if ofile == "" {
+		return fmt.Errorf("must specify output file")
+	}
+	sigFile := ctx.String(utils.SignatureFileFlag.Name)
+	signature, err := distributer.VerifyOutputSignature(ofile, sigFile, ctx.String(utils.SenderFlag.Name))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("output file %v is signed by %v, hash %v\n", ofile, signature.Signer, signature.Hash.String())
+	return nil
+}
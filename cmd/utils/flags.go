@@ -1,6 +1,10 @@
 package utils
 
 import (
+	"os"
+	"time"
+
+	"github.com/anyswap/ANYToken-distribution/callapi"
 	"github.com/anyswap/ANYToken-distribution/log"
 	"github.com/urfave/cli/v2"
 )
@@ -66,6 +70,21 @@ var (
 		Usage: "sync end height (excluding end), 0 means endless",
 		Value: 0,
 	}
+	// SyncMigrateFlag --syncmigrate
+	SyncMigrateFlag = &cli.BoolFlag{
+		Name:  "syncmigrate",
+		Usage: "migrate stored sync state of old version to current version",
+	}
+	// SyncResetFlag --syncreset
+	SyncResetFlag = &cli.BoolFlag{
+		Name:  "syncreset",
+		Usage: "reset stored sync state of unrecognized version and sync from start",
+	}
+	// SyncChunkSizeFlag --syncchunksize
+	SyncChunkSizeFlag = &cli.Uint64Flag{
+		Name:  "syncchunksize",
+		Usage: "blocks of a chunk in range syncing, progress is checkpointed after every chunk (default 10000)",
+	}
 	// OverwriteFlag --overwrite
 	OverwriteFlag = &cli.BoolFlag{
 		Name:  "overwrite",
@@ -75,18 +94,268 @@ var (
 	// KeyStoreFileFlag --keystore
 	KeyStoreFileFlag = &cli.StringFlag{
 		Name:  "keystore",
-		Usage: "keystore file path",
+		Usage: "keystore file path, or keystore directory to find keystore of --sender in",
 	}
 	// PasswordFileFlag --password
 	PasswordFileFlag = &cli.StringFlag{
 		Name:  "password",
 		Usage: "password file path",
 	}
+	// MnemonicEnvFlag --mnemonicEnv
+	MnemonicEnvFlag = &cli.StringFlag{
+		Name:  "mnemonicEnv",
+		Usage: "name of environment variable which contains BIP-39 mnemonic (instead of keystore)",
+	}
+	// DerivationPathFlag --derivationPath
+	DerivationPathFlag = &cli.StringFlag{
+		Name:  "derivationPath",
+		Usage: "BIP-32/BIP-44 derivation path of mnemonic",
+		Value: "m/44'/60'/0'/0/0",
+	}
+	// MaxGasSpendFlag --maxGasSpend
+	MaxGasSpendFlag = &cli.StringFlag{
+		Name:  "maxGasSpend",
+		Usage: "abort sending if total gas cost (in wei) will exceed this value",
+	}
+	// MinGasPriceFlag --minGasPrice
+	MinGasPriceFlag = &cli.StringFlag{
+		Name:  "minGasPrice",
+		Usage: "replace zero or lower suggested gas price with this value",
+	}
+	// AbortOnLowGasPriceFlag --abortOnLowGasPrice
+	AbortOnLowGasPriceFlag = &cli.BoolFlag{
+		Name:  "abortOnLowGasPrice",
+		Usage: "abort instead of replacing zero or lower than min suggested gas price",
+	}
+	// OutputFormatFlag --outputFormat
+	OutputFormatFlag = &cli.StringFlag{
+		Name:  "outputFormat",
+		Usage: "output format, one of legacy, csv, json, jsonl",
+		Value: "legacy",
+	}
+	// SplitOutputFlag --splitOutput
+	SplitOutputFlag = &cli.BoolFlag{
+		Name:  "splitOutput",
+		Usage: "write skipped and failed recipients to <output>.skipped and <output>.failed",
+	}
+	// SkipReasonsFlag --skipReasons
+	SkipReasonsFlag = &cli.BoolFlag{
+		Name:  "skipReasons",
+		Usage: "add skip reason (zero, dust, burnAddress, blocklisted, ineligible, alreadyPaid) column to <output>.skipped, implies --splitOutput",
+	}
+	// TxTypeFlag --txType
+	TxTypeFlag = &cli.StringFlag{
+		Name:  "txType",
+		Usage: "transaction type, one of auto (detect by base fee of latest header), legacy, dynamic",
+		Value: "auto",
+	}
+	// GasPriceStrategyFlag --gasPriceStrategy
+	GasPriceStrategyFlag = &cli.StringFlag{
+		Name:  "gasPriceStrategy",
+		Usage: "how to get gas price if not specified, one of node-suggest, fee-history-percentile, fixed (must specify gas price)",
+		Value: "node-suggest",
+	}
+	// GasPricePercentileFlag --gasPricePercentile
+	GasPricePercentileFlag = &cli.Float64Flag{
+		Name:  "gasPricePercentile",
+		Usage: "percentile of priority fees of every block in fee-history-percentile gas price strategy",
+		Value: 60,
+	}
+	// GasPriceBlocksFlag --gasPriceBlocks
+	GasPriceBlocksFlag = &cli.Uint64Flag{
+		Name:  "gasPriceBlocks",
+		Usage: "latest blocks to get fee history from in fee-history-percentile gas price strategy",
+		Value: 20,
+	}
+	// EmitUnsignedJSONFlag --emitUnsignedJSON
+	EmitUnsignedJSONFlag = &cli.StringFlag{
+		Name:  "emitUnsignedJSON",
+		Usage: "in dry run, write unsigned transactions to this file as one JSON object per recipient",
+	}
+	// SenderLockDirFlag --senderLockDir
+	SenderLockDirFlag = &cli.StringFlag{
+		Name:  "senderLockDir",
+		Usage: "hold a per sender lock file in this directory while sending, so runs sharing a sender do not conflict on nonces",
+	}
+	// SenderLockWaitFlag --senderLockWait
+	SenderLockWaitFlag = &cli.Uint64Flag{
+		Name:  "senderLockWait",
+		Usage: "seconds to wait for the sender lock held by another run before abort (0 means abort at once)",
+	}
+	// ExpectedFactoryFlag --expectedFactory
+	ExpectedFactoryFlag = &cli.StringFlag{
+		Name:  "expectedFactory",
+		Usage: "abort if any exchange does not belong to this factory address",
+	}
+	// MultisigOutputFlag --multisigOutput
+	MultisigOutputFlag = &cli.StringFlag{
+		Name:  "multisigOutput",
+		Usage: "in dry run, write transfers of sender (a Safe multisig) to this file as a Safe transaction builder batch",
+	}
+	// AllowDuplicateFlag --allowDuplicate
+	AllowDuplicateFlag = &cli.BoolFlag{
+		Name:  "allowDuplicate",
+		Usage: "do not report duplicate accounts which are intended to be merged",
+	}
+	// MerkleOutputFlag --merkleOutput
+	MerkleOutputFlag = &cli.BoolFlag{
+		Name:  "merkleOutput",
+		Usage: "write merkle root and tree of (account, reward, txhash) results to <output>.merkle.json",
+	}
+	// SignOutputFlag --signOutput
+	SignOutputFlag = &cli.BoolFlag{
+		Name:  "signOutput",
+		Usage: "sign output files with sender key, detached signature is written to <output>.sig",
+	}
+	// SignatureFileFlag --signatureFile
+	SignatureFileFlag = &cli.StringFlag{
+		Name:  "signatureFile",
+		Usage: "detached signature file of output file (default <output>.sig)",
+	}
+	// VerboseOutputFlag --verboseOutput
+	VerboseOutputFlag = &cli.BoolFlag{
+		Name:  "verboseOutput",
+		Usage: "write nonce and gas price actually used by every transfer to output",
+	}
+	// PreflightReportFlag --preflightReport
+	PreflightReportFlag = &cli.StringFlag{
+		Name:  "preflightReport",
+		Usage: "write report of pre-flight checks (balances, simulation, token metadata, warnings) to this json file",
+	}
+	// ReplayLogFlag --replayLog
+	ReplayLogFlag = &cli.StringFlag{
+		Name:  "replayLog",
+		Usage: "replay log file of sending run",
+	}
+	// BalanceCacheFlag --balanceCache
+	BalanceCacheFlag = &cli.StringFlag{
+		Name:  "balanceCache",
+		Usage: "prefetched balance cache file",
+	}
+	// ReceiptProofsFlag --receiptProofs
+	ReceiptProofsFlag = &cli.StringFlag{
+		Name:  "receiptProofs",
+		Usage: "write proof of every verified transfer (receipt, its inclusion proof in receipts trie and Transfer log) to this directory",
+	}
+	// VerifyByBalanceDeltaFlag --verifyByBalanceDelta
+	VerifyByBalanceDeltaFlag = &cli.BoolFlag{
+		Name:  "verifyByBalanceDelta",
+		Usage: "verify by recipient's balance delta instead of transfer log",
+	}
+	// VerifyAfterEachFlag --verifyAfterEach
+	VerifyAfterEachFlag = &cli.BoolFlag{
+		Name:  "verifyAfterEach",
+		Usage: "wait every transfer confirmed successfully before sending the next one",
+	}
+	// ContinueOnErrorFlag --continueOnError
+	ContinueOnErrorFlag = &cli.BoolFlag{
+		Name:  "continueOnError",
+		Usage: "continue when a send failed, and retry the ones proved not broadcast once at the end",
+	}
+	// ConfirmationsFlag --confirmations
+	ConfirmationsFlag = &cli.Uint64Flag{
+		Name:  "confirmations",
+		Usage: "wait transaction confirmed by this number of blocks (0 means no wait)",
+	}
+	// ConfirmTiersFlag --confirmTiers
+	ConfirmTiersFlag = &cli.StringSliceFlag{
+		Name:  "confirmTiers",
+		Usage: "confirmations scaled by transfer amount, format is <amount>:<confirmations>",
+	}
+	// ConfirmTimeoutFlag --confirmTimeout
+	ConfirmTimeoutFlag = &cli.Uint64Flag{
+		Name:  "confirmTimeout",
+		Usage: "seconds of timeout to wait transaction confirmations",
+		Value: 600,
+	}
+	// ScheduleIntervalFlag --scheduleInterval
+	ScheduleIntervalFlag = &cli.Uint64Flag{
+		Name:  "scheduleInterval",
+		Usage: "seconds between scheduled distributions (eg. 604800 for weekly)",
+		Value: 604800,
+	}
+	// QueueOverlapFlag --queueOverlap
+	QueueOverlapFlag = &cli.BoolFlag{
+		Name:  "queueOverlap",
+		Usage: "queue the due distribution if the previous one is still running, instead of skipping it",
+	}
+	// FailOnKnownTxFlag --failOnKnownTx
+	FailOnKnownTxFlag = &cli.BoolFlag{
+		Name:  "failOnKnownTx",
+		Usage: "abort on 'already known' error of resending identical tx instead of treating it as sended",
+	}
+	// ReplaceBumpPercentFlag --replaceBumpPercent
+	ReplaceBumpPercentFlag = &cli.Uint64Flag{
+		Name:  "replaceBumpPercent",
+		Usage: "on 'replacement transaction underpriced' error, bump gas price by this percent and retry (0 means no retry)",
+	}
+	// MaxReplaceBumpPercentFlag --maxReplaceBumpPercent
+	MaxReplaceBumpPercentFlag = &cli.Uint64Flag{
+		Name:  "maxReplaceBumpPercent",
+		Usage: "cap of the increasing gas price bump percent of replacement",
+		Value: 100,
+	}
+	// MaxBumpGasPriceFlag --maxBumpGasPrice
+	MaxBumpGasPriceFlag = &cli.StringFlag{
+		Name:  "maxBumpGasPrice",
+		Usage: "per transaction ceiling of bumped gas price of replacement (in wei), stop bumping once it would be exceeded",
+	}
+	// WaitAtBumpCeilingFlag --waitAtBumpCeiling
+	WaitAtBumpCeilingFlag = &cli.BoolFlag{
+		Name:  "waitAtBumpCeiling",
+		Usage: "when bumped gas price reaches --maxBumpGasPrice, keep resending at the ceiling price until the nonce is available instead of aborting",
+	}
+	// MaxNonceGapFlag --maxNonceGap
+	MaxNonceGapFlag = &cli.Uint64Flag{
+		Name:  "maxNonceGap",
+		Usage: "when sending native coin, max gap between sent nonce and confirmed nonce (0 means no limit)",
+	}
+	// BurnAddressSliceFlag --burnAddresses
+	BurnAddressSliceFlag = &cli.StringSliceFlag{
+		Name:  "burnAddresses",
+		Usage: "burn addresses which are not allowed to be recipients (zero address is always checked)",
+	}
+	// SkipBurnAddressesFlag --skipBurnAddresses
+	SkipBurnAddressesFlag = &cli.BoolFlag{
+		Name:  "skipBurnAddresses",
+		Usage: "skip zero and burn address recipients instead of aborting",
+	}
+	// BlocklistFlag --blocklist
+	BlocklistFlag = &cli.StringFlag{
+		Name:  "blocklist",
+		Usage: "file or http(s) URL of blocked addresses which are not allowed to be recipients",
+	}
+	// SkipBlocklistedFlag --skipBlocklisted
+	SkipBlocklistedFlag = &cli.BoolFlag{
+		Name:  "skipBlocklisted",
+		Usage: "skip blocklisted recipients with loud logging instead of aborting",
+	}
+	// ConfirmFlag --confirm
+	ConfirmFlag = &cli.BoolFlag{
+		Name:  "confirm",
+		Usage: "present summary and ask to confirm before sending (after warm up checks)",
+	}
 	// GasLimitFlag --gas
 	GasLimitFlag = &cli.StringFlag{
 		Name:  "gasLimit",
 		Usage: "gas limit in transaction, use default if not specified",
 	}
+	// EstimateGasFlag --estimateGas
+	EstimateGasFlag = &cli.BoolFlag{
+		Name:  "estimateGas",
+		Usage: "estimate gas limit of every transfer plus --gasBuffer percent, instead of using --gasLimit",
+	}
+	// GasBufferFlag --gasBuffer
+	GasBufferFlag = &cli.Uint64Flag{
+		Name:  "gasBuffer",
+		Usage: "percent added to estimated gas as gas limit",
+		Value: 20,
+	}
+	// CacheGasEstimateFlag --cacheGasEstimate
+	CacheGasEstimateFlag = &cli.BoolFlag{
+		Name:  "cacheGasEstimate",
+		Usage: "with --estimateGas, reuse the estimate once the first transfers of a token are estimated the same (homogeneous batch)",
+	}
 	// GasPriceFlag --gasPrice
 	GasPriceFlag = &cli.StringFlag{
 		Name:  "gasPrice",
@@ -97,6 +366,16 @@ var (
 		Name:  "nonce",
 		Usage: "nonce in transaction, use default if not specified",
 	}
+	// FixedNonceFlag --fixedNonce
+	FixedNonceFlag = &cli.BoolFlag{
+		Name:  "fixedNonce",
+		Usage: "assign sequential nonces from --nonce without querying node",
+	}
+	// StrictNonceFlag --strictNonce
+	StrictNonceFlag = &cli.BoolFlag{
+		Name:  "strictNonce",
+		Usage: "abort if pending nonce of node is not the specified --nonce (or confirmed nonce if not specified) before sending",
+	}
 
 	// RewardTokenFlag --rewardToken
 	RewardTokenFlag = &cli.StringFlag{
@@ -171,6 +450,21 @@ var (
 		Name:  "input",
 		Usage: "input file slice",
 	}
+	// EligibilityContractFlag --eligibilityContract
+	EligibilityContractFlag = &cli.StringFlag{
+		Name:  "eligibilityContract",
+		Usage: "contract to check eligibility of every recipient",
+	}
+	// EligibilitySelectorFlag --eligibilitySelector
+	EligibilitySelectorFlag = &cli.StringFlag{
+		Name:  "eligibilitySelector",
+		Usage: "4 bytes selector of eligibility function '(address) returns (bool)', eg. isEligible(address)",
+	}
+	// SkipIneligibleFlag --skipIneligible
+	SkipIneligibleFlag = &cli.BoolFlag{
+		Name:  "skipIneligible",
+		Usage: "skip ineligible recipients instead of aborting",
+	}
 	// OutputFileFlag --output
 	OutputFileFlag = &cli.StringFlag{
 		Name:  "output",
@@ -181,6 +475,38 @@ var (
 		Name:  "output",
 		Usage: "output file slice",
 	}
+	// PendingFileFlag --pendingFile
+	PendingFileFlag = &cli.StringFlag{
+		Name:  "pendingFile",
+		Usage: "new input file of not sended accounts",
+	}
+	// TestCountFlag --count
+	TestCountFlag = &cli.IntFlag{
+		Name:  "count",
+		Usage: "count of synthetic recipients",
+		Value: 100,
+	}
+	// MinRewardFlag --minReward
+	MinRewardFlag = &cli.StringFlag{
+		Name:  "minReward",
+		Usage: "min reward of synthetic recipient",
+		Value: "1",
+	}
+	// MaxRewardFlag --maxReward
+	MaxRewardFlag = &cli.StringFlag{
+		Name:  "maxReward",
+		Usage: "max reward of synthetic recipient (default is min reward)",
+	}
+	// TestSeedFlag --seed
+	TestSeedFlag = &cli.StringFlag{
+		Name:  "seed",
+		Usage: "derive synthetic recipients from seed (default is random)",
+	}
+	// OutputStdoutFlag --outputStdout
+	OutputStdoutFlag = &cli.BoolFlag{
+		Name:  "outputStdout",
+		Usage: "write output to stdout as well as output file (log is redirected to stderr)",
+	}
 	// DryRunFlag --dryrun
 	DryRunFlag = &cli.BoolFlag{
 		Name:  "dryrun",
@@ -191,11 +517,186 @@ var (
 		Name:  "gateway",
 		Usage: "gateway URL address slice",
 	}
+	// GatewayFileFlag --gatewayFile
+	GatewayFileFlag = &cli.StringFlag{
+		Name:  "gatewayFile",
+		Usage: "toml file of gateway endpoints list with weight and role (primary or archive)",
+	}
+	// GatewaySecretsFileFlag --gatewaySecretsFile
+	GatewaySecretsFileFlag = &cli.StringFlag{
+		Name:  "gatewaySecretsFile",
+		Usage: "toml file of gateway credentials (bearer token or basic auth) by URL, sent as request headers",
+	}
+	// DropMismatchChainClientFlag --dropMismatchChainClient
+	DropMismatchChainClientFlag = &cli.BoolFlag{
+		Name:  "dropMismatchChainClient",
+		Usage: "drop gateway client with mismatched chain ID instead of refusing to start",
+	}
+	// DialRetriesFlag --dialRetries
+	DialRetriesFlag = &cli.IntFlag{
+		Name:  "dialRetries",
+		Usage: "attempts to connect every gateway URL at startup",
+		Value: callapi.DefaultDialRetry.Count,
+	}
+	// DialRetryIntervalFlag --dialRetryInterval
+	DialRetryIntervalFlag = &cli.Uint64Flag{
+		Name:  "dialRetryInterval",
+		Usage: "seconds between attempts to connect gateway",
+		Value: uint64(callapi.DefaultDialRetry.Interval / time.Second),
+	}
+	// DialTimeoutFlag --dialTimeout
+	DialTimeoutFlag = &cli.Uint64Flag{
+		Name:  "dialTimeout",
+		Usage: "seconds of connect timeout of every attempt to connect gateway",
+		Value: uint64(callapi.DefaultDialRetry.Timeout / time.Second),
+	}
+	// CallTimeoutFlag --callTimeout
+	CallTimeoutFlag = &cli.Uint64Flag{
+		Name:  "callTimeout",
+		Usage: "seconds of timeout of every eth_call, balance and header read to one gateway, then the next gateway is tried (0 means no timeout)",
+		Value: uint64(callapi.DefaultCallTimeout / time.Second),
+	}
+	// RPCBatchSizeFlag --rpcBatchSize
+	RPCBatchSizeFlag = &cli.IntFlag{
+		Name:  "rpcBatchSize",
+		Usage: "max requests in one json rpc batch call when reading balances (0 means disable batching)",
+		Value: callapi.DefaultRPCBatchSize,
+	}
+	// MulticallFlag --multicall
+	MulticallFlag = &cli.StringFlag{
+		Name:  "multicall",
+		Usage: "Multicall2 contract address, liquidity balance reads are aggregated by its tryAggregate in chunks of '--rpcBatchSize'",
+	}
+	// RateLimitCoolDownFlag --rateLimitCoolDown
+	RateLimitCoolDownFlag = &cli.Uint64Flag{
+		Name:  "rateLimitCoolDown",
+		Usage: "seconds to cool down rate limited server if it has no Retry-After hint",
+		Value: 10,
+	}
+	// ParallelReadFlag --parallelRead
+	ParallelReadFlag = &cli.BoolFlag{
+		Name:  "parallelRead",
+		Usage: "send eth_call, balance and header reads to all gateways concurrently and take the fastest success",
+	}
+	// ClientDemoteFailuresFlag --clientDemoteFailures
+	ClientDemoteFailuresFlag = &cli.IntFlag{
+		Name:  "clientDemoteFailures",
+		Usage: "consecutive failures of a gateway to be tried last as failing",
+		Value: callapi.DefaultDemoteFailures,
+	}
+	// ClientRecoverSuccessesFlag --clientRecoverSuccesses
+	ClientRecoverSuccessesFlag = &cli.IntFlag{
+		Name:  "clientRecoverSuccesses",
+		Usage: "consecutive successes of a failing gateway to be tried in turn again (failing gateways are tried last)",
+		Value: callapi.DefaultRecoverSuccesses,
+	}
+	// MempoolFullRetriesFlag --mempoolFullRetries
+	MempoolFullRetriesFlag = &cli.IntFlag{
+		Name:  "mempoolFullRetries",
+		Usage: "retries of sending transaction rejected by full mempool (0 means no retry)",
+		Value: 5,
+	}
+	// MempoolFullBackoffFlag --mempoolFullBackoff
+	MempoolFullBackoffFlag = &cli.Uint64Flag{
+		Name:  "mempoolFullBackoff",
+		Usage: "seconds to wait before the first retry of full mempool, doubled every retry",
+		Value: 15,
+	}
+	// RetryPolicyFlag --retryPolicy
+	RetryPolicyFlag = &cli.StringFlag{
+		Name:  "retryPolicy",
+		Usage: "retry policy of rpc calls, 'default' retries every error, 'failfast' does not retry revert and nonce too low errors",
+		Value: callapi.RetryPolicyDefault,
+	}
+	// MaxSupplyPercentFlag --maxSupplyPercent
+	MaxSupplyPercentFlag = &cli.Float64Flag{
+		Name:  "maxSupplyPercent",
+		Usage: "warn if total reward exceeds this percent of reward token's total supply (0 means no check)",
+		Value: 10,
+	}
+	// AbortOnSupplyRatioFlag --abortOnSupplyRatio
+	AbortOnSupplyRatioFlag = &cli.BoolFlag{
+		Name:  "abortOnSupplyRatio",
+		Usage: "abort instead of warning if total reward exceeds max supply percent",
+	}
+	// RevalidateIntervalFlag --revalidateInterval
+	RevalidateIntervalFlag = &cli.Uint64Flag{
+		Name:  "revalidateInterval",
+		Usage: "every this many sends, re-probe reward token state and re-simulate the next transfer, abort if it changed since pre-flight (0 means never)",
+	}
+	// PausedSelectorFlag --pausedSelector
+	PausedSelectorFlag = &cli.StringFlag{
+		Name:  "pausedSelector",
+		Usage: "abort if reward token's view function of this selector returns true, eg. 0x5c975abb for paused()",
+	}
+	// BlacklistSelectorFlag --blacklistSelector
+	BlacklistSelectorFlag = &cli.StringFlag{
+		Name:  "blacklistSelector",
+		Usage: "abort if reward token's view function of this selector returns true for sender, eg. 0xfe575a87 for isBlacklisted(address)",
+	}
+	// SweepToFlag --sweepTo
+	SweepToFlag = &cli.StringFlag{
+		Name:  "sweepTo",
+		Usage: "after sending, sweep remaining native coin balance of sender to this treasury address",
+	}
+	// SweepReserveFlag --sweepReserve
+	SweepReserveFlag = &cli.StringFlag{
+		Name:  "sweepReserve",
+		Usage: "native coin amount (in wei) kept in sender when sweep",
+	}
+	// MinClientsFlag --minClients
+	MinClientsFlag = &cli.IntFlag{
+		Name:  "minClients",
+		Usage: "refuse to send unless at least this number of clients are healthy (0 means no limit)",
+	}
+	// MaxRPCFailuresFlag --maxRPCFailures
+	MaxRPCFailuresFlag = &cli.IntFlag{
+		Name:  "maxRPCFailures",
+		Usage: "abort run if total rpc failures in window reach this value (0 means no limit)",
+	}
+	// MaxRPCFailureRateFlag --maxRPCFailureRate
+	MaxRPCFailureRateFlag = &cli.Float64Flag{
+		Name:  "maxRPCFailureRate",
+		Usage: "abort run if rpc failure rate in window reach this value, range (0,1] (0 means no limit)",
+	}
+	// RPCFailureWindowFlag --rpcFailureWindow
+	RPCFailureWindowFlag = &cli.Uint64Flag{
+		Name:  "rpcFailureWindow",
+		Usage: "sliding window of seconds to count rpc failures (0 means the whole run)",
+		Value: 600,
+	}
 	// SenderFlag --sender
 	SenderFlag = &cli.StringFlag{
 		Name:  "sender",
 		Usage: "transaction sender",
 	}
+	// ChainsFileFlag --chainsFile
+	ChainsFileFlag = &cli.StringFlag{
+		Name:  "chainsFile",
+		Usage: "toml file of chains to send rewards on in multichain sending",
+	}
+	// ExternalMergeThresholdFlag --externalMergeThreshold
+	ExternalMergeThresholdFlag = &cli.Int64Flag{
+		Name:  "externalMergeThreshold",
+		Usage: "input file larger than this (in bytes) is merged on disk by external sort (0 means never)",
+		Value: 256 * 1024 * 1024,
+	}
+	// ExternalMergeFlag --externalMerge
+	ExternalMergeFlag = &cli.BoolFlag{
+		Name:  "externalMerge",
+		Usage: "always merge on disk by external sort",
+	}
+	// MergeChunkSizeFlag --mergeChunkSize
+	MergeChunkSizeFlag = &cli.IntFlag{
+		Name:  "mergeChunkSize",
+		Usage: "max recipients held in memory by external merge",
+		Value: 1000000,
+	}
+	// TreasuryFlag --treasury
+	TreasuryFlag = &cli.StringFlag{
+		Name:  "treasury",
+		Usage: "treasury address whose balances also fund rewards",
+	}
 	// SaveDBFlag --savedb
 	SaveDBFlag = &cli.BoolFlag{
 		Name:  "savedb",
@@ -206,6 +707,32 @@ var (
 		Name:  "sample",
 		Usage: "sample height or timestamp",
 	}
+	// SampleIntervalFlag --sampleInterval
+	SampleIntervalFlag = &cli.Uint64Flag{
+		Name:  "sampleInterval",
+		Usage: "calc time weighted liquidity by sampling every interval blocks (archive mode)",
+	}
+	// SnapshotWorkersFlag --snapshotWorkers
+	SnapshotWorkersFlag = &cli.IntFlag{
+		Name:  "snapshotWorkers",
+		Usage: "number of workers to calc time weighted liquidity concurrently",
+		Value: 4,
+	}
+	// CombineExchangesFlag --combineExchanges
+	CombineExchangesFlag = &cli.BoolFlag{
+		Name:  "combineExchanges",
+		Usage: "distribute one pool by combined liquidity of all exchanges multiplied by their weights, in one output file",
+	}
+	// SnapshotHeightsFlag --snapshotHeights
+	SnapshotHeightsFlag = &cli.Int64SliceFlag{
+		Name:  "snapshotHeights",
+		Usage: "average liquidity over these snapshot heights (archive mode)",
+	}
+	// SnapshotProvenanceFlag --snapshotProvenance
+	SnapshotProvenanceFlag = &cli.BoolFlag{
+		Name:  "snapshotProvenance",
+		Usage: "output hash of the block every liquidity balance is read at after the height column",
+	}
 	// RewardTyepFlag --rewardType
 	RewardTyepFlag = &cli.StringFlag{
 		Name:  "rewardType",
@@ -228,6 +755,11 @@ var (
 		Usage: "batch interval of milli seconds",
 		Value: 13000,
 	}
+	// AdaptiveThrottleFlag --adaptiveThrottle
+	AdaptiveThrottleFlag = &cli.BoolFlag{
+		Name:  "adaptiveThrottle",
+		Usage: "adjust send delay according to txpool pending count and gas price trend",
+	}
 	// OnlySyncAccountFlag --onlySyncAccount
 	OnlySyncAccountFlag = &cli.BoolFlag{
 		Name:  "onlySyncAccount",
@@ -253,6 +785,43 @@ var (
 		Name:  "scaling",
 		Usage: "scaling value, comma separated interger of numerator and denominator. eg. 80,100 is scaling 80%",
 	}
+	// InputWeightsFlag --inputWeights
+	InputWeightsFlag = &cli.BoolFlag{
+		Name:  "inputWeights",
+		Usage: "reward column of input file is weight in basis points of total pool",
+	}
+	// TotalPoolFlag --totalPool
+	TotalPoolFlag = &cli.StringFlag{
+		Name:  "totalPool",
+		Usage: "total pool distributed proportionally by input weights",
+	}
+	// AssertTotalSliceFlag --assertTotal
+	AssertTotalSliceFlag = &cli.StringSliceFlag{
+		Name:  "assertTotal",
+		Usage: "expected total reward of every input file, abort if mismatch",
+	}
+	// ExpectedCommitmentSliceFlag --expectedCommitment
+	ExpectedCommitmentSliceFlag = &cli.StringSliceFlag{
+		Name:  "expectedCommitment",
+		Usage: "approved commitment (keccak256 of sorted account and amount pairs, see 'validate') of every input file, abort if mismatch",
+	}
+	// RequireUniqueFlag --requireUnique
+	RequireUniqueFlag = &cli.BoolFlag{
+		Name:  "requireUnique",
+		Usage: "abort if any recipient appears more than once in input file, report every duplicate with its lines",
+	}
+	// PaidMismatchPolicyFlag --paidMismatchPolicy
+	PaidMismatchPolicyFlag = &cli.StringFlag{
+		Name:  "paidMismatchPolicy",
+		Usage: "how to handle recipient whose idempotency key is already paid for a different amount than input, one of skip, difference (pay the difference), abort",
+		Value: "skip",
+	}
+	// AssertToleranceFlag --assertTolerance
+	AssertToleranceFlag = &cli.StringFlag{
+		Name:  "assertTolerance",
+		Usage: "allowed difference of base units in assert total",
+		Value: "0",
+	}
 )
 
 // SyncArguments command line arguments
@@ -260,6 +829,9 @@ type SyncArguments struct {
 	SyncStartHeight *uint64
 	SyncEndHeight   *uint64
 	SyncOverwrite   *bool
+	SyncChunkSize   *uint64
+	SyncMigrate     bool
+	SyncReset       bool
 }
 
 // SyncArgs sync arguments
@@ -272,6 +844,11 @@ func SetLogger(ctx *cli.Context) {
 	colorFormat := ctx.Bool(ColorFormatFlag.Name)
 	log.SetLogger(uint32(logLevel), jsonFormat, colorFormat)
 
+	// keep stdout clean for output consumers
+	if ctx.Bool(OutputStdoutFlag.Name) {
+		log.SetOutput(os.Stderr)
+	}
+
 	logFile := ctx.String(LogFileFlag.Name)
 	if logFile != "" {
 		logRotation := ctx.Uint64(LogRotationFlag.Name)
@@ -299,4 +876,10 @@ func InitSyncArguments(ctx *cli.Context) {
 		overwrite := ctx.Bool(OverwriteFlag.Name)
 		SyncArgs.SyncOverwrite = &overwrite
 	}
+	if ctx.IsSet(SyncChunkSizeFlag.Name) {
+		chunkSize := ctx.Uint64(SyncChunkSizeFlag.Name)
+		SyncArgs.SyncChunkSize = &chunkSize
+	}
+	SyncArgs.SyncMigrate = ctx.Bool(SyncMigrateFlag.Name)
+	SyncArgs.SyncReset = ctx.Bool(SyncResetFlag.Name)
 }
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -8,6 +10,7 @@ import (
 	"github.com/anyswap/ANYToken-distribution/log"
 	"github.com/anyswap/ANYToken-distribution/mongodb"
 	"github.com/anyswap/ANYToken-distribution/params"
+	"github.com/anyswap/ANYToken-distribution/tracing"
 	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
 	"github.com/urfave/cli/v2"
 )
@@ -17,7 +20,9 @@ func InitApp(ctx *cli.Context, withConfigFile bool) *callapi.APICaller {
 	return initApp(ctx, withConfigFile, nil)
 }
 
-// InitAppWithURL init app for library use (remember close client in the caller)
+// InitAppWithURL init app for library use (remember close client in the caller).
+// rpc calls of the returned API caller are cancelled when ctx.Context is done,
+// embedders can run app with a cancellable context by app.RunContext
 func InitAppWithURL(ctx *cli.Context, serverURL []string, withConfigFile bool) *callapi.APICaller {
 	return initApp(ctx, withConfigFile, serverURL)
 }
@@ -25,8 +30,18 @@ func InitAppWithURL(ctx *cli.Context, serverURL []string, withConfigFile bool) *
 func initApp(ctx *cli.Context, withConfigFile bool, serverURL []string) *callapi.APICaller {
 	SetLogger(ctx)
 
+	if retryPolicy := ctx.String(RetryPolicyFlag.Name); !callapi.IsValidRetryPolicy(retryPolicy) {
+		log.Fatalf("unknown retry policy '%v'", retryPolicy)
+	}
+
+	setGatewayCredentials(ctx)
+	initTracing()
+
+	dropMismatchChainClient := ctx.Bool(DropMismatchChainClientFlag.Name)
+	dialRetry := getDialRetry(ctx)
+
 	if !withConfigFile {
-		return DialServer(serverURL)
+		return dialGateway(ctx, serverURL, nil, dropMismatchChainClient, dialRetry)
 	}
 
 	InitSyncArguments(ctx)
@@ -36,11 +51,17 @@ func initApp(ctx *cli.Context, withConfigFile bool, serverURL []string) *callapi
 
 	InitMongodb()
 
+	gatewayConfig := params.GetConfig().Gateway
+	var endpoints []*params.GatewayEndpoint
 	if len(serverURL) == 0 {
-		serverURL = params.GetConfig().Gateway.APIAddress
+		serverURL = gatewayConfig.APIAddress
+		endpoints = gatewayConfig.Endpoints
+	}
+	if gatewayConfig.DropMismatchChainClient {
+		dropMismatchChainClient = true
 	}
 
-	capi := DialServer(serverURL)
+	capi := dialGateway(ctx, serverURL, endpoints, dropMismatchChainClient, dialRetry)
 
 	if err := verifyConfig(capi); err != nil {
 		log.Fatalf("verifyConfig error. %v", err)
@@ -49,14 +70,121 @@ func initApp(ctx *cli.Context, withConfigFile bool, serverURL []string) *callapi
 	return capi
 }
 
-// DialServer connect to serverURL
-func DialServer(serverURL []string) *callapi.APICaller {
-	capi := callapi.NewDefaultAPICaller()
+// dialGateway connect to serverURL and gateway endpoints (of config and '--gatewayFile')
+func dialGateway(ctx *cli.Context, serverURL []string, endpoints []*params.GatewayEndpoint, dropMismatchChainClient bool, dialRetry *callapi.DialRetry) *callapi.APICaller {
+	if gatewayFile := ctx.String(GatewayFileFlag.Name); gatewayFile != "" {
+		fileEndpoints, err := params.LoadGatewayFile(gatewayFile)
+		if err != nil {
+			log.Fatalf("load gateway file failed. %v", err)
+		}
+		endpoints = append(endpoints, fileEndpoints...)
+	}
+
+	var capi *callapi.APICaller
+	if len(endpoints) == 0 {
+		capi = DialServer(ctx.Context, serverURL, dropMismatchChainClient, dialRetry)
+	} else {
+		capiEndpoints := make([]*callapi.Endpoint, 0, len(serverURL)+len(endpoints))
+		for _, url := range serverURL {
+			capiEndpoints = append(capiEndpoints, &callapi.Endpoint{URL: url})
+		}
+		for _, endpoint := range endpoints {
+			capiEndpoints = append(capiEndpoints, &callapi.Endpoint{
+				URL:    endpoint.URL,
+				Weight: endpoint.Weight,
+				Role:   endpoint.Role,
+			})
+		}
+		capi = DialEndpoints(ctx.Context, capiEndpoints, dropMismatchChainClient, dialRetry)
+	}
+	setCallerOptions(ctx, capi)
+	return capi
+}
+
+// DialChain connect to gateways of one chain in multichain sending ('--gatewayFile' is not applied)
+func DialChain(ctx *cli.Context, serverURL []string) *callapi.APICaller {
+	capi := DialServer(ctx.Context, serverURL, ctx.Bool(DropMismatchChainClientFlag.Name), getDialRetry(ctx))
+	setCallerOptions(ctx, capi)
+	return capi
+}
+
+// setGatewayCredentials load '--gatewaySecretsFile', it must be done before dialing
+func setGatewayCredentials(ctx *cli.Context) {
+	secretsFile := ctx.String(GatewaySecretsFileFlag.Name)
+	if secretsFile == "" {
+		return
+	}
+	credentials, err := callapi.LoadCredentialsFile(secretsFile)
+	if err != nil {
+		log.Fatalf("load gateway secrets file failed. %v", err)
+	}
+	if err = callapi.SetCredentials(credentials); err != nil {
+		log.Fatalf("set gateway credentials failed. %v", err)
+	}
+}
+
+// initTracing enable tracing if otlp exporter is configured by environment variables
+func initTracing() {
+	if err := tracing.Init(); err != nil {
+		log.Fatalf("init tracing failed. %v", err)
+	}
+	callapi.EnableRPCTracing()
+}
+
+func getDialRetry(ctx *cli.Context) *callapi.DialRetry {
+	return &callapi.DialRetry{
+		Count:    ctx.Int(DialRetriesFlag.Name),
+		Interval: time.Duration(ctx.Uint64(DialRetryIntervalFlag.Name)) * time.Second,
+		Timeout:  time.Duration(ctx.Uint64(DialTimeoutFlag.Name)) * time.Second,
+	}
+}
+
+func setCallerOptions(ctx *cli.Context, capi *callapi.APICaller) {
+	capi.SetCallTimeout(time.Duration(ctx.Uint64(CallTimeoutFlag.Name)) * time.Second)
+	capi.SetRPCBatchSize(ctx.Int(RPCBatchSizeFlag.Name))
+	if multicall := ctx.String(MulticallFlag.Name); multicall != "" {
+		if !common.IsHexAddress(multicall) {
+			log.Fatalf("wrong multicall address '%v'", multicall)
+		}
+		multicallAddr := common.HexToAddress(multicall)
+		capi.SetMulticallAddress(&multicallAddr)
+	}
+	capi.SetRateLimitCoolDown(time.Duration(ctx.Uint64(RateLimitCoolDownFlag.Name)) * time.Second)
+	capi.SetParallelRead(ctx.Bool(ParallelReadFlag.Name))
+	capi.SetDemoteFailures(ctx.Int(ClientDemoteFailuresFlag.Name))
+	capi.SetRecoverSuccesses(ctx.Int(ClientRecoverSuccessesFlag.Name))
+	capi.SetMempoolFullRetry(ctx.Int(MempoolFullRetriesFlag.Name), time.Duration(ctx.Uint64(MempoolFullBackoffFlag.Name))*time.Second)
+	if err := capi.UseRetryPolicy(ctx.String(RetryPolicyFlag.Name)); err != nil {
+		log.Fatalf("use retry policy failed. %v", err)
+	}
+}
+
+// DialServer connect to serverURL, rpc calls are cancelled when parentCtx is done
+func DialServer(parentCtx context.Context, serverURL []string, dropMismatchChainClient bool, dialRetry *callapi.DialRetry) *callapi.APICaller {
+	return dialWithRetry(parentCtx, dropMismatchChainClient, dialRetry, func(capi *callapi.APICaller) error {
+		return capi.DialServer(serverURL)
+	})
+}
+
+// DialEndpoints connect to gateway endpoints, rpc calls are cancelled when parentCtx is done
+func DialEndpoints(parentCtx context.Context, endpoints []*callapi.Endpoint, dropMismatchChainClient bool, dialRetry *callapi.DialRetry) *callapi.APICaller {
+	return dialWithRetry(parentCtx, dropMismatchChainClient, dialRetry, func(capi *callapi.APICaller) error {
+		return capi.DialEndpoints(endpoints)
+	})
+}
+
+func dialWithRetry(parentCtx context.Context, dropMismatchChainClient bool, dialRetry *callapi.DialRetry, dial func(capi *callapi.APICaller) error) *callapi.APICaller {
+	capi := callapi.NewDefaultAPICallerWithContext(parentCtx)
+	capi.SetDropMismatchChainClient(dropMismatchChainClient)
+	capi.SetDialRetry(dialRetry)
 	for {
-		err := capi.DialServer(serverURL)
+		err := dial(capi)
 		if err == nil {
 			break
 		}
+		if errors.Is(err, callapi.ErrChainIDMismatch) || errors.Is(err, callapi.ErrDialServerFailed) || parentCtx.Err() != nil {
+			log.Fatalf("dial server failed. %v", err)
+		}
 		time.Sleep(3 * time.Second)
 	}
 	return capi
@@ -74,11 +202,17 @@ func verifyConfig(capi *callapi.APICaller) error {
 	for _, ex := range config.Exchanges {
 		exchange := common.HexToAddress(ex.Exchange)
 		token := common.HexToAddress(ex.Token)
-		wantToken := capi.GetExchangeTokenAddress(exchange)
+		wantToken, err := capi.GetExchangeTokenAddress(exchange)
+		if err != nil {
+			return fmt.Errorf("get exchange %v 's token address failed: %w", ex.Exchange, err)
+		}
 		if token != wantToken {
 			return fmt.Errorf("exchange token mismatch. exchange %v want token %v, but have %v", ex.Exchange, wantToken.String(), ex.Token)
 		}
-		factory := capi.GetExchangeFactoryAddress(exchange)
+		factory, err := capi.GetExchangeFactoryAddress(exchange)
+		if err != nil {
+			return fmt.Errorf("get exchange %v 's factory address failed: %w", ex.Exchange, err)
+		}
 		if !params.IsConfigedFactory(factory) {
 			return fmt.Errorf("exchange %v 's factory %v is not configed", ex.Exchange, factory.String())
 		}
@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+)
+
+// WithSignalCancel return context which is canceled when interrupt or terminate signal is received
+func WithSignalCancel(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		defer signal.Stop(signalChan)
+		select {
+		case sig := <-signalChan:
+			log.Info("receive signal, stopping", "signal", sig)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
@@ -0,0 +1,274 @@
+package distributer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/anyswap/ANYToken-distribution/tools"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+const unknownRewardToken = "unknown"
+
+// AggregateReport consolidated report of multiple output files
+type AggregateReport struct {
+	Files  []*AggregateFileInfo
+	Tokens []*TokenAggregate
+}
+
+// AggregateFileInfo info of an aggregated output file
+type AggregateFileInfo struct {
+	File        string
+	Format      string
+	RewardToken string
+	Records     int
+}
+
+// TokenAggregate rewards of a token aggregated across files
+type TokenAggregate struct {
+	RewardToken string
+	Files       int
+	Records     int
+	SentRecords int // records with txhash
+	Recipients  int
+	TotalReward *big.Int
+	PerAccount  []*RecipientAggregate
+}
+
+// RecipientAggregate rewards of a recipient aggregated across files
+type RecipientAggregate struct {
+	Account     string
+	Records     int
+	TotalReward *big.Int
+}
+
+// LoadRewardResults load send reward results from output file of any output format
+func LoadRewardResults(ifile string) (results []*RewardResult, title *ResultTitle, format string, err error) {
+	data, err := ioutil.ReadFile(ifile)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("read %v failed. %v", ifile, err)
+	}
+	trimed := bytes.TrimSpace(bytes.TrimPrefix(data, utf8BOM))
+	switch {
+	case bytes.HasPrefix(trimed, []byte("{")):
+		var doc struct {
+			Title   *ResultTitle
+			Results []*RewardResult
+		}
+		if err = json.Unmarshal(trimed, &doc); err == nil && (doc.Title != nil || doc.Results != nil) {
+			return doc.Results, doc.Title, OutputFormatJSON, nil
+		}
+		results, title, err = parseJSONLResults(trimed)
+		return results, title, OutputFormatJSONL, err
+	case bytes.HasPrefix(trimed, []byte("account,reward")):
+		results, title, err = parseCSVResults(trimed)
+		return results, title, OutputFormatCSV, err
+	default:
+		var titleLine string
+		results, titleLine, err = GetRewardResultsFromFile(ifile)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		return results, parseTitleLine(titleLine, false), OutputFormatLegacy, nil
+	}
+}
+
+func parseJSONLResults(data []byte) (results []*RewardResult, title *ResultTitle, err error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var result RewardResult
+		if err = json.Unmarshal(line, &result); err != nil {
+			return nil, nil, fmt.Errorf("wrong line %v, err=%v", string(line), err)
+		}
+		if result.Account != "" {
+			results = append(results, &result)
+			continue
+		}
+		title = &ResultTitle{}
+		if err = json.Unmarshal(line, title); err != nil {
+			return nil, nil, fmt.Errorf("wrong title line %v, err=%v", string(line), err)
+		}
+	}
+	return results, title, scanner.Err()
+}
+
+func parseCSVResults(data []byte) (results []*RewardResult, title *ResultTitle, err error) {
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	header := records[0]
+	title = &ResultTitle{}
+	txHashIndex := -1
+	for i, column := range header {
+		switch column {
+		case "txhash":
+			txHashIndex = i
+			title.HasTxHash = true
+		case "nonce", "gasPrice":
+			title.HasTxInfo = true
+		default:
+			title.Columns = append(title.Columns, column)
+		}
+	}
+	for _, record := range records[1:] {
+		if len(record) < 2 || !common.IsHexAddress(record[0]) {
+			return nil, nil, fmt.Errorf("wrong record %v", record)
+		}
+		reward, errf := tools.GetBigIntFromString(record[1])
+		if errf != nil {
+			return nil, nil, fmt.Errorf("wrong reward in record %v, err=%v", record, errf)
+		}
+		result := &RewardResult{
+			Account: strings.ToLower(record[0]),
+			Reward:  reward,
+		}
+		if txHashIndex >= 0 && txHashIndex < len(record) {
+			result.TxHash = record[txHashIndex]
+		}
+		results = append(results, result)
+	}
+	return results, title, nil
+}
+
+// AggregateRewardFiles aggregate output files by reward token and by recipient.
+// reward token is read from title of file, or use defaultToken if file has no token info
+func AggregateRewardFiles(files []string, defaultToken string) (*AggregateReport, error) {
+	report := &AggregateReport{}
+	tokenAggs := make(map[string]*TokenAggregate)
+	accountAggs := make(map[string]map[string]*RecipientAggregate)
+	for _, file := range files {
+		results, title, format, err := LoadRewardResults(file)
+		if err != nil {
+			return nil, err
+		}
+		rewardToken := ""
+		if title != nil {
+			rewardToken = getTitleExtraInfo(title.ExtraInfo, "rewardToken")
+		}
+		switch {
+		case rewardToken != "":
+			if !common.IsHexAddress(rewardToken) {
+				return nil, fmt.Errorf("wrong reward token '%v' in %v", rewardToken, file)
+			}
+			rewardToken = strings.ToLower(rewardToken)
+			if defaultToken != "" && !strings.EqualFold(rewardToken, defaultToken) {
+				log.Warn("[aggregate] reward token in file differs from specified, use the one in file", "file", file, "fileToken", rewardToken, "specified", defaultToken)
+			}
+		case defaultToken != "":
+			rewardToken = strings.ToLower(defaultToken)
+		default:
+			rewardToken = unknownRewardToken
+			log.Warn("[aggregate] no reward token info in file, please specify reward token", "file", file, "format", format)
+		}
+		report.Files = append(report.Files, &AggregateFileInfo{
+			File:        file,
+			Format:      format,
+			RewardToken: rewardToken,
+			Records:     len(results),
+		})
+
+		tokenAgg, exist := tokenAggs[rewardToken]
+		if !exist {
+			tokenAgg = &TokenAggregate{RewardToken: rewardToken, TotalReward: big.NewInt(0)}
+			tokenAggs[rewardToken] = tokenAgg
+			accountAggs[rewardToken] = make(map[string]*RecipientAggregate)
+		}
+		tokenAgg.Files++
+		for _, result := range results {
+			tokenAgg.Records++
+			if result.TxHash != "" {
+				tokenAgg.SentRecords++
+			}
+			tokenAgg.TotalReward.Add(tokenAgg.TotalReward, result.Reward)
+			account := strings.ToLower(result.Account)
+			accountAgg, exist := accountAggs[rewardToken][account]
+			if !exist {
+				accountAgg = &RecipientAggregate{Account: account, TotalReward: big.NewInt(0)}
+				accountAggs[rewardToken][account] = accountAgg
+			}
+			accountAgg.Records++
+			accountAgg.TotalReward.Add(accountAgg.TotalReward, result.Reward)
+		}
+		log.Info("[aggregate] load output file success", "file", file, "format", format, "rewardToken", rewardToken, "records", len(results))
+	}
+
+	for token, tokenAgg := range tokenAggs {
+		for _, accountAgg := range accountAggs[token] {
+			tokenAgg.PerAccount = append(tokenAgg.PerAccount, accountAgg)
+		}
+		sort.Slice(tokenAgg.PerAccount, func(i, j int) bool {
+			cmp := tokenAgg.PerAccount[i].TotalReward.Cmp(tokenAgg.PerAccount[j].TotalReward)
+			if cmp != 0 {
+				return cmp > 0
+			}
+			return tokenAgg.PerAccount[i].Account < tokenAgg.PerAccount[j].Account
+		})
+		tokenAgg.Recipients = len(tokenAgg.PerAccount)
+		report.Tokens = append(report.Tokens, tokenAgg)
+	}
+	sort.Slice(report.Tokens, func(i, j int) bool {
+		return report.Tokens[i].RewardToken < report.Tokens[j].RewardToken
+	})
+	return report, nil
+}
+
+// WriteAggregateReport write aggregate report in csv (default) or json format
+func WriteAggregateReport(w io.Writer, report *AggregateReport, format string) error {
+	switch format {
+	case "", OutputFormatCSV:
+		return writeAggregateReportCSV(w, report)
+	case OutputFormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	default:
+		return fmt.Errorf("unsupported aggregate report format '%v'", format)
+	}
+}
+
+// writeAggregateReportCSV write token totals, then recipient totals
+func writeAggregateReportCSV(w io.Writer, report *AggregateReport) error {
+	writer := csv.NewWriter(w)
+	records := [][]string{{"token", "files", "records", "sentRecords", "recipients", "totalReward"}}
+	for _, tokenAgg := range report.Tokens {
+		records = append(records, []string{
+			tokenAgg.RewardToken,
+			fmt.Sprintf("%d", tokenAgg.Files),
+			fmt.Sprintf("%d", tokenAgg.Records),
+			fmt.Sprintf("%d", tokenAgg.SentRecords),
+			fmt.Sprintf("%d", tokenAgg.Recipients),
+			tokenAgg.TotalReward.String(),
+		})
+	}
+	records = append(records, []string{}, []string{"token", "account", "records", "totalReward"})
+	for _, tokenAgg := range report.Tokens {
+		for _, accountAgg := range tokenAgg.PerAccount {
+			records = append(records, []string{
+				tokenAgg.RewardToken,
+				accountAgg.Account,
+				fmt.Sprintf("%d", accountAgg.Records),
+				accountAgg.TotalReward.String(),
+			})
+		}
+	}
+	if err := writer.WriteAll(records); err != nil {
+		return err
+	}
+	return writer.Error()
+}
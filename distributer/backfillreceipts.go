@@ -0,0 +1,157 @@
+package distributer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	ethereum "github.com/fsn-dev/fsn-go-sdk/efsn"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+// receipt status of backfilled output
+const (
+	ReceiptStatusSuccess  = "success"
+	ReceiptStatusFailed   = "failed"
+	ReceiptStatusNotFound = "notfound" // dropped or not mined yet
+)
+
+var receiptColumns = []string{"status", "gasUsed", "block"}
+
+// BackfillOption backfill receipts of sended rewards option
+type BackfillOption struct {
+	InputFile  string
+	OutputFile string // empty means rewrite input file
+}
+
+// BackfillResult result of backfilling receipts
+type BackfillResult struct {
+	Records  int
+	Success  int
+	Failed   int
+	NotFound int
+	NoTxHash int
+}
+
+// BackfillReceipts append status,gasUsed,block columns of transaction receipt
+// to every line of output file (legacy format) which has txhash but no receipt info.
+// other columns are kept as is. transaction without receipt is marked 'notfound',
+// line without txhash is kept as is. nothing is written if any receipt query fails.
+func BackfillReceipts(bopt *BackfillOption) (*BackfillResult, error) {
+	if bopt.InputFile == "" {
+		return nil, fmt.Errorf("must specify input file")
+	}
+	results, titleLine, err := GetRewardResultsFromFile(bopt.InputFile)
+	if err != nil {
+		return nil, err
+	}
+	if hasReceiptColumns(titleLine) {
+		return nil, fmt.Errorf("input file %v already has receipt columns", bopt.InputFile)
+	}
+	log.Info("[backfill] start", "input", bopt.InputFile, "records", len(results))
+
+	stat := &BackfillResult{Records: len(results)}
+	lines := make([]string, 0, len(results)+1)
+	if titleLine != "" {
+		lines = append(lines, addReceiptColumnsToTitle(titleLine))
+	}
+	for _, result := range results {
+		if result.TxHash == "" {
+			stat.NoTxHash++
+			lines = append(lines, result.line)
+			continue
+		}
+		var status, gasUsed, block string
+		receipt, errf := capi.GetTransactionReceipt(common.HexToHash(result.TxHash))
+		switch {
+		case errors.Is(errf, ethereum.NotFound):
+			status = ReceiptStatusNotFound
+			stat.NotFound++
+			log.Warn("[backfill] receipt not found", "account", result.Account, "txhash", result.TxHash)
+		case errf != nil:
+			return nil, fmt.Errorf("get receipt of %v failed. %w", result.TxHash, errf)
+		default:
+			if receipt.IsSuccess() {
+				status = ReceiptStatusSuccess
+				stat.Success++
+			} else {
+				status = ReceiptStatusFailed
+				stat.Failed++
+				log.Warn("[backfill] transaction failed", "account", result.Account, "txhash", result.TxHash)
+			}
+			if receipt.GasUsed != nil {
+				gasUsed = fmt.Sprintf("%d", uint64(*receipt.GasUsed))
+			}
+			if receipt.BlockNumber != nil {
+				block = receipt.BlockNumber.ToInt().String()
+			}
+		}
+		lines = append(lines, strings.Join([]string{result.line, status, gasUsed, block}, ","))
+	}
+
+	outputFile := bopt.OutputFile
+	if outputFile == "" {
+		outputFile = bopt.InputFile
+	}
+	if err = writeLinesAtomic(outputFile, lines); err != nil {
+		return nil, err
+	}
+	log.Info("[backfill] finished", "output", outputFile, "records", stat.Records, "success", stat.Success,
+		"failed", stat.Failed, "notfound", stat.NotFound, "noTxHash", stat.NoTxHash)
+	return stat, nil
+}
+
+func hasReceiptColumns(titleLine string) bool {
+	for _, part := range strings.Split(strings.TrimPrefix(titleLine, "#"), ",") {
+		if part == receiptColumns[0] {
+			return true
+		}
+	}
+	return false
+}
+
+// addReceiptColumnsToTitle add receipt columns before extra info of title line
+func addReceiptColumnsToTitle(titleLine string) string {
+	parts := strings.Split(titleLine, ",")
+	var extraInfo []string
+	if last := parts[len(parts)-1]; len(parts) > 1 && strings.Contains(last, "=") {
+		parts, extraInfo = parts[:len(parts)-1], []string{last}
+	}
+	parts = append(parts, receiptColumns...)
+	return strings.Join(append(parts, extraInfo...), ",")
+}
+
+// writeLinesAtomic write to temp file and rename it, so the file is not left half written
+func writeLinesAtomic(fileName string, lines []string) error {
+	file, err := ioutil.TempFile(filepath.Dir(fileName), filepath.Base(fileName)+".tmp-")
+	if err != nil {
+		return err
+	}
+	tempFile := file.Name()
+	defer os.Remove(tempFile)
+
+	writer := bufio.NewWriter(file)
+	for _, line := range lines {
+		if _, err = writer.WriteString(line + "\n"); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = writer.Flush()
+	}
+	if errc := file.Close(); err == nil {
+		err = errc
+	}
+	if err != nil {
+		return err
+	}
+	if err = os.Chmod(tempFile, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tempFile, fileName)
+}
@@ -0,0 +1,193 @@
+package distributer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+const (
+	prefetchWorkers = 8
+
+	// in non archive mode, cache is stale if it lags latest block more than this
+	balanceCacheMaxLag = 100
+)
+
+// BalanceCache liquidity balances snapshot of recipients at a height
+type BalanceCache struct {
+	Height    uint64
+	Timestamp int64
+	Exchanges map[string]*ExchangeBalances // key is lower case exchange address
+}
+
+// ExchangeBalances exchange's liquidity balances snapshot
+type ExchangeBalances struct {
+	TotalSupply *big.Int
+	CoinBalance *big.Int
+	Liquidity   map[string]*big.Int // key is lower case account address
+}
+
+// PrefetchBalances snapshot liquidity balances of accounts at sample height
+// (latest block if sample height is zero) and write to cache file
+func PrefetchBalances(opt *Option, cacheFile string) error {
+	accountsSlice, err := opt.getAccounts()
+	if err != nil {
+		return err
+	}
+	height := opt.SampleHeight
+	if height == 0 {
+		height = capi.LoopGetLatestBlockHeader().Number.Uint64()
+	}
+	blockNumber := new(big.Int).SetUint64(height)
+	cache := &BalanceCache{
+		Height:    height,
+		Timestamp: time.Now().Unix(),
+		Exchanges: make(map[string]*ExchangeBalances, len(opt.Exchanges)),
+	}
+	for i, exchange := range opt.Exchanges {
+		exchangeAddr := common.HexToAddress(exchange)
+		accounts := accountsSlice[i]
+		exBalances := &ExchangeBalances{
+			TotalSupply: capi.LoopGetExchangeLiquidity(exchangeAddr, blockNumber),
+			CoinBalance: capi.LoopGetCoinBalance(exchangeAddr, blockNumber),
+			Liquidity:   make(map[string]*big.Int, len(accounts)),
+		}
+		values := prefetchLiquidityBalances(exchangeAddr, accounts, blockNumber)
+		for j, account := range accounts {
+			exBalances.Liquidity[strings.ToLower(account.String())] = values[j]
+		}
+		cache.Exchanges[strings.ToLower(exchange)] = exBalances
+		log.Info("[prefetch] get liquidity balances success", "exchange", exchange, "accounts", len(accounts), "height", height)
+	}
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(cacheFile, data, 0644)
+	if err != nil {
+		return err
+	}
+	log.Info("[prefetch] write balance cache success", "file", cacheFile, "height", height, "exchanges", len(cache.Exchanges))
+	return nil
+}
+
+// prefetchLiquidityBalances read liquidity balances in chunks of rpc batch size concurrently
+func prefetchLiquidityBalances(exchange common.Address, accounts []common.Address, blockNumber *big.Int) []*big.Int {
+	values := make([]*big.Int, len(accounts))
+	chunkSize := getReadChunkSize()
+	startCh := make(chan int)
+	wg := new(sync.WaitGroup)
+	for w := 0; w < prefetchWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for start := range startCh {
+				end := start + chunkSize
+				if end > len(accounts) {
+					end = len(accounts)
+				}
+				readLiquidityBalances(exchange, accounts[start:end], blockNumber, values[start:end])
+			}
+		}()
+	}
+	for start := 0; start < len(accounts); start += chunkSize {
+		startCh <- start
+	}
+	close(startCh)
+	wg.Wait()
+	return values
+}
+
+// batchReadLiquidityBalances read liquidity balances in chunks of rpc batch size sequentially
+func batchReadLiquidityBalances(exchange common.Address, accounts []common.Address, blockNumber *big.Int) []*big.Int {
+	values := make([]*big.Int, len(accounts))
+	chunkSize := getReadChunkSize()
+	for start := 0; start < len(accounts); start += chunkSize {
+		end := start + chunkSize
+		if end > len(accounts) {
+			end = len(accounts)
+		}
+		readLiquidityBalances(exchange, accounts[start:end], blockNumber, values[start:end])
+	}
+	return values
+}
+
+func getReadChunkSize() int {
+	if size := capi.GetRPCBatchSize(); size > 1 {
+		return size
+	}
+	return 1
+}
+
+// readLiquidityBalances read by batch call if possible,
+// failed reads are retried one by one until success
+func readLiquidityBalances(exchange common.Address, accounts []common.Address, blockNumber *big.Int, values []*big.Int) {
+	if len(accounts) > 1 {
+		batchValues, err := capi.BatchGetTokenBalances(exchange, accounts, blockNumber)
+		if err == nil {
+			copy(values, batchValues)
+		}
+	}
+	for i, account := range accounts {
+		if values[i] == nil {
+			values[i] = capi.LoopGetLiquidityBalance(exchange, account, blockNumber)
+		}
+	}
+}
+
+// LoadBalanceCache load balance cache from file
+func LoadBalanceCache(cacheFile string) (*BalanceCache, error) {
+	data, err := ioutil.ReadFile(cacheFile)
+	if err != nil {
+		return nil, err
+	}
+	var cache BalanceCache
+	err = json.Unmarshal(data, &cache)
+	if err != nil {
+		return nil, err
+	}
+	return &cache, nil
+}
+
+// loadBalanceCache load balance cache and check it's not stale to the snapshot block
+func (opt *Option) loadBalanceCache() error {
+	if opt.BalanceCacheFile == "" {
+		return nil
+	}
+	cache, err := LoadBalanceCache(opt.BalanceCacheFile)
+	if err != nil {
+		return err
+	}
+	if opt.ArchiveMode {
+		if cache.Height != opt.SampleHeight {
+			return fmt.Errorf("[balance cache] cache height %v is not sample height %v", cache.Height, opt.SampleHeight)
+		}
+	} else {
+		latest := capi.LoopGetLatestBlockHeader().Number.Uint64()
+		if cache.Height > latest || latest-cache.Height > balanceCacheMaxLag {
+			return fmt.Errorf("[balance cache] cache height %v is stale, latest height is %v", cache.Height, latest)
+		}
+	}
+	for _, exchange := range opt.Exchanges {
+		if _, exist := cache.Exchanges[strings.ToLower(exchange)]; !exist {
+			return fmt.Errorf("[balance cache] exchange %v is not cached", exchange)
+		}
+	}
+	opt.balanceCache = cache
+	log.Info("[balance cache] load balance cache success", "file", opt.BalanceCacheFile, "height", cache.Height)
+	return nil
+}
+
+func (opt *Option) getCachedExchangeBalances(exchange string) *ExchangeBalances {
+	if opt.balanceCache == nil {
+		return nil
+	}
+	return opt.balanceCache.Exchanges[strings.ToLower(exchange)]
+}
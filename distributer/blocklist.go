@@ -0,0 +1,101 @@
+package distributer
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/anyswap/ANYToken-distribution/mongodb"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+var blocklistHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// loadBlocklist load blocked addresses from file or http(s) URL once.
+// format: addresses separated by blank, comma or newline, '#' starts a comment line
+func (opt *Option) loadBlocklist() error {
+	if opt.BlocklistFile == "" || opt.blocklist != nil {
+		return nil
+	}
+	var reader io.ReadCloser
+	if strings.HasPrefix(opt.BlocklistFile, "http://") || strings.HasPrefix(opt.BlocklistFile, "https://") {
+		resp, err := blocklistHTTPClient.Get(opt.BlocklistFile)
+		if err != nil {
+			return fmt.Errorf("download blocklist failed. %v", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return fmt.Errorf("download blocklist failed, http status %v", resp.Status)
+		}
+		reader = resp.Body
+	} else {
+		file, err := os.Open(opt.BlocklistFile)
+		if err != nil {
+			return fmt.Errorf("open blocklist failed. %v", err)
+		}
+		reader = file
+	}
+	defer reader.Close()
+
+	blocklist := make(map[common.Address]struct{})
+	input := newInputReader(reader)
+	for {
+		lineData, _, errf := input.ReadLine()
+		if errf == io.EOF {
+			break
+		}
+		if errf != nil {
+			return fmt.Errorf("read blocklist failed. %v", errf)
+		}
+		line := strings.TrimSpace(string(lineData))
+		if line == "" || isCommentedLine(line) {
+			continue
+		}
+		for _, addr := range blankOrCommaSepRegexp.Split(line, -1) {
+			if addr == "" {
+				continue
+			}
+			if !common.IsHexAddress(addr) {
+				return fmt.Errorf("wrong address '%v' in blocklist", addr)
+			}
+			blocklist[common.HexToAddress(addr)] = struct{}{}
+		}
+	}
+	opt.blocklist = blocklist
+	log.Info("[blocklist] load blocklist success", "source", opt.BlocklistFile, "addresses", len(blocklist))
+	return nil
+}
+
+// checkBlocklist abort if any recipient is in blocklist,
+// or skip them if SkipBlocklisted is true
+func (opt *Option) checkBlocklist(accountStats mongodb.AccountStatSlice) (mongodb.AccountStatSlice, error) {
+	if err := opt.loadBlocklist(); err != nil {
+		return nil, err
+	}
+	if len(opt.blocklist) == 0 {
+		return accountStats, nil
+	}
+	result := make(mongodb.AccountStatSlice, 0, len(accountStats))
+	flagged := 0
+	for _, stat := range accountStats {
+		if _, blocked := opt.blocklist[stat.Account]; !blocked {
+			result = append(result, stat)
+			continue
+		}
+		flagged++
+		opt.recordSkipped(stat, SkipReasonBlocklisted)
+		log.Error("[check blocklist] found blocklisted recipient", "account", stat.Account.String(), "reward", stat.Reward, "skip", opt.SkipBlocklisted)
+	}
+	if flagged == 0 {
+		return result, nil
+	}
+	if !opt.SkipBlocklisted {
+		return nil, fmt.Errorf("found %v blocklisted recipients", flagged)
+	}
+	log.Error("[check blocklist] SKIPPED BLOCKLISTED RECIPIENTS", "count", flagged, "blocklist", opt.BlocklistFile)
+	return result, nil
+}
@@ -0,0 +1,38 @@
+package distributer
+
+import (
+	"testing"
+
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+func TestLoadBlocklistLineEndings(t *testing.T) {
+	const (
+		account1 = "0x1111111111111111111111111111111111111111"
+		account2 = "0x2222222222222222222222222222222222222222"
+		account3 = "0x3333333333333333333333333333333333333333"
+	)
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "lf", content: "# blocked\n" + account1 + "\n" + account2 + "," + account3 + "\n"},
+		{name: "crlf", content: "# blocked\r\n" + account1 + "\r\n" + account2 + "," + account3 + "\r\n"},
+		{name: "bom no trailing newline", content: "\xef\xbb\xbf" + account1 + "\n" + account2 + " " + account3},
+	}
+	for _, test := range tests {
+		opt := &Option{BlocklistFile: writeTempInput(t, "blocklist.txt", test.content)}
+		if err := opt.loadBlocklist(); err != nil {
+			t.Errorf("%v: unexpected error %v", test.name, err)
+			continue
+		}
+		if len(opt.blocklist) != 3 {
+			t.Errorf("%v: got %v addresses, want 3", test.name, len(opt.blocklist))
+		}
+		for _, account := range []string{account1, account2, account3} {
+			if _, exist := opt.blocklist[common.HexToAddress(account)]; !exist {
+				t.Errorf("%v: %v is not loaded", test.name, account)
+			}
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package distributer
+
+import (
+	"fmt"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/anyswap/ANYToken-distribution/mongodb"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+// well known burn addresses, zero address is always checked
+var defaultBurnAddresses = []common.Address{
+	common.HexToAddress("0x000000000000000000000000000000000000dEaD"),
+}
+
+func (opt *Option) isBurnAddress(account common.Address) bool {
+	if account == (common.Address{}) {
+		return true
+	}
+	for _, addr := range defaultBurnAddresses {
+		if account == addr {
+			return true
+		}
+	}
+	for _, addr := range opt.BurnAddresses {
+		if account == common.HexToAddress(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkBurnAddresses abort if zero address or burn addresses are recipients,
+// or skip them if SkipBurnAddresses is true
+func (opt *Option) checkBurnAddresses(accountStats mongodb.AccountStatSlice) (mongodb.AccountStatSlice, error) {
+	for _, addr := range opt.BurnAddresses {
+		if !common.IsHexAddress(addr) {
+			return nil, fmt.Errorf("wrong burn address '%v'", addr)
+		}
+	}
+	result := make(mongodb.AccountStatSlice, 0, len(accountStats))
+	flagged := 0
+	for _, stat := range accountStats {
+		if !opt.isBurnAddress(stat.Account) {
+			result = append(result, stat)
+			continue
+		}
+		flagged++
+		opt.recordSkipped(stat, SkipReasonBurnAddress)
+		log.Warn("[check burn address] found burn address recipient", "account", stat.Account.String(), "reward", stat.Reward, "skip", opt.SkipBurnAddresses)
+	}
+	if flagged > 0 && !opt.SkipBurnAddresses {
+		return nil, fmt.Errorf("found %v zero or burn address recipients", flagged)
+	}
+	return result, nil
+}
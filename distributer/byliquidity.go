@@ -27,6 +27,11 @@ func ByLiquidity(opt *Option) error {
 		log.Error("[byliquid] check option error", "option", opt.String(), "err", err)
 		return errCheckOptionFailed
 	}
+	err = opt.loadBalanceCache()
+	if err != nil {
+		log.Error("[byliquid] load balance cache error", "err", err)
+		return errCheckOptionFailed
+	}
 	accountStats, err := opt.GetAccountsAndShares()
 	if err != nil {
 		log.Error("[byliquid] GetAccountsAndShares error", "err", err)
@@ -44,6 +49,15 @@ func ByLiquidity(opt *Option) error {
 		log.Warn("[byliquid] account list is not complete. " + opt.String())
 		return errAccountsNotComplete
 	}
+	opt.fillSnapshotProvenance(accountStats)
+	if opt.CombineExchanges {
+		accountStats, err = opt.calcCombinedRewards(accountStats)
+		if err != nil {
+			log.Error("[byliquid] combine exchanges error", "err", err)
+			return err
+		}
+		return opt.dispatchRewards(accountStats)
+	}
 	mongodb.CalcWeightedRewards(accountStats, opt.TotalValue, opt.Weights)
 	return opt.dispatchRewards(accountStats)
 }
@@ -53,7 +67,15 @@ func (opt *Option) getLiquidityBalances(accountsSlice [][]common.Address) (accou
 	for i, exchange := range opt.Exchanges {
 		accounts := accountsSlice[i]
 		WriteLiquiditySubject(exchange, opt.StartHeight, opt.EndHeight, len(accounts))
-		stats, _ := opt.getLiquidityBalancesOfExchange(exchange, accounts)
+		var stats mongodb.AccountStatSlice
+		switch {
+		case len(opt.SnapshotHeights) > 0:
+			stats = opt.getMultiSnapshotLiquidityBalancesOfExchange(exchange, accounts)
+		case opt.SampleInterval > 0:
+			stats = opt.getTimeWeightedLiquidityBalancesOfExchange(exchange, accounts)
+		default:
+			stats, _ = opt.getLiquidityBalancesOfExchange(exchange, accounts)
+		}
 		totalLiquids := stats.CalcTotalShare()
 		WriteLiquiditySummary(exchange, opt.StartHeight, opt.EndHeight, len(stats), totalLiquids, opt.TotalValue)
 		for _, stat := range stats {
@@ -75,21 +97,42 @@ func (opt *Option) getLiquidityBalancesOfExchange(exchange string, accounts []co
 		latestBlock := capi.LoopGetLatestBlockHeader()
 		height = latestBlock.Number.Uint64()
 		blockNumber = nil // use latest block in non archive mode
+		if opt.SnapshotProvenance {
+			// pin reads to the recorded height, so the provenance is exact
+			blockNumber = latestBlock.Number
+		}
 		log.Warn("get liquidity balance in non archive mode", "latest", height)
 	} else {
 		blockNumber = new(big.Int).SetUint64(height)
 	}
-	totalSupply := capi.LoopGetExchangeLiquidity(exchangeAddr, blockNumber)
-	exCoinBalance := capi.LoopGetCoinBalance(exchangeAddr, blockNumber)
+	var totalSupply, exCoinBalance *big.Int
+	cached := opt.getCachedExchangeBalances(exchange)
+	if cached != nil {
+		height = opt.balanceCache.Height
+		totalSupply = cached.TotalSupply
+		exCoinBalance = cached.CoinBalance
+		log.Info("get liquidity balance from balance cache", "exchange", exchange, "height", height)
+	} else {
+		totalSupply = capi.LoopGetExchangeLiquidity(exchangeAddr, blockNumber)
+		exCoinBalance = capi.LoopGetCoinBalance(exchangeAddr, blockNumber)
+	}
 	log.Info("get exchange liquidity and coin balance", "totalSupply", totalSupply, "exCoinBalance", exCoinBalance, "blockNumber", blockNumber)
 	totalLiquid := big.NewInt(0)
 	totalCoinBalance := big.NewInt(0)
 	for _, account := range accounts {
 		var value *big.Int
 		accoutStr := strings.ToLower(account.String())
-		liquidStr, err := mongodb.FindLiquidityBalance(exchange, accoutStr, height)
-		if err == nil {
-			value, _ = tools.GetBigIntFromString(liquidStr)
+		if cached != nil {
+			value = cached.Liquidity[accoutStr]
+			if value == nil {
+				log.Warn("account is not in balance cache, read it from node", "exchange", exchange, "account", accoutStr)
+			}
+		}
+		if value == nil {
+			liquidStr, err := mongodb.FindLiquidityBalance(exchange, accoutStr, height)
+			if err == nil {
+				value, _ = tools.GetBigIntFromString(liquidStr)
+			}
 		}
 		for value == nil {
 			value = capi.LoopGetLiquidityBalance(exchangeAddr, account, blockNumber)
@@ -0,0 +1,22 @@
+package distributer
+
+import (
+	"fmt"
+
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+// CheckKey decrypt keystore (or derive key from mnemonic) of args without touching the chain,
+// return the derived address, error if it mismatches the specified sender
+func CheckKey(args *BuildTxArgs) (common.Address, error) {
+	if args.Sender != "" && !common.IsHexAddress(args.Sender) {
+		return common.Address{}, fmt.Errorf("wrong sender address '%v'", args.Sender)
+	}
+	if args.KeystoreFile != "" && args.MnemonicEnv != "" {
+		return common.Address{}, fmt.Errorf("can not specify both keystore and mnemonic")
+	}
+	if err := args.loadKey(); err != nil {
+		return args.fromAddr, err
+	}
+	return args.fromAddr, nil
+}
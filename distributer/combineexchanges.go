@@ -0,0 +1,75 @@
+package distributer
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/anyswap/ANYToken-distribution/mongodb"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+// CombineWeightedShares combine shares of accounts across exchanges into one slice,
+// share of every exchange is multiplied by its weight (weight is 1 if weights is nil).
+// number (and block hash) of combined stat is the minimum number of the account across exchanges.
+func CombineWeightedShares(accountStats []mongodb.AccountStatSlice, weights []uint64) (mongodb.AccountStatSlice, error) {
+	if weights != nil && len(weights) != len(accountStats) {
+		return nil, fmt.Errorf("count of exchanges %v != count of weights %v", len(accountStats), len(weights))
+	}
+	combinedMap := make(map[common.Address]*mongodb.AccountStat)
+	weight := uint64(1)
+	for i, stats := range accountStats {
+		if weights != nil {
+			weight = weights[i]
+		}
+		biWeight := new(big.Int).SetUint64(weight)
+		for _, stat := range stats {
+			weightShare := new(big.Int).Mul(stat.Share, biWeight)
+			combined, exist := combinedMap[stat.Account]
+			if !exist {
+				combinedMap[stat.Account] = &mongodb.AccountStat{
+					Account:   stat.Account,
+					Share:     weightShare,
+					Number:    stat.Number,
+					BlockHash: stat.BlockHash,
+				}
+				continue
+			}
+			combined.Share.Add(combined.Share, weightShare)
+			if stat.Number < combined.Number {
+				combined.Number = stat.Number
+				combined.BlockHash = stat.BlockHash
+			}
+		}
+	}
+	return mongodb.ConvertToSortedSlice(combinedMap), nil
+}
+
+// checkCombineExchanges combined pool is weighted by multipliers of liquidity
+func (opt *Option) checkCombineExchanges() error {
+	if !opt.CombineExchanges {
+		return nil
+	}
+	if opt.byWhat != byLiquidMethodID {
+		return fmt.Errorf("[check option] combine exchanges is only supported by liquidity")
+	}
+	if opt.WeightIsPercentage {
+		return fmt.Errorf("[check option] combine exchanges conflicts with percentage weight")
+	}
+	if len(opt.OutputFiles) > 1 {
+		return fmt.Errorf("[check option] combine exchanges writes only one output file, but %v are specified", len(opt.OutputFiles))
+	}
+	return nil
+}
+
+// calcCombinedRewards distribute total rewards proportionally by combined weighted shares of all exchanges.
+// the result has only one slice, which is dispatched as rewards of the first exchange.
+func (opt *Option) calcCombinedRewards(accountStats []mongodb.AccountStatSlice) ([]mongodb.AccountStatSlice, error) {
+	combined, err := CombineWeightedShares(accountStats, opt.Weights)
+	if err != nil {
+		return nil, err
+	}
+	combined.CalcRewards(opt.TotalValue)
+	log.Info("[byliquid] combine exchanges", "exchanges", len(opt.Exchanges), "weights", opt.Weights, "accounts", len(combined), "totalShare", combined.CalcTotalShare(), "totalReward", combined.CalcTotalReward())
+	return []mongodb.AccountStatSlice{combined}, nil
+}
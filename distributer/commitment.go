@@ -0,0 +1,60 @@
+package distributer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/anyswap/ANYToken-distribution/mongodb"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/crypto"
+)
+
+var errCommitmentMismatch = errors.New("input file does not match the approved commitment")
+
+// CalcRewardsCommitment canonical commitment of (account, amount) pairs of input file.
+// every pair is encoded as 52 bytes: account (20 bytes) followed by amount
+// (uint256, 32 bytes big endian), which is abi.encodePacked(address, uint256).
+// pairs are sorted by account bytes then by amount ascending, duplicate accounts
+// are kept as separate pairs, and the commitment is keccak256 of the concatenation.
+// amount is the reward column as it's in file (weight if input weights is used).
+// lines of zero reward are not paid and not committed.
+func CalcRewardsCommitment(accountStats mongodb.AccountStatSlice) common.Hash {
+	accountStats = nonZeroRewards(accountStats)
+	pairs := make([][]byte, len(accountStats))
+	for i, stat := range accountStats {
+		pair := make([]byte, 0, common.AddressLength+32)
+		pair = append(pair, stat.Account.Bytes()...)
+		pair = append(pair, common.LeftPadBytes(stat.Reward.Bytes(), 32)...)
+		pairs[i] = pair
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return bytes.Compare(pairs[i], pairs[j]) < 0
+	})
+	return crypto.Keccak256Hash(pairs...)
+}
+
+// GetRewardsCommitmentOfFile get canonical commitment of input file
+func GetRewardsCommitmentOfFile(ifile string) (common.Hash, error) {
+	accountStats, _, err := GetAccountsAndRewardsFromFile(ifile)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return CalcRewardsCommitment(accountStats), nil
+}
+
+func (opt *Option) checkCommitment(i int, accountStats mongodb.AccountStatSlice) error {
+	if len(opt.ExpectedCommitments) == 0 {
+		return nil
+	}
+	expected := opt.ExpectedCommitments[i]
+	commitment := CalcRewardsCommitment(accountStats)
+	if commitment != expected {
+		log.Error("[commitment] input file is altered after approval", "inputfile", opt.InputFiles[i], "commitment", commitment.String(), "expected", expected.String())
+		return fmt.Errorf("%w, input file %v has commitment %v, expected %v", errCommitmentMismatch, opt.InputFiles[i], commitment.String(), expected.String())
+	}
+	log.Info("[commitment] input file matches approved commitment", "inputfile", opt.InputFiles[i], "commitment", commitment.String())
+	return nil
+}
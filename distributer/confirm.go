@@ -0,0 +1,118 @@
+package distributer
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anyswap/ANYToken-distribution/callapi"
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/anyswap/ANYToken-distribution/tools"
+	ethereum "github.com/fsn-dev/fsn-go-sdk/efsn"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+var errWaitReceiptTimeout = errors.New("wait transaction receipt timeout")
+
+const waitReceiptInterval = 3 * time.Second
+
+// waitTxReceipt wait transaction receipt until it's mined or timeout
+func waitTxReceipt(txHash common.Hash, timeout time.Duration) (*callapi.RPCReceipt, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		receipt, err := capi.GetTransactionReceipt(txHash)
+		if err == nil {
+			return receipt, nil
+		}
+		if err != ethereum.NotFound {
+			log.Warn("[confirm] get transaction receipt failed", "txHash", txHash.String(), "err", err)
+		}
+		if time.Now().After(deadline) {
+			return nil, errWaitReceiptTimeout
+		}
+		time.Sleep(waitReceiptInterval)
+	}
+}
+
+// ConfirmTier transfers of amount not less than Threshold require Confirmations
+type ConfirmTier struct {
+	Threshold     *big.Int
+	Confirmations uint64
+}
+
+// ConfirmPolicy required confirmations of transfers.
+// Confirmations is the flat requirement, tiers can raise it for larger amounts.
+type ConfirmPolicy struct {
+	Confirmations uint64
+	Tiers         []*ConfirmTier // sorted by threshold
+}
+
+// ParseConfirmTiers parse tiers of format "<amount>:<confirmations>"
+func ParseConfirmTiers(tiers []string) ([]*ConfirmTier, error) {
+	result := make([]*ConfirmTier, 0, len(tiers))
+	for _, tier := range tiers {
+		parts := strings.Split(tier, ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("wrong confirm tier '%v', format is <amount>:<confirmations>", tier)
+		}
+		threshold, err := tools.GetBigIntFromString(parts[0])
+		if err != nil || threshold.Sign() < 0 {
+			return nil, fmt.Errorf("wrong amount in confirm tier '%v'", tier)
+		}
+		confirmations, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("wrong confirmations in confirm tier '%v'", tier)
+		}
+		result = append(result, &ConfirmTier{Threshold: threshold, Confirmations: confirmations})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Threshold.Cmp(result[j].Threshold) < 0
+	})
+	return result, nil
+}
+
+// RequiredConfirmations required confirmations of transfer amount,
+// which is the flat requirement if no tier matches
+func (p *ConfirmPolicy) RequiredConfirmations(amount *big.Int) uint64 {
+	required := p.Confirmations
+	for _, tier := range p.Tiers {
+		if amount.Cmp(tier.Threshold) < 0 {
+			break
+		}
+		if tier.Confirmations > required {
+			required = tier.Confirmations
+		}
+	}
+	return required
+}
+
+// waitTxConfirmations wait transaction mined and confirmed by enough blocks, or timeout.
+// the block including the transaction is the first confirmation.
+func waitTxConfirmations(txHash common.Hash, confirmations uint64, timeout time.Duration) (*callapi.RPCReceipt, error) {
+	deadline := time.Now().Add(timeout)
+	receipt, err := waitTxReceipt(txHash, timeout)
+	if err != nil || confirmations <= 1 {
+		return receipt, err
+	}
+	txBlock := receipt.BlockNumber.ToInt().Uint64()
+	for {
+		latest := capi.LoopGetLatestBlockHeader().Number.Uint64()
+		if latest+1 >= txBlock+confirmations {
+			// reorg may drop the transaction while waiting
+			receipt, err = capi.GetTransactionReceipt(txHash)
+			if err != nil {
+				return nil, fmt.Errorf("get receipt after confirmed failed, %v", err)
+			}
+			return receipt, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, errWaitReceiptTimeout
+		}
+		log.Info("[confirm] wait transaction confirmations", "txHash", txHash.String(), "block", txBlock, "latest", latest, "confirmations", confirmations)
+		time.Sleep(waitReceiptInterval)
+	}
+}
@@ -2,7 +2,6 @@ package distributer
 
 import (
 	"fmt"
-	"io"
 	"math/big"
 	"strings"
 	"time"
@@ -10,11 +9,34 @@ import (
 	"github.com/anyswap/ANYToken-distribution/log"
 	"github.com/anyswap/ANYToken-distribution/mongodb"
 	"github.com/anyswap/ANYToken-distribution/params"
+	"github.com/anyswap/ANYToken-distribution/tracing"
 )
 
-func (opt *Option) dispatchRewards(accountStats []mongodb.AccountStatSlice) error {
+func (opt *Option) dispatchRewards(accountStats []mongodb.AccountStatSlice) (err error) {
+	if _, err = opt.acquireSenderLock(); err != nil {
+		return err
+	}
+	defer opt.releaseSenderLock()
+
+	opt.initReplayLog()
+	opt.initRunSummary()
+	defer func() {
+		opt.saveReplayLog(err)
+		opt.saveRunSummary(err)
+	}()
+
+	span := tracing.StartSpan("distribute "+opt.byWhat, "exchanges", len(opt.Exchanges), "dryrun", opt.DryRun)
+	defer func() { span.End(err) }()
+
 	for i, exchange := range opt.Exchanges {
-		rewardsSended, err := opt.sendRewards(i, exchange, accountStats[i])
+		if i >= len(accountStats) {
+			break // combined rewards of all exchanges (see CombineExchanges)
+		}
+		var rewardsSended *big.Int
+		exchangeSpan := tracing.StartSpan("send exchange", "exchange", exchange, "recipients", len(accountStats[i]))
+		rewardsSended, err = opt.sendRewards(i, exchange, accountStats[i])
+		exchangeSpan.SetAttributes("rewardsSended", rewardsSended)
+		exchangeSpan.End(err)
 		if err != nil {
 			return err
 		}
@@ -31,6 +53,7 @@ func (opt *Option) dispatchRewards(accountStats []mongodb.AccountStatSlice) erro
 				RewardToken:  opt.RewardToken,
 				Rewards:      rewardsSended.String(),
 				SampleHeight: opt.SampleHeight,
+				RunID:        opt.RunID(),
 				Timestamp:    uint64(time.Now().Unix()),
 			}
 			_ = mongodb.TryDoTimes("AddDistributeInfo "+mdist.Pairs, func() error {
@@ -38,10 +61,13 @@ func (opt *Option) dispatchRewards(accountStats []mongodb.AccountStatSlice) erro
 			})
 		}
 	}
-	return nil
+	if err = opt.BuildTxArgs.WriteMultisigProposals(); err != nil {
+		return err
+	}
+	return opt.sweepToTreasury()
 }
 
-func (opt *Option) writeSendRewardTitleLine(outputFile io.Writer, exchange string) (keyShare, keyNumber string, err error) {
+func (opt *Option) writeSendRewardTitleLine(outputFile ResultWriter, exchange string) (keyShare, keyNumber string, err error) {
 	var extraInfo string
 	switch opt.byWhat {
 	case byLiquidMethodID:
@@ -61,12 +87,23 @@ func (opt *Option) writeSendRewardTitleLine(outputFile io.Writer, exchange strin
 		"&&start=%v&&end=%v&&totalReward=%v&&exchange=%v&&rewardToken=%v",
 		opt.StartHeight, opt.EndHeight, opt.TotalValue,
 		strings.ToLower(exchange), strings.ToLower(opt.RewardToken))
-	// write title
-	if opt.DryRun {
-		err = WriteOutput(outputFile, "#account", "reward", keyShare, keyNumber, extraInfo)
-	} else {
-		err = WriteOutput(outputFile, "#account", "reward", keyShare, keyNumber, "txhash", extraInfo)
+	if opt.CombineExchanges {
+		weights := make([]string, len(opt.Weights))
+		for i, weight := range opt.Weights {
+			weights[i] = fmt.Sprint(weight)
+		}
+		extraInfo += fmt.Sprintf("&&combinedExchanges=%v&&weights=%v",
+			strings.ToLower(strings.Join(opt.Exchanges, "+")), strings.Join(weights, "+"))
 	}
+	// write title
+	err = outputFile.WriteTitle(&ResultTitle{
+		Columns:   []string{"account", "reward", keyShare, keyNumber},
+		ExtraInfo: extraInfo,
+		HasTxHash: !opt.DryRun,
+		HasTxInfo: opt.VerboseOutput && !opt.DryRun,
+
+		HasBlockHash: opt.SnapshotProvenance,
+	})
 	return
 }
 
@@ -75,6 +112,7 @@ func (opt *Option) sendRewards(idx int, exchange string, accountStats mongodb.Ac
 	if err != nil {
 		return nil, err
 	}
+	defer func() { _ = outputFile.Flush() }()
 
 	keyShare, keyNumber, err := opt.writeSendRewardTitleLine(outputFile, exchange)
 	if err != nil {
@@ -90,13 +128,37 @@ func (opt *Option) sendRewards(idx int, exchange string, accountStats mongodb.Ac
 			log.Warn("empty reward stat exist", "stat", stat.String())
 			continue
 		}
+		if err = capi.CheckErrorBudget(); err != nil {
+			log.Error("[sendRewards] abort as rpc failures budget exhausted", "err", err)
+			return rewardsSended, err
+		}
+		unpaid, err := opt.checkPaid(stat)
+		if err != nil {
+			return rewardsSended, err
+		}
+		if unpaid == nil {
+			continue
+		}
+		stat = unpaid
+		if err = opt.revalidateToken(stat); err != nil {
+			return rewardsSended, err
+		}
 		log.Info("sendRewards begin", "account", stat.Account.String(), "reward", stat.Reward, keyShare, stat.Share, keyNumber, stat.Number, "dryrun", opt.DryRun)
-		txHash, err := opt.SendRewardsTransaction(stat.Account, stat.Reward)
+		rewardSpan := startRewardSpan(stat)
+		txHash, err := opt.SendRewardsTransactionWithGas(stat.Account, stat.Reward, stat.GasLimit, stat.GasPrice)
+		endRewardSpan(rewardSpan, txHash, err, nil)
+		opt.addReplayRecord(exchange, stat, txHash, err)
+		opt.addRunSummaryRecord(stat.Reward, err)
+		opt.markPaid(stat, txHash)
 		switch err {
 		case nil:
+			opt.countRevalidateSend()
 		case errDustReward:
 			totalDustReward.Add(totalDustReward, stat.Reward)
 			totalDustRewardCount++
+		case errGasSpendExceeded:
+			log.Error("[sendRewards] abort as max gas spend exceeded", "gasSpent", opt.BuildTxArgs.GetGasSpent())
+			return rewardsSended, err
 		default:
 			log.Error("[sendRewards] send tx failed", "account", stat.Account.String(), "reward", stat.Reward, "dryrun", opt.DryRun, "err", err)
 			return rewardsSended, errSendTransactionFailed
@@ -119,6 +181,7 @@ func (opt *Option) sendRewards(idx int, exchange string, accountStats mongodb.Ac
 		"allRewardsSended", opt.TotalValue == nil || rewardsSended.Cmp(opt.TotalValue) == 0,
 		"totalDustReward", totalDustReward,
 		"totalDustRewardCount", totalDustRewardCount,
+		"gasSpent", opt.BuildTxArgs.GetGasSpent(),
 	)
 	return rewardsSended, nil
 }
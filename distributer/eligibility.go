@@ -0,0 +1,61 @@
+package distributer
+
+import (
+	"fmt"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/anyswap/ANYToken-distribution/mongodb"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common/hexutil"
+)
+
+func (opt *Option) getEligibilityCall() (contract common.Address, selector []byte, err error) {
+	if !common.IsHexAddress(opt.EligibilityContract) {
+		return contract, nil, fmt.Errorf("wrong eligibility contract '%v'", opt.EligibilityContract)
+	}
+	selector, err = hexutil.Decode(opt.EligibilitySelector)
+	if err != nil || len(selector) != 4 {
+		return contract, nil, fmt.Errorf("wrong eligibility selector '%v', must be 4 bytes hex", opt.EligibilitySelector)
+	}
+	return common.HexToAddress(opt.EligibilityContract), selector, nil
+}
+
+// checkEligibility call eligibility function `<selector>(address) returns (bool)`
+// of eligibility contract for every recipient, ineligible recipients abort sending,
+// or are skipped if SkipIneligible is true
+func (opt *Option) checkEligibility(accountStats mongodb.AccountStatSlice) (mongodb.AccountStatSlice, error) {
+	if opt.EligibilityContract == "" {
+		return accountStats, nil
+	}
+	contract, selector, err := opt.getEligibilityCall()
+	if err != nil {
+		return nil, err
+	}
+	result := make(mongodb.AccountStatSlice, 0, len(accountStats))
+	flagged := 0
+	for _, stat := range accountStats {
+		data := make([]byte, 36)
+		copy(data[:4], selector)
+		copy(data[4:], stat.Account.Hash().Bytes())
+		eligible, err := capi.CallBool(contract, data)
+		if err != nil {
+			return nil, fmt.Errorf("check eligibility of %v failed. %v", stat.Account.String(), err)
+		}
+		if eligible {
+			result = append(result, stat)
+			continue
+		}
+		flagged++
+		opt.recordSkipped(stat, SkipReasonIneligible)
+		log.Error("[check eligibility] found ineligible recipient", "account", stat.Account.String(), "reward", stat.Reward, "skip", opt.SkipIneligible)
+	}
+	if flagged == 0 {
+		log.Info("[check eligibility] all recipients are eligible", "contract", opt.EligibilityContract, "count", len(result))
+		return result, nil
+	}
+	if !opt.SkipIneligible {
+		return nil, fmt.Errorf("found %v ineligible recipients", flagged)
+	}
+	log.Error("[check eligibility] SKIPPED INELIGIBLE RECIPIENTS", "count", flagged, "contract", opt.EligibilityContract)
+	return result, nil
+}
@@ -0,0 +1,31 @@
+package distributer
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+var errFactoryMismatch = errors.New("exchange factory mismatch")
+
+// checkExchangeFactory every exchange must belong to ExpectedFactory,
+// so rewards are not calculated from a forked or impostor exchange
+func (opt *Option) checkExchangeFactory() (detail string, err error) {
+	if opt.ExpectedFactory == "" || len(opt.Exchanges) == 0 {
+		return "no expected factory", nil
+	}
+	expected := common.HexToAddress(opt.ExpectedFactory)
+	for _, exchange := range opt.Exchanges {
+		factory, err := capi.GetExchangeFactoryAddress(common.HexToAddress(exchange))
+		if err != nil {
+			return "", fmt.Errorf("get exchange %v 's factory address failed: %w", exchange, err)
+		}
+		if factory != expected {
+			log.Error("[check option] exchange factory mismatch", "exchange", exchange, "factory", factory.String(), "expected", expected.String())
+			return "", fmt.Errorf("%w. exchange %v 's factory is %v, expected %v", errFactoryMismatch, exchange, factory.String(), expected.String())
+		}
+	}
+	return fmt.Sprintf("%v exchanges belong to factory %v", len(opt.Exchanges), expected.String()), nil
+}
@@ -0,0 +1,82 @@
+package distributer
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	ethereum "github.com/fsn-dev/fsn-go-sdk/efsn"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+// count of identical estimates of consecutive transfers to treat batch as homogeneous
+const homogeneousGasSamples = 3
+
+// gasEstimateCache estimates of transfers of a reward token (zero address for native coin)
+type gasEstimateCache struct {
+	samples  []uint64
+	cached   uint64 // reused estimate once batch is homogeneous
+	variable bool   // estimates differ, estimate every transfer
+}
+
+// add sample estimate, return whether batch is detected as homogeneous
+func (c *gasEstimateCache) add(gas uint64) bool {
+	if len(c.samples) != 0 && c.samples[0] != gas {
+		c.variable = true
+		c.samples = nil
+		return false
+	}
+	c.samples = append(c.samples, gas)
+	if len(c.samples) < homogeneousGasSamples {
+		return false
+	}
+	c.cached = gas
+	return true
+}
+
+// getEstimatedGasLimit estimate gas of transfer and add GasBufferPercent to it.
+// if CacheGasEstimate is true, the estimate is reused for the rest transfers of the same token
+// once the first homogeneousGasSamples transfers have identical estimates (standard transfers
+// of a homogeneous batch), otherwise (eg. variable cost of first time storage) every transfer
+// is estimated.
+func (args *BuildTxArgs) getEstimatedGasLimit(account common.Address, reward *big.Int, rewardToken common.Address) (uint64, error) {
+	var cache *gasEstimateCache
+	if args.CacheGasEstimate {
+		if args.gasEstimates == nil {
+			args.gasEstimates = make(map[common.Address]*gasEstimateCache)
+		}
+		cache = args.gasEstimates[rewardToken]
+		if cache == nil {
+			cache = &gasEstimateCache{}
+			args.gasEstimates[rewardToken] = cache
+		}
+		if cache.cached != 0 {
+			return args.addGasBuffer(cache.cached), nil
+		}
+	}
+
+	msg := &ethereum.CallMsg{From: args.fromAddr}
+	if rewardToken != (common.Address{}) {
+		msg.To = &rewardToken
+		msg.Data = packTransferData(account, reward)
+	} else {
+		msg.To = &account
+		msg.Value = reward
+	}
+	gas, err := capi.EstimateGas(msg)
+	if err != nil {
+		return 0, fmt.Errorf("estimate gas of transfer %v to %v failed. %v", reward, account.String(), err)
+	}
+	if cache != nil && !cache.variable {
+		if cache.add(gas) {
+			log.Info("sendRewards homogeneous batch detected, reuse gas estimate", "rewardToken", rewardToken.String(), "gas", gas, "samples", homogeneousGasSamples)
+		} else if cache.variable {
+			log.Info("sendRewards variable gas cost detected, estimate every transfer", "rewardToken", rewardToken.String())
+		}
+	}
+	return args.addGasBuffer(gas), nil
+}
+
+func (args *BuildTxArgs) addGasBuffer(gas uint64) uint64 {
+	return gas * (100 + args.GasBufferPercent) / 100
+}
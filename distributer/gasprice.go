@@ -0,0 +1,112 @@
+package distributer
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+)
+
+// gas price strategies
+const (
+	GasPriceStrategyNodeSuggest = "node-suggest"
+	GasPriceStrategyFeeHistory  = "fee-history-percentile"
+	GasPriceStrategyFixed       = "fixed"
+)
+
+// defaults of fee history gas price strategy
+const (
+	DefaultGasPricePercentile = 60
+	DefaultGasPriceBlocks     = 20
+
+	maxGasPriceBlocks = 1024
+)
+
+// IsValidGasPriceStrategy is valid gas price strategy
+func IsValidGasPriceStrategy(strategy string) bool {
+	switch strategy {
+	case "", GasPriceStrategyNodeSuggest, GasPriceStrategyFeeHistory, GasPriceStrategyFixed:
+		return true
+	default:
+		return false
+	}
+}
+
+func (args *BuildTxArgs) checkGasPriceStrategy() error {
+	switch args.GasPriceStrategy {
+	case "", GasPriceStrategyNodeSuggest:
+	case GasPriceStrategyFixed:
+		if args.GasPrice == nil {
+			return fmt.Errorf("must specify gas price with fixed gas price strategy")
+		}
+	case GasPriceStrategyFeeHistory:
+		if args.GasPrice != nil {
+			return fmt.Errorf("can not specify gas price with fee history gas price strategy")
+		}
+		if args.GasPricePercentile == 0 {
+			args.GasPricePercentile = DefaultGasPricePercentile
+		}
+		if args.GasPriceBlocks == 0 {
+			args.GasPriceBlocks = DefaultGasPriceBlocks
+		}
+		if args.GasPricePercentile < 0 || args.GasPricePercentile > 100 {
+			return fmt.Errorf("gas price percentile %v is not in range [0, 100]", args.GasPricePercentile)
+		}
+		if args.GasPriceBlocks > maxGasPriceBlocks {
+			return fmt.Errorf("gas price blocks %v is greater than %v", args.GasPriceBlocks, maxGasPriceBlocks)
+		}
+	default:
+		return fmt.Errorf("unknown gas price strategy '%v'", args.GasPriceStrategy)
+	}
+	return nil
+}
+
+// getGasPriceByStrategy get gas price when it's not specified
+func (args *BuildTxArgs) getGasPriceByStrategy() (*big.Int, error) {
+	if args.GasPriceStrategy != GasPriceStrategyFeeHistory {
+		return capi.SuggestGasPrice()
+	}
+	gasPrice, err := args.getFeeHistoryGasPrice()
+	if err != nil {
+		log.Warn("get gas price from fee history failed, use suggested gas price instead", "err", err)
+		return capi.SuggestGasPrice()
+	}
+	return gasPrice, nil
+}
+
+// getFeeHistoryGasPrice gas price is base fee of the next block plus median of the priority fees
+// at GasPricePercentile of the latest GasPriceBlocks blocks, empty blocks are skipped.
+// on chains without base fee, priority fee is the whole gas price.
+// same blocks always give the same gas price, which is not true of node suggestion.
+func (args *BuildTxArgs) getFeeHistoryGasPrice() (*big.Int, error) {
+	history, err := capi.GetFeeHistory(args.GasPriceBlocks, []float64{args.GasPricePercentile})
+	if err != nil {
+		return nil, err
+	}
+	tips := make([]*big.Int, 0, len(history.Reward))
+	for i, reward := range history.Reward {
+		if len(reward) == 0 || reward[0] == nil {
+			continue
+		}
+		if i < len(history.GasUsedRatio) && history.GasUsedRatio[i] == 0 {
+			continue
+		}
+		tips = append(tips, reward[0].ToInt())
+	}
+	if len(tips) == 0 {
+		return nil, fmt.Errorf("no transactions in latest %v blocks", args.GasPriceBlocks)
+	}
+	sort.Slice(tips, func(i, j int) bool { return tips[i].Cmp(tips[j]) < 0 })
+	tip := tips[len(tips)/2]
+
+	gasPrice := new(big.Int).Set(tip)
+	baseFee := big.NewInt(0)
+	if n := len(history.BaseFee); n > 0 && history.BaseFee[n-1] != nil {
+		baseFee = history.BaseFee[n-1].ToInt()
+		gasPrice.Add(gasPrice, baseFee)
+	}
+	log.Info("get gas price from fee history", "percentile", args.GasPricePercentile, "blocks", args.GasPriceBlocks,
+		"oldestBlock", history.OldestBlock, "nonEmptyBlocks", len(tips), "baseFee", baseFee, "tip", tip, "gasPrice", gasPrice)
+	return gasPrice, nil
+}
@@ -0,0 +1,120 @@
+package distributer
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/crypto"
+)
+
+// TestFileOption generate test reward file option
+type TestFileOption struct {
+	Count     int
+	MinReward *big.Int
+	MaxReward *big.Int
+
+	// derive addresses and rewards from seed if not empty, otherwise random
+	Seed string
+
+	// title line metadata
+	RewardType  string
+	RewardToken string
+	Exchange    string
+	StartHeight uint64
+	EndHeight   uint64
+}
+
+func (topt *TestFileOption) check() error {
+	if topt.Count <= 0 {
+		return fmt.Errorf("count must be positive")
+	}
+	if topt.MinReward == nil || topt.MinReward.Sign() <= 0 {
+		return fmt.Errorf("min reward must be positive")
+	}
+	if topt.MaxReward == nil || topt.MaxReward.Cmp(topt.MinReward) < 0 {
+		return fmt.Errorf("max reward is less than min reward")
+	}
+	if topt.RewardType != "" && GetStandardByWhat(topt.RewardType) == "" {
+		return fmt.Errorf("unknown reward type '%v'", topt.RewardType)
+	}
+	if topt.RewardToken != "" && !common.IsHexAddress(topt.RewardToken) {
+		return fmt.Errorf("wrong reward token '%v'", topt.RewardToken)
+	}
+	if topt.Exchange != "" && !common.IsHexAddress(topt.Exchange) {
+		return fmt.Errorf("wrong exchange '%v'", topt.Exchange)
+	}
+	return nil
+}
+
+// titleLine title line which passes title line check of sendrewards
+func (topt *TestFileOption) titleLine() string {
+	columns := []string{"#account", "reward"}
+	switch GetStandardByWhat(topt.RewardType) {
+	case byLiquidMethodID:
+		columns = append(columns, byLiquidMethodID, "height")
+	case byVolumeMethodID:
+		columns = append(columns, byVolumeMethodID, "txcount")
+	}
+	extraInfo := fmt.Sprintf("test=%v&&start=%v&&end=%v&&exchange=%v&&rewardToken=%v",
+		topt.Count, topt.StartHeight, topt.EndHeight,
+		strings.ToLower(topt.Exchange), strings.ToLower(topt.RewardToken))
+	columns = append(columns, extraInfo)
+	return strings.Join(columns, ",")
+}
+
+// randomness of the i-th recipient, derived from seed or read from crypto rand
+func (topt *TestFileOption) entropy(i int) ([]byte, error) {
+	if topt.Seed == "" {
+		data := make([]byte, 32)
+		_, err := rand.Read(data)
+		return data, err
+	}
+	index := make([]byte, 8)
+	binary.BigEndian.PutUint64(index, uint64(i))
+	return crypto.Keccak256([]byte(topt.Seed), index), nil
+}
+
+// GenerateTestRewardFile generate input file of sendrewards with synthetic recipients
+func GenerateTestRewardFile(fileName string, topt *TestFileOption) (totalReward *big.Int, err error) {
+	if err = topt.check(); err != nil {
+		return nil, err
+	}
+	file, err := openOutputFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if err = WriteOutputLine(file, topt.titleLine()); err != nil {
+		return nil, err
+	}
+	hasShare := GetStandardByWhat(topt.RewardType) == byLiquidMethodID || GetStandardByWhat(topt.RewardType) == byVolumeMethodID
+	rewardRange := new(big.Int).Sub(topt.MaxReward, topt.MinReward)
+	rewardRange.Add(rewardRange, big.NewInt(1))
+	totalReward = big.NewInt(0)
+	for i := 0; i < topt.Count; i++ {
+		data, errf := topt.entropy(i)
+		if errf != nil {
+			return nil, errf
+		}
+		account := common.BytesToAddress(data[12:32])
+		reward := new(big.Int).SetBytes(crypto.Keccak256(data))
+		reward.Mod(reward, rewardRange)
+		reward.Add(reward, topt.MinReward)
+		totalReward.Add(totalReward, reward)
+		contents := []string{strings.ToLower(account.String()), reward.String()}
+		if hasShare {
+			contents = append(contents, reward.String(), "1")
+		}
+		if err = WriteOutput(file, contents...); err != nil {
+			return nil, err
+		}
+	}
+	log.Info("generate test reward file success", "file", fileName, "count", topt.Count, "totalReward", totalReward, "seed", topt.Seed != "")
+	return totalReward, nil
+}
@@ -0,0 +1,134 @@
+package distributer
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/anyswap/ANYToken-distribution/mongodb"
+	"github.com/anyswap/ANYToken-distribution/tools"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+const idempotencyKeyPrefix = "idempotencyKey="
+
+var (
+	errIdempotencyWithoutDB = errors.New("idempotency keys require saving to database with config file")
+	errPaidAmountMismatch   = errors.New("paid amount is different from input")
+)
+
+// parseIdempotencyKey extract optional "idempotencyKey=<key>" from line parts
+func parseIdempotencyKey(parts []string) (rest []string, key string) {
+	rest = make([]string, 0, len(parts))
+	for _, part := range parts {
+		if strings.HasPrefix(part, idempotencyKeyPrefix) {
+			key = strings.TrimPrefix(part, idempotencyKeyPrefix)
+			continue
+		}
+		rest = append(rest, part)
+	}
+	return rest, key
+}
+
+// policies of paid amount mismatch, when recipient is already paid
+// for a different amount than the input (eg. input is corrected after a partial run)
+const (
+	PaidMismatchSkip           = "skip"
+	PaidMismatchSendDifference = "difference"
+	PaidMismatchAbort          = "abort"
+)
+
+// IsValidPaidMismatchPolicy is valid paid amount mismatch policy
+func IsValidPaidMismatchPolicy(policy string) bool {
+	switch policy {
+	case "", PaidMismatchSkip, PaidMismatchSendDifference, PaidMismatchAbort:
+		return true
+	default:
+		return false
+	}
+}
+
+// checkPaid recipient with idempotency key which is marked paid in database
+// (by any previous run of any file) should be skipped to prevent double pay.
+// return recipient to pay, or nil if it should be skipped.
+// if paid amount is different from input, it's handled by PaidMismatchPolicy:
+// skip anyway, pay the difference (if input is larger) or abort.
+func (opt *Option) checkPaid(stat *mongodb.AccountStat) (*mongodb.AccountStat, error) {
+	if stat.IdempotencyKey == "" || !opt.SaveDB {
+		return stat, nil
+	}
+	if !mongodb.IsInitialized() {
+		return nil, errIdempotencyWithoutDB
+	}
+	mp, err := mongodb.GetPaidKey(stat.IdempotencyKey)
+	if err != nil {
+		log.Error("[idempotency] check paid key failed", "key", stat.IdempotencyKey, "err", err)
+		return nil, err
+	}
+	if mp == nil {
+		return stat, nil
+	}
+	paidReward, err := tools.GetBigIntFromString(mp.Reward)
+	if err != nil {
+		return nil, fmt.Errorf("wrong paid reward '%v' of key %v", mp.Reward, stat.IdempotencyKey)
+	}
+	cmp := stat.Reward.Cmp(paidReward)
+	if cmp == 0 {
+		log.Warn("[idempotency] skip already paid recipient", "account", stat.Account.String(), "reward", stat.Reward, "key", stat.IdempotencyKey)
+		return nil, nil
+	}
+	log.Warn("[idempotency] paid amount is different from input", "account", stat.Account.String(), "key", stat.IdempotencyKey,
+		"paid", paidReward, "input", stat.Reward, "paidTx", mp.TxHash, "policy", opt.PaidMismatchPolicy)
+	switch opt.PaidMismatchPolicy {
+	case PaidMismatchAbort:
+		return nil, fmt.Errorf("%w, key %v, paid %v, input %v", errPaidAmountMismatch, stat.IdempotencyKey, paidReward, stat.Reward)
+	case PaidMismatchSendDifference:
+		if cmp < 0 {
+			log.Error("[idempotency] already paid more than input, skip", "account", stat.Account.String(), "key", stat.IdempotencyKey, "overpaid", new(big.Int).Sub(paidReward, stat.Reward))
+			return nil, nil
+		}
+		unpaid := *stat
+		unpaid.Reward = new(big.Int).Sub(stat.Reward, paidReward)
+		if opt.paidTotals == nil {
+			opt.paidTotals = make(map[string]*big.Int)
+		}
+		opt.paidTotals[stat.IdempotencyKey] = stat.Reward
+		log.Info("[idempotency] pay the difference", "account", stat.Account.String(), "key", stat.IdempotencyKey, "difference", unpaid.Reward)
+		return &unpaid, nil
+	default:
+		return nil, nil
+	}
+}
+
+// markPaid mark idempotency key of recipient paid in database after tx is sent
+func (opt *Option) markPaid(stat *mongodb.AccountStat, txHash *common.Hash) {
+	if stat.IdempotencyKey == "" || !opt.SaveDB || txHash == nil || !mongodb.IsInitialized() {
+		return
+	}
+	reward := stat.Reward
+	paidTotal, isDifference := opt.paidTotals[stat.IdempotencyKey]
+	if isDifference {
+		reward = paidTotal
+	}
+	mp := &mongodb.MgoPaidKey{
+		Key:         stat.IdempotencyKey,
+		Account:     strings.ToLower(stat.Account.String()),
+		Reward:      reward.String(),
+		RewardToken: strings.ToLower(opt.RewardToken),
+		TxHash:      txHash.String(),
+		RunID:       opt.RunID(),
+		Timestamp:   uint64(time.Now().Unix()),
+	}
+	if isDifference {
+		_ = mongodb.TryDoTimes("UpdatePaidKey "+mp.Key, func() error {
+			return mongodb.UpdatePaidKey(mp)
+		})
+		return
+	}
+	_ = mongodb.TryDoTimes("AddPaidKey "+mp.Key, func() error {
+		return mongodb.AddPaidKey(mp)
+	})
+}
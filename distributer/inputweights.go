@@ -0,0 +1,67 @@
+package distributer
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/anyswap/ANYToken-distribution/mongodb"
+)
+
+// BasisPointsTotal weight of the whole pool in basis points
+const BasisPointsTotal = 10000
+
+// checkInputWeightsOption check options of weight based input
+func (opt *Option) checkInputWeightsOption() error {
+	if !opt.InputWeights {
+		if opt.TotalPool != nil {
+			return fmt.Errorf("[check option] total pool is only used with input weights")
+		}
+		return nil
+	}
+	if opt.TotalPool == nil || opt.TotalPool.Sign() <= 0 {
+		return fmt.Errorf("[check option] input weights require positive total pool")
+	}
+	if opt.ScalingNumerator != nil {
+		return fmt.Errorf("[check option] input weights can not be used with scaling")
+	}
+	return nil
+}
+
+// convertWeightsToRewards reward column of input weights mode is weight in basis points,
+// convert it to absolute reward of total pool. the part of total pool covered by
+// the weights sum is divided proportionally and the remainder is assigned
+// one base unit each in file order, so the result is deterministic.
+func (opt *Option) convertWeightsToRewards(accountStats mongodb.AccountStatSlice) error {
+	maxWeight := big.NewInt(BasisPointsTotal)
+	sumWeight := big.NewInt(0)
+	weights := make([]*big.Int, len(accountStats))
+	for i, stat := range accountStats {
+		if stat.Reward.Cmp(maxWeight) > 0 {
+			return fmt.Errorf("weight %v of %v exceeds %v basis points", stat.Reward, stat.Account.String(), BasisPointsTotal)
+		}
+		weights[i] = stat.Reward
+		sumWeight.Add(sumWeight, stat.Reward)
+	}
+	if sumWeight.Cmp(maxWeight) > 0 {
+		return fmt.Errorf("sum of weights %v exceeds %v basis points", sumWeight, BasisPointsTotal)
+	}
+	if sumWeight.Sign() <= 0 {
+		return fmt.Errorf("sum of weights is zero")
+	}
+	if sumWeight.Cmp(maxWeight) < 0 {
+		log.Warn("[input weights] sum of weights is less than whole pool, only part of pool is distributed", "sumWeight", sumWeight, "basisPoints", BasisPointsTotal)
+	}
+	poolPart := new(big.Int).Mul(opt.TotalPool, sumWeight)
+	poolPart.Div(poolPart, maxWeight)
+	rewards := mongodb.DivideRewards(poolPart, weights)
+	if len(rewards) != len(accountStats) {
+		return fmt.Errorf("divide total pool %v by weights failed", opt.TotalPool)
+	}
+	for i, stat := range accountStats {
+		stat.Share = weights[i]
+		stat.Reward = rewards[i]
+	}
+	log.Info("[input weights] convert weights to rewards success", "totalPool", opt.TotalPool, "sumWeight", sumWeight, "distributed", poolPart, "accounts", len(accountStats))
+	return nil
+}
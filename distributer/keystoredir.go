@@ -0,0 +1,54 @@
+package distributer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+// findKeyStoreInDir find keystore of sender in keystore directory (like geth keystore directory),
+// keystore files are matched by their plain 'address' field. hidden files, sub directories
+// and files which are not keystore are ignored. return the keystore content of sender.
+func findKeyStoreInDir(dir string, sender common.Address) ([]byte, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read keystore directory failed. %w", err)
+	}
+	var matched string
+	var keyjson []byte
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~") {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		data, errf := ioutil.ReadFile(path)
+		if errf != nil {
+			log.Warn("read keystore file failed", "file", path, "err", errf)
+			continue
+		}
+		var key struct {
+			Address string `json:"address"`
+		}
+		if json.Unmarshal(data, &key) != nil || !common.IsHexAddress(key.Address) {
+			continue
+		}
+		if common.HexToAddress(key.Address) != sender {
+			continue
+		}
+		if matched != "" {
+			return nil, fmt.Errorf("multiple keystores of sender %v in directory '%v': %v and %v", sender.String(), dir, matched, name)
+		}
+		matched, keyjson = name, data
+	}
+	if matched == "" {
+		return nil, fmt.Errorf("no keystore of sender %v in directory '%v'", sender.String(), dir)
+	}
+	log.Info("found keystore of sender in directory", "sender", sender.String(), "dir", dir, "file", matched)
+	return keyjson, nil
+}
@@ -0,0 +1,360 @@
+package distributer
+
+import (
+	"bufio"
+	"container/heap"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/anyswap/ANYToken-distribution/tools"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+const (
+	// DefaultExternalMergeThreshold input file larger than this (in bytes) is merged on disk
+	DefaultExternalMergeThreshold = 256 * 1024 * 1024
+	// DefaultMergeChunkSize max recipients held in memory by external merge
+	DefaultMergeChunkSize = 1000000
+)
+
+// MergeOption merge duplicate accounts of input file option
+type MergeOption struct {
+	InputFile  string
+	OutputFile string
+
+	// input file larger than ExternalThreshold bytes (or any if ForceExternal)
+	// is merged by external sort, which holds at most ChunkSize recipients in memory
+	ExternalThreshold int64
+	ForceExternal     bool
+	ChunkSize         int
+	TempDir           string
+}
+
+// MergeResult result of merging input file
+type MergeResult struct {
+	Lines       int
+	Recipients  int
+	TotalReward *big.Int
+	External    bool
+	Chunks      int
+}
+
+// MergeInputFile merge duplicate accounts of input file by summing their rewards.
+// output is sorted by account and has line format: <account> <reward>,
+// title line is kept, other commented lines and non positive rewards are dropped.
+// in memory merge and external merge (for huge files) have the same output.
+func MergeInputFile(mopt *MergeOption) (result *MergeResult, err error) {
+	if mopt.InputFile == "" || mopt.OutputFile == "" {
+		return nil, fmt.Errorf("must specify input file and output file")
+	}
+	if resolveFilePath(mopt.InputFile) == resolveFilePath(mopt.OutputFile) {
+		return nil, fmt.Errorf("input file and output file are the same '%v'", mopt.InputFile)
+	}
+	info, err := os.Stat(mopt.InputFile)
+	if err != nil {
+		return nil, err
+	}
+	external := mopt.ForceExternal || (mopt.ExternalThreshold > 0 && info.Size() > mopt.ExternalThreshold)
+	log.Info("[merge] start merge input file", "input", mopt.InputFile, "size", info.Size(), "external", external)
+
+	input, err := os.Open(mopt.InputFile)
+	if err != nil {
+		return nil, err
+	}
+	defer input.Close()
+
+	output, err := openOutputFile(mopt.OutputFile)
+	if err != nil {
+		return nil, err
+	}
+	defer output.Close()
+
+	writer := &mergeWriter{writer: bufio.NewWriter(output), totalReward: big.NewInt(0)}
+	if external {
+		result, err = mopt.externalMerge(input, writer)
+	} else {
+		result, err = inMemoryMerge(input, writer)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = writer.writer.Flush(); err != nil {
+		return nil, err
+	}
+	result.Recipients = writer.recipients
+	result.TotalReward = writer.totalReward
+	log.Info("[merge] merge input file success", "input", mopt.InputFile, "output", mopt.OutputFile,
+		"lines", result.Lines, "recipients", result.Recipients, "totalReward", result.TotalReward,
+		"external", result.External, "chunks", result.Chunks)
+	return result, nil
+}
+
+// readMergeLines call onRecord with every (account, reward) of input, and onTitle with the title line
+func readMergeLines(input io.Reader, onTitle func(string) error, onRecord func(string, *big.Int) error) (lines int, err error) {
+	reader := newInputReader(input)
+	isFirstLine := true
+	for {
+		lineData, _, errf := reader.ReadLine()
+		if errf == io.EOF {
+			break
+		}
+		if errf != nil {
+			return lines, errf
+		}
+		line := strings.TrimSpace(string(lineData))
+		if isCommentedLine(line) {
+			if isFirstLine && line != "" {
+				if err = onTitle(line); err != nil {
+					return lines, err
+				}
+			}
+			isFirstLine = false
+			continue
+		}
+		isFirstLine = false
+		lines++
+		account, reward, errp := parseMergeLine(line)
+		if errp != nil {
+			return lines, errp
+		}
+		if reward.Sign() <= 0 {
+			continue
+		}
+		if err = onRecord(account, reward); err != nil {
+			return lines, err
+		}
+	}
+	return lines, nil
+}
+
+// parseMergeLine parse '<account> <reward>' (extra share and number columns are dropped),
+// lines with per recipient options can not be merged
+func parseMergeLine(line string) (account string, reward *big.Int, err error) {
+	parts := blankOrCommaSepRegexp.Split(line, -1)
+	for _, part := range parts {
+		if strings.Contains(part, "=") {
+			return "", nil, fmt.Errorf("can not merge line with per recipient option '%v'", line)
+		}
+	}
+	if len(parts) < 2 {
+		return "", nil, fmt.Errorf("less than 2 parts in line %v", line)
+	}
+	if !common.IsHexAddress(parts[0]) {
+		return "", nil, fmt.Errorf("wrong address in line %v", line)
+	}
+	reward, err = tools.GetBigIntFromString(parts[1])
+	if err != nil {
+		return "", nil, fmt.Errorf("wrong reward in line %v, err=%v", line, err)
+	}
+	return strings.ToLower(common.HexToAddress(parts[0]).String()), reward, nil
+}
+
+func addMergeRecord(merged map[string]*big.Int, account string, reward *big.Int) {
+	if sum, exist := merged[account]; exist {
+		sum.Add(sum, reward)
+	} else {
+		merged[account] = new(big.Int).Set(reward)
+	}
+}
+
+func sortedAccounts(merged map[string]*big.Int) []string {
+	accounts := make([]string, 0, len(merged))
+	for account := range merged {
+		accounts = append(accounts, account)
+	}
+	sort.Strings(accounts)
+	return accounts
+}
+
+func inMemoryMerge(input io.Reader, writer *mergeWriter) (*MergeResult, error) {
+	merged := make(map[string]*big.Int)
+	lines, err := readMergeLines(input, writer.writeTitle, func(account string, reward *big.Int) error {
+		addMergeRecord(merged, account, reward)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, account := range sortedAccounts(merged) {
+		if err = writer.writeRecord(account, merged[account]); err != nil {
+			return nil, err
+		}
+	}
+	return &MergeResult{Lines: lines}, nil
+}
+
+// externalMerge merge records of every chunk in memory and spill it sorted to a temp file,
+// then k-way merge all chunk files, so at most ChunkSize recipients are held in memory
+func (mopt *MergeOption) externalMerge(input io.Reader, writer *mergeWriter) (result *MergeResult, err error) {
+	chunkSize := mopt.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultMergeChunkSize
+	}
+	tempDir, err := ioutil.TempDir(mopt.TempDir, "merge-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tempDir)
+
+	var chunkFiles []string
+	merged := make(map[string]*big.Int)
+	spill := func() error {
+		if len(merged) == 0 {
+			return nil
+		}
+		chunkFile := filepath.Join(tempDir, fmt.Sprintf("chunk-%d", len(chunkFiles)))
+		if errs := spillMergeChunk(chunkFile, merged); errs != nil {
+			return errs
+		}
+		log.Info("[merge] spill chunk to disk", "chunk", len(chunkFiles), "recipients", len(merged))
+		chunkFiles = append(chunkFiles, chunkFile)
+		merged = make(map[string]*big.Int)
+		return nil
+	}
+	lines, err := readMergeLines(input, writer.writeTitle, func(account string, reward *big.Int) error {
+		addMergeRecord(merged, account, reward)
+		if len(merged) >= chunkSize {
+			return spill()
+		}
+		return nil
+	})
+	if err == nil {
+		err = spill()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = mergeChunkFiles(chunkFiles, writer); err != nil {
+		return nil, err
+	}
+	return &MergeResult{Lines: lines, External: true, Chunks: len(chunkFiles)}, nil
+}
+
+func spillMergeChunk(chunkFile string, merged map[string]*big.Int) error {
+	file, err := os.Create(chunkFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	w := bufio.NewWriter(file)
+	for _, account := range sortedAccounts(merged) {
+		if _, err = fmt.Fprintf(w, "%v %v\n", account, merged[account]); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// chunkReader sorted records of a spilled chunk file
+type chunkReader struct {
+	scanner *bufio.Scanner
+	account string
+	reward  *big.Int
+}
+
+func (r *chunkReader) next() (bool, error) {
+	if !r.scanner.Scan() {
+		return false, r.scanner.Err()
+	}
+	parts := strings.SplitN(r.scanner.Text(), " ", 2)
+	if len(parts) != 2 {
+		return false, fmt.Errorf("wrong merge chunk record '%v'", r.scanner.Text())
+	}
+	reward, ok := new(big.Int).SetString(parts[1], 10)
+	if !ok {
+		return false, fmt.Errorf("wrong merge chunk reward '%v'", r.scanner.Text())
+	}
+	r.account, r.reward = parts[0], reward
+	return true, nil
+}
+
+// chunkHeap min heap of chunk readers by current account
+type chunkHeap []*chunkReader
+
+func (h chunkHeap) Len() int            { return len(h) }
+func (h chunkHeap) Less(i, j int) bool  { return h[i].account < h[j].account }
+func (h chunkHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *chunkHeap) Push(x interface{}) { *h = append(*h, x.(*chunkReader)) }
+func (h *chunkHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
+
+func mergeChunkFiles(chunkFiles []string, writer *mergeWriter) error {
+	h := make(chunkHeap, 0, len(chunkFiles))
+	for _, chunkFile := range chunkFiles {
+		file, err := os.Open(chunkFile)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		reader := &chunkReader{scanner: bufio.NewScanner(file)}
+		ok, err := reader.next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			h = append(h, reader)
+		}
+	}
+	heap.Init(&h)
+
+	var account string
+	var sum *big.Int
+	for h.Len() > 0 {
+		reader := h[0]
+		if sum != nil && reader.account != account {
+			if err := writer.writeRecord(account, sum); err != nil {
+				return err
+			}
+			sum = nil
+		}
+		if sum == nil {
+			account, sum = reader.account, new(big.Int)
+		}
+		sum.Add(sum, reader.reward)
+
+		ok, err := reader.next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			heap.Fix(&h, 0)
+		} else {
+			heap.Pop(&h)
+		}
+	}
+	if sum != nil {
+		return writer.writeRecord(account, sum)
+	}
+	return nil
+}
+
+// mergeWriter write merged output and count it
+type mergeWriter struct {
+	writer      *bufio.Writer
+	recipients  int
+	totalReward *big.Int
+}
+
+func (w *mergeWriter) writeTitle(titleLine string) error {
+	_, err := fmt.Fprintln(w.writer, titleLine)
+	return err
+}
+
+func (w *mergeWriter) writeRecord(account string, reward *big.Int) error {
+	w.recipients++
+	w.totalReward.Add(w.totalReward, reward)
+	_, err := fmt.Fprintf(w.writer, "%v %v\n", account, reward)
+	return err
+}
@@ -0,0 +1,126 @@
+package distributer
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common/math"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/crypto"
+)
+
+const (
+	merkleOutputSuffix = ".merkle.json"
+
+	// MerkleLeafEncoding leaf encoding of merkle tree of reward results
+	MerkleLeafEncoding = "keccak256(abi.encodePacked(address account, uint256 reward, bytes32 txhash))"
+	// MerkleNodeEncoding inner node encoding of merkle tree of reward results
+	MerkleNodeEncoding = "keccak256(abi.encodePacked(min(left, right), max(left, right))), odd node is promoted to next level"
+)
+
+// MerkleLeaf leaf of merkle tree of reward results
+type MerkleLeaf struct {
+	Account string
+	Reward  string
+	TxHash  string
+	Hash    common.Hash
+}
+
+// MerkleSummary merkle root and tree of reward results in output order
+type MerkleSummary struct {
+	Root         common.Hash
+	LeafEncoding string
+	NodeEncoding string
+	Leaves       []*MerkleLeaf
+	Tree         [][]common.Hash // levels from leaves to root
+}
+
+// merkleResultWriter collect results written to output,
+// and write merkle summary file when flush
+type merkleResultWriter struct {
+	ResultWriter
+	fileName string
+	leaves   []*MerkleLeaf
+}
+
+func (opt *Option) wrapMerkleResultWriter(writer ResultWriter, ofile string) ResultWriter {
+	if !opt.MerkleOutput {
+		return writer
+	}
+	return &merkleResultWriter{
+		ResultWriter: writer,
+		fileName:     ofile + merkleOutputSuffix,
+	}
+}
+
+func (w *merkleResultWriter) WriteResult(result *RewardResult) error {
+	err := w.ResultWriter.WriteResult(result)
+	if err == nil {
+		w.leaves = append(w.leaves, newMerkleLeaf(result))
+	}
+	return err
+}
+
+func (w *merkleResultWriter) Flush() error {
+	err := w.ResultWriter.Flush()
+	summary := BuildMerkleSummary(w.leaves)
+	data, errm := json.MarshalIndent(summary, "", "  ")
+	if errm == nil {
+		errm = ioutil.WriteFile(w.fileName, data, 0644)
+	}
+	if errm != nil {
+		log.Error("[merkle] write merkle summary failed", "file", w.fileName, "err", errm)
+		return errm
+	}
+	log.Info("[merkle] write merkle summary success", "file", w.fileName, "root", summary.Root.String(), "leaves", len(summary.Leaves))
+	return err
+}
+
+func newMerkleLeaf(result *RewardResult) *MerkleLeaf {
+	account := common.HexToAddress(result.Account)
+	txHash := common.HexToHash(result.TxHash)
+	leaf := &MerkleLeaf{
+		Account: result.Account,
+		Reward:  result.Reward.String(),
+		TxHash:  result.TxHash,
+	}
+	leaf.Hash = crypto.Keccak256Hash(account.Bytes(), math.PaddedBigBytes(result.Reward, 32), txHash.Bytes())
+	return leaf
+}
+
+// BuildMerkleSummary build merkle tree of leaves, root of empty leaves is zero hash
+func BuildMerkleSummary(leaves []*MerkleLeaf) *MerkleSummary {
+	summary := &MerkleSummary{
+		LeafEncoding: MerkleLeafEncoding,
+		NodeEncoding: MerkleNodeEncoding,
+		Leaves:       leaves,
+	}
+	if len(leaves) == 0 {
+		return summary
+	}
+	level := make([]common.Hash, len(leaves))
+	for i, leaf := range leaves {
+		level[i] = leaf.Hash
+	}
+	summary.Tree = append(summary.Tree, level)
+	for len(level) > 1 {
+		next := make([]common.Hash, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			left, right := level[i], level[i+1]
+			if bytes.Compare(left.Bytes(), right.Bytes()) > 0 {
+				left, right = right, left
+			}
+			next = append(next, crypto.Keccak256Hash(left.Bytes(), right.Bytes()))
+		}
+		summary.Tree = append(summary.Tree, next)
+		level = next
+	}
+	summary.Root = level[0]
+	return summary
+}
@@ -0,0 +1,52 @@
+package distributer
+
+import (
+	"errors"
+	"time"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+)
+
+const (
+	minClientsCheckInterval = 60 * time.Second
+	minClientsRetryInterval = 10 * time.Second
+	minClientsWaitTimeout   = 10 * time.Minute
+)
+
+var errNotEnoughClients = errors.New("healthy clients are less than min clients")
+
+// checkMinClients check healthy clients are not less than MinClients
+func (opt *Option) checkMinClients() error {
+	if opt.MinClients <= 0 {
+		return nil
+	}
+	opt.lastClientsCheck = time.Now()
+	healthy := capi.GetHealthyClientCount()
+	if healthy < opt.MinClients {
+		log.Warn("[min clients] healthy clients are not enough", "healthy", healthy, "minClients", opt.MinClients)
+		return errNotEnoughClients
+	}
+	log.Info("[min clients] healthy clients are enough", "healthy", healthy, "minClients", opt.MinClients)
+	return nil
+}
+
+// waitMinClients check healthy clients periodically in send loop,
+// pause sending until enough clients are healthy again, or abort on timeout
+func (opt *Option) waitMinClients() error {
+	if opt.MinClients <= 0 || time.Since(opt.lastClientsCheck) < minClientsCheckInterval {
+		return nil
+	}
+	deadline := time.Now().Add(minClientsWaitTimeout)
+	for {
+		err := opt.checkMinClients()
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			log.Error("[min clients] abort as healthy clients are not enough for too long", "minClients", opt.MinClients, "waited", minClientsWaitTimeout)
+			return err
+		}
+		log.Warn("[min clients] pause sending until enough clients are healthy")
+		time.Sleep(minClientsRetryInterval)
+	}
+}
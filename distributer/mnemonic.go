@@ -0,0 +1,89 @@
+package distributer
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/fsn-dev/fsn-go-sdk/efsn/accounts"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/crypto"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+var errInvalidChildKey = errors.New("invalid child key")
+
+// deriveKeyFromMnemonic derive private key from BIP-39 mnemonic
+// and BIP-32/BIP-44 derivation path (eg. m/44'/60'/0'/0/0)
+func deriveKeyFromMnemonic(mnemonic, path string) (*ecdsa.PrivateKey, error) {
+	words := strings.Fields(mnemonic)
+	switch len(words) {
+	case 12, 15, 18, 21, 24:
+	default:
+		return nil, fmt.Errorf("wrong mnemonic words count %v", len(words))
+	}
+	derivationPath, err := accounts.ParseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+	seed := pbkdf2.Key([]byte(strings.Join(words, " ")), []byte("mnemonic"), 2048, 64, sha512.New)
+
+	key, chainCode := hmacSHA512([]byte("Bitcoin seed"), seed)
+	if !isValidPrivateKey(key) {
+		return nil, errInvalidChildKey
+	}
+	for _, index := range derivationPath {
+		key, chainCode, err = deriveChildKey(key, chainCode, index)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return crypto.ToECDSA(key)
+}
+
+func deriveChildKey(parentKey, chainCode []byte, index uint32) (key, childChainCode []byte, err error) {
+	var data []byte
+	if index >= 0x80000000 { // hardened
+		data = append([]byte{0}, parentKey...)
+	} else {
+		priv, errf := crypto.ToECDSA(parentKey)
+		if errf != nil {
+			return nil, nil, errf
+		}
+		data = crypto.CompressPubkey(&priv.PublicKey)
+	}
+	indexBytes := make([]byte, 4)
+	binary.BigEndian.PutUint32(indexBytes, index)
+	data = append(data, indexBytes...)
+
+	il, ir := hmacSHA512(chainCode, data)
+	if !isValidPrivateKey(il) {
+		return nil, nil, errInvalidChildKey
+	}
+	curveN := crypto.S256().Params().N
+	childKey := new(big.Int).SetBytes(il)
+	childKey.Add(childKey, new(big.Int).SetBytes(parentKey))
+	childKey.Mod(childKey, curveN)
+	if childKey.Sign() == 0 {
+		return nil, nil, errInvalidChildKey
+	}
+	key = make([]byte, 32)
+	childKey.FillBytes(key)
+	return key, ir, nil
+}
+
+func hmacSHA512(key, data []byte) (il, ir []byte) {
+	mac := hmac.New(sha512.New, key)
+	_, _ = mac.Write(data)
+	sum := mac.Sum(nil)
+	return sum[:32], sum[32:]
+}
+
+func isValidPrivateKey(key []byte) bool {
+	k := new(big.Int).SetBytes(key)
+	return k.Sign() > 0 && k.Cmp(crypto.S256().Params().N) < 0
+}
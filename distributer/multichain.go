@@ -0,0 +1,266 @@
+package distributer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+
+	"github.com/anyswap/ANYToken-distribution/callapi"
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/anyswap/ANYToken-distribution/mongodb"
+	"github.com/anyswap/ANYToken-distribution/params"
+	"github.com/anyswap/ANYToken-distribution/tools"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+const chainTagPrefix = "chain="
+
+var (
+	errChainTagInSingleChain = errors.New("input file has per recipient chain")
+	errMultiChainUnderfunded = errors.New("sender balance is not enough on some chains")
+)
+
+// parseChainTag extract optional "chain=<name>" from line parts
+func parseChainTag(parts []string) (rest []string, chain string) {
+	rest = make([]string, 0, len(parts))
+	for _, part := range parts {
+		if strings.HasPrefix(part, chainTagPrefix) {
+			chain = strings.TrimPrefix(part, chainTagPrefix)
+			continue
+		}
+		rest = append(rest, part)
+	}
+	return rest, chain
+}
+
+// getMultiChainTag get the first chain tag of recipients if any
+func getMultiChainTag(accountStats mongodb.AccountStatSlice) string {
+	for _, stat := range accountStats {
+		if stat.Chain != "" {
+			return stat.Chain
+		}
+	}
+	return ""
+}
+
+// ChainTarget a chain to send rewards on, with its own api caller
+type ChainTarget struct {
+	Config *params.ChainConfig
+	Caller *callapi.APICaller
+
+	args        *BuildTxArgs
+	rewardToken common.Address
+	stats       mongodb.AccountStatSlice
+}
+
+// MultiChainOption send rewards across multiple chains in one run
+type MultiChainOption struct {
+	InputFile  string
+	OutputFile string
+	DryRun     bool
+
+	// template of every chain, each chain uses a copy with its own nonce sequence
+	BuildTxArgs *BuildTxArgs
+
+	Chains []*ChainTarget
+}
+
+// SendMultiChainRewards send rewards of input file where every recipient specifies
+// its chain by "chain=<name>". all chains are checked (chain ID and sender balances)
+// before any transfer is sent, then chains are sent one after another.
+// output line format is: <account>,<reward>,<txhash>,chain=<name>
+func (mopt *MultiChainOption) SendMultiChainRewards() error {
+	if err := mopt.check(); err != nil {
+		return err
+	}
+	accountStats, _, err := GetAccountsAndRewardsFromFile(mopt.InputFile)
+	if err != nil {
+		return err
+	}
+	accountStats = nonZeroRewards(accountStats)
+	if err = mopt.routeRecipients(accountStats); err != nil {
+		return err
+	}
+	if err = mopt.prepareChains(); err != nil {
+		return err
+	}
+
+	file, err := openOutputFile(mopt.OutputFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	for _, target := range mopt.Chains {
+		if err = mopt.sendOnChain(target, file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (mopt *MultiChainOption) check() error {
+	if mopt.InputFile == "" || mopt.OutputFile == "" {
+		return fmt.Errorf("must specify input file and output file")
+	}
+	if resolveFilePath(mopt.InputFile) == resolveFilePath(mopt.OutputFile) {
+		return fmt.Errorf("input file and output file are the same '%v'", mopt.InputFile)
+	}
+	if len(mopt.Chains) == 0 {
+		return fmt.Errorf("no chains to send rewards on")
+	}
+	args := mopt.BuildTxArgs
+	if args.FixedNonce || args.Nonce != nil {
+		return fmt.Errorf("can not specify nonce in multichain sending, every chain has its own nonce")
+	}
+	if args.GasPrice != nil {
+		return fmt.Errorf("can not specify gas price in multichain sending, use 'GasPrice' of chain instead")
+	}
+	if args.UnsignedTxFile != "" {
+		return fmt.Errorf("emit unsigned transactions is not supported in multichain sending")
+	}
+	if args.MultisigOutput != "" {
+		return fmt.Errorf("multisig output is not supported in multichain sending")
+	}
+	return nil
+}
+
+// routeRecipients group recipients by their chain, every recipient must specify a known chain
+func (mopt *MultiChainOption) routeRecipients(accountStats mongodb.AccountStatSlice) error {
+	targets := make(map[string]*ChainTarget, len(mopt.Chains))
+	for _, target := range mopt.Chains {
+		targets[target.Config.Name] = target
+	}
+	for _, stat := range accountStats {
+		if stat.Chain == "" {
+			return fmt.Errorf("recipient %v has no chain, please specify '%v<name>'", stat.Account.String(), chainTagPrefix)
+		}
+		target, exist := targets[stat.Chain]
+		if !exist {
+			return fmt.Errorf("recipient %v has unknown chain '%v'", stat.Account.String(), stat.Chain)
+		}
+		target.stats = append(target.stats, stat)
+	}
+	for _, target := range mopt.Chains {
+		log.Info("[multichain] route recipients", "chain", target.Config.Name, "recipients", len(target.stats), "totalReward", target.stats.CalcTotalReward())
+	}
+	return nil
+}
+
+// prepareChains check every chain before sending anything, so a bad chain aborts the whole run
+func (mopt *MultiChainOption) prepareChains() (err error) {
+	underfunded := false
+	for _, target := range mopt.Chains {
+		if len(target.stats) == 0 {
+			continue
+		}
+		SetAPICaller(target.Caller)
+		target.args, err = mopt.newChainTxArgs(target.Config)
+		if err != nil {
+			return err
+		}
+		if err = target.args.Check(mopt.DryRun); err != nil {
+			return fmt.Errorf("check chain '%v' failed. %v", target.Config.Name, err)
+		}
+		if target.args.chainID.Uint64() != target.Config.ChainID {
+			return fmt.Errorf("chain '%v' has chain ID %v, want %v", target.Config.Name, target.args.chainID, target.Config.ChainID)
+		}
+		if target.Config.RewardToken != "" {
+			target.rewardToken = common.HexToAddress(target.Config.RewardToken)
+		}
+		enough, errb := target.checkSenderBalances()
+		if errb != nil {
+			return errb
+		}
+		if !enough {
+			underfunded = true
+		}
+	}
+	if underfunded {
+		if !mopt.DryRun {
+			return errMultiChainUnderfunded
+		}
+		log.Warn("[multichain] sender balance is not enough on some chains, but ignore in dry run")
+	}
+	return nil
+}
+
+func (mopt *MultiChainOption) newChainTxArgs(chain *params.ChainConfig) (*BuildTxArgs, error) {
+	args := *mopt.BuildTxArgs
+	if chain.GasPrice != "" {
+		gasPrice, err := tools.GetBigIntFromString(chain.GasPrice)
+		if err != nil {
+			return nil, err
+		}
+		args.GasPrice = gasPrice
+		args.GasPriceStrategy = GasPriceStrategyFixed // gas price of chain overrides strategy
+	}
+	return &args, nil
+}
+
+// checkSenderBalances sender must have enough reward token (or coin) and gas on this chain
+func (target *ChainTarget) checkSenderBalances() (enough bool, err error) {
+	args := target.args
+	sender := args.fromAddr
+	totalReward := target.stats.CalcTotalReward()
+	gasCost := new(big.Int).Mul(estimateGasCost(*args.GasLimit, args.GasPrice), big.NewInt(int64(len(target.stats))))
+
+	coinBalance, err := capi.GetCoinBalance(sender, nil)
+	if err != nil {
+		return false, fmt.Errorf("get sender coin balance on chain '%v' failed. %v", target.Config.Name, err)
+	}
+	coinNeeded := new(big.Int).Set(gasCost)
+	enough = true
+	if target.rewardToken != (common.Address{}) {
+		tokenBalance, errt := capi.GetTokenBalance(target.rewardToken, sender, nil)
+		if errt != nil {
+			return false, fmt.Errorf("get sender reward token balance on chain '%v' failed. %v", target.Config.Name, errt)
+		}
+		if tokenBalance.Cmp(totalReward) < 0 {
+			log.Error("[multichain] not enough reward token balance", "chain", target.Config.Name, "sender", sender.String(), "balance", tokenBalance, "needed", totalReward)
+			enough = false
+		}
+	} else {
+		coinNeeded.Add(coinNeeded, totalReward)
+	}
+	if coinBalance.Cmp(coinNeeded) < 0 {
+		log.Error("[multichain] not enough coin balance", "chain", target.Config.Name, "sender", sender.String(), "balance", coinBalance, "needed", coinNeeded)
+		enough = false
+	}
+	if enough {
+		log.Info("[multichain] sender balance is enough", "chain", target.Config.Name, "sender", sender.String(), "totalReward", totalReward, "gasCost", gasCost)
+	}
+	return enough, nil
+}
+
+func (mopt *MultiChainOption) sendOnChain(target *ChainTarget, writer io.Writer) error {
+	if len(target.stats) == 0 {
+		return nil
+	}
+	SetAPICaller(target.Caller)
+	chainTag := chainTagPrefix + target.Config.Name
+	rewardsSended := big.NewInt(0)
+	for _, stat := range target.stats {
+		txHash, err := target.args.sendRewardsTransaction(stat.Account, stat.Reward, target.rewardToken, mopt.DryRun, stat.GasLimit, stat.GasPrice)
+		switch err {
+		case nil:
+		case errDustReward:
+			continue
+		default:
+			log.Error("[multichain] send tx failed", "chain", target.Config.Name, "account", stat.Account.String(), "reward", stat.Reward, "err", err)
+			return err
+		}
+		rewardsSended.Add(rewardsSended, stat.Reward)
+		txHashStr := ""
+		if txHash != nil {
+			txHashStr = txHash.String()
+		}
+		if err = WriteOutput(writer, strings.ToLower(stat.Account.String()), stat.Reward.String(), txHashStr, chainTag); err != nil {
+			return err
+		}
+	}
+	log.Info("[multichain] rewards sended", "chain", target.Config.Name, "rewardsSended", rewardsSended, "gasSpent", target.args.GetGasSpent())
+	return nil
+}
@@ -0,0 +1,107 @@
+package distributer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common/hexutil"
+)
+
+const safeTxBatchVersion = "1.0"
+
+// SafeTxBatch batch of transaction proposals in Safe transaction builder JSON format,
+// it's imported to the Safe UI and executed by the multisig as one multi send
+type SafeTxBatch struct {
+	Version      string         `json:"version"`
+	ChainID      string         `json:"chainId"`
+	CreatedAt    int64          `json:"createdAt"`
+	Meta         SafeTxMeta     `json:"meta"`
+	Transactions []*SafeTxEntry `json:"transactions"`
+}
+
+// SafeTxMeta meta of Safe transaction batch
+type SafeTxMeta struct {
+	Name                   string `json:"name"`
+	Description            string `json:"description"`
+	CreatedFromSafeAddress string `json:"createdFromSafeAddress"`
+}
+
+// SafeTxEntry a transaction of Safe transaction batch
+type SafeTxEntry struct {
+	To    common.Address `json:"to"`
+	Value string         `json:"value"`
+	Data  hexutil.Bytes  `json:"data"`
+
+	Account string `json:"account"`
+	Reward  string `json:"reward"`
+}
+
+// addMultisigProposal add transfer of reward to multisig batch, nothing is signed or sent
+func (args *BuildTxArgs) addMultisigProposal(account common.Address, reward *big.Int, rewardToken common.Address) error {
+	if args.multisigBatch == nil {
+		chainID := ""
+		if args.chainID != nil {
+			chainID = args.chainID.String()
+		}
+		args.multisigBatch = &SafeTxBatch{
+			Version:   safeTxBatchVersion,
+			ChainID:   chainID,
+			CreatedAt: time.Now().UnixNano() / int64(time.Millisecond),
+			Meta: SafeTxMeta{
+				Name:                   "ANYToken distribution",
+				CreatedFromSafeAddress: args.fromAddr.String(),
+			},
+		}
+	}
+	entry := &SafeTxEntry{
+		Account: strings.ToLower(account.String()),
+		Reward:  reward.String(),
+	}
+	if rewardToken != (common.Address{}) {
+		entry.To = rewardToken
+		entry.Value = "0"
+		entry.Data = packTransferData(account, reward)
+	} else {
+		entry.To = account
+		entry.Value = reward.String()
+		entry.Data = hexutil.Bytes{}
+	}
+	args.multisigBatch.Transactions = append(args.multisigBatch.Transactions, entry)
+	log.Info("sendRewards dry run add multisig proposal", "account", account.String(), "reward", reward, "multisig", args.fromAddr.String())
+	return nil
+}
+
+// WriteMultisigProposals write multisig batch to MultisigOutput, it's only written
+// after all transfers are proposed, as a partial batch must not be executed
+func (args *BuildTxArgs) WriteMultisigProposals() error {
+	if args.MultisigOutput == "" {
+		return nil
+	}
+	batch := args.multisigBatch
+	if batch == nil || len(batch.Transactions) == 0 {
+		log.Warn("no multisig proposal to write", "file", args.MultisigOutput)
+		return nil
+	}
+	totalReward := big.NewInt(0)
+	for _, entry := range batch.Transactions {
+		reward, _ := new(big.Int).SetString(entry.Reward, 10)
+		totalReward.Add(totalReward, reward)
+	}
+	batch.Meta.Description = fmt.Sprintf("%v transfers of total reward %v", len(batch.Transactions), totalReward)
+	content, err := json.MarshalIndent(batch, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(args.MultisigOutput, content, 0644); err != nil {
+		log.Error("write multisig proposals failed", "file", args.MultisigOutput, "err", err)
+		return err
+	}
+	log.Info("write multisig proposals success", "file", args.MultisigOutput, "multisig", batch.Meta.CreatedFromSafeAddress, "transactions", len(batch.Transactions), "totalReward", totalReward)
+	return nil
+}
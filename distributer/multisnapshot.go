@@ -0,0 +1,123 @@
+package distributer
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/anyswap/ANYToken-distribution/mongodb"
+	"github.com/anyswap/ANYToken-distribution/params"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+// checkSnapshotHeights sort and dedup snapshot heights, and check they are in range.
+// heights in range are reached if the range is stable (see CheckStable)
+func (opt *Option) checkSnapshotHeights() error {
+	if len(opt.SnapshotHeights) == 0 {
+		return nil
+	}
+	if !opt.ArchiveMode {
+		return fmt.Errorf("[check option] multi snapshot averaging requires archive mode")
+	}
+	if opt.SampleInterval > 0 {
+		return fmt.Errorf("[check option] snapshot heights conflict with sample interval")
+	}
+	if opt.BalanceCacheFile != "" {
+		return fmt.Errorf("[check option] snapshot heights conflict with balance cache")
+	}
+	heights := sortUniqueHeights(opt.SnapshotHeights)
+	first, last := heights[0], heights[len(heights)-1]
+	if !opt.UseTimeMeasurement && (first < opt.StartHeight || last >= opt.EndHeight) {
+		return fmt.Errorf("[check option] snapshot heights [%v, %v] out of range [%v, %v)", first, last, opt.StartHeight, opt.EndHeight)
+	}
+	opt.SnapshotHeights = heights
+	return nil
+}
+
+func sortUniqueHeights(heights []uint64) []uint64 {
+	sorted := make([]uint64, len(heights))
+	copy(sorted, heights)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	result := sorted[:1]
+	for _, height := range sorted[1:] {
+		if height != result[len(result)-1] {
+			result = append(result, height)
+		}
+	}
+	return result
+}
+
+// checkSnapshotHeightsReached heights must be sorted
+func checkSnapshotHeightsReached(heights []uint64) error {
+	latest := capi.LoopGetLatestBlockHeader().Number.Uint64()
+	if last := heights[len(heights)-1]; last > latest {
+		return fmt.Errorf("snapshot height %v is higher than latest height %v", last, latest)
+	}
+	return nil
+}
+
+// getMultiSnapshotLiquidityBalancesOfExchange calc average liquidity (in coin balance)
+// of accounts over the snapshot heights. every snapshot read is retried until success,
+// so a failed read never counts as zero balance.
+func (opt *Option) getMultiSnapshotLiquidityBalancesOfExchange(exchange string, accounts []common.Address) (accountStats mongodb.AccountStatSlice) {
+	exchangeAddr := common.HexToAddress(exchange)
+	heights := opt.SnapshotHeights
+	log.Info("[byliquid] calc multi snapshot liquidity start", "exchange", exchange, "snapshots", len(heights), "accounts", len(accounts))
+
+	sums := make([]*big.Int, len(accounts))
+	for i := range sums {
+		sums[i] = big.NewInt(0)
+	}
+	for _, height := range heights {
+		blockNumber := new(big.Int).SetUint64(height)
+		totalSupply := capi.LoopGetExchangeLiquidity(exchangeAddr, blockNumber)
+		if totalSupply.Sign() <= 0 {
+			log.Warn("[byliquid] exchange has no liquidity at snapshot", "exchange", exchange, "height", height)
+			continue
+		}
+		exCoinBalance := capi.LoopGetCoinBalance(exchangeAddr, blockNumber)
+		values := prefetchLiquidityBalances(exchangeAddr, accounts, blockNumber)
+		for i, value := range values {
+			// convert liquid balance to coin balance
+			coinBalance := new(big.Int).Mul(value, exCoinBalance)
+			coinBalance.Div(coinBalance, totalSupply)
+			sums[i].Add(sums[i], coinBalance)
+		}
+		log.Info("[byliquid] read snapshot liquidity finished", "exchange", exchange, "height", height)
+	}
+
+	numSnapshots := big.NewInt(int64(len(heights)))
+	lastHeight := heights[len(heights)-1]
+	finStatMap := make(map[common.Address]*mongodb.AccountStat)
+	for i, account := range accounts {
+		if params.IsExcludedRewardAccount(account) {
+			continue
+		}
+		finStatMap[account] = &mongodb.AccountStat{
+			Account: account,
+			Share:   sums[i].Div(sums[i], numSnapshots),
+			Number:  lastHeight,
+		}
+	}
+	return mongodb.ConvertToSortedSlice(finStatMap)
+}
+
+// CalcMultiSnapshotRewards average liquidity of accounts over the snapshot heights,
+// and calc rewards proportional to the average from total reward
+func CalcMultiSnapshotRewards(exchange string, accounts []common.Address, heights []uint64, totalReward *big.Int) (mongodb.AccountStatSlice, error) {
+	if len(heights) == 0 {
+		return nil, fmt.Errorf("no snapshot heights")
+	}
+	if totalReward == nil || totalReward.Sign() <= 0 {
+		return nil, errTotalRewardsIsZero
+	}
+	heights = sortUniqueHeights(heights)
+	if err := checkSnapshotHeightsReached(heights); err != nil {
+		return nil, err
+	}
+	opt := &Option{SnapshotHeights: heights}
+	accountStats := opt.getMultiSnapshotLiquidityBalancesOfExchange(exchange, accounts)
+	accountStats.CalcRewards(totalReward)
+	return accountStats, nil
+}
@@ -0,0 +1,120 @@
+package distributer
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/anyswap/ANYToken-distribution/mongodb"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+// ObligationsOption accrued but not sended rewards reconciliation option
+type ObligationsOption struct {
+	Sender   string
+	Treasury string
+}
+
+// Obligation accrued but not sended rewards of a reward token and balances to cover them
+type Obligation struct {
+	RewardToken     string
+	Records         uint64
+	Accrued         *big.Int
+	SenderBalance   *big.Int `json:",omitempty"`
+	TreasuryBalance *big.Int `json:",omitempty"`
+	Available       *big.Int
+	Shortfall       *big.Int
+}
+
+// IsUnderfunded is available balances less than accrued rewards
+func (o *Obligation) IsUnderfunded() bool {
+	return o.Shortfall.Sign() > 0
+}
+
+// GetObligations sum accrued but not sended rewards in database per reward token,
+// and compare with current balances of sender and treasury. this is read only.
+func GetObligations(oopt *ObligationsOption) ([]*Obligation, error) {
+	if oopt.Sender == "" && oopt.Treasury == "" {
+		return nil, fmt.Errorf("must specify sender or treasury")
+	}
+	for _, address := range []string{oopt.Sender, oopt.Treasury} {
+		if address != "" && !common.IsHexAddress(address) {
+			return nil, fmt.Errorf("wrong address '%v'", address)
+		}
+	}
+	unsent, err := mongodb.SumUnsentRewards()
+	if err != nil {
+		return nil, fmt.Errorf("sum unsent rewards failed. %v", err)
+	}
+	obligations := make([]*Obligation, 0, len(unsent))
+	for _, sum := range unsent {
+		obligation := &Obligation{
+			RewardToken: sum.RewardToken,
+			Records:     sum.Records,
+			Accrued:     sum.Reward,
+			Available:   big.NewInt(0),
+			Shortfall:   big.NewInt(0),
+		}
+		if oopt.Sender != "" {
+			obligation.SenderBalance, err = getObligationBalance(sum.RewardToken, oopt.Sender)
+			if err != nil {
+				return nil, err
+			}
+			obligation.Available.Add(obligation.Available, obligation.SenderBalance)
+		}
+		if oopt.Treasury != "" {
+			obligation.TreasuryBalance, err = getObligationBalance(sum.RewardToken, oopt.Treasury)
+			if err != nil {
+				return nil, err
+			}
+			obligation.Available.Add(obligation.Available, obligation.TreasuryBalance)
+		}
+		if obligation.Available.Cmp(obligation.Accrued) < 0 {
+			obligation.Shortfall.Sub(obligation.Accrued, obligation.Available)
+		}
+		obligations = append(obligations, obligation)
+	}
+	sort.Slice(obligations, func(i, j int) bool {
+		return obligations[i].RewardToken < obligations[j].RewardToken
+	})
+	return obligations, nil
+}
+
+// reward token of empty or zero address means native coin
+func getObligationBalance(rewardToken, address string) (balance *big.Int, err error) {
+	account := common.HexToAddress(address)
+	token := common.HexToAddress(rewardToken)
+	if token == (common.Address{}) {
+		balance, err = capi.GetCoinBalance(account, nil)
+	} else {
+		balance, err = capi.GetTokenBalance(token, account, nil)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get balance of %v (token %v) failed. %v", address, rewardToken, err)
+	}
+	return balance, nil
+}
+
+// PrintObligations print accrued but not sended rewards and whether they are funded
+func PrintObligations(oopt *ObligationsOption, obligations []*Obligation) (underfunded int) {
+	if len(obligations) == 0 {
+		log.Info("no accrued but not sended rewards")
+		return 0
+	}
+	for _, o := range obligations {
+		ctx := []interface{}{"rewardToken", o.RewardToken, "records", o.Records, "accrued", o.Accrued}
+		if oopt.Sender != "" {
+			ctx = append(ctx, "sender", oopt.Sender, "senderBalance", o.SenderBalance)
+		}
+		if oopt.Treasury != "" {
+			ctx = append(ctx, "treasury", oopt.Treasury, "treasuryBalance", o.TreasuryBalance)
+		}
+		ctx = append(ctx, "available", o.Available, "shortfall", o.Shortfall, "underfunded", o.IsUnderfunded())
+		log.Info("accrued but not sended rewards", ctx...)
+		if o.IsUnderfunded() {
+			underfunded++
+		}
+	}
+	return underfunded
+}
@@ -1,11 +1,11 @@
 package distributer
 
 import (
-	"bufio"
 	"fmt"
 	"io"
 	"math/big"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -37,9 +37,112 @@ type Option struct {
 
 	WeightIsPercentage bool
 
+	// write output to stdout as well as output files
+	OutputToStdout bool `json:",omitempty"`
+
+	// write skipped and failed recipients to <output>.skipped and <output>.failed
+	SplitOutput bool `json:",omitempty"`
+
+	// add skip reason column to <output>.skipped, implies SplitOutput
+	SkipReasons bool `json:",omitempty"`
+
+	// write merkle root and tree of (account, reward, txhash) results to <output>.merkle.json
+	MerkleOutput bool `json:",omitempty"`
+
+	// sign output files with sender key, detached signature is written to <output>.sig
+	SignOutput bool `json:",omitempty"`
+
+	// output format: legacy (default), csv, json, jsonl
+	OutputFormat string `json:",omitempty"`
+
+	// write nonce and gas price actually used by every transfer to output
+	VerboseOutput bool `json:",omitempty"`
+
+	// save structured replay log of sending run to this file
+	ReplayLogFile string `json:",omitempty"`
+
+	// time weighted liquidity by sampling every SampleInterval blocks in archive mode,
+	// range is splitted into segments and calculated by SnapshotWorkers concurrently
+	SampleInterval  uint64 `json:",omitempty"`
+	SnapshotWorkers int    `json:",omitempty"`
+
+	// average liquidity over these discrete snapshot heights in archive mode
+	SnapshotHeights []uint64 `json:",omitempty"`
+
+	// distribute one pool by combined weighted liquidity of all exchanges,
+	// instead of splitting it into a pool of every exchange
+	CombineExchanges bool `json:",omitempty"`
+
+	// output hash of the block every liquidity balance is read at after the height column,
+	// so the shares can be re-read and rewards reproduced independently
+	SnapshotProvenance bool `json:",omitempty"`
+
+	// recipients of zero address and burn addresses abort sending,
+	// or are skipped if SkipBurnAddresses is true
+	BurnAddresses     []string `json:",omitempty"`
+	SkipBurnAddresses bool     `json:",omitempty"`
+
+	// recipients in blocklist (file or URL) abort sending,
+	// or are skipped if SkipBlocklisted is true
+	BlocklistFile   string `json:",omitempty"`
+	SkipBlocklisted bool   `json:",omitempty"`
+
+	// recipients which are ineligible by calling EligibilitySelector(address)
+	// of EligibilityContract abort sending, or are skipped if SkipIneligible is true
+	EligibilityContract string `json:",omitempty"`
+	EligibilitySelector string `json:",omitempty"`
+	SkipIneligible      bool   `json:",omitempty"`
+
+	// every exchange must belong to this factory (by calling exchange's factoryAddress())
+	ExpectedFactory string `json:",omitempty"`
+
+	// read liquidity balances from prefetched cache file instead of node
+	BalanceCacheFile string `json:",omitempty"`
+
 	BatchCount    uint64
 	BatchInterval uint64
 
+	// adjust send delay according to observed mempool pressure
+	AdaptiveThrottle bool `json:",omitempty"`
+
+	// refuse to send unless at least MinClients clients are healthy,
+	// and pause sending when healthy clients drop below it
+	MinClients int `json:",omitempty"`
+
+	// warn if total reward of an input file exceeds MaxSupplyPercent (0 means no check)
+	// of reward token's total supply, or abort if AbortOnSupplyRatio is true
+	MaxSupplyPercent   float64 `json:",omitempty"`
+	AbortOnSupplyRatio bool    `json:",omitempty"`
+
+	// probe reward token by calling PausedSelector() and BlacklistSelector(sender)
+	// returning bool, abort if token is paused or sender is blacklisted
+	PausedSelector    string `json:",omitempty"`
+	BlacklistSelector string `json:",omitempty"`
+
+	// after sending, sweep remaining native coin balance of sender
+	// (minus SweepReserve and gas of the sweep tx) to SweepTo
+	SweepTo      string   `json:",omitempty"`
+	SweepReserve *big.Int `json:",omitempty"`
+
+	// hold lock file '<SenderLockDir>/<sender>.lock' during sending, so runs sharing
+	// a sender do not conflict on nonces. wait up to SenderLockWait on contention
+	SenderLockDir  string        `json:",omitempty"`
+	SenderLockWait time.Duration `json:",omitempty"`
+
+	// present summary and ask to confirm after warm up checks
+	ConfirmBeforeSend bool `json:",omitempty"`
+
+	// wait every transfer confirmed successfully before sending the next one,
+	// and abort on the first failed transfer. confirmations are required by
+	// Confirm policy (at least 1) within ConfirmTimeout
+	VerifyAfterEach bool           `json:",omitempty"`
+	Confirm         *ConfirmPolicy `json:",omitempty"`
+	ConfirmTimeout  time.Duration  `json:",omitempty"`
+
+	// continue with the rest of the recipients when a send failed, sends which are
+	// proved not broadcast (build, sign, nonce too low) are retried once at the end of the file
+	ContinueOnError bool `json:",omitempty"`
+
 	// if use time measurement,
 	// then StartHeight/EndHeight are unix timestamp,
 	// and StableHeight/StepCount are time duration of seconds.
@@ -49,6 +152,33 @@ type Option struct {
 	ScalingNumerator   *big.Int
 	ScalingDenominator *big.Int
 
+	// reward column of input files is weight in basis points of TotalPool
+	InputWeights bool     `json:",omitempty"`
+	TotalPool    *big.Int `json:",omitempty"`
+
+	// expected total reward of every input file, abort if computed total
+	// differs by more than AssertTolerance base units
+	AssertTotals    []*big.Int `json:",omitempty"`
+	AssertTolerance *big.Int   `json:",omitempty"`
+
+	// expected canonical commitment of (account, amount) pairs of every input file,
+	// abort if input file is altered after approval (see CalcRewardsCommitment)
+	ExpectedCommitments []common.Hash `json:",omitempty"`
+
+	// abort if any recipient appears more than once in an input file
+	RequireUnique bool `json:",omitempty"`
+
+	// how to handle recipient already paid for a different amount than input
+	// (see checkPaid), one of skip (default), difference, abort
+	PaidMismatchPolicy string `json:",omitempty"`
+
+	// write results of warm up (pre-flight) checks to this file as json
+	PreflightReportFile string `json:",omitempty"`
+
+	// re-probe token state and re-simulate the next transfer every RevalidateInterval sends,
+	// abort if reward token behaves differently since pre-flight (0 means never)
+	RevalidateInterval uint64 `json:",omitempty"`
+
 	byWhat    string
 	noVolumes uint64
 
@@ -56,6 +186,21 @@ type Option struct {
 	noVolumeStartHeights []uint64
 
 	outputFiles []*os.File
+
+	replayLog    *ReplayLog
+	runSummary   *runSummary
+	balanceCache *BalanceCache
+	throttle     *adaptiveThrottle
+	blocklist    map[common.Address]struct{}
+	warmUpReport *warmUpReport
+	skippedStats []*skippedStat
+	paidTotals   map[string]*big.Int // paid total after paying difference, keyed by idempotency key
+
+	sendsSinceRevalidate uint64
+
+	senderLockFile string
+
+	lastClientsCheck time.Time
 }
 
 // ByWhat distribute by what method
@@ -125,6 +270,24 @@ func (opt *Option) deinit() {
 
 // CheckBasic check option basic
 func (opt *Option) CheckBasic() error {
+	if !IsValidOutputFormat(opt.OutputFormat) {
+		return fmt.Errorf("[check option] unknown output format '%v'", opt.OutputFormat)
+	}
+	if !IsValidPaidMismatchPolicy(opt.PaidMismatchPolicy) {
+		return fmt.Errorf("[check option] unknown paid mismatch policy '%v'", opt.PaidMismatchPolicy)
+	}
+	if err := opt.checkInputOutputFiles(); err != nil {
+		return err
+	}
+	if opt.SignOutput && opt.DryRun {
+		return fmt.Errorf("[check option] sign output requires sender key, not supported in dry run")
+	}
+	if opt.ExpectedFactory != "" && !common.IsHexAddress(opt.ExpectedFactory) {
+		return fmt.Errorf("[check option] wrong expected factory: '%v'", opt.ExpectedFactory)
+	}
+	if opt.SweepTo != "" && !common.IsHexAddress(opt.SweepTo) {
+		return fmt.Errorf("[check option] wrong sweep address: '%v'", opt.SweepTo)
+	}
 	if opt.byWhat == customMethodID {
 		if opt.RewardToken != "" && !common.IsHexAddress(opt.RewardToken) {
 			return fmt.Errorf("[check option] wrong reward token: '%v'", opt.RewardToken)
@@ -151,12 +314,56 @@ func (opt *Option) CheckBasic() error {
 	if !common.IsHexAddress(opt.RewardToken) {
 		return fmt.Errorf("[check option] wrong reward token: '%v'", opt.RewardToken)
 	}
+	if opt.SampleInterval > 0 && !opt.ArchiveMode {
+		return fmt.Errorf("[check option] time weighted liquidity sampling requires archive mode")
+	}
+	if err := opt.checkSnapshotHeights(); err != nil {
+		return err
+	}
 	if opt.ScalingDenominator != nil && opt.ScalingDenominator.Sign() == 0 {
 		return fmt.Errorf("[check option] scaling denominator is zero (divided by zero)")
 	}
 	return nil
 }
 
+// checkInputOutputFiles output file must not be any input file
+func (opt *Option) checkInputOutputFiles() error {
+	for _, ofile := range opt.OutputFiles {
+		if ofile == "" {
+			continue
+		}
+		outPath := resolveFilePath(ofile)
+		outInfo, _ := os.Stat(ofile)
+		for _, ifile := range opt.InputFiles {
+			if ifile == "" {
+				continue
+			}
+			inPath := resolveFilePath(ifile)
+			isSame := inPath == outPath
+			if !isSame && outInfo != nil {
+				if inInfo, err := os.Stat(ifile); err == nil {
+					isSame = os.SameFile(inInfo, outInfo)
+				}
+			}
+			if isSame {
+				return fmt.Errorf("[check option] output file '%v' (%v) is the same as input file '%v' (%v)", ofile, outPath, ifile, inPath)
+			}
+		}
+	}
+	return nil
+}
+
+func resolveFilePath(fileName string) string {
+	path, err := filepath.Abs(fileName)
+	if err != nil {
+		return filepath.Clean(fileName)
+	}
+	if realPath, err := filepath.EvalSymlinks(path); err == nil {
+		return realPath
+	}
+	return path
+}
+
 func (opt *Option) checkWeights() error {
 	if opt.byWhat == customMethodID {
 		return nil
@@ -211,6 +418,22 @@ func (opt *Option) checkAndInit() (err error) {
 	if err != nil {
 		return err
 	}
+	err = opt.checkCombineExchanges()
+	if err != nil {
+		return err
+	}
+	err = opt.checkSnapshotProvenance()
+	if err != nil {
+		return err
+	}
+	_, err = opt.checkExchangeFactory()
+	if err != nil {
+		return err
+	}
+	_, err = opt.checkTokenState()
+	if err != nil {
+		return err
+	}
 	err = opt.CheckSenderRewardTokenBalance()
 	if err != nil {
 		return err
@@ -275,10 +498,18 @@ func (opt *Option) openOutputFile(i int) (err error) {
 	return err
 }
 
-func openOutputFile(fileName string) (io.Writer, error) {
+func openOutputFile(fileName string) (*os.File, error) {
 	return os.OpenFile(fileName, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
 }
 
+// newOutputWriter tee output to stdout if specified
+func (opt *Option) newOutputWriter(file *os.File) io.Writer {
+	if opt.OutputToStdout {
+		return io.MultiWriter(file, os.Stdout)
+	}
+	return file
+}
+
 // WriteOutputLine write output line, will append '\n' automatically
 func WriteOutputLine(ofile io.Writer, msg string) error {
 	_, err := ofile.Write([]byte(msg + "\n"))
@@ -315,40 +546,29 @@ func WriteLiquidityBalance(account common.Address, value *big.Int, height uint64
 }
 
 // WriteSendRewardResult write send reward result
-func (opt *Option) WriteSendRewardResult(ofile io.Writer, exchange string, stat *mongodb.AccountStat, txHash *common.Hash) (err error) {
-	account := stat.Account
-	reward := stat.Reward
-	share := stat.Share
-	number := stat.Number
-
-	accoutStr := strings.ToLower(account.String())
-	rewardStr := reward.String()
-	numStr := fmt.Sprintf("%d", number)
-
-	var shareStr, hashStr string
-	if share != nil {
-		shareStr = share.String()
-	}
-	if txHash != nil {
-		hashStr = txHash.Hex()
+func (opt *Option) WriteSendRewardResult(writer ResultWriter, exchange string, stat *mongodb.AccountStat, txHash *common.Hash) (err error) {
+	result := newRewardResult(stat, txHash)
+	if opt.VerboseOutput && txHash != nil {
+		if sent := opt.BuildTxArgs.lastSentTx; sent != nil {
+			nonce := sent.nonce
+			result.Nonce = &nonce
+			result.GasPrice = sent.gasPrice
+			if sent.effectiveGasPrice != nil {
+				gasUsed := sent.gasUsed
+				result.GasUsed = &gasUsed
+				result.EffectiveGasPrice = sent.effectiveGasPrice
+			}
+		}
 	}
 
 	// write output beofre write database
-	if txHash == nil {
-		if share != nil {
-			err = WriteOutput(ofile, accoutStr, rewardStr, shareStr, numStr)
-		} else {
-			err = WriteOutput(ofile, accoutStr, rewardStr)
-		}
-	} else {
-		if share != nil {
-			err = WriteOutput(ofile, accoutStr, rewardStr, shareStr, numStr, hashStr)
-		} else {
-			err = WriteOutput(ofile, accoutStr, rewardStr, hashStr)
-		}
-	}
+	err = writer.WriteResult(result)
 
-	opt.WriteRewardResultToDB(exchange, accoutStr, rewardStr, shareStr, number, hashStr)
+	var shareStr string
+	if result.Share != nil {
+		shareStr = result.Share.String()
+	}
+	opt.WriteRewardResultToDB(exchange, result.Account, result.Reward.String(), shareStr, stat.Number, result.TxHash)
 
 	return err
 }
@@ -374,6 +594,7 @@ func (opt *Option) WriteRewardResultToDB(exchange, accoutStr, rewardStr, shareSt
 			Volume:      shareStr,
 			TxCount:     number,
 			RewardTx:    hashStr,
+			RunID:       opt.RunID(),
 			Timestamp:   uint64(time.Now().Unix()),
 		}
 		_ = mongodb.TryDoTimes("AddVolumeRewardResult "+mr.Key, func() error {
@@ -392,6 +613,7 @@ func (opt *Option) WriteRewardResultToDB(exchange, accoutStr, rewardStr, shareSt
 			Liquidity:   shareStr,
 			Height:      number,
 			RewardTx:    hashStr,
+			RunID:       opt.RunID(),
 			Timestamp:   uint64(time.Now().Unix()),
 		}
 		_ = mongodb.TryDoTimes("AddLiquidRewardResult "+mr.Key, func() error {
@@ -417,8 +639,52 @@ func (opt *Option) WriteNoVolumeSummary() {
 
 // SendRewardsTransaction send rewards
 func (opt *Option) SendRewardsTransaction(account common.Address, reward *big.Int) (txHash *common.Hash, err error) {
+	return opt.SendRewardsTransactionWithGas(account, reward, nil, nil)
+}
+
+// SendRewardsTransactionWithGas send rewards with gas limit and gas price overrides (nil means use default)
+func (opt *Option) SendRewardsTransactionWithGas(account common.Address, reward *big.Int, gasLimit *uint64, gasPrice *big.Int) (txHash *common.Hash, err error) {
+	if opt.AdaptiveThrottle && !opt.DryRun {
+		if opt.throttle == nil {
+			opt.throttle = newAdaptiveThrottle()
+		}
+		opt.throttle.wait()
+	}
 	rewardToken := common.HexToAddress(opt.RewardToken)
-	return opt.BuildTxArgs.sendRewardsTransaction(account, reward, rewardToken, opt.DryRun)
+	return opt.BuildTxArgs.sendRewardsTransaction(account, reward, rewardToken, opt.DryRun, gasLimit, gasPrice)
+}
+
+// checkSenderFunded check sender is not a completely unfunded account
+// (no reward token and no native coin), which is most likely a wrong sender.
+func (opt *Option) checkSenderFunded() error {
+	sender := opt.BuildTxArgs.fromAddr
+	getBalance := func(token string) (balance *big.Int, err error) {
+		err = capi.LoopRetry(func() (errf error) {
+			if token == "" {
+				balance, errf = capi.GetCoinBalance(sender, nil)
+			} else {
+				balance, errf = capi.GetTokenBalance(common.HexToAddress(token), sender, nil)
+			}
+			return errf
+		}, capi.CheckErrorBudget)
+		return balance, err
+	}
+	coinBalance, err := getBalance("")
+	if err != nil || coinBalance.Sign() > 0 {
+		return err
+	}
+	if opt.RewardToken != "" {
+		tokenBalance, errt := getBalance(opt.RewardToken)
+		if errt != nil || tokenBalance.Sign() > 0 {
+			return errt
+		}
+	}
+	err = fmt.Errorf("[check option] sender %v has no reward token and no native coin at all, please check if the keystore or sender is the wrong account", sender.String())
+	if opt.DryRun {
+		log.Warn("[check option] sender is unfunded, but ignore in dry run", "err", err)
+		return nil
+	}
+	return err
 }
 
 // CheckSenderRewardTokenBalance check token balance
@@ -426,24 +692,26 @@ func (opt *Option) CheckSenderRewardTokenBalance() (err error) {
 	if opt.RewardToken == "" {
 		return fmt.Errorf("[check option] check token balance with empty reward token")
 	}
+	if err = opt.checkSenderFunded(); err != nil {
+		return err
+	}
 	sender := opt.BuildTxArgs.fromAddr
 	rewardTokenAddr := common.HexToAddress(opt.RewardToken)
 	var senderTokenBalance *big.Int
-	for {
-		senderTokenBalance, err = capi.GetTokenBalance(rewardTokenAddr, sender, nil)
-		if err != nil {
-			time.Sleep(time.Second)
-			continue
-		}
-		if senderTokenBalance.Cmp(opt.TotalValue) < 0 {
-			err = fmt.Errorf("[check option] not enough reward token balance, %v < %v, sender: %v token: %v", senderTokenBalance, opt.TotalValue, sender.String(), opt.RewardToken)
-			if opt.DryRun {
-				log.Warn("[check option] check sender reward token balance failed, but ignore in dry run", "err", err)
-				return nil // only warn not enough balance in dry run
-			}
-			return err
+	err = capi.LoopRetry(func() (errf error) {
+		senderTokenBalance, errf = capi.GetTokenBalance(rewardTokenAddr, sender, nil)
+		return errf
+	}, capi.CheckErrorBudget)
+	if err != nil {
+		return err
+	}
+	if senderTokenBalance.Cmp(opt.TotalValue) < 0 {
+		err = fmt.Errorf("[check option] not enough reward token balance, %v < %v, sender: %v token: %v", senderTokenBalance, opt.TotalValue, sender.String(), opt.RewardToken)
+		if opt.DryRun {
+			log.Warn("[check option] check sender reward token balance failed, but ignore in dry run", "err", err)
+			return nil // only warn not enough balance in dry run
 		}
-		break
+		return err
 	}
 	log.Info("sender reward token balance is enough", "sender", sender.String(), "token", rewardTokenAddr.String(), "balance", senderTokenBalance, "needed", opt.TotalValue)
 
@@ -461,23 +729,25 @@ func (opt *Option) CheckSenderCoinBalance() (err error) {
 	if opt.RewardToken != "" {
 		return fmt.Errorf("[check option] check coin balance with nonempty reward token %v", opt.RewardToken)
 	}
+	if err = opt.checkSenderFunded(); err != nil {
+		return err
+	}
 	sender := opt.BuildTxArgs.fromAddr
 	var senderBalance *big.Int
-	for {
-		senderBalance, err = capi.GetCoinBalance(sender, nil)
-		if err != nil {
-			time.Sleep(time.Second)
-			continue
-		}
-		if senderBalance.Cmp(opt.TotalValue) < 0 {
-			err = fmt.Errorf("[check option] not enough coin balance, %v < %v, sender: %v", senderBalance, opt.TotalValue, sender.String())
-			if opt.DryRun {
-				log.Warn("[check option] check sender coin balance failed, but ignore in dry run", "err", err)
-				return nil // only warn not enough balance in dry run
-			}
-			return err
+	err = capi.LoopRetry(func() (errf error) {
+		senderBalance, errf = capi.GetCoinBalance(sender, nil)
+		return errf
+	}, capi.CheckErrorBudget)
+	if err != nil {
+		return err
+	}
+	if senderBalance.Cmp(opt.TotalValue) < 0 {
+		err = fmt.Errorf("[check option] not enough coin balance, %v < %v, sender: %v", senderBalance, opt.TotalValue, sender.String())
+		if opt.DryRun {
+			log.Warn("[check option] check sender coin balance failed, but ignore in dry run", "err", err)
+			return nil // only warn not enough balance in dry run
 		}
-		break
+		return err
 	}
 	log.Info("sender coin balance is enough", "sender", sender.String(), "balance", senderBalance, "needed", opt.TotalValue)
 	return nil
@@ -512,7 +782,7 @@ func getAccountsFromFile(ifile string) (accounts []common.Address, err error) {
 	}
 	defer file.Close()
 
-	reader := bufio.NewReader(file)
+	reader := newInputReader(file)
 	for {
 		lineData, _, errf := reader.ReadLine()
 		if errf == io.EOF {
@@ -547,9 +817,14 @@ func (opt *Option) getInputFileName(i int) string {
 	return ""
 }
 
-func (opt *Option) getOutputFile(i int) (io.Writer, error) {
+func (opt *Option) getOutputFile(i int) (ResultWriter, error) {
 	err := opt.openOutputFile(i)
-	return opt.outputFiles[i], err
+	if err != nil {
+		return nil, err
+	}
+	file := opt.outputFiles[i]
+	writer := opt.wrapMerkleResultWriter(opt.newResultWriter(opt.newOutputWriter(file)), file.Name())
+	return opt.wrapSignResultWriter(writer, file.Name()), nil
 }
 
 // GetAccountsAndRewards get from file if input file exist, or else from database
@@ -565,6 +840,15 @@ func (opt *Option) GetAccountsAndRewards() (accountStats []mongodb.AccountStatSl
 			if err != nil {
 				return nil, err
 			}
+			stats = nonZeroRewards(stats)
+			stats, err = opt.checkBurnAddresses(stats)
+			if err != nil {
+				return nil, err
+			}
+			stats, err = opt.checkBlocklist(stats)
+			if err != nil {
+				return nil, err
+			}
 		}
 		accountStats[i] = stats
 	}
@@ -701,7 +985,9 @@ func calcAddPercentOfStaking(stakeWholeAmount uint64) (percent uint64) {
 	return percent
 }
 
-// GetAccountsAndRewardsFromFile pass line format "<address> <amount>" from input file
+// GetAccountsAndRewardsFromFile pass line format "<address> <amount>" from input file,
+// line can be ended with optional per recipient "gasLimit=<value>" and "gasPrice=<value>".
+// lines of zero reward are kept, so they can be recorded as skipped (see nonZeroRewards)
 func GetAccountsAndRewardsFromFile(ifile string) (accountStats mongodb.AccountStatSlice, titleLine string, err error) {
 	file, err := os.Open(ifile)
 	if err != nil {
@@ -711,8 +997,9 @@ func GetAccountsAndRewardsFromFile(ifile string) (accountStats mongodb.AccountSt
 
 	accountStats = make(mongodb.AccountStatSlice, 0)
 
-	reader := bufio.NewReader(file)
+	reader := newInputReader(file)
 	isFirstLine := true
+	hasBlockHash := false
 
 	for {
 		lineData, _, errf := reader.ReadLine()
@@ -723,12 +1010,18 @@ func GetAccountsAndRewardsFromFile(ifile string) (accountStats mongodb.AccountSt
 		if isCommentedLine(line) {
 			if isFirstLine {
 				titleLine = line
+				hasBlockHash = parseTitleLine(titleLine, false).HasBlockHash
 			}
 			isFirstLine = false
 			continue
 		}
 		isFirstLine = false
-		parts := blankOrCommaSepRegexp.Split(line, -1)
+		parts, idempotencyKey := parseIdempotencyKey(blankOrCommaSepRegexp.Split(line, -1))
+		parts, chain := parseChainTag(parts)
+		parts, gasLimit, gasPrice, err := parseGasOverrides(parts)
+		if err != nil {
+			return nil, "", fmt.Errorf("wrong gas override in line %v, err=%v", line, err)
+		}
 		if len(parts) < 2 {
 			return nil, "", fmt.Errorf("less than 2 parts in line %v", line)
 		}
@@ -738,7 +1031,8 @@ func GetAccountsAndRewardsFromFile(ifile string) (accountStats mongodb.AccountSt
 			return nil, "", fmt.Errorf("wrong address in line %v", line)
 		}
 		account := common.HexToAddress(accountStr)
-		if params.IsExcludedRewardAccount(account) {
+		// zero address is left to burn addresses check
+		if account != (common.Address{}) && params.IsExcludedRewardAccount(account) {
 			log.Warn("ignore excluded account", "account", accountStr)
 			continue
 		}
@@ -749,14 +1043,16 @@ func GetAccountsAndRewardsFromFile(ifile string) (accountStats mongodb.AccountSt
 		if err != nil {
 			return nil, "", fmt.Errorf("wrong reward in line %v, err=%v", line, err)
 		}
-		if reward.Sign() <= 0 {
-			continue
-		}
 		stat := &mongodb.AccountStat{
-			Account: account,
-			Reward:  reward,
+			Account:  account,
+			Reward:   reward,
+			GasLimit: gasLimit,
+			GasPrice: gasPrice,
+
+			IdempotencyKey: idempotencyKey,
+			Chain:          chain,
 		}
-		if len(parts) >= 4 {
+		if len(parts) >= 4 && !isTxHashString(parts[2]) {
 			shareStr := parts[2]
 			numberStr := parts[3]
 			share, err := tools.GetBigIntFromString(shareStr)
@@ -769,6 +1065,10 @@ func GetAccountsAndRewardsFromFile(ifile string) (accountStats mongodb.AccountSt
 			}
 			stat.Share = share
 			stat.Number = number.Uint64()
+			if hasBlockHash && len(parts) >= 5 && isTxHashString(parts[4]) {
+				blockHash := common.HexToHash(parts[4])
+				stat.BlockHash = &blockHash
+			}
 		}
 		accountStats = append(accountStats, stat)
 	}
@@ -776,6 +1076,41 @@ func GetAccountsAndRewardsFromFile(ifile string) (accountStats mongodb.AccountSt
 	return accountStats, titleLine, nil
 }
 
+// parseGasOverrides extract "gasLimit=<value>" and "gasPrice=<value>" from line parts
+func parseGasOverrides(parts []string) (rest []string, gasLimit *uint64, gasPrice *big.Int, err error) {
+	rest = make([]string, 0, len(parts))
+	for _, part := range parts {
+		pos := strings.Index(part, "=")
+		if pos < 0 {
+			rest = append(rest, part)
+			continue
+		}
+		value, err := tools.GetBigIntFromString(part[pos+1:])
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if value.Sign() <= 0 {
+			return nil, nil, nil, fmt.Errorf("nonpositive value in '%v'", part)
+		}
+		switch part[:pos] {
+		case "gasLimit":
+			if !value.IsUint64() {
+				return nil, nil, nil, fmt.Errorf("gas limit overflow in '%v'", part)
+			}
+			limit := value.Uint64()
+			if limit < minTxGasLimit {
+				return nil, nil, nil, fmt.Errorf("gas limit lower than intrinsic gas %v in '%v'", minTxGasLimit, part)
+			}
+			gasLimit = &limit
+		case "gasPrice":
+			gasPrice = value
+		default:
+			return nil, nil, nil, fmt.Errorf("unknown key in '%v'", part)
+		}
+	}
+	return rest, gasLimit, gasPrice, nil
+}
+
 // GetAccountsAndShares get accounts and shares
 func (opt *Option) GetAccountsAndShares() (accountStats []mongodb.AccountStatSlice, err error) {
 	if len(opt.InputFiles) == 0 {
@@ -806,7 +1141,7 @@ func GetAccountsAndSharesFromFile(ifile string, sampleHeight uint64) (accountSta
 
 	accountStats = make(mongodb.AccountStatSlice, 0)
 
-	reader := bufio.NewReader(file)
+	reader := newInputReader(file)
 
 	for {
 		lineData, _, errf := reader.ReadLine()
@@ -827,7 +1162,8 @@ func GetAccountsAndSharesFromFile(ifile string, sampleHeight uint64) (accountSta
 			return nil, fmt.Errorf("wrong address in line %v", line)
 		}
 		account := common.HexToAddress(accountStr)
-		if params.IsExcludedRewardAccount(account) {
+		// zero address is left to burn addresses check
+		if account != (common.Address{}) && params.IsExcludedRewardAccount(account) {
 			log.Warn("ignore excluded account", "account", accountStr)
 			continue
 		}
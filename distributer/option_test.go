@@ -0,0 +1,93 @@
+package distributer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/anyswap/ANYToken-distribution/mongodb"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+func writeTempInput(t *testing.T, name, content string) string {
+	dir, err := ioutil.TempDir("", "distributer-input")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	file := filepath.Join(dir, name)
+	if err = ioutil.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestGetAccountsAndRewardsFromFileLineEndings(t *testing.T) {
+	const (
+		account1 = "0x1111111111111111111111111111111111111111"
+		account2 = "0x2222222222222222222222222222222222222222"
+		bom      = "\xef\xbb\xbf"
+	)
+	tests := []struct {
+		name      string
+		content   string
+		wantTitle string
+	}{
+		{name: "lf", content: "#title\n" + account1 + " 100\n" + account2 + " 200\n", wantTitle: "#title"},
+		{name: "crlf", content: "#title\r\n" + account1 + " 100\r\n" + account2 + " 200\r\n", wantTitle: "#title"},
+		{name: "no trailing newline", content: "#title\n" + account1 + " 100\n" + account2 + " 200", wantTitle: "#title"},
+		{name: "bom before title", content: bom + "#title\n" + account1 + " 100\n" + account2 + " 200\n", wantTitle: "#title"},
+		{name: "bom before record", content: bom + account1 + " 100\n" + account2 + " 200\n"},
+		{name: "bom crlf no trailing newline", content: bom + "#title\r\n" + account1 + " 100\r\n" + account2 + " 200", wantTitle: "#title"},
+	}
+	for _, test := range tests {
+		file := writeTempInput(t, "input.txt", test.content)
+		stats, title, err := GetAccountsAndRewardsFromFile(file)
+		if err != nil {
+			t.Errorf("%v: unexpected error %v", test.name, err)
+			continue
+		}
+		if title != test.wantTitle {
+			t.Errorf("%v: got title %q, want %q", test.name, title, test.wantTitle)
+		}
+		if len(stats) != 2 {
+			t.Errorf("%v: got %v records, want 2", test.name, len(stats))
+			continue
+		}
+		if stats[0].Account != common.HexToAddress(account1) || stats[0].Reward.Int64() != 100 ||
+			stats[1].Account != common.HexToAddress(account2) || stats[1].Reward.Int64() != 200 {
+			t.Errorf("%v: wrong records %v %v, %v %v", test.name,
+				stats[0].Account.String(), stats[0].Reward, stats[1].Account.String(), stats[1].Reward)
+		}
+	}
+}
+
+func TestZeroRewardLinesAreSkipped(t *testing.T) {
+	const (
+		account1 = "0x1111111111111111111111111111111111111111"
+		account2 = "0x2222222222222222222222222222222222222222"
+		account3 = "0x3333333333333333333333333333333333333333"
+	)
+	file := writeTempInput(t, "input.txt", account1+" 100\n"+account2+" 0\n"+account3+" 300\n")
+	stats, _, err := GetAccountsAndRewardsFromFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 3 {
+		t.Fatalf("zero reward line should be kept, got %v records", len(stats))
+	}
+	if CalcRewardsCommitment(stats) != CalcRewardsCommitment(mongodb.AccountStatSlice{stats[0], stats[2]}) {
+		t.Fatal("zero reward line should not be committed")
+	}
+
+	opt := &Option{SplitOutput: true}
+	stats = opt.skipZeroRewards(stats)
+	if len(stats) != 2 || stats[0].Account != common.HexToAddress(account1) || stats[1].Account != common.HexToAddress(account3) {
+		t.Fatalf("zero reward line should be removed, got %v records", len(stats))
+	}
+	skipped := opt.takeSkipped()
+	if len(skipped) != 1 || skipped[0].stat.Account != common.HexToAddress(account2) || skipped[0].reason != SkipReasonZero {
+		t.Fatalf("zero reward line should be recorded as skipped with reason %v, got %v", SkipReasonZero, skipped)
+	}
+}
@@ -0,0 +1,337 @@
+package distributer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/anyswap/ANYToken-distribution/mongodb"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+// output formats
+const (
+	OutputFormatLegacy = "legacy"
+	OutputFormatCSV    = "csv"
+	OutputFormatJSON   = "json"
+	OutputFormatJSONL  = "jsonl"
+)
+
+// IsValidOutputFormat is valid output format
+func IsValidOutputFormat(format string) bool {
+	switch format {
+	case "", OutputFormatLegacy, OutputFormatCSV, OutputFormatJSON, OutputFormatJSONL:
+		return true
+	default:
+		return false
+	}
+}
+
+const (
+	skipReasonColumn = "skipReason"
+	blockHashColumn  = "blockHash"
+)
+
+// ResultTitle title of send reward results
+type ResultTitle struct {
+	Columns   []string // account,reward[,share,number]
+	ExtraInfo string   `json:",omitempty"`
+	HasTxHash bool
+	HasTxInfo bool `json:",omitempty"` // nonce,gasPrice columns after txhash
+
+	HasBlockHash bool `json:",omitempty"` // blockHash column after number (snapshot provenance)
+
+	HasSkipReason bool `json:",omitempty"` // skipReason column of skipped output
+}
+
+// RewardResult send reward result
+type RewardResult struct {
+	Account string
+	Reward  *big.Int
+	Share   *big.Int `json:",omitempty"`
+	Number  uint64   `json:",omitempty"`
+	TxHash  string   `json:",omitempty"`
+
+	// hash of block at height Number the share is read at
+	BlockHash string `json:",omitempty"`
+
+	// verbose output of sent transaction
+	Nonce    *uint64  `json:",omitempty"`
+	GasPrice *big.Int `json:",omitempty"`
+
+	// verbose output of confirmed transaction (json formats only)
+	GasUsed           *uint64  `json:",omitempty"`
+	EffectiveGasPrice *big.Int `json:",omitempty"`
+
+	SkipReason SkipReason `json:",omitempty"`
+
+	line string // original line of output file it's parsed from
+}
+
+// ResultWriter write send reward results in specified format
+type ResultWriter interface {
+	WriteTitle(title *ResultTitle) error
+	WriteResult(result *RewardResult) error
+	Flush() error
+}
+
+func (opt *Option) newResultWriter(w io.Writer) ResultWriter {
+	switch opt.OutputFormat {
+	case OutputFormatCSV:
+		return &csvResultWriter{writer: csv.NewWriter(w)}
+	case OutputFormatJSON:
+		return &jsonResultWriter{writer: w}
+	case OutputFormatJSONL:
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		return &jsonlResultWriter{encoder: encoder}
+	default:
+		return &legacyResultWriter{writer: w}
+	}
+}
+
+func newRewardResult(stat *mongodb.AccountStat, txHash *common.Hash) *RewardResult {
+	result := &RewardResult{
+		Account: strings.ToLower(stat.Account.String()),
+		Reward:  stat.Reward,
+	}
+	if stat.Share != nil {
+		result.Share = stat.Share
+		result.Number = stat.Number
+		if stat.BlockHash != nil {
+			result.BlockHash = stat.BlockHash.Hex()
+		}
+	}
+	if txHash != nil {
+		result.TxHash = txHash.Hex()
+	}
+	return result
+}
+
+// parseTitleLine parse title line of input file.
+// format: #account,reward[,share,number[,blockHash]][,txhash[,nonce,gasPrice]][,extraInfo]
+func parseTitleLine(titleLine string, hasTxHash bool) *ResultTitle {
+	title := &ResultTitle{HasTxHash: hasTxHash}
+	if titleLine == "" {
+		return title
+	}
+	parts := strings.Split(strings.TrimPrefix(titleLine, "#"), ",")
+	for i, part := range parts {
+		if i == len(parts)-1 && strings.Contains(part, "=") {
+			title.ExtraInfo = part
+			break
+		}
+		if part == blockHashColumn {
+			title.HasBlockHash = true
+			continue
+		}
+		if isTxColumn(part) || part == skipReasonColumn {
+			continue
+		}
+		title.Columns = append(title.Columns, part)
+	}
+	return title
+}
+
+func isTxColumn(column string) bool {
+	switch column {
+	case "txhash", "nonce", "gasPrice":
+		return true
+	default:
+		return false
+	}
+}
+
+// legacyResultWriter terse format: comma separated without quoting,
+// title line is prefixed by '#' and ended with extra info
+type legacyResultWriter struct {
+	writer io.Writer
+}
+
+func (w *legacyResultWriter) WriteTitle(title *ResultTitle) error {
+	if len(title.Columns) == 0 {
+		return nil
+	}
+	contents := make([]string, 0, len(title.Columns)+2)
+	contents = append(contents, title.Columns...)
+	contents[0] = "#" + contents[0]
+	if title.HasBlockHash {
+		contents = append(contents, blockHashColumn)
+	}
+	if title.HasTxHash {
+		contents = append(contents, "txhash")
+		if title.HasTxInfo {
+			contents = append(contents, "nonce", "gasPrice")
+		}
+	}
+	if title.HasSkipReason {
+		contents = append(contents, skipReasonColumn)
+	}
+	if title.ExtraInfo != "" {
+		contents = append(contents, title.ExtraInfo)
+	}
+	return WriteOutput(w.writer, contents...)
+}
+
+func (w *legacyResultWriter) WriteResult(result *RewardResult) error {
+	contents := []string{result.Account, result.Reward.String()}
+	if result.Share != nil {
+		contents = append(contents, result.Share.String(), fmt.Sprintf("%d", result.Number))
+		if result.BlockHash != "" {
+			contents = append(contents, result.BlockHash)
+		}
+	}
+	if result.TxHash != "" {
+		contents = append(contents, result.TxHash)
+		if result.Nonce != nil && result.GasPrice != nil {
+			contents = append(contents, fmt.Sprintf("%d", *result.Nonce), result.GasPrice.String())
+		}
+	}
+	if result.SkipReason != "" {
+		contents = append(contents, string(result.SkipReason))
+	}
+	return WriteOutput(w.writer, contents...)
+}
+
+func (w *legacyResultWriter) Flush() error {
+	return nil
+}
+
+// csvResultWriter standard csv format with fixed columns
+type csvResultWriter struct {
+	writer        *csv.Writer
+	hasBlockHash  bool
+	hasTxHash     bool
+	hasTxInfo     bool
+	hasSkipReason bool
+}
+
+func (w *csvResultWriter) WriteTitle(title *ResultTitle) error {
+	header := []string{"account", "reward", "share", "number"}
+	if len(title.Columns) >= 4 {
+		header[2], header[3] = title.Columns[2], title.Columns[3]
+	}
+	w.hasBlockHash = title.HasBlockHash
+	if w.hasBlockHash {
+		header = append(header, blockHashColumn)
+	}
+	w.hasTxHash = title.HasTxHash
+	w.hasTxInfo = title.HasTxHash && title.HasTxInfo
+	if w.hasTxHash {
+		header = append(header, "txhash")
+	}
+	if w.hasTxInfo {
+		header = append(header, "nonce", "gasPrice")
+	}
+	w.hasSkipReason = title.HasSkipReason
+	if w.hasSkipReason {
+		header = append(header, skipReasonColumn)
+	}
+	return w.write(header)
+}
+
+func (w *csvResultWriter) WriteResult(result *RewardResult) error {
+	record := []string{result.Account, result.Reward.String(), "", ""}
+	if result.Share != nil {
+		record[2], record[3] = result.Share.String(), fmt.Sprintf("%d", result.Number)
+	}
+	if w.hasBlockHash {
+		record = append(record, result.BlockHash)
+	}
+	if w.hasTxHash {
+		record = append(record, result.TxHash)
+	}
+	if w.hasTxInfo {
+		nonceStr, gasPriceStr := "", ""
+		if result.Nonce != nil {
+			nonceStr = fmt.Sprintf("%d", *result.Nonce)
+		}
+		if result.GasPrice != nil {
+			gasPriceStr = result.GasPrice.String()
+		}
+		record = append(record, nonceStr, gasPriceStr)
+	}
+	if w.hasSkipReason {
+		record = append(record, string(result.SkipReason))
+	}
+	return w.write(record)
+}
+
+func (w *csvResultWriter) write(record []string) error {
+	err := w.writer.Write(record)
+	if err == nil {
+		w.writer.Flush()
+		err = w.writer.Error()
+	}
+	if err != nil {
+		log.Warn("[write output] error", "record", record, "err", err)
+	} else {
+		log.Printf("[write output] %v", strings.Join(record, ","))
+	}
+	return err
+}
+
+func (w *csvResultWriter) Flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// jsonResultWriter write a json document when flush
+type jsonResultWriter struct {
+	writer io.Writer
+	doc    struct {
+		Title   *ResultTitle
+		Results []*RewardResult
+	}
+}
+
+func (w *jsonResultWriter) WriteTitle(title *ResultTitle) error {
+	w.doc.Title = title
+	return nil
+}
+
+func (w *jsonResultWriter) WriteResult(result *RewardResult) error {
+	w.doc.Results = append(w.doc.Results, result)
+	log.Printf("[write output] %v", result.Account)
+	return nil
+}
+
+func (w *jsonResultWriter) Flush() error {
+	encoder := json.NewEncoder(w.writer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	err := encoder.Encode(&w.doc)
+	if err != nil {
+		log.Warn("[write output] write json document error", "err", err)
+	}
+	return err
+}
+
+// jsonlResultWriter write one json object per line, the first line is title
+type jsonlResultWriter struct {
+	encoder *json.Encoder
+}
+
+func (w *jsonlResultWriter) WriteTitle(title *ResultTitle) error {
+	return w.encode(title)
+}
+
+func (w *jsonlResultWriter) WriteResult(result *RewardResult) error {
+	return w.encode(result)
+}
+
+func (w *jsonlResultWriter) encode(v interface{}) error {
+	err := w.encoder.Encode(v)
+	if err != nil {
+		log.Warn("[write output] error", "err", err)
+	}
+	return err
+}
+
+func (w *jsonlResultWriter) Flush() error {
+	return nil
+}
@@ -0,0 +1,135 @@
+package distributer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+// PreflightReport results of warm up (pre-flight) checks, captures state of chain
+// when the run is approved, saved to PreflightReportFile for archiving or review
+type PreflightReport struct {
+	Time        int64
+	Sender      string
+	ChainID     string  `json:",omitempty"`
+	StartNonce  *uint64 `json:",omitempty"`
+	RewardToken string  `json:",omitempty"`
+	TokenSymbol string  `json:",omitempty"`
+	DryRun      bool
+
+	Inputs      []*PreflightInput
+	Recipients  int
+	TotalReward string
+
+	SenderCoinBalance  string `json:",omitempty"`
+	SenderTokenBalance string `json:",omitempty"`
+
+	Steps    []*PreflightStep
+	Warnings []string `json:",omitempty"`
+	Passed   bool
+	Error    string `json:",omitempty"`
+}
+
+// PreflightInput checked input file
+type PreflightInput struct {
+	File        string
+	Recipients  int
+	TotalReward string
+	Skipped     int `json:",omitempty"`
+}
+
+// PreflightStep result of warm up step
+type PreflightStep struct {
+	Step   string
+	Detail string `json:",omitempty"`
+	Error  string `json:",omitempty"`
+}
+
+func (report *warmUpReport) addWarning(format string, args ...interface{}) {
+	report.warnings = append(report.warnings, fmt.Sprintf(format, args...))
+}
+
+// collectPreflightBalances read balances of sender for pre-flight report, only if it's saved.
+// not enough balance is ignored in dry run (see CheckSenderCoinBalance), record it as warning
+func (opt *Option) collectPreflightBalances(report *warmUpReport) {
+	if opt.PreflightReportFile == "" {
+		return
+	}
+	sender := opt.GetSender()
+	coinBalance, err := capi.GetCoinBalance(sender, nil)
+	if err != nil {
+		report.addWarning("get sender coin balance failed. %v", err)
+	} else {
+		report.coinBalance = coinBalance
+	}
+	if opt.RewardToken == "" {
+		if coinBalance != nil && coinBalance.Cmp(report.totalReward) < 0 {
+			report.addWarning("not enough coin balance, %v < %v", coinBalance, report.totalReward)
+		}
+		return
+	}
+	tokenBalance, err := capi.GetTokenBalance(common.HexToAddress(opt.RewardToken), sender, nil)
+	if err != nil {
+		report.addWarning("get sender reward token balance failed. %v", err)
+		return
+	}
+	report.tokenBalance = tokenBalance
+	if tokenBalance.Cmp(report.totalReward) < 0 {
+		report.addWarning("not enough reward token balance, %v < %v", tokenBalance, report.totalReward)
+	}
+}
+
+// savePreflightReport write pre-flight report whether warm up passed or not
+func (opt *Option) savePreflightReport(report *warmUpReport, warmUpErr error) error {
+	if opt.PreflightReportFile == "" {
+		return nil
+	}
+	args := opt.BuildTxArgs
+	preflight := &PreflightReport{
+		Time:        time.Now().Unix(),
+		Sender:      opt.GetSender().String(),
+		StartNonce:  args.Nonce,
+		RewardToken: opt.RewardToken,
+		TokenSymbol: report.tokenSymbol,
+		DryRun:      opt.DryRun,
+		Recipients:  report.recipients,
+		TotalReward: report.totalReward.String(),
+		Steps:       report.steps,
+		Warnings:    report.warnings,
+		Passed:      warmUpErr == nil,
+	}
+	if chainID := opt.GetChainID(); chainID != nil {
+		preflight.ChainID = chainID.String()
+	}
+	if report.coinBalance != nil {
+		preflight.SenderCoinBalance = report.coinBalance.String()
+	}
+	if report.tokenBalance != nil {
+		preflight.SenderTokenBalance = report.tokenBalance.String()
+	}
+	for _, input := range report.inputs {
+		preflight.Inputs = append(preflight.Inputs, &PreflightInput{
+			File:        input.file,
+			Recipients:  len(input.accountStats),
+			TotalReward: input.totalReward.String(),
+			Skipped:     len(input.skipped),
+		})
+	}
+	if warmUpErr != nil {
+		preflight.Error = warmUpErr.Error()
+	}
+	data, err := json.MarshalIndent(preflight, "", "  ")
+	if err == nil {
+		err = ioutil.WriteFile(opt.PreflightReportFile, data, 0644)
+	}
+	if err != nil {
+		log.Error("[warm up] save pre-flight report failed", "file", opt.PreflightReportFile, "err", err)
+		return fmt.Errorf("save pre-flight report failed. %v", err)
+	}
+	log.Info("[warm up] save pre-flight report success", "file", opt.PreflightReportFile, "passed", preflight.Passed)
+	return nil
+}
@@ -0,0 +1,183 @@
+package distributer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"strings"
+
+	"github.com/anyswap/ANYToken-distribution/callapi"
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common/hexutil"
+)
+
+// ReceiptProof proof of a transfer to a recipient, which can be verified independently
+// against the block hash: receipt is included in receipts trie of the block by ReceiptProof
+// (rlp encoded trie nodes from receiptsRoot), and contains the Transfer log of reward token.
+type ReceiptProof struct {
+	Account     string
+	Reward      string
+	RewardToken string `json:",omitempty"`
+
+	TxHash         string
+	TxIndex        uint64
+	BlockNumber    uint64
+	BlockHash      string
+	BlockTimestamp uint64
+	ReceiptsRoot   string
+
+	Transfer *TransferProof `json:",omitempty"`
+
+	// consensus encoding of receipt and its proof of inclusion,
+	// omitted with reason in ProofNote if proof is not derivable
+	Receipt      hexutil.Bytes   `json:",omitempty"`
+	ReceiptProof []hexutil.Bytes `json:",omitempty"`
+	ProofNote    string          `json:",omitempty"`
+}
+
+// TransferProof decoded Transfer log in receipt
+type TransferProof struct {
+	Token    string
+	From     string
+	To       string
+	Value    string
+	LogIndex uint
+}
+
+// blockReceipts encoded receipts of block, cached as consecutive transfers are in the same block
+type blockReceipts struct {
+	block    *callapi.RPCBlockTxs
+	encoded  [][]byte
+	proofErr error // receipts are not available, or receipts root mismatch
+}
+
+// writeReceiptProof write proof of verified transfer to ReceiptProofDir as <account>-<txhash>.json
+func (vopt *VerifyOption) writeReceiptProof(result *RewardResult, rewardToken string, receipt *callapi.RPCReceipt) error {
+	txHash := common.HexToHash(result.TxHash)
+	block, err := vopt.getBlockReceipts(receipt.BlockHash)
+	if err != nil {
+		return err
+	}
+	proof := &ReceiptProof{
+		Account:        result.Account,
+		Reward:         result.Reward.String(),
+		RewardToken:    strings.ToLower(rewardToken),
+		TxHash:         txHash.String(),
+		BlockNumber:    receipt.BlockNumber.ToInt().Uint64(),
+		BlockHash:      receipt.BlockHash.String(),
+		BlockTimestamp: uint64(block.block.Timestamp),
+		ReceiptsRoot:   block.block.ReceiptsRoot.String(),
+		Transfer:       findTransferProof(result, rewardToken, receipt),
+	}
+	txIndex := -1
+	for i, hash := range block.block.Transactions {
+		if hash == txHash {
+			txIndex = i
+			break
+		}
+	}
+	switch {
+	case txIndex < 0:
+		return fmt.Errorf("transaction %v is not in block %v", txHash.String(), receipt.BlockHash.String())
+	case block.proofErr != nil:
+		proof.TxIndex = uint64(txIndex)
+		proof.ProofNote = block.proofErr.Error()
+	default:
+		proof.TxIndex = uint64(txIndex)
+		_, nodes, errp := calcReceiptProof(block.encoded, proof.TxIndex)
+		if errp != nil {
+			return errp
+		}
+		proof.Receipt = block.encoded[txIndex]
+		for _, node := range nodes {
+			proof.ReceiptProof = append(proof.ReceiptProof, node)
+		}
+	}
+
+	data, err := json.MarshalIndent(proof, "", "  ")
+	if err != nil {
+		return err
+	}
+	fileName := filepath.Join(vopt.ReceiptProofDir, fmt.Sprintf("%v-%v.json", result.Account, txHash.String()))
+	if err = ioutil.WriteFile(fileName, data, 0644); err != nil {
+		return fmt.Errorf("write receipt proof failed. %v", err)
+	}
+	log.Info("[verify] write receipt proof success", "account", result.Account, "file", fileName, "hasProof", proof.ProofNote == "")
+	return nil
+}
+
+// findTransferProof find Transfer log of reward to the recipient, nil for native coin
+func findTransferProof(result *RewardResult, rewardToken string, receipt *callapi.RPCReceipt) *TransferProof {
+	if rewardToken == "" {
+		return nil
+	}
+	token := common.HexToAddress(rewardToken)
+	account := common.HexToAddress(result.Account)
+	for _, rlog := range receipt.Logs {
+		if rlog.Address != token || len(rlog.Topics) != 3 || rlog.Topics[0] != transferLogTopic {
+			continue
+		}
+		if common.BytesToAddress(rlog.Topics[2].Bytes()) != account {
+			continue
+		}
+		return &TransferProof{
+			Token:    strings.ToLower(token.String()),
+			From:     strings.ToLower(common.BytesToAddress(rlog.Topics[1].Bytes()).String()),
+			To:       strings.ToLower(account.String()),
+			Value:    new(big.Int).SetBytes(rlog.Data).String(),
+			LogIndex: rlog.Index,
+		}
+	}
+	return nil
+}
+
+// getBlockReceipts get block and encoded receipts of all its transactions,
+// the receipts root calculated from them must match the block's.
+// eth_getBlockReceipts is preferred, falls back to get receipts one by one.
+func (vopt *VerifyOption) getBlockReceipts(blockHash common.Hash) (*blockReceipts, error) {
+	if cached := vopt.proofBlock; cached != nil && cached.block.Hash == blockHash {
+		return cached, nil
+	}
+	block, err := capi.GetBlockTxsByHash(blockHash)
+	if err != nil {
+		return nil, fmt.Errorf("get block %v failed. %v", blockHash.String(), err)
+	}
+	result := &blockReceipts{block: block}
+	vopt.proofBlock = result
+
+	receipts, err := capi.GetBlockReceipts(blockHash)
+	if err != nil || len(receipts) != len(block.Transactions) {
+		log.Info("[verify] get block receipts failed, get receipts one by one", "block", blockHash.String(), "txs", len(block.Transactions), "err", err)
+		receipts = make([]*callapi.RPCConsensusReceipt, len(block.Transactions))
+		for i, txHash := range block.Transactions {
+			receipts[i], err = capi.GetConsensusReceipt(txHash)
+			if err != nil {
+				result.proofErr = fmt.Errorf("receipt proof is not derivable, get receipt of %v failed. %v", txHash.String(), err)
+				return result, nil
+			}
+		}
+	}
+	result.encoded = make([][]byte, len(receipts))
+	for i, receipt := range receipts {
+		if receipt.TxHash != block.Transactions[i] {
+			result.proofErr = fmt.Errorf("receipt proof is not derivable, receipt %v mismatch transaction %v", i, block.Transactions[i].String())
+			return result, nil
+		}
+		result.encoded[i], err = encodeConsensusReceipt(receipt)
+		if err != nil {
+			return nil, err
+		}
+	}
+	root, _, err := calcReceiptProof(result.encoded, 0)
+	if err != nil {
+		return nil, err
+	}
+	if root != block.ReceiptsRoot {
+		// eg. chains with non standard receipt encoding
+		result.proofErr = fmt.Errorf("receipt proof is not derivable, calculated receipts root %v mismatch block's", root.String())
+	}
+	return result, nil
+}
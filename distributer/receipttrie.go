@@ -0,0 +1,204 @@
+package distributer
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/anyswap/ANYToken-distribution/callapi"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/crypto"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/rlp"
+)
+
+// encodeConsensusReceipt encode receipt as value in receipts trie:
+// rlp([postStateOrStatus, cumulativeGasUsed, bloom, logs]), prefixed by type if it's typed receipt (EIP-2718)
+func encodeConsensusReceipt(receipt *callapi.RPCConsensusReceipt) ([]byte, error) {
+	var postStateOrStatus []byte
+	switch {
+	case len(receipt.Root) != 0:
+		postStateOrStatus = receipt.Root
+	case receipt.Status != nil && *receipt.Status != 0:
+		postStateOrStatus = []byte{1}
+	}
+	type rlpLog struct {
+		Address common.Address
+		Topics  []common.Hash
+		Data    []byte
+	}
+	logs := make([]*rlpLog, len(receipt.Logs))
+	for i, rlog := range receipt.Logs {
+		logs[i] = &rlpLog{Address: rlog.Address, Topics: rlog.Topics, Data: rlog.Data}
+	}
+	enc, err := rlp.EncodeToBytes([]interface{}{
+		postStateOrStatus,
+		uint64(receipt.CumulativeGasUsed),
+		[]byte(receipt.Bloom),
+		logs,
+	})
+	if err != nil || receipt.Type == 0 {
+		return enc, err
+	}
+	return append([]byte{byte(receipt.Type)}, enc...), nil
+}
+
+// trieItem key (in nibbles) and value of trie
+type trieItem struct {
+	key   []byte
+	value []byte
+}
+
+// calcReceiptProof build receipts trie of block (keyed by rlp of transaction index),
+// return trie root and proof of receipt at index: rlp encoded trie nodes on the path
+// from root to the receipt, nodes embedded in parent node (shorter than 32 bytes) are not listed.
+func calcReceiptProof(encodedReceipts [][]byte, index uint64) (root common.Hash, proof [][]byte, err error) {
+	if index >= uint64(len(encodedReceipts)) {
+		return root, nil, fmt.Errorf("receipt index %v out of range of %v receipts", index, len(encodedReceipts))
+	}
+	items := make([]*trieItem, len(encodedReceipts))
+	for i, value := range encodedReceipts {
+		key, errf := rlp.EncodeToBytes(uint64(i))
+		if errf != nil {
+			return root, nil, errf
+		}
+		items[i] = &trieItem{key: keyToNibbles(key), value: value}
+	}
+	targetKey, err := rlp.EncodeToBytes(index)
+	if err != nil {
+		return root, nil, err
+	}
+	rootEnc, path, err := buildTrieNode(items, 0, keyToNibbles(targetKey))
+	if err != nil {
+		return root, nil, err
+	}
+	root = common.BytesToHash(crypto.Keccak256(rootEnc))
+	for i, node := range path {
+		if i == 0 || len(node) >= 32 {
+			proof = append(proof, node)
+		}
+	}
+	return root, proof, nil
+}
+
+// buildTrieNode build trie node of items (keys are different and have common prefix to depth),
+// return rlp encoding of the node, and encodings of nodes on the path to target key (nil if not in subtree)
+func buildTrieNode(items []*trieItem, depth int, target []byte) (enc []byte, path [][]byte, err error) {
+	onPath := target != nil
+
+	if len(items) == 1 {
+		item := items[0]
+		enc, err = rlp.EncodeToBytes([]interface{}{hexPrefix(item.key[depth:], true), item.value})
+		if onPath && bytes.Equal(item.key, target) {
+			path = [][]byte{enc}
+		}
+		return enc, path, err
+	}
+
+	// extension node of common prefix
+	prefixLen := commonPrefixLength(items, depth)
+	if prefixLen > 0 {
+		childOnPath := onPath && len(target) >= depth+prefixLen && bytes.Equal(items[0].key[depth:depth+prefixLen], target[depth:depth+prefixLen])
+		childTarget := target
+		if !childOnPath {
+			childTarget = nil
+		}
+		childEnc, childPath, errb := buildTrieNode(items, depth+prefixLen, childTarget)
+		if errb != nil {
+			return nil, nil, errb
+		}
+		enc, err = rlp.EncodeToBytes([]interface{}{hexPrefix(items[0].key[depth:depth+prefixLen], false), trieNodeRef(childEnc)})
+		if childPath != nil {
+			path = append([][]byte{enc}, childPath...)
+		}
+		return enc, path, err
+	}
+
+	// branch node
+	var groups [16][]*trieItem
+	var value []byte
+	for _, item := range items {
+		if len(item.key) == depth {
+			value = item.value
+			continue
+		}
+		nibble := item.key[depth]
+		groups[nibble] = append(groups[nibble], item)
+	}
+	slots := make([]interface{}, 17)
+	var childPath [][]byte
+	for i, group := range groups {
+		if len(group) == 0 {
+			slots[i] = []byte{}
+			continue
+		}
+		childTarget := target
+		if !onPath || len(target) <= depth || int(target[depth]) != i {
+			childTarget = nil
+		}
+		childEnc, groupPath, errb := buildTrieNode(group, depth+1, childTarget)
+		if errb != nil {
+			return nil, nil, errb
+		}
+		if groupPath != nil {
+			childPath = groupPath
+		}
+		slots[i] = trieNodeRef(childEnc)
+	}
+	slots[16] = value
+	if slots[16] == nil {
+		slots[16] = []byte{}
+	}
+	enc, err = rlp.EncodeToBytes(slots)
+	if childPath != nil || (onPath && len(target) == depth && value != nil) {
+		path = append([][]byte{enc}, childPath...)
+	}
+	return enc, path, err
+}
+
+// trieNodeRef node is embedded in parent if its encoding is shorter than 32 bytes, otherwise referenced by hash
+func trieNodeRef(enc []byte) interface{} {
+	if len(enc) < 32 {
+		return rlp.RawValue(enc)
+	}
+	return crypto.Keccak256(enc)
+}
+
+func commonPrefixLength(items []*trieItem, depth int) int {
+	first := items[0].key
+	length := len(first) - depth
+	for _, item := range items[1:] {
+		i := 0
+		for i < length && depth+i < len(item.key) && item.key[depth+i] == first[depth+i] {
+			i++
+		}
+		length = i
+	}
+	return length
+}
+
+func keyToNibbles(key []byte) []byte {
+	nibbles := make([]byte, len(key)*2)
+	for i, b := range key {
+		nibbles[i*2] = b / 16
+		nibbles[i*2+1] = b % 16
+	}
+	return nibbles
+}
+
+// hexPrefix compact encoding of nibbles with leaf flag
+func hexPrefix(nibbles []byte, isLeaf bool) []byte {
+	flag := byte(0)
+	if isLeaf {
+		flag = 2
+	}
+	var result []byte
+	if len(nibbles)%2 == 1 {
+		result = append(result, (flag+1)<<4|nibbles[0])
+		nibbles = nibbles[1:]
+	} else {
+		result = append(result, flag<<4)
+	}
+	for i := 0; i < len(nibbles); i += 2 {
+		result = append(result, nibbles[i]<<4|nibbles[i+1])
+	}
+	return result
+}
@@ -0,0 +1,97 @@
+package distributer
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/core/types"
+)
+
+var (
+	errReplacementUnderpriced = errors.New("replacement transaction underpriced")
+	errBumpCeilingReached     = errors.New("max bump gas price reached")
+)
+
+// isReplacementUnderpricedError a pending tx with the same nonce exists
+// and our gas price is not high enough to replace it
+func isReplacementUnderpricedError(err error) bool {
+	return strings.Contains(strings.ToLower(err.Error()), errReplacementUnderpriced.Error())
+}
+
+// interval of resending at bump ceiling price while waiting the nonce available
+const bumpCeilingRetryInterval = 15 * time.Second
+
+// sendReplacementWithBump resend rawTx with bumped gas price to replace the stuck tx of the same nonce,
+// the bump percentage is increased by ReplaceBumpPercent on every rejection up to MaxReplaceBumpPercent.
+// bumped gas price never exceeds MaxBumpGasPrice if it's specified (see sendAtBumpCeiling).
+func (args *BuildTxArgs) sendReplacementWithBump(rawTx *types.Transaction, account common.Address) (*types.Transaction, error) {
+	basePrice := rawTx.GasPrice()
+	for percent := args.ReplaceBumpPercent; percent <= args.MaxReplaceBumpPercent; percent += args.ReplaceBumpPercent {
+		gasPrice := new(big.Int).Mul(basePrice, new(big.Int).SetUint64(100+percent))
+		gasPrice.Div(gasPrice, big.NewInt(100))
+		if args.MaxBumpGasPrice != nil && gasPrice.Cmp(args.MaxBumpGasPrice) > 0 {
+			log.Warn("sendRewards bumped gas price exceeds ceiling", "account", account.String(), "nonce", rawTx.Nonce(), "bumpPercent", percent, "gasPrice", gasPrice, "maxBumpGasPrice", args.MaxBumpGasPrice)
+			return args.sendAtBumpCeiling(rawTx, account)
+		}
+
+		signedTx, err := args.sendBumpedTx(rawTx, gasPrice)
+		if err == nil {
+			log.Info("sendRewards replace pending tx success", "account", account.String(), "nonce", rawTx.Nonce(), "bumpPercent", percent, "gasPrice", gasPrice)
+			return signedTx, nil
+		}
+		if !isReplacementUnderpricedError(err) {
+			return nil, err
+		}
+		log.Warn("sendRewards replacement underpriced, increase bump", "account", account.String(), "nonce", rawTx.Nonce(), "bumpPercent", percent, "gasPrice", gasPrice)
+	}
+	return nil, fmt.Errorf("send tx failed, %w: nonce %v is still occupied after bumping gas price by max %v%%", errReplacementUnderpriced, rawTx.Nonce(), args.MaxReplaceBumpPercent)
+}
+
+// sendAtBumpCeiling stop bumping as bumped gas price would exceed MaxBumpGasPrice.
+// abort, or resend at the ceiling price until the nonce is available if WaitAtBumpCeiling is true
+func (args *BuildTxArgs) sendAtBumpCeiling(rawTx *types.Transaction, account common.Address) (*types.Transaction, error) {
+	ceiling := args.MaxBumpGasPrice
+	// waiting is useless if the ceiling is not above the rejected gas price
+	if !args.WaitAtBumpCeiling || ceiling.Cmp(rawTx.GasPrice()) <= 0 {
+		return nil, fmt.Errorf("send tx failed, %w: nonce %v is still occupied, bumped gas price would exceed %v", errBumpCeilingReached, rawTx.Nonce(), ceiling)
+	}
+	for i := 0; ; i++ {
+		signedTx, err := args.sendBumpedTx(rawTx, ceiling)
+		if err == nil {
+			log.Info("sendRewards replace pending tx at ceiling gas price success", "account", account.String(), "nonce", rawTx.Nonce(), "gasPrice", ceiling)
+			return signedTx, nil
+		}
+		if !isReplacementUnderpricedError(err) {
+			return nil, err
+		}
+		if i%10 == 0 {
+			log.Warn("sendRewards replacement underpriced at ceiling gas price, wait", "account", account.String(), "nonce", rawTx.Nonce(), "gasPrice", ceiling, "retries", i)
+		}
+		if errb := capi.CheckErrorBudget(); errb != nil {
+			return nil, errb
+		}
+		time.Sleep(bumpCeilingRetryInterval)
+	}
+}
+
+// sendBumpedTx sign and send rawTx with gasPrice
+func (args *BuildTxArgs) sendBumpedTx(rawTx *types.Transaction, gasPrice *big.Int) (*types.Transaction, error) {
+	if err := args.checkGasSpend(estimateGasCost(rawTx.Gas(), gasPrice)); err != nil {
+		return nil, err
+	}
+	bumpedTx := types.NewTransaction(rawTx.Nonce(), *rawTx.To(), rawTx.Value(), rawTx.Gas(), gasPrice, rawTx.Data())
+	signedTx, err := types.SignTx(bumpedTx, args.chainSigner, args.keyWrapper.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("sign tx failed, %v", err)
+	}
+	err = capi.SendTransaction(signedTx)
+	if err != nil && !isReplacementUnderpricedError(err) {
+		return nil, classifySendError(err, rawTx.Gas())
+	}
+	return signedTx, err
+}
@@ -0,0 +1,234 @@
+package distributer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/anyswap/ANYToken-distribution/mongodb"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+const replayLogVersion = 1
+
+// replay record outcomes
+const (
+	ReplayOutcomeSent   = "sent"
+	ReplayOutcomeDryRun = "dryrun"
+	ReplayOutcomeDust   = "dust"
+	ReplayOutcomeFailed = "failed"
+)
+
+// ReplayLog self-contained record of a sending run
+type ReplayLog struct {
+	Version     int
+	ByWhat      string `json:",omitempty"`
+	StartTime   int64
+	EndTime     int64
+	ChainID     *big.Int
+	Sender      string
+	RewardToken *ReplayTokenInfo `json:",omitempty"`
+	DryRun      bool
+	StartNonce  uint64
+	Config      json.RawMessage
+	Records     []*ReplayRecord
+	TotalSended *big.Int
+	GasSpent    *big.Int
+	Error       string `json:",omitempty"`
+}
+
+// ReplayTokenInfo reward token metadata
+type ReplayTokenInfo struct {
+	Address  string
+	Symbol   string
+	Decimals uint8
+}
+
+// ReplayRecord per recipient record
+type ReplayRecord struct {
+	Exchange string `json:",omitempty"`
+	Account  string
+	Reward   *big.Int
+	Share    *big.Int `json:",omitempty"`
+	Number   uint64   `json:",omitempty"`
+	Nonce    *uint64  `json:",omitempty"`
+	GasLimit uint64
+	GasPrice *big.Int
+	TxHash   string `json:",omitempty"`
+	Outcome  string
+	Error    string `json:",omitempty"`
+}
+
+func (opt *Option) initReplayLog() {
+	if opt.ReplayLogFile == "" || opt.replayLog != nil {
+		return
+	}
+	args := opt.BuildTxArgs
+	config, _ := json.Marshal(opt)
+	rlog := &ReplayLog{
+		Version:     replayLogVersion,
+		ByWhat:      opt.byWhat,
+		StartTime:   time.Now().Unix(),
+		ChainID:     args.chainID,
+		Sender:      args.fromAddr.String(),
+		DryRun:      opt.DryRun,
+		Config:      config,
+		TotalSended: big.NewInt(0),
+	}
+	if args.Nonce != nil {
+		rlog.StartNonce = *args.Nonce
+	}
+	if opt.RewardToken != "" {
+		token := common.HexToAddress(opt.RewardToken)
+		rlog.RewardToken = &ReplayTokenInfo{Address: token.String()}
+		metadata, err := capi.BatchGetErc20Metadata(token)
+		if err != nil {
+			log.Warn("[replaylog] get reward token metadata failed", "token", opt.RewardToken, "err", err)
+		} else {
+			rlog.RewardToken.Symbol = metadata.Symbol
+			rlog.RewardToken.Decimals = metadata.Decimals
+		}
+	}
+	opt.replayLog = rlog
+}
+
+func (opt *Option) addReplayRecord(exchange string, stat *mongodb.AccountStat, txHash *common.Hash, sendErr error) {
+	rlog := opt.replayLog
+	if rlog == nil {
+		return
+	}
+	args := opt.BuildTxArgs
+	record := &ReplayRecord{
+		Exchange: strings.ToLower(exchange),
+		Account:  stat.Account.String(),
+		Reward:   stat.Reward,
+		Share:    stat.Share,
+		Number:   stat.Number,
+		GasPrice: args.GasPrice,
+	}
+	if args.GasLimit != nil {
+		record.GasLimit = *args.GasLimit
+	}
+	switch {
+	case sendErr == errDustReward:
+		record.Outcome = ReplayOutcomeDust
+	case sendErr != nil:
+		record.Outcome = ReplayOutcomeFailed
+		record.Error = sendErr.Error()
+		if args.Nonce != nil {
+			nonce := *args.Nonce
+			record.Nonce = &nonce
+		}
+	case txHash != nil:
+		record.Outcome = ReplayOutcomeSent
+		record.TxHash = txHash.String()
+		if sent := args.lastSentTx; sent != nil {
+			nonce := sent.nonce
+			record.Nonce = &nonce
+			record.GasLimit = sent.gasLimit
+			record.GasPrice = sent.gasPrice
+		}
+	default:
+		record.Outcome = ReplayOutcomeDryRun
+	}
+	if record.Outcome != ReplayOutcomeFailed {
+		rlog.TotalSended.Add(rlog.TotalSended, stat.Reward)
+	}
+	rlog.Records = append(rlog.Records, record)
+}
+
+func (opt *Option) saveReplayLog(runErr error) {
+	rlog := opt.replayLog
+	if rlog == nil {
+		return
+	}
+	rlog.EndTime = time.Now().Unix()
+	rlog.GasSpent = opt.BuildTxArgs.GetGasSpent()
+	if runErr != nil {
+		rlog.Error = runErr.Error()
+	}
+	data, err := json.MarshalIndent(rlog, "", "  ")
+	if err == nil {
+		err = ioutil.WriteFile(opt.ReplayLogFile, data, 0644)
+	}
+	if err != nil {
+		log.Error("[replaylog] save replay log failed", "file", opt.ReplayLogFile, "err", err)
+		return
+	}
+	log.Info("[replaylog] save replay log success", "file", opt.ReplayLogFile, "records", len(rlog.Records))
+}
+
+// LoadReplayLog load replay log from file
+func LoadReplayLog(fileName string) (*ReplayLog, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var rlog ReplayLog
+	err = json.Unmarshal(data, &rlog)
+	if err != nil {
+		return nil, err
+	}
+	return &rlog, nil
+}
+
+// Verify verify internal consistency of replay log (totals, nonce sequence)
+func (rlog *ReplayLog) Verify() error {
+	if rlog.Version != replayLogVersion {
+		return fmt.Errorf("unsupported replay log version %v", rlog.Version)
+	}
+	if rlog.ChainID == nil && !rlog.DryRun {
+		return fmt.Errorf("replay log without chain id")
+	}
+	totalSended := big.NewInt(0)
+	txHashes := make(map[string]struct{})
+	var lastNonce *uint64
+	nonceGaps := 0
+	for i, record := range rlog.Records {
+		if !common.IsHexAddress(record.Account) {
+			return fmt.Errorf("record %v: wrong account '%v'", i, record.Account)
+		}
+		if record.Reward == nil || record.Reward.Sign() <= 0 {
+			return fmt.Errorf("record %v: wrong reward %v", i, record.Reward)
+		}
+		switch record.Outcome {
+		case ReplayOutcomeSent:
+			if record.Nonce == nil || record.TxHash == "" {
+				return fmt.Errorf("record %v: sent record without nonce or txhash", i)
+			}
+			if _, exist := txHashes[record.TxHash]; exist {
+				return fmt.Errorf("record %v: duplicate txhash %v", i, record.TxHash)
+			}
+			txHashes[record.TxHash] = struct{}{}
+			nonce := *record.Nonce
+			switch {
+			case lastNonce == nil && nonce < rlog.StartNonce:
+				return fmt.Errorf("record %v: nonce %v is lower than start nonce %v", i, nonce, rlog.StartNonce)
+			case lastNonce != nil && nonce <= *lastNonce:
+				return fmt.Errorf("record %v: nonce %v is not increasing, last nonce is %v", i, nonce, *lastNonce)
+			case lastNonce != nil && nonce != *lastNonce+1:
+				log.Warn("[replaylog] nonce gap found", "index", i, "nonce", nonce, "lastNonce", *lastNonce)
+				nonceGaps++
+			}
+			lastNonce = &nonce
+		case ReplayOutcomeDryRun, ReplayOutcomeDust:
+			if record.TxHash != "" {
+				return fmt.Errorf("record %v: %v record with txhash", i, record.Outcome)
+			}
+		case ReplayOutcomeFailed:
+			continue
+		default:
+			return fmt.Errorf("record %v: unknown outcome '%v'", i, record.Outcome)
+		}
+		totalSended.Add(totalSended, record.Reward)
+	}
+	if rlog.TotalSended == nil || totalSended.Cmp(rlog.TotalSended) != 0 {
+		return fmt.Errorf("total sended mismatch, recorded %v, calculated %v", rlog.TotalSended, totalSended)
+	}
+	log.Info("[replaylog] verify replay log success", "records", len(rlog.Records), "totalSended", totalSended, "nonceGaps", nonceGaps)
+	return nil
+}
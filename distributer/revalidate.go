@@ -0,0 +1,38 @@
+package distributer
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/anyswap/ANYToken-distribution/mongodb"
+)
+
+var errTokenBehaviorChanged = errors.New("reward token behavior changed since pre-flight")
+
+// revalidateToken re-probe token state and re-simulate the next transfer every RevalidateInterval sends,
+// abort if the token behaves differently since pre-flight (eg. paused, or upgraded transfer logic reverts).
+// nothing is revalidated in dry run
+func (opt *Option) revalidateToken(next *mongodb.AccountStat) error {
+	if opt.RevalidateInterval == 0 || opt.DryRun || opt.sendsSinceRevalidate < opt.RevalidateInterval {
+		return nil
+	}
+	opt.sendsSinceRevalidate = 0
+	detail, err := opt.checkTokenState()
+	if err == nil {
+		detail, err = opt.simulateTransfer(next)
+	}
+	if err != nil {
+		log.Error("[revalidate] reward token behavior changed, abort", "token", opt.RewardToken, "err", err)
+		return fmt.Errorf("%w: %v", errTokenBehaviorChanged, err)
+	}
+	log.Info("[revalidate] reward token behaves as pre-flight", "token", opt.RewardToken, "detail", detail)
+	return nil
+}
+
+// countRevalidateSend count successful send for periodic revalidation
+func (opt *Option) countRevalidateSend() {
+	if opt.RevalidateInterval != 0 {
+		opt.sendsSinceRevalidate++
+	}
+}
@@ -0,0 +1,105 @@
+package distributer
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/anyswap/ANYToken-distribution/mongodb"
+	"gopkg.in/mgo.v2/bson"
+)
+
+type runSummary struct {
+	summary       *mongodb.MgoRunSummary
+	rewardsSended *big.Int
+	dustReward    *big.Int
+}
+
+// RunID get ID of current run, reward results saved to database are tagged with it
+func (opt *Option) RunID() string {
+	if opt.runSummary == nil {
+		return ""
+	}
+	return opt.runSummary.summary.Key
+}
+
+func (opt *Option) initRunSummary() {
+	if !opt.SaveDB || opt.byWhat == customMethodID || opt.runSummary != nil {
+		return
+	}
+	args := opt.BuildTxArgs
+	config, _ := json.Marshal(opt)
+	summary := &mongodb.MgoRunSummary{
+		Key:          bson.NewObjectId().Hex(),
+		ByWhat:       opt.byWhat,
+		Exchanges:    opt.getLowerExchanges(),
+		Start:        opt.StartHeight,
+		End:          opt.EndHeight,
+		SampleHeight: opt.SampleHeight,
+		RewardToken:  strings.ToLower(opt.RewardToken),
+		Sender:       strings.ToLower(args.fromAddr.String()),
+		DryRun:       opt.DryRun,
+		Config:       string(config),
+		StartTime:    uint64(time.Now().Unix()),
+	}
+	if args.chainID != nil {
+		summary.ChainID = args.chainID.String()
+	}
+	if opt.TotalValue != nil {
+		summary.TotalReward = opt.TotalValue.String()
+	}
+	opt.runSummary = &runSummary{
+		summary:       summary,
+		rewardsSended: big.NewInt(0),
+		dustReward:    big.NewInt(0),
+	}
+	log.Info("[runsummary] start run", "runID", summary.Key)
+}
+
+func (opt *Option) getLowerExchanges() []string {
+	exchanges := make([]string, len(opt.Exchanges))
+	for i, exchange := range opt.Exchanges {
+		exchanges[i] = strings.ToLower(exchange)
+	}
+	return exchanges
+}
+
+func (opt *Option) addRunSummaryRecord(reward *big.Int, sendErr error) {
+	rs := opt.runSummary
+	if rs == nil {
+		return
+	}
+	switch sendErr {
+	case nil:
+		rs.summary.SentCount++
+		rs.rewardsSended.Add(rs.rewardsSended, reward)
+	case errDustReward:
+		rs.summary.DustCount++
+		rs.dustReward.Add(rs.dustReward, reward)
+	default:
+		rs.summary.FailedCount++
+	}
+}
+
+func (opt *Option) saveRunSummary(runErr error) {
+	rs := opt.runSummary
+	if rs == nil {
+		return
+	}
+	summary := rs.summary
+	summary.RewardsSended = rs.rewardsSended.String()
+	summary.DustReward = rs.dustReward.String()
+	summary.GasSpent = opt.BuildTxArgs.GetGasSpent().String()
+	summary.EndTime = uint64(time.Now().Unix())
+	if runErr != nil {
+		summary.Outcome = mongodb.RunOutcomeAborted
+		summary.Error = runErr.Error()
+	} else {
+		summary.Outcome = mongodb.RunOutcomeCompleted
+	}
+	_ = mongodb.TryDoTimes("AddRunSummary "+summary.Key, func() error {
+		return mongodb.AddRunSummary(summary)
+	})
+}
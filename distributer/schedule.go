@@ -0,0 +1,163 @@
+package distributer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/anyswap/ANYToken-distribution/mongodb"
+)
+
+// ScheduleOption distribute continuously every Interval
+type ScheduleOption struct {
+	// option of every run, TotalValue is the rewards of every run,
+	// StartHeight is the window start if no previous completed run is found
+	Template *Option
+
+	Interval time.Duration
+
+	// if a run is still in progress when the next one is due,
+	// queue it to start right after (at most one is queued) instead of skipping it
+	QueueOverlap bool
+
+	lastEnd uint64
+}
+
+func (sopt *ScheduleOption) check() error {
+	opt := sopt.Template
+	if sopt.Interval <= 0 {
+		return fmt.Errorf("[schedule] interval must be positive")
+	}
+	switch opt.byWhat {
+	case byLiquidMethodID, byVolumeMethodID:
+	default:
+		return fmt.Errorf("[schedule] reward type must be liquidity or volume, not '%v'", opt.byWhat)
+	}
+	if len(opt.InputFiles) != 0 || len(opt.OutputFiles) != 0 {
+		return fmt.Errorf("[schedule] can not specify input or output files, every run pulls accounts from database and has its own output files")
+	}
+	if opt.SampleHeight != 0 || len(opt.SnapshotHeights) != 0 {
+		return fmt.Errorf("[schedule] can not specify sample or snapshot heights, they depend on the window of every run")
+	}
+	// window is unknown yet, check others with a non empty window
+	basic := *opt
+	basic.EndHeight = basic.StartHeight + 1
+	return basic.CheckBasic()
+}
+
+// RunSchedule distribute on schedule until ctx is done.
+// the first run starts immediately, then every Interval. the window of a run
+// starts from the end of the previous completed run (same reward type, exchanges
+// and dry run, found in database) and ends at the latest stable height.
+// runs never overlap, a due run is skipped or queued if the previous one has not finished,
+// and the run in progress is waited to finish before return.
+func (sopt *ScheduleOption) RunSchedule(ctx context.Context) error {
+	if err := sopt.check(); err != nil {
+		return err
+	}
+	log.Info("[schedule] start", "byWhat", sopt.Template.byWhat, "interval", sopt.Interval, "queueOverlap", sopt.QueueOverlap)
+
+	ticker := time.NewTicker(sopt.Interval)
+	defer ticker.Stop()
+
+	done := make(chan struct{}, 1)
+	cycle := 0
+	running, queued := false, false
+	startCycle := func() {
+		cycle++
+		running = true
+		go func(cycle int) {
+			sopt.runCycle(cycle)
+			done <- struct{}{}
+		}(cycle)
+	}
+
+	startCycle()
+	for {
+		select {
+		case <-ctx.Done():
+			if running {
+				log.Info("[schedule] stopping, wait running cycle to finish", "cycle", cycle)
+				<-done
+			}
+			log.Info("[schedule] stopped", "cycles", cycle)
+			return nil
+		case <-ticker.C:
+			switch {
+			case !running:
+				startCycle()
+			case sopt.QueueOverlap && !queued:
+				queued = true
+				log.Warn("[schedule] previous cycle is still running, queue next cycle", "running", cycle)
+			default:
+				log.Warn("[schedule] previous cycle is still running, skip this cycle", "running", cycle, "queued", queued)
+			}
+		case <-done:
+			running = false
+			if queued {
+				queued = false
+				startCycle()
+			}
+		}
+	}
+}
+
+func (sopt *ScheduleOption) runCycle(cycle int) {
+	tmpl := sopt.Template
+	start, end := sopt.getWindow()
+	if start >= end {
+		log.Info("[schedule] no new window, skip cycle", "cycle", cycle, "start", start, "end", end)
+		return
+	}
+
+	opt := *tmpl
+	opt.StartHeight = start
+	opt.EndHeight = end
+	log.Info("[schedule] cycle start", "cycle", cycle, "byWhat", tmpl.byWhat, "start", start, "end", end, "rewards", opt.TotalValue)
+
+	beginTime := time.Now()
+	var err error
+	switch tmpl.byWhat {
+	case byLiquidMethodID:
+		err = ByLiquidity(&opt)
+	case byVolumeMethodID:
+		err = ByVolume(&opt)
+	}
+	if err != nil {
+		log.Error("[schedule] cycle failed", "cycle", cycle, "start", start, "end", end, "runID", opt.RunID(), "elapsed", time.Since(beginTime).String(), "err", err)
+		return
+	}
+	sopt.lastEnd = end
+	log.Info("[schedule] cycle success", "cycle", cycle, "start", start, "end", end, "runID", opt.RunID(), "gasSpent", opt.BuildTxArgs.GetGasSpent(), "elapsed", time.Since(beginTime).String())
+}
+
+// getWindow window starts from end of the last completed run, and ends at the latest stable height
+func (sopt *ScheduleOption) getWindow() (start, end uint64) {
+	opt := sopt.Template
+	switch {
+	case sopt.lastEnd != 0:
+		start = sopt.lastEnd
+	case opt.SaveDB:
+		lastRun, errf := mongodb.FindLatestCompletedRun(opt.byWhat, opt.getLowerExchanges(), opt.DryRun)
+		if errf == nil {
+			start = lastRun.End
+			log.Info("[schedule] found last completed run", "runID", lastRun.Key, "start", lastRun.Start, "end", lastRun.End)
+		} else {
+			start = opt.StartHeight
+			log.Info("[schedule] no last completed run found, start from configed start", "start", start, "err", errf)
+		}
+	default:
+		start = opt.StartHeight
+	}
+
+	latest := calcLatestBlockNumberOrTimestamp(opt.UseTimeMeasurement)
+	if latest < opt.StableHeight {
+		return start, start
+	}
+	end = latest - opt.StableHeight
+	if step := opt.StepCount; step != 0 && opt.byWhat == byVolumeMethodID && end > start {
+		end = start + (end-start)/step*step
+	}
+	return start, end
+}
@@ -0,0 +1,147 @@
+package distributer
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+const (
+	selfTestRecipients     = 3
+	selfTestReceiptTimeout = 2 * time.Minute
+)
+
+// SelfTestStep self test step result
+type SelfTestStep struct {
+	Name string
+	Err  error
+}
+
+// SelfTestReport self test report
+type SelfTestReport struct {
+	Steps []*SelfTestStep
+}
+
+// Passed is all steps passed
+func (r *SelfTestReport) Passed() bool {
+	for _, step := range r.Steps {
+		if step.Err != nil {
+			return false
+		}
+	}
+	return len(r.Steps) > 0
+}
+
+func (r *SelfTestReport) add(name string, err error) error {
+	r.Steps = append(r.Steps, &SelfTestStep{Name: name, Err: err})
+	if err != nil {
+		log.Error("[selftest] step failed", "step", name, "err", err)
+	} else {
+		log.Info("[selftest] step passed", "step", name)
+	}
+	return err
+}
+
+func (r *SelfTestReport) String() string {
+	var sb strings.Builder
+	for _, step := range r.Steps {
+		if step.Err != nil {
+			fmt.Fprintf(&sb, "[FAIL] %v: %v\n", step.Name, step.Err)
+		} else {
+			fmt.Fprintf(&sb, "[PASS] %v\n", step.Name)
+		}
+	}
+	if r.Passed() {
+		sb.WriteString("selftest PASSED\n")
+	} else {
+		sb.WriteString("selftest FAILED\n")
+	}
+	return sb.String()
+}
+
+// SelfTest send a small distribution of `totalReward` to some random recipients,
+// then wait receipts and verify balances, all temporary files are removed at last.
+// option must have checked BuildTxArgs (signing key) and RewardToken.
+func SelfTest(opt *Option, totalReward *big.Int) *SelfTestReport {
+	report := &SelfTestReport{}
+
+	reward := new(big.Int).Div(totalReward, big.NewInt(selfTestRecipients))
+	if reward.Sign() <= 0 {
+		reward = big.NewInt(1)
+	}
+
+	tmpDir, err := ioutil.TempDir("", "distribute-selftest-")
+	if report.add("create temp dir", err) != nil {
+		return report
+	}
+	defer os.RemoveAll(tmpDir)
+
+	inputFile := filepath.Join(tmpDir, "input.csv")
+	outputFile := filepath.Join(tmpDir, "output.csv")
+	if report.add("generate input file", writeSelfTestInputFile(inputFile, reward)) != nil {
+		return report
+	}
+
+	opt.InputFiles = []string{inputFile}
+	opt.OutputFiles = []string{outputFile}
+	opt.DryRun = false
+	if report.add("send rewards", opt.SendRewardsFromFile()) != nil {
+		return report
+	}
+
+	if report.add("confirm receipts", confirmSelfTestReceipts(outputFile)) != nil {
+		return report
+	}
+
+	vopt := &VerifyOption{
+		InputFile:   outputFile,
+		RewardToken: opt.RewardToken,
+		Sender:      opt.GetSender().String(),
+	}
+	if opt.RewardToken != "" {
+		_ = report.add("verify by transfer log", VerifyRewardsFromFile(vopt))
+	}
+	vopt.ByBalanceDelta = true
+	_ = report.add("verify by balance delta", VerifyRewardsFromFile(vopt))
+	return report
+}
+
+func writeSelfTestInputFile(fileName string, reward *big.Int) error {
+	lines := make([]string, 0, selfTestRecipients)
+	for i := 0; i < selfTestRecipients; i++ {
+		var addr common.Address
+		if _, err := rand.Read(addr[:]); err != nil {
+			return err
+		}
+		lines = append(lines, fmt.Sprintf("%v,%v", strings.ToLower(addr.String()), reward))
+	}
+	return ioutil.WriteFile(fileName, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
+
+func confirmSelfTestReceipts(outputFile string) error {
+	results, _, err := GetRewardResultsFromFile(outputFile)
+	if err != nil {
+		return err
+	}
+	if len(results) != selfTestRecipients {
+		return fmt.Errorf("sended %v rewards, want %v", len(results), selfTestRecipients)
+	}
+	for _, result := range results {
+		receipt, err := waitTxReceipt(common.HexToHash(result.TxHash), selfTestReceiptTimeout)
+		if err != nil {
+			return fmt.Errorf("wait receipt of %v failed, %v", result.TxHash, err)
+		}
+		if !receipt.IsSuccess() {
+			return fmt.Errorf("transaction %v failed", result.TxHash)
+		}
+	}
+	return nil
+}
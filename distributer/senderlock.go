@@ -0,0 +1,131 @@
+package distributer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+)
+
+var errSenderLocked = errors.New("sender is locked by another run")
+
+const senderLockRetryInterval = 3 * time.Second
+
+// senderLockInfo content of sender lock file, tells who holds the lock
+type senderLockInfo struct {
+	Sender string
+	PID    int
+	Host   string
+	Since  string
+}
+
+func (info *senderLockInfo) String() string {
+	if info == nil {
+		return "unknown"
+	}
+	return fmt.Sprintf("pid %v on host %v since %v", info.PID, info.Host, info.Since)
+}
+
+// acquireSenderLock create lock file '<SenderLockDir>/<sender>.lock' exclusively,
+// so runs sharing a sender do not produce nonce conflicts. on contention wait up to
+// SenderLockWait then abort. lock of a dead process on this host is taken over.
+func (opt *Option) acquireSenderLock() (detail string, err error) {
+	if opt.SenderLockDir == "" || opt.DryRun {
+		return "no sender lock", nil
+	}
+	if opt.senderLockFile != "" {
+		return "sender lock is already held", nil
+	}
+	sender := strings.ToLower(opt.GetSender().String())
+	lockFile := filepath.Join(opt.SenderLockDir, sender+".lock")
+	host, _ := os.Hostname()
+	content, _ := json.Marshal(&senderLockInfo{
+		Sender: sender,
+		PID:    os.Getpid(),
+		Host:   host,
+		Since:  time.Now().Format(time.RFC3339),
+	})
+
+	deadline := time.Now().Add(opt.SenderLockWait)
+	for {
+		err = createLockFile(lockFile, content)
+		if err == nil {
+			opt.senderLockFile = lockFile
+			log.Info("[sender lock] acquire sender lock success", "sender", sender, "lockFile", lockFile)
+			return fmt.Sprintf("sender locked by %v", lockFile), nil
+		}
+		if !os.IsExist(err) {
+			return "", fmt.Errorf("create sender lock file %v failed. %v", lockFile, err)
+		}
+		holder := readSenderLockInfo(lockFile)
+		if holder != nil && holder.Host == host && !isProcessAlive(holder.PID) {
+			log.Warn("[sender lock] take over lock of dead process", "lockFile", lockFile, "holder", holder.String())
+			_ = os.Remove(lockFile)
+			continue
+		}
+		if time.Now().After(deadline) {
+			log.Error("[sender lock] sender is locked by another run, abort", "sender", sender, "lockFile", lockFile, "holder", holder.String(), "waited", opt.SenderLockWait)
+			return "", fmt.Errorf("%w, lock file %v, holder %v. wait it to finish or remove the lock file if it's stale", errSenderLocked, lockFile, holder)
+		}
+		log.Warn("[sender lock] sender is locked by another run, wait", "sender", sender, "lockFile", lockFile, "holder", holder.String(), "deadline", deadline.Format(time.RFC3339))
+		time.Sleep(senderLockRetryInterval)
+	}
+}
+
+func (opt *Option) releaseSenderLock() {
+	if opt.senderLockFile == "" {
+		return
+	}
+	if err := os.Remove(opt.senderLockFile); err != nil {
+		log.Warn("[sender lock] release sender lock failed", "lockFile", opt.senderLockFile, "err", err)
+	} else {
+		log.Info("[sender lock] release sender lock success", "lockFile", opt.senderLockFile)
+	}
+	opt.senderLockFile = ""
+}
+
+func createLockFile(lockFile string, content []byte) error {
+	file, err := os.OpenFile(lockFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(content)
+	if errc := file.Close(); err == nil {
+		err = errc
+	}
+	if err != nil {
+		_ = os.Remove(lockFile)
+	}
+	return err
+}
+
+func readSenderLockInfo(lockFile string) *senderLockInfo {
+	content, err := ioutil.ReadFile(lockFile)
+	if err != nil {
+		return nil
+	}
+	info := &senderLockInfo{}
+	if err = json.Unmarshal(content, info); err != nil {
+		return nil
+	}
+	return info
+}
+
+func isProcessAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
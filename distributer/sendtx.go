@@ -1,25 +1,46 @@
 package distributer
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"math/big"
+	"os"
 	"strings"
 	"time"
 
+	"github.com/anyswap/ANYToken-distribution/callapi"
 	"github.com/anyswap/ANYToken-distribution/log"
 	"github.com/anyswap/ANYToken-distribution/mongodb"
 	"github.com/anyswap/ANYToken-distribution/params"
+	"github.com/anyswap/ANYToken-distribution/tracing"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/accounts"
 	"github.com/fsn-dev/fsn-go-sdk/efsn/accounts/keystore"
 	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
 	"github.com/fsn-dev/fsn-go-sdk/efsn/core/types"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/crypto"
+)
+
+const (
+	// minTxGasLimit intrinsic gas of a plain transaction
+	minTxGasLimit = 21000
+	// defaultTxGasLimit gas limit of reward transaction if not specified
+	defaultTxGasLimit = 90000
 )
 
 var (
 	transferFuncHash = common.FromHex("0xa9059cbb")
 
-	errDustReward = errors.New("dust reward")
+	errDustReward       = errors.New("dust reward")
+	errGasSpendExceeded = errors.New("gas spend exceeded")
+
+	errZeroSender     = errors.New("sender is zero address")
+	errContractSender = errors.New("sender is a contract")
+	errNonceMismatch  = errors.New("pending nonce mismatch")
+
+	errIntrinsicGasTooLow    = errors.New("intrinsic gas too low")
+	errVerifyAfterEachFailed = errors.New("transfer is not confirmed successfully")
 )
 
 // BuildTxArgs build tx args
@@ -28,6 +49,69 @@ type BuildTxArgs struct {
 	KeystoreFile string `json:"-"`
 	PasswordFile string `json:"-"`
 
+	// sign with mnemonic read from environment variable MnemonicEnv
+	MnemonicEnv    string `json:",omitempty"`
+	DerivationPath string `json:",omitempty"`
+
+	// cap of total native coin spent on gas
+	MaxGasSpend *big.Int `json:",omitempty"`
+
+	// suggested gas price lower than MinGasPrice (or zero) is replaced
+	// with MinGasPrice, or abort if AbortOnLowGasPrice is true
+	MinGasPrice        *big.Int `json:",omitempty"`
+	AbortOnLowGasPrice bool     `json:",omitempty"`
+
+	// when sending native coin, wait if our highest sent nonce
+	// is ahead of confirmed nonce by MaxNonceGap or more
+	MaxNonceGap uint64 `json:",omitempty"`
+
+	// assign sequential nonces from Nonce without querying node,
+	// for reproducible offline signing
+	FixedNonce bool `json:",omitempty"`
+
+	// before sending, assert pending nonce of node equals the expected start nonce
+	// (Nonce if specified, or confirmed nonce), instead of silently taking the max of them
+	StrictNonce bool `json:",omitempty"`
+
+	// abort on "already known" error of resending identical tx,
+	// instead of treating it as sended with the known tx hash
+	FailOnKnownTx bool `json:",omitempty"`
+
+	// on "replacement transaction underpriced" error, bump gas price by ReplaceBumpPercent
+	// and retry, increasing the bump by ReplaceBumpPercent each time up to MaxReplaceBumpPercent
+	ReplaceBumpPercent    uint64 `json:",omitempty"`
+	MaxReplaceBumpPercent uint64 `json:",omitempty"`
+
+	// per transaction ceiling of bumped gas price (unlike MaxGasSpend which is for the whole run),
+	// stop bumping once it would be exceeded, then abort, or keep resending at the ceiling
+	// price until the stuck nonce is available if WaitAtBumpCeiling is true
+	MaxBumpGasPrice   *big.Int `json:",omitempty"`
+	WaitAtBumpCeiling bool     `json:",omitempty"`
+
+	// transaction type: auto (default, detect by latest header), legacy, or dynamic
+	TxType string `json:",omitempty"`
+
+	// how to get gas price if not specified: node-suggest (default), fee-history-percentile
+	// (GasPricePercentile of priority fees in latest GasPriceBlocks blocks, 0 means default),
+	// or fixed (must specify)
+	GasPriceStrategy   string  `json:",omitempty"`
+	GasPricePercentile float64 `json:",omitempty"`
+	GasPriceBlocks     uint64  `json:",omitempty"`
+
+	// in dry run, write fully formed unsigned transactions to this file
+	// as one JSON object per recipient, nonce and gas are resolved as real run
+	UnsignedTxFile string `json:",omitempty"`
+
+	// in dry run, write transfers as a batch proposal of Sender (a Safe multisig)
+	// to this file in Safe transaction builder JSON format, instead of signing them
+	MultisigOutput string `json:",omitempty"`
+
+	// estimate gas limit of every transfer plus GasBufferPercent instead of using GasLimit,
+	// reuse the estimate across a homogeneous batch if CacheGasEstimate is true
+	EstimateGas      bool   `json:",omitempty"`
+	GasBufferPercent uint64 `json:",omitempty"`
+	CacheGasEstimate bool   `json:",omitempty"`
+
 	Nonce    *uint64
 	GasLimit *uint64
 	GasPrice *big.Int
@@ -37,6 +121,23 @@ type BuildTxArgs struct {
 	fromAddr    common.Address
 	chainID     *big.Int
 	chainSigner types.Signer
+	gasSpent    *big.Int
+	lastSentTx  *sentTxInfo
+
+	unsignedTxEncoder *json.Encoder
+	multisigBatch     *SafeTxBatch
+	gasEstimates      map[common.Address]*gasEstimateCache
+}
+
+// sentTxInfo nonce and gas actually used by the last sent transaction
+type sentTxInfo struct {
+	nonce    uint64
+	gasLimit uint64
+	gasPrice *big.Int
+
+	// set from receipt when the transaction is confirmed
+	gasUsed           uint64
+	effectiveGasPrice *big.Int
 }
 
 // GetSender get sender from keystore
@@ -54,13 +155,71 @@ func (args *BuildTxArgs) Check(dryRun bool) error {
 	if args.Sender != "" && !common.IsHexAddress(args.Sender) {
 		return fmt.Errorf("wrong sender address '%v'", args.Sender)
 	}
+	if args.KeystoreFile != "" && args.MnemonicEnv != "" {
+		return fmt.Errorf("can not specify both keystore and mnemonic")
+	}
+	if args.GasLimit != nil && *args.GasLimit < minTxGasLimit {
+		return fmt.Errorf("gas limit %v is lower than intrinsic gas %v of any transaction", *args.GasLimit, minTxGasLimit)
+	}
+	if !IsValidTxType(args.TxType) {
+		return fmt.Errorf("unknown transaction type '%v'", args.TxType)
+	}
+	if err := args.checkGasPriceStrategy(); err != nil {
+		return err
+	}
+	if args.UnsignedTxFile != "" {
+		if !dryRun {
+			return fmt.Errorf("emit unsigned transactions is only supported in dry run")
+		}
+		if args.Sender == "" {
+			return fmt.Errorf("must specify sender to emit unsigned transactions")
+		}
+	}
+	if args.MultisigOutput != "" {
+		if !dryRun {
+			return fmt.Errorf("multisig output is only supported in dry run")
+		}
+		if args.Sender == "" {
+			return fmt.Errorf("must specify multisig address as sender to write multisig proposals")
+		}
+		if args.UnsignedTxFile != "" {
+			return fmt.Errorf("can not specify both multisig output and unsigned transactions")
+		}
+	}
+	if args.ReplaceBumpPercent > args.MaxReplaceBumpPercent {
+		return fmt.Errorf("replace bump percent %v is greater than max replace bump percent %v", args.ReplaceBumpPercent, args.MaxReplaceBumpPercent)
+	}
+	if args.MaxBumpGasPrice != nil {
+		if args.ReplaceBumpPercent == 0 {
+			return fmt.Errorf("max bump gas price is specified without replace bump percent")
+		}
+		if args.GasPrice != nil && args.MaxBumpGasPrice.Cmp(args.GasPrice) < 0 {
+			return fmt.Errorf("max bump gas price %v is lower than gas price %v", args.MaxBumpGasPrice, args.GasPrice)
+		}
+	}
+	if args.CacheGasEstimate && !args.EstimateGas {
+		return fmt.Errorf("cache gas estimate requires estimate gas")
+	}
+	if args.FixedNonce {
+		if args.Nonce == nil {
+			return fmt.Errorf("must specify start nonce in fixed nonce mode")
+		}
+		if args.MaxNonceGap != 0 {
+			return fmt.Errorf("can not specify max nonce gap in fixed nonce mode")
+		}
+	}
 	if !dryRun {
-		err := args.loadKeyStore()
+		err := args.loadKey()
 		if err != nil {
 			return err
 		}
-		if !strings.EqualFold(args.Sender, args.fromAddr.String()) {
-			return fmt.Errorf("sender mismatch. sender from args = '%v', sender from keystore = '%v'", args.Sender, args.fromAddr.String())
+		if err = args.checkSenderIsEOA(); err != nil {
+			return err
+		}
+		if args.StrictNonce {
+			if err = args.checkStrictNonce(); err != nil {
+				return err
+			}
 		}
 	} else {
 		if args.Sender != "" {
@@ -70,14 +229,75 @@ func (args *BuildTxArgs) Check(dryRun bool) error {
 		}
 	}
 	log.Info("get build transaction's sender", "sender", args.Sender)
-	args.setDefaults()
+	return args.setDefaults()
+}
+
+// loadKey load signing key from mnemonic or keystore, the derived address must match Sender if specified
+func (args *BuildTxArgs) loadKey() error {
+	var err error
+	switch {
+	case args.MnemonicEnv != "":
+		err = args.loadMnemonic()
+	case args.KeystoreFile != "":
+		err = args.loadKeyStore()
+	default:
+		err = fmt.Errorf("must specify keystore or mnemonic to sign transaction")
+	}
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(args.Sender, args.fromAddr.String()) {
+		return fmt.Errorf("sender mismatch. sender from args = '%v', sender from keystore = '%v'", args.Sender, args.fromAddr.String())
+	}
+	return nil
+}
+
+// checkSenderIsEOA resolved sender must be a non-zero externally owned account,
+// a zero or contract sender means wrong keystore or key derivation
+func (args *BuildTxArgs) checkSenderIsEOA() error {
+	if args.fromAddr == (common.Address{}) {
+		return errZeroSender
+	}
+	isContract, err := capi.IsContract(args.fromAddr)
+	if err != nil {
+		return fmt.Errorf("check sender %v is contract failed. %v", args.fromAddr.String(), err)
+	}
+	if isContract {
+		return fmt.Errorf("%w: %v, please check keystore or mnemonic derivation path", errContractSender, args.fromAddr.String())
+	}
+	return nil
+}
+
+// checkStrictNonce pending nonce of node must equal the expected start nonce,
+// which is the specified nonce, or confirmed nonce of sender if not specified.
+// mismatch means there're pending external transactions or the specified nonce is wrong.
+func (args *BuildTxArgs) checkStrictNonce() error {
+	pendingNonce, err := capi.GetAccountNonce(args.fromAddr)
+	if err != nil {
+		return fmt.Errorf("get pending nonce of sender failed. %v", err)
+	}
+	expected := "specified"
+	var startNonce uint64
+	if args.Nonce != nil {
+		startNonce = *args.Nonce
+	} else {
+		expected = "confirmed"
+		startNonce, err = capi.GetAccountConfirmedNonce(args.fromAddr)
+		if err != nil {
+			return fmt.Errorf("get confirmed nonce of sender failed. %v", err)
+		}
+	}
+	if pendingNonce != startNonce {
+		return fmt.Errorf("%w: pending nonce of node is %v, %v start nonce is %v", errNonceMismatch, pendingNonce, expected, startNonce)
+	}
+	log.Info("strict nonce check passed", "sender", args.fromAddr.String(), "nonce", startNonce)
 	return nil
 }
 
 func (args *BuildTxArgs) loadKeyStore() error {
 	keyfile := args.KeystoreFile
 	passfile := args.PasswordFile
-	keyjson, err := ioutil.ReadFile(keyfile)
+	keyjson, err := args.readKeyStore(keyfile)
 	if err != nil {
 		log.Println("read keystore fail", err)
 		return err
@@ -103,8 +323,52 @@ func (args *BuildTxArgs) loadKeyStore() error {
 	return nil
 }
 
-func (args *BuildTxArgs) setDefaults() {
+// readKeyStore read keystore file, or find keystore of sender if it's a directory
+func (args *BuildTxArgs) readKeyStore(keyfile string) ([]byte, error) {
+	info, err := os.Stat(keyfile)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return ioutil.ReadFile(keyfile)
+	}
+	if args.Sender == "" {
+		return nil, fmt.Errorf("must specify sender to find its keystore in directory '%v'", keyfile)
+	}
+	return findKeyStoreInDir(keyfile, common.HexToAddress(args.Sender))
+}
+
+func (args *BuildTxArgs) loadMnemonic() error {
+	mnemonic := os.Getenv(args.MnemonicEnv)
+	if mnemonic == "" {
+		return fmt.Errorf("empty mnemonic in environment variable '%v'", args.MnemonicEnv)
+	}
+	path := args.DerivationPath
+	if path == "" {
+		path = accounts.DefaultBaseDerivationPath.String()
+		args.DerivationPath = path
+	}
+	log.Println("derive key from mnemonic ......", "path", path)
+	privKey, err := deriveKeyFromMnemonic(mnemonic, path)
+	if err != nil {
+		// never log the mnemonic
+		log.Println("derive key from mnemonic fail", err)
+		return err
+	}
+	args.keyWrapper = &keystore.Key{
+		Address:    crypto.PubkeyToAddress(privKey.PublicKey),
+		PrivateKey: privKey,
+	}
+	args.fromAddr = args.keyWrapper.Address
+	if args.Sender == "" {
+		args.Sender = args.fromAddr.String()
+	}
+	return nil
+}
+
+func (args *BuildTxArgs) setDefaults() error {
 	from := args.fromAddr
+	explicitGasPrice := args.GasPrice != nil
 	var err error
 	for {
 		if args.chainID == nil {
@@ -127,61 +391,141 @@ func (args *BuildTxArgs) setDefaults() {
 		}
 		log.Info("get nonce succeed", "from", from.String(), "nonce", *args.Nonce)
 		if args.GasPrice == nil {
-			args.GasPrice, err = capi.SuggestGasPrice()
+			args.GasPrice, err = args.getGasPriceByStrategy()
 			if err != nil {
 				log.Warn("get gas price error", "err", err)
 				continue
 			}
+			if err = args.checkSuggestedGasPrice(); err != nil {
+				return err
+			}
 		}
 		log.Info("get gas price succeed", "gasPrice", args.GasPrice)
 		if args.GasLimit == nil {
-			defaultGasLimit := uint64(90000)
+			defaultGasLimit := uint64(defaultTxGasLimit)
 			args.GasLimit = &defaultGasLimit
 		}
 		log.Info("get gas limit succeed", "gasLimit", *args.GasLimit)
 		break
 	}
+	return args.detectTxType(explicitGasPrice)
+}
+
+// checkSuggestedGasPrice suggested gas price must not be zero or lower than min gas price
+func (args *BuildTxArgs) checkSuggestedGasPrice() error {
+	minGasPrice := args.MinGasPrice
+	if minGasPrice == nil {
+		minGasPrice = big.NewInt(1)
+	}
+	if args.GasPrice.Cmp(minGasPrice) >= 0 {
+		return nil
+	}
+	if args.AbortOnLowGasPrice || args.MinGasPrice == nil {
+		log.Error("suggested gas price is too low", "gasPrice", args.GasPrice, "minGasPrice", args.MinGasPrice)
+		return fmt.Errorf("suggested gas price %v is lower than min gas price %v, please specify gas price explicitly", args.GasPrice, minGasPrice)
+	}
+	log.Warn("suggested gas price is too low, use min gas price instead", "suggested", args.GasPrice, "minGasPrice", args.MinGasPrice)
+	args.GasPrice = new(big.Int).Set(args.MinGasPrice)
+	return nil
 }
 
-func (args *BuildTxArgs) sendRewardsTransaction(account common.Address, reward *big.Int, rewardToken common.Address, dryRun bool) (txHash *common.Hash, err error) {
+func (args *BuildTxArgs) sendRewardsTransaction(account common.Address, reward *big.Int, rewardToken common.Address, dryRun bool, gasLimitOverride *uint64, gasPriceOverride *big.Int) (txHash *common.Hash, err error) {
 	dustRewardThreshold := params.GetDustRewardThreshold()
 	if reward.Cmp(dustRewardThreshold) < 0 {
 		log.Info("sendRewards ignore dust reward", "account", account.String(), "reward", reward, "dustRewardThreshold", dustRewardThreshold)
 		return nil, errDustReward
 	}
-	if dryRun {
+	if dryRun && args.MultisigOutput != "" {
+		return nil, args.addMultisigProposal(account, reward, rewardToken)
+	}
+	if dryRun && args.UnsignedTxFile == "" {
 		log.Info("sendRewards dry run", "account", account.String(), "reward", reward)
 		return nil, nil
 	}
 
-	nonce, err := capi.GetAccountNonce(args.fromAddr)
-	if err == nil && nonce > *args.Nonce {
-		*args.Nonce = nonce
+	buildSpan := tracing.StartSpan("build tx")
+	if !args.FixedNonce {
+		nonce, errn := capi.GetAccountNonce(args.fromAddr)
+		if errn == nil && nonce > *args.Nonce {
+			*args.Nonce = nonce
+		}
+	}
+
+	gasLimit, gasPrice := *args.GasLimit, args.GasPrice
+	if gasLimitOverride != nil {
+		gasLimit = *gasLimitOverride
+	} else if args.EstimateGas {
+		gasLimit, err = args.getEstimatedGasLimit(account, reward, rewardToken)
+		if err != nil {
+			buildSpan.End(err)
+			return nil, &unsentTxError{err}
+		}
+	}
+	if gasPriceOverride != nil {
+		gasPrice = gasPriceOverride
+	}
+	if gasLimitOverride != nil || gasPriceOverride != nil {
+		log.Info("sendRewards use gas override", "account", account.String(), "gasLimit", gasLimit, "gasPrice", gasPrice)
+	}
+	txGasCost := estimateGasCost(gasLimit, gasPrice)
+
+	if err = args.checkGasSpend(txGasCost); err != nil {
+		buildSpan.End(err)
+		return nil, err
 	}
 
 	var rawTx *types.Transaction
 
 	if rewardToken != (common.Address{}) {
-		data := make([]byte, 68)
-		copy(data[:4], transferFuncHash)
-		copy(data[4:36], account.Hash().Bytes())
-		copy(data[36:68], common.LeftPadBytes(reward.Bytes(), 32))
-
-		rawTx = types.NewTransaction(*args.Nonce, rewardToken, big.NewInt(0), *args.GasLimit, args.GasPrice, data)
+		data := packTransferData(account, reward)
+		rawTx = types.NewTransaction(*args.Nonce, rewardToken, big.NewInt(0), gasLimit, gasPrice, data)
 	} else {
-		rawTx = types.NewTransaction(*args.Nonce, account, reward, *args.GasLimit, args.GasPrice, nil)
+		if !dryRun {
+			args.waitNonceGap()
+		}
+		rawTx = types.NewTransaction(*args.Nonce, account, reward, gasLimit, gasPrice, nil)
 	}
+	buildSpan.SetAttributes("nonce", *args.Nonce, "gasLimit", gasLimit, "gasPrice", gasPrice)
+	buildSpan.End(nil)
 
+	if dryRun {
+		return nil, args.emitUnsignedTx(rawTx, account, reward, rewardToken)
+	}
+
+	signSpan := tracing.StartSpan("sign tx")
 	signedTx, err := types.SignTx(rawTx, args.chainSigner, args.keyWrapper.PrivateKey)
+	signSpan.End(err)
 	if err != nil {
-		return nil, fmt.Errorf("sign tx failed, %v", err)
+		return nil, &unsentTxError{fmt.Errorf("sign tx failed, %v", err)}
 	}
 
+	broadcastSpan := tracing.StartSpan("broadcast tx", "txhash", signedTx.Hash().String())
 	err = capi.SendTransaction(signedTx)
+	if err != nil && args.ReplaceBumpPercent > 0 && isReplacementUnderpricedError(err) {
+		log.Warn("sendRewards replacement underpriced, bump gas price", "account", account.String(), "nonce", *args.Nonce, "gasPrice", gasPrice, "err", err)
+		signedTx, err = args.sendReplacementWithBump(rawTx, account)
+		if err != nil {
+			broadcastSpan.End(err)
+			return nil, err
+		}
+		gasPrice = signedTx.GasPrice()
+		txGasCost = estimateGasCost(gasLimit, gasPrice)
+	}
+	broadcastSpan.End(err)
 	if err != nil {
-		return nil, fmt.Errorf("send tx failed, %v", err)
+		if !callapi.IsKnownTxError(err) || args.FailOnKnownTx {
+			return nil, classifySendError(err, gasLimit)
+		}
+		// identical tx is already in mempool (eg. resend when resume), treat it as sended
+		log.Warn("sendRewards tx is already known, treat as success", "account", account.String(), "reward", reward, "txHash", signedTx.Hash().String(), "err", err)
+	}
+	args.lastSentTx = &sentTxInfo{
+		nonce:    *args.Nonce,
+		gasLimit: gasLimit,
+		gasPrice: gasPrice,
 	}
 	*args.Nonce++
+	args.addGasSpent(txGasCost)
 
 	signedTxHash := signedTx.Hash()
 	txHash = &signedTxHash
@@ -189,15 +533,167 @@ func (args *BuildTxArgs) sendRewardsTransaction(account common.Address, reward *
 	return txHash, nil
 }
 
-func (opt *Option) checkSendRewardsFromFile(ifile string) (mongodb.AccountStatSlice, error) {
-	accountStats, _, err := GetAccountsAndRewardsFromFile(ifile)
+// packTransferData pack calldata of token transfer(account, reward)
+func packTransferData(account common.Address, reward *big.Int) []byte {
+	data := make([]byte, 68)
+	copy(data[:4], transferFuncHash)
+	copy(data[4:36], account.Hash().Bytes())
+	copy(data[36:68], common.LeftPadBytes(reward.Bytes(), 32))
+	return data
+}
+
+// unsentTxError is an error of building or signing tx, which proves the tx is not broadcast
+type unsentTxError struct {
+	err error
+}
+
+func (e *unsentTxError) Error() string { return e.err.Error() }
+
+func (e *unsentTxError) Unwrap() error { return e.err }
+
+// isSafeToRebuild is the failed send proved not broadcast, so rebuild it with a new nonce
+// will not pay twice. nonce too low rejects the tx before it enters mempool.
+// other broadcast errors (eg. timeout) may have reached the node, they are never rebuilt.
+func isSafeToRebuild(err error) bool {
+	var unsent *unsentTxError
+	return errors.As(err, &unsent) || callapi.IsNonceTooLowError(err)
+}
+
+// classifySendError convert known send errors to actionable errors
+func classifySendError(err error, gasLimit uint64) error {
+	if strings.Contains(err.Error(), errIntrinsicGasTooLow.Error()) {
+		return fmt.Errorf("send tx failed, %w: gas limit %v is not enough, please specify a higher gas limit by '--gasLimit' (or per recipient 'gasLimit=' in input file)", errIntrinsicGasTooLow, gasLimit)
+	}
+	return fmt.Errorf("send tx failed, %v", err)
+}
+
+// waitNonceGap wait until the gap between next nonce and confirmed nonce is below MaxNonceGap
+func (args *BuildTxArgs) waitNonceGap() {
+	if args.MaxNonceGap == 0 {
+		return
+	}
+	for i := 0; ; i++ {
+		confirmedNonce, err := capi.GetAccountConfirmedNonce(args.fromAddr)
+		if err != nil {
+			log.Warn("[nonce pacing] get confirmed nonce failed", "err", err)
+		} else if *args.Nonce < confirmedNonce+args.MaxNonceGap {
+			return
+		} else if i%10 == 0 {
+			log.Info("[nonce pacing] wait confirmed nonce catch up", "nonce", *args.Nonce, "confirmedNonce", confirmedNonce, "maxNonceGap", args.MaxNonceGap)
+		}
+		if errb := capi.CheckErrorBudget(); errb != nil {
+			return // let the send loop abort
+		}
+		time.Sleep(3 * time.Second)
+	}
+}
+
+// GetGasSpent get accumulated gas cost of sent transactions
+func (args *BuildTxArgs) GetGasSpent() *big.Int {
+	if args.gasSpent == nil {
+		return big.NewInt(0)
+	}
+	return new(big.Int).Set(args.gasSpent)
+}
+
+func (args *BuildTxArgs) addGasSpent(cost *big.Int) {
+	if args.gasSpent == nil {
+		args.gasSpent = big.NewInt(0)
+	}
+	args.gasSpent.Add(args.gasSpent, cost)
+}
+
+// reconcileGasSpent replace estimated gas cost (gasLimit * gasPrice) of the last sent transaction
+// in gas spent with the actual cost (gasUsed * effectiveGasPrice) from its receipt.
+// in EIP-1559 the price paid depends on base fee at inclusion, and receipts of
+// legacy nodes have no effectiveGasPrice, then the gas price of transaction is used.
+func (args *BuildTxArgs) reconcileGasSpent(receipt *callapi.RPCReceipt) {
+	sent := args.lastSentTx
+	if sent == nil || sent.effectiveGasPrice != nil || receipt.GasUsed == nil {
+		return
+	}
+	effectiveGasPrice := sent.gasPrice
+	if receipt.EffectiveGasPrice != nil {
+		effectiveGasPrice = receipt.EffectiveGasPrice.ToInt()
+	}
+	gasUsed := uint64(*receipt.GasUsed)
+	estimated := estimateGasCost(sent.gasLimit, sent.gasPrice)
+	actual := estimateGasCost(gasUsed, effectiveGasPrice)
+	args.addGasSpent(new(big.Int).Sub(actual, estimated))
+	sent.gasUsed = gasUsed
+	sent.effectiveGasPrice = effectiveGasPrice
+	log.Info("reconcile gas spent with receipt", "txHash", receipt.TxHash.String(), "gasUsed", gasUsed, "effectiveGasPrice", effectiveGasPrice, "estimated", estimated, "actual", actual, "gasSpent", args.GetGasSpent())
+}
+
+// estimateGasCost estimate upper bound of gas cost (gasLimit * gasPrice)
+func estimateGasCost(gasLimit uint64, gasPrice *big.Int) *big.Int {
+	return new(big.Int).Mul(new(big.Int).SetUint64(gasLimit), gasPrice)
+}
+
+// checkGasSpend check sending another tx will not exceed max gas spend
+func (args *BuildTxArgs) checkGasSpend(cost *big.Int) error {
+	if args.MaxGasSpend == nil {
+		return nil
+	}
+	spent := args.GetGasSpent()
+	if new(big.Int).Add(spent, cost).Cmp(args.MaxGasSpend) > 0 {
+		log.Error("[checkGasSpend] max gas spend will be exceeded", "gasSpent", spent, "txGasCost", cost, "maxGasSpend", args.MaxGasSpend)
+		return errGasSpendExceeded
+	}
+	return nil
+}
+
+func (opt *Option) checkSendRewardsFromFile(i int) (accountStats mongodb.AccountStatSlice, titleLine string, err error) {
+	ifile := opt.InputFiles[i]
+	err = opt.checkUniqueRecipients(ifile)
+	if err != nil {
+		return nil, "", err
+	}
+	accountStats, titleLine, err = GetAccountsAndRewardsFromFile(ifile)
 	if err != nil {
 		log.Error("[sendRewards] get accounts and rewards from input file failed", "inputfile", ifile, "err", err)
-		return nil, err
+		return nil, "", err
+	}
+	accountStats = opt.skipZeroRewards(accountStats)
+	err = opt.checkCommitment(i, accountStats)
+	if err != nil {
+		return nil, "", err
 	}
 	if len(accountStats) == 0 {
 		log.Warn("empty account list, no need to send reward")
-		return nil, nil
+		return nil, "", nil
+	}
+	if chain := getMultiChainTag(accountStats); chain != "" {
+		log.Error("[sendRewards] input file has per recipient chain, please use 'sendmultichain' command", "inputfile", ifile, "chain", chain)
+		return nil, "", errChainTagInSingleChain
+	}
+	err = opt.checkTitleLine(titleLine)
+	if err != nil {
+		log.Error("[sendRewards] check title line failed", "inputfile", ifile, "err", err)
+		return nil, "", err
+	}
+	accountStats, err = opt.checkBurnAddresses(accountStats)
+	if err != nil {
+		log.Error("[sendRewards] check burn addresses failed", "inputfile", ifile, "err", err)
+		return nil, "", err
+	}
+	accountStats, err = opt.checkBlocklist(accountStats)
+	if err != nil {
+		log.Error("[sendRewards] check blocklist failed", "inputfile", ifile, "err", err)
+		return nil, "", err
+	}
+	accountStats, err = opt.checkEligibility(accountStats)
+	if err != nil {
+		log.Error("[sendRewards] check eligibility failed", "inputfile", ifile, "err", err)
+		return nil, "", err
+	}
+
+	if opt.InputWeights {
+		err = opt.convertWeightsToRewards(accountStats)
+		if err != nil {
+			log.Error("[sendRewards] convert weights to rewards failed", "inputfile", ifile, "err", err)
+			return nil, "", err
+		}
 	}
 
 	// scaling reward value
@@ -210,18 +706,81 @@ func (opt *Option) checkSendRewardsFromFile(ifile string) (mongodb.AccountStatSl
 		}
 	}
 
-	// assign total value before check balance
-	opt.TotalValue = accountStats.CalcTotalReward()
-	if opt.RewardToken != "" {
-		err = opt.CheckSenderRewardTokenBalance()
-	} else {
-		err = opt.CheckSenderCoinBalance()
+	if len(opt.AssertTotals) != 0 {
+		err = opt.assertTotalReward(opt.AssertTotals[i], accountStats.CalcTotalReward())
+		if err != nil {
+			log.Error("[sendRewards] assert total reward failed", "inputfile", ifile, "err", err)
+			return nil, "", err
+		}
 	}
+
+	err = opt.checkSupplyRatio(accountStats.CalcTotalReward())
 	if err != nil {
-		return nil, err
+		log.Error("[sendRewards] check supply ratio failed", "inputfile", ifile, "err", err)
+		return nil, "", err
 	}
 
-	return accountStats, nil
+	return accountStats, titleLine, nil
+}
+
+// assertTotalReward computed total reward must equal declared total within tolerance
+func (opt *Option) assertTotalReward(declared, computed *big.Int) error {
+	diff := new(big.Int).Sub(computed, declared)
+	tolerance := opt.AssertTolerance
+	if tolerance == nil {
+		tolerance = big.NewInt(0)
+	}
+	if new(big.Int).Abs(diff).Cmp(tolerance) > 0 {
+		return fmt.Errorf("total reward mismatch, declared %v, computed %v, diff %v, tolerance %v", declared, computed, diff, tolerance)
+	}
+	log.Info("[sendRewards] assert total reward success", "declared", declared, "computed", computed, "tolerance", tolerance)
+	return nil
+}
+
+// checkTitleLine check title line is consistent with option.
+// title line format: #account,reward,<keyShare>,<keyNumber>[,txhash],<extraInfo>
+// reward token is sourced from title line if it's not specified explicitly
+func (opt *Option) checkTitleLine(titleLine string) error {
+	if titleLine == "" {
+		return nil
+	}
+	title := parseTitleLine(titleLine, false)
+	if len(title.Columns) >= 4 && opt.byWhat != "" && opt.byWhat != customMethodID {
+		keyShare := GetStandardByWhat(title.Columns[2])
+		if keyShare != "" && keyShare != opt.byWhat {
+			return fmt.Errorf("byWhat mismatch, title line is '%v', option is '%v'", keyShare, opt.byWhat)
+		}
+	}
+	titleToken := getTitleExtraInfo(titleLine, "rewardToken")
+	if titleToken == "" {
+		return nil
+	}
+	if !common.IsHexAddress(titleToken) {
+		return fmt.Errorf("wrong reward token '%v' in title line", titleToken)
+	}
+	if opt.RewardToken == "" {
+		log.Info("use reward token from title line", "rewardToken", titleToken)
+		opt.RewardToken = titleToken
+		return nil
+	}
+	if common.HexToAddress(titleToken) != common.HexToAddress(opt.RewardToken) {
+		return fmt.Errorf("reward token mismatch, title line is '%v', option is '%v'", titleToken, opt.RewardToken)
+	}
+	return nil
+}
+
+// getTitleExtraInfo get value of key in extra info of title line,
+// extra info format: key1=value1&&key2=value2
+func getTitleExtraInfo(titleLine, key string) string {
+	parts := strings.Split(titleLine, ",")
+	extraInfo := parts[len(parts)-1]
+	for _, kv := range strings.Split(extraInfo, "&&") {
+		pos := strings.Index(kv, "=")
+		if pos > 0 && kv[:pos] == key {
+			return kv[pos+1:]
+		}
+	}
+	return ""
 }
 
 // SendRewardsFromFile send rewards from file
@@ -237,68 +796,203 @@ func (opt *Option) SendRewardsFromFile() (err error) {
 		return fmt.Errorf("count of input and output files is not equal")
 	}
 
+	err = opt.checkInputWeightsOption()
+	if err != nil {
+		return err
+	}
+	if len(opt.AssertTotals) != 0 && len(opt.AssertTotals) != len(opt.InputFiles) {
+		return fmt.Errorf("count of assert totals and input files is not equal")
+	}
+	if len(opt.ExpectedCommitments) != 0 && len(opt.ExpectedCommitments) != len(opt.InputFiles) {
+		return fmt.Errorf("count of expected commitments and input files is not equal")
+	}
+
+	defer opt.releaseSenderLock()
+	span := tracing.StartSpan("sendrewards", "files", len(opt.InputFiles), "dryrun", opt.DryRun)
+	defer func() { span.End(err) }()
+	inputs, err := opt.warmUp()
+	if err != nil {
+		log.Error("[sendRewards] warm up failed, nothing is sended", "err", err)
+		return err
+	}
+	span.SetAttributes("sender", opt.GetSender().String(), "recipients", opt.warmUpReport.recipients)
+	err = opt.confirmSend(inputs)
+	if err != nil {
+		return err
+	}
+
+	opt.initReplayLog()
+	defer func() { opt.saveReplayLog(err) }()
+
 	totalRewardsSended := big.NewInt(0)
 
 	var rewardsSended *big.Int
 	var exchange string
-	for i, inputFile := range opt.InputFiles {
+	var failedErr error
+	for i, input := range inputs {
 		if len(opt.Exchanges) != 0 {
 			exchange = opt.Exchanges[i]
 		}
 		outputFile := opt.OutputFiles[i]
-		rewardsSended, err = opt.sendRewardsFromFile(exchange, inputFile, outputFile)
+		rewardsSended, err = opt.sendRewardsFromFile(exchange, input, outputFile)
 		if rewardsSended != nil {
 			totalRewardsSended.Add(totalRewardsSended, rewardsSended)
 		}
 		if err != nil {
-			log.Error("send reward from file failed", "exchange", exchange, "index", i, "input", inputFile, "output", outputFile, "err", err)
+			log.Error("send reward from file failed", "exchange", exchange, "index", i, "input", input.file, "output", outputFile, "err", err)
+			if opt.ContinueOnError && errors.Is(err, errSendTransactionFailed) {
+				failedErr, err = err, nil
+				continue
+			}
 			break
 		}
 	}
+	if err == nil {
+		err = failedErr
+	}
 	log.Infof("total sended reward is %v, input file count is %v\n", totalRewardsSended, len(opt.InputFiles))
-	return err
-}
-
-func (opt *Option) sendRewardsFromFile(exchange, ifile, ofile string) (rewardsSended *big.Int, err error) {
-	accountStats, err := opt.checkSendRewardsFromFile(ifile)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if err = opt.BuildTxArgs.WriteMultisigProposals(); err != nil {
+		return err
 	}
-	outputFile, err := openOutputFile(ofile)
+	return opt.sweepToTreasury()
+}
+
+func (opt *Option) sendRewardsFromFile(exchange string, input *sendInput, ofile string) (rewardsSended *big.Int, err error) {
+	ifile, accountStats, titleLine := input.file, input.accountStats, input.titleLine
+	opt.TotalValue = input.totalReward
+	file, err := openOutputFile(ofile)
 	if err != nil {
 		return nil, err
 	}
+	defer file.Close()
+	outputFile := opt.wrapMerkleResultWriter(opt.newResultWriter(opt.newOutputWriter(file)), ofile)
+	outputFile = opt.wrapSignResultWriter(outputFile, ofile)
+	defer func() { _ = outputFile.Flush() }()
 
 	log.Info("call send rewards from file", "input", ifile, "output", ofile)
 	defer opt.deinit()
 
+	span := tracing.StartSpan("send file", "input", ifile, "output", ofile, "recipients", len(accountStats))
+	defer func() {
+		span.SetAttributes("rewardsSended", rewardsSended)
+		span.End(err)
+	}()
+
+	title := parseTitleLine(titleLine, !opt.DryRun)
+	title.HasTxInfo = opt.VerboseOutput && !opt.DryRun
+	_ = outputFile.WriteTitle(title)
+
+	var split *splitOutput
+	if opt.SplitOutput {
+		split, err = opt.openSplitOutput(ofile, parseTitleLine(titleLine, false))
+		if err != nil {
+			return nil, err
+		}
+		defer split.close()
+		for _, skipped := range input.skipped {
+			split.writeSkipped(skipped.stat, skipped.reason)
+		}
+	}
+
 	rewardsSended = big.NewInt(0)
 	totalDustReward := big.NewInt(0)
 	totalDustRewardCount := 0
+	var sentResults []*RewardResult
+	// in continue on error mode, failed sends which are proved not broadcast
+	// are appended to stats to retry once after the first pass, others are recorded as failed
+	stats := accountStats[:len(accountStats):len(accountStats)]
+	firstPassCount := len(stats)
+	failedCount := 0
 	i := uint64(0)
-	for _, stat := range accountStats {
+	for index := 0; index < len(stats); index++ {
+		stat := stats[index]
+		retrying := index >= firstPassCount
+		if index == firstPassCount {
+			log.Info("[sendRewardsFromFile] retry deferred failed sends", "count", len(stats)-firstPassCount)
+		}
 		account := stat.Account
 		reward := stat.Reward
 		if reward == nil || reward.Sign() <= 0 {
 			log.Info("ignore zero reward line", "account", account)
+			split.writeSkipped(stat, SkipReasonZero)
+			continue
+		}
+		if err = capi.CheckErrorBudget(); err != nil {
+			log.Error("[sendRewardsFromFile] abort as rpc failures budget exhausted", "err", err)
+			return rewardsSended, err
+		}
+		if err = opt.waitMinClients(); err != nil {
+			return rewardsSended, err
+		}
+		var unpaid *mongodb.AccountStat
+		unpaid, err = opt.checkPaid(stat)
+		if err != nil {
+			return rewardsSended, err
+		}
+		if unpaid == nil {
+			split.writeSkipped(stat, SkipReasonAlreadyPaid)
 			continue
 		}
-		txHash, err := opt.SendRewardsTransaction(account, reward)
+		stat, reward = unpaid, unpaid.Reward
+		if err = opt.revalidateToken(stat); err != nil {
+			return rewardsSended, err
+		}
+		rewardSpan := startRewardSpan(stat)
+		var txHash *common.Hash
+		txHash, err = opt.SendRewardsTransactionWithGas(account, reward, stat.GasLimit, stat.GasPrice)
+		opt.addReplayRecord(exchange, stat, txHash, err)
+		opt.markPaid(stat, txHash)
 		switch err {
 		case nil:
+			opt.countRevalidateSend()
 		case errDustReward:
 			totalDustReward.Add(totalDustReward, reward)
 			totalDustRewardCount++
+			split.writeSkipped(stat, SkipReasonDust)
+		case errGasSpendExceeded:
+			log.Error("[sendRewardsFromFile] abort as max gas spend exceeded", "gasSpent", opt.BuildTxArgs.GetGasSpent())
+			endRewardSpan(rewardSpan, txHash, err, nil)
+			split.writeFailed(stat)
+			return rewardsSended, err
 		default:
-			log.Error("[sendRewardsFromFile] send tx failed", "account", account.String(), "reward", reward, "dryrun", opt.DryRun, "err", err)
+			endRewardSpan(rewardSpan, txHash, err, nil)
+			log.Error("[sendRewardsFromFile] send tx failed", "account", account.String(), "reward", reward, "dryrun", opt.DryRun, "retrying", retrying, "err", err)
+			if opt.ContinueOnError && !retrying && isSafeToRebuild(err) {
+				log.Warn("[sendRewardsFromFile] defer failed send to retry at the end", "account", account.String(), "reward", reward)
+				stats = append(stats, stats[index])
+				continue
+			}
+			split.writeFailed(stat)
+			if opt.ContinueOnError {
+				failedCount++
+				continue
+			}
 			return rewardsSended, errSendTransactionFailed
 		}
 		rewardsSended.Add(rewardsSended, reward)
+		// verify before write body, so output has the effective gas price of receipt
+		var verifyErr error
+		if txHash != nil && opt.VerifyAfterEach {
+			verifyErr = opt.verifySentTx(*txHash, account, reward)
+		}
+		endRewardSpan(rewardSpan, txHash, err, verifyErr)
 		if opt.DryRun || txHash != nil {
 			// write body
 			_ = opt.WriteSendRewardResult(outputFile, exchange, stat, txHash)
 			i++
 		}
+		if txHash != nil {
+			sentResults = append(sentResults, &RewardResult{Account: account.String(), Reward: reward, TxHash: txHash.String()})
+			if opt.VerifyAfterEach {
+				if verifyErr != nil {
+					return rewardsSended, verifyErr
+				}
+				continue
+			}
+		}
 		if !opt.DryRun && opt.BatchCount > 0 && i%opt.BatchCount == 0 {
 			time.Sleep(time.Duration(opt.BatchInterval) * time.Millisecond)
 		}
@@ -311,6 +1005,39 @@ func (opt *Option) sendRewardsFromFile(exchange, ifile, ofile string) (rewardsSe
 		"allRewardsSended", opt.TotalValue == nil || rewardsSended.Cmp(opt.TotalValue) == 0,
 		"totalDustReward", totalDustReward,
 		"totalDustRewardCount", totalDustRewardCount,
+		"failedCount", failedCount,
+		"gasSpent", opt.BuildTxArgs.GetGasSpent(),
 	)
+	if err = checkDuplicateTxHashes(sentResults); err != nil {
+		return rewardsSended, err
+	}
+	if failedCount > 0 {
+		return rewardsSended, fmt.Errorf("%w, %v sends failed", errSendTransactionFailed, failedCount)
+	}
 	return rewardsSended, nil
 }
+
+// verifySentTx wait sent transaction confirmed successfully in verify after each mode
+func (opt *Option) verifySentTx(txHash common.Hash, account common.Address, reward *big.Int) error {
+	confirmations := uint64(1)
+	if opt.Confirm != nil {
+		if required := opt.Confirm.RequiredConfirmations(reward); required > confirmations {
+			confirmations = required
+		}
+	}
+	span := tracing.StartSpan("confirm tx", "txhash", txHash.String(), "confirmations", confirmations)
+	receipt, err := waitTxConfirmations(txHash, confirmations, opt.ConfirmTimeout)
+	span.End(err)
+	if receipt != nil {
+		opt.BuildTxArgs.reconcileGasSpent(receipt)
+	}
+	if err == nil && !receipt.IsSuccess() {
+		err = errors.New("transaction failed")
+	}
+	if err != nil {
+		log.Error("[verifyAfterEach] abort as transfer is not confirmed successfully", "account", account.String(), "reward", reward, "txHash", txHash.String(), "err", err)
+		return fmt.Errorf("%w, txHash %v, %v", errVerifyAfterEachFailed, txHash.String(), err)
+	}
+	log.Info("[verifyAfterEach] transfer confirmed", "account", account.String(), "reward", reward, "txHash", txHash.String(), "block", receipt.BlockNumber, "confirmations", confirmations)
+	return nil
+}
@@ -0,0 +1,26 @@
+package distributer
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestIsSafeToRebuild(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&unsentTxError{errors.New("estimate gas of transfer failed")}, true},
+		{&unsentTxError{fmt.Errorf("sign tx failed, %v", errors.New("invalid key"))}, true},
+		{classifySendError(errors.New("nonce too low"), 21000), true},
+		{classifySendError(errors.New("context deadline exceeded"), 21000), false},
+		{classifySendError(errors.New("insufficient funds for gas * price + value"), 21000), false},
+		{errSendTransactionFailed, false},
+	}
+	for _, test := range tests {
+		if got := isSafeToRebuild(test.err); got != test.want {
+			t.Errorf("isSafeToRebuild(%v) = %v, want %v", test.err, got, test.want)
+		}
+	}
+}
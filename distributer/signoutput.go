@@ -0,0 +1,155 @@
+package distributer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common/hexutil"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/crypto"
+)
+
+const (
+	signatureSuffix = ".sig"
+
+	// OutputSignatureScheme how output file is signed, compatible with personal_sign (EIP-191)
+	OutputSignatureScheme = "personal_sign(keccak256(file bytes))"
+)
+
+var (
+	errOutputHashMismatch   = errors.New("output file is altered, hash mismatch")
+	errOutputSignerMismatch = errors.New("output file is not signed by signer")
+)
+
+// OutputSignature detached signature of output file
+type OutputSignature struct {
+	File      string
+	Hash      common.Hash
+	Signer    string
+	Signature hexutil.Bytes
+	Scheme    string
+}
+
+// GetSignatureFileName get default detached signature file of output file
+func GetSignatureFileName(ofile string) string {
+	return ofile + signatureSuffix
+}
+
+// signResultWriter sign the output file with sender key when flush
+type signResultWriter struct {
+	ResultWriter
+	fileName string
+	args     *BuildTxArgs
+}
+
+func (opt *Option) wrapSignResultWriter(writer ResultWriter, ofile string) ResultWriter {
+	if !opt.SignOutput {
+		return writer
+	}
+	return &signResultWriter{
+		ResultWriter: writer,
+		fileName:     ofile,
+		args:         opt.BuildTxArgs,
+	}
+}
+
+func (w *signResultWriter) Flush() error {
+	err := w.ResultWriter.Flush()
+	if errs := w.args.SignOutputFile(w.fileName); errs != nil {
+		log.Error("[sign output] sign output file failed", "file", w.fileName, "err", errs)
+		return errs
+	}
+	return err
+}
+
+// personalSignHash hash of personal_sign message of 32 bytes hash
+func personalSignHash(hash common.Hash) []byte {
+	prefix := fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(hash))
+	return crypto.Keccak256([]byte(prefix), hash[:])
+}
+
+// SignOutputFile sign keccak256 of output file bytes with sender key,
+// and write detached signature to '<ofile>.sig'
+func (args *BuildTxArgs) SignOutputFile(ofile string) error {
+	if args.keyWrapper == nil {
+		return fmt.Errorf("sign output file %v failed, no sender key", ofile)
+	}
+	data, err := ioutil.ReadFile(ofile)
+	if err != nil {
+		return err
+	}
+	hash := crypto.Keccak256Hash(data)
+	sig, err := crypto.Sign(personalSignHash(hash), args.keyWrapper.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("sign output file %v failed, %v", ofile, err)
+	}
+	sig[64] += 27
+	signature := &OutputSignature{
+		File:      ofile,
+		Hash:      hash,
+		Signer:    args.fromAddr.String(),
+		Signature: sig,
+		Scheme:    OutputSignatureScheme,
+	}
+	content, err := json.MarshalIndent(signature, "", "  ")
+	if err != nil {
+		return err
+	}
+	sigFile := GetSignatureFileName(ofile)
+	if err = ioutil.WriteFile(sigFile, content, 0644); err != nil {
+		log.Error("[sign output] write signature file failed", "file", sigFile, "err", err)
+		return err
+	}
+	log.Info("[sign output] sign output file success", "file", ofile, "hash", hash.String(), "signer", signature.Signer, "signatureFile", sigFile)
+	return nil
+}
+
+// VerifyOutputSignature verify output file is not altered and is signed by the signer in signature file,
+// if signer is not empty, it must also be the expected signer
+func VerifyOutputSignature(ofile, sigFile, signer string) (*OutputSignature, error) {
+	if sigFile == "" {
+		sigFile = GetSignatureFileName(ofile)
+	}
+	content, err := ioutil.ReadFile(sigFile)
+	if err != nil {
+		return nil, err
+	}
+	signature := &OutputSignature{}
+	if err = json.Unmarshal(content, signature); err != nil {
+		return nil, fmt.Errorf("wrong signature file %v, %v", sigFile, err)
+	}
+	if signature.Scheme != OutputSignatureScheme {
+		return nil, fmt.Errorf("unknown signature scheme '%v'", signature.Scheme)
+	}
+	if len(signature.Signature) != 65 {
+		return nil, fmt.Errorf("wrong signature length %v", len(signature.Signature))
+	}
+	data, err := ioutil.ReadFile(ofile)
+	if err != nil {
+		return nil, err
+	}
+	hash := crypto.Keccak256Hash(data)
+	if hash != signature.Hash {
+		return signature, fmt.Errorf("%w, file hash %v, signed hash %v", errOutputHashMismatch, hash.String(), signature.Hash.String())
+	}
+	sig := common.CopyBytes(signature.Signature)
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+	pubKey, err := crypto.SigToPub(personalSignHash(hash), sig)
+	if err != nil {
+		return signature, fmt.Errorf("recover signer failed, %v", err)
+	}
+	recovered := crypto.PubkeyToAddress(*pubKey).String()
+	if !strings.EqualFold(recovered, signature.Signer) {
+		return signature, fmt.Errorf("%w %v, recovered signer is %v", errOutputSignerMismatch, signature.Signer, recovered)
+	}
+	if signer != "" && !strings.EqualFold(recovered, signer) {
+		return signature, fmt.Errorf("%w %v, recovered signer is %v", errOutputSignerMismatch, signer, recovered)
+	}
+	return signature, nil
+}
@@ -0,0 +1,166 @@
+package distributer
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/anyswap/ANYToken-distribution/callapi"
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/anyswap/ANYToken-distribution/mongodb"
+	"github.com/anyswap/ANYToken-distribution/params"
+	ethereum "github.com/fsn-dev/fsn-go-sdk/efsn"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+// bundle simulation methods
+const (
+	SimulateMethodBundle      = "eth_simulateV1"
+	SimulateMethodPerTransfer = "per-transfer"
+)
+
+// SimulateBundleOption simulate all transfers of input file option
+type SimulateBundleOption struct {
+	InputFile   string
+	Sender      common.Address
+	RewardToken string
+	GasLimit    uint64 // 0 means not specified, gas override of line takes precedence
+}
+
+// SimulateBundleResult result of simulating all transfers
+type SimulateBundleResult struct {
+	Method      string
+	Transfers   int
+	TotalReward *big.Int
+	GasUsed     uint64 // total gas used, only of bundle simulation
+
+	FailedIndex   int // index of the first failed transfer in non dust transfers, -1 if all success
+	FailedAccount string
+	FailedReason  string
+}
+
+// SimulateBundle simulate all non dust transfers of input file in sequence in one request,
+// so cumulative issues like sender running out of balance partway are caught.
+// it falls back to simulate every transfer separately on nodes without eth_simulateV1,
+// in which case cumulative balance of sender is checked against the sum of rewards.
+func SimulateBundle(sopt *SimulateBundleOption) (*SimulateBundleResult, error) {
+	if sopt.RewardToken != "" && !common.IsHexAddress(sopt.RewardToken) {
+		return nil, fmt.Errorf("wrong reward token '%v'", sopt.RewardToken)
+	}
+	accountStats, _, err := GetAccountsAndRewardsFromFile(sopt.InputFile)
+	if err != nil {
+		return nil, err
+	}
+	dustRewardThreshold := params.GetDustRewardThreshold()
+	transfers := make(mongodb.AccountStatSlice, 0, len(accountStats))
+	for _, stat := range accountStats {
+		if stat.Reward.Sign() > 0 && stat.Reward.Cmp(dustRewardThreshold) >= 0 {
+			transfers = append(transfers, stat)
+		}
+	}
+	result := &SimulateBundleResult{
+		Transfers:   len(transfers),
+		TotalReward: transfers.CalcTotalReward(),
+		FailedIndex: -1,
+	}
+	if len(transfers) == 0 {
+		return result, nil
+	}
+	msgs := make([]*ethereum.CallMsg, len(transfers))
+	for i, stat := range transfers {
+		msgs[i] = sopt.newTransferMsg(stat)
+	}
+
+	log.Info("[simulate bundle] start", "input", sopt.InputFile, "sender", sopt.Sender.String(), "transfers", len(transfers))
+	calls, err := capi.SimulateCalls(msgs)
+	switch {
+	case err == nil:
+		result.Method = SimulateMethodBundle
+		for i, call := range calls {
+			result.GasUsed += uint64(call.GasUsed)
+			if reason := getSimulatedCallFailure(call); reason != "" {
+				result.setFailed(i, transfers[i], reason)
+				break
+			}
+		}
+	case callapi.IsMethodNotSupported(err):
+		log.Warn("[simulate bundle] bundle simulation is not supported, simulate every transfer instead", "err", err)
+		result.Method = SimulateMethodPerTransfer
+		err = sopt.simulatePerTransfer(transfers, msgs, result)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+	log.Info("[simulate bundle] finished", "method", result.Method, "transfers", result.Transfers,
+		"totalReward", result.TotalReward, "gasUsed", result.GasUsed, "failedIndex", result.FailedIndex)
+	return result, nil
+}
+
+func (sopt *SimulateBundleOption) newTransferMsg(stat *mongodb.AccountStat) *ethereum.CallMsg {
+	msg := &ethereum.CallMsg{From: sopt.Sender, Gas: sopt.GasLimit}
+	if stat.GasLimit != nil {
+		msg.Gas = *stat.GasLimit
+	}
+	if sopt.RewardToken != "" {
+		rewardToken := common.HexToAddress(sopt.RewardToken)
+		msg.To = &rewardToken
+		msg.Data = packTransferData(stat.Account, stat.Reward)
+	} else {
+		account := stat.Account
+		msg.To = &account
+		msg.Value = stat.Reward
+	}
+	return msg
+}
+
+// getSimulatedCallFailure failure reason of simulated call, empty if success.
+// token transfer returning false is failure too.
+func getSimulatedCallFailure(call *callapi.SimulatedCall) string {
+	if !call.IsSuccess() {
+		if call.Error != nil {
+			return call.Error.Message
+		}
+		return "execution reverted"
+	}
+	if len(call.ReturnData) == 32 && new(big.Int).SetBytes(call.ReturnData).Sign() == 0 {
+		return "transfer returns false"
+	}
+	return ""
+}
+
+// simulatePerTransfer estimate gas of every transfer on latest state,
+// and check cumulative rewards against balance of sender
+func (sopt *SimulateBundleOption) simulatePerTransfer(transfers mongodb.AccountStatSlice, msgs []*ethereum.CallMsg, result *SimulateBundleResult) error {
+	var balance *big.Int
+	var err error
+	if sopt.RewardToken != "" {
+		balance, err = capi.GetTokenBalance(common.HexToAddress(sopt.RewardToken), sopt.Sender, nil)
+	} else {
+		balance, err = capi.GetCoinBalance(sopt.Sender, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("get balance of sender failed. %w", err)
+	}
+	cumulative := big.NewInt(0)
+	for i, stat := range transfers {
+		cumulative.Add(cumulative, stat.Reward)
+		if cumulative.Cmp(balance) > 0 {
+			result.setFailed(i, stat, fmt.Sprintf("insufficient balance, cumulative rewards %v, balance %v", cumulative, balance))
+			return nil
+		}
+		_, err = capi.EstimateGas(msgs[i])
+		if err != nil {
+			result.setFailed(i, stat, err.Error())
+			return nil
+		}
+	}
+	return nil
+}
+
+func (result *SimulateBundleResult) setFailed(index int, stat *mongodb.AccountStat, reason string) {
+	result.FailedIndex = index
+	result.FailedAccount = stat.Account.String()
+	result.FailedReason = reason
+	log.Error("[simulate bundle] transfer will fail", "index", index, "account", result.FailedAccount, "reward", stat.Reward, "reason", reason)
+}
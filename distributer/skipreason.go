@@ -0,0 +1,62 @@
+package distributer
+
+import (
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/anyswap/ANYToken-distribution/mongodb"
+)
+
+// SkipReason reason of not paying a recipient
+type SkipReason string
+
+// skip reasons
+const (
+	SkipReasonZero        SkipReason = "zero"
+	SkipReasonDust        SkipReason = "dust" // below dust reward threshold
+	SkipReasonBurnAddress SkipReason = "burnAddress"
+	SkipReasonBlocklisted SkipReason = "blocklisted"
+	SkipReasonIneligible  SkipReason = "ineligible"
+	SkipReasonAlreadyPaid SkipReason = "alreadyPaid"
+)
+
+// skippedStat recipient skipped by checks before sending
+type skippedStat struct {
+	stat   *mongodb.AccountStat
+	reason SkipReason
+}
+
+// recordSkipped record recipient skipped by checks of input file,
+// they are written to skipped output when the input file is sent
+func (opt *Option) recordSkipped(stat *mongodb.AccountStat, reason SkipReason) {
+	if opt.SplitOutput {
+		opt.skippedStats = append(opt.skippedStats, &skippedStat{stat: stat, reason: reason})
+	}
+}
+
+// skipZeroRewards record recipients of zero (or negative) reward as skipped and remove them
+func (opt *Option) skipZeroRewards(accountStats mongodb.AccountStatSlice) mongodb.AccountStatSlice {
+	for _, stat := range accountStats {
+		if stat.Reward.Sign() <= 0 {
+			log.Info("ignore zero reward line", "account", stat.Account.String(), "reward", stat.Reward)
+			opt.recordSkipped(stat, SkipReasonZero)
+		}
+	}
+	return nonZeroRewards(accountStats)
+}
+
+// nonZeroRewards recipients of positive reward
+func nonZeroRewards(accountStats mongodb.AccountStatSlice) mongodb.AccountStatSlice {
+	result := make(mongodb.AccountStatSlice, 0, len(accountStats))
+	for _, stat := range accountStats {
+		if stat.Reward.Sign() > 0 {
+			result = append(result, stat)
+		}
+	}
+	return result
+}
+
+// takeSkipped take recorded skipped recipients and clear the record
+func (opt *Option) takeSkipped() []*skippedStat {
+	skipped := opt.skippedStats
+	opt.skippedStats = nil
+	return skipped
+}
@@ -0,0 +1,44 @@
+package distributer
+
+import (
+	"fmt"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/anyswap/ANYToken-distribution/mongodb"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+// checkSnapshotProvenance provenance is the single block every share is read at,
+// averaged liquidity over many heights has no such block.
+func (opt *Option) checkSnapshotProvenance() error {
+	if !opt.SnapshotProvenance {
+		return nil
+	}
+	if opt.byWhat != byLiquidMethodID {
+		return fmt.Errorf("[check option] snapshot provenance is only supported by liquidity")
+	}
+	if opt.SampleInterval > 0 || len(opt.SnapshotHeights) > 0 {
+		return fmt.Errorf("[check option] snapshot provenance conflicts with liquidity averaged over many heights")
+	}
+	return nil
+}
+
+// fillSnapshotProvenance set hash of block at height (Number) every share is read at
+func (opt *Option) fillSnapshotProvenance(accountStats []mongodb.AccountStatSlice) {
+	if !opt.SnapshotProvenance {
+		return
+	}
+	blockHashes := make(map[uint64]*common.Hash)
+	for _, stats := range accountStats {
+		for _, stat := range stats {
+			blockHash, exist := blockHashes[stat.Number]
+			if !exist {
+				hash := capi.LoopGetBlockHash(stat.Number)
+				blockHash = &hash
+				blockHashes[stat.Number] = blockHash
+				log.Info("[byliquid] snapshot provenance", "height", stat.Number, "blockHash", hash.Hex())
+			}
+			stat.BlockHash = blockHash
+		}
+	}
+}
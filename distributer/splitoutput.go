@@ -0,0 +1,84 @@
+package distributer
+
+import (
+	"os"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/anyswap/ANYToken-distribution/mongodb"
+)
+
+const (
+	skippedOutputSuffix = ".skipped"
+	failedOutputSuffix  = ".failed"
+)
+
+// splitOutput write skipped and failed recipients into separate files,
+// successful sends are written to the output file as usual.
+// both files have input file format so they can be used to retry directly,
+// skipped file has a trailing skip reason column if SkipReasons is true
+// (the column is ignored when the file is used as input)
+type splitOutput struct {
+	files   []*os.File
+	skipped ResultWriter
+	failed  ResultWriter
+
+	withSkipReason bool
+}
+
+func (opt *Option) openSplitOutput(ofile string, title *ResultTitle) (*splitOutput, error) {
+	split := &splitOutput{withSkipReason: opt.SkipReasons}
+	open := func(fileName string, title *ResultTitle) (ResultWriter, error) {
+		file, err := openOutputFile(fileName)
+		if err != nil {
+			return nil, err
+		}
+		split.files = append(split.files, file)
+		writer := opt.newResultWriter(file)
+		_ = writer.WriteTitle(title)
+		log.Info("open split output file success", "file", fileName)
+		return writer, nil
+	}
+	var err error
+	skippedTitle := *title
+	skippedTitle.HasSkipReason = split.withSkipReason
+	if split.skipped, err = open(ofile+skippedOutputSuffix, &skippedTitle); err != nil {
+		split.close()
+		return nil, err
+	}
+	if split.failed, err = open(ofile+failedOutputSuffix, title); err != nil {
+		split.close()
+		return nil, err
+	}
+	return split, nil
+}
+
+func (split *splitOutput) writeSkipped(stat *mongodb.AccountStat, reason SkipReason) {
+	if split == nil {
+		return
+	}
+	result := newRewardResult(stat, nil)
+	if split.withSkipReason {
+		result.SkipReason = reason
+	}
+	_ = split.skipped.WriteResult(result)
+}
+
+func (split *splitOutput) writeFailed(stat *mongodb.AccountStat) {
+	if split != nil {
+		_ = split.failed.WriteResult(newRewardResult(stat, nil))
+	}
+}
+
+func (split *splitOutput) close() {
+	if split == nil {
+		return
+	}
+	for _, writer := range []ResultWriter{split.skipped, split.failed} {
+		if writer != nil {
+			_ = writer.Flush()
+		}
+	}
+	for _, file := range split.files {
+		file.Close()
+	}
+}
@@ -0,0 +1,219 @@
+package distributer
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/anyswap/ANYToken-distribution/mongodb"
+	"github.com/anyswap/ANYToken-distribution/params"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+// StatusOption status of partially completed sending run option
+type StatusOption struct {
+	InputFile   string
+	OutputFile  string
+	RewardToken string
+	Sender      string
+	GasLimit    *uint64
+	GasPrice    *big.Int
+}
+
+// RunStatus remaining work and cost to complete of a sending run
+type RunStatus struct {
+	Recipients      int
+	SentRecipients  int
+	DustRecipients  int
+	LeftRecipients  int
+	TotalReward     *big.Int
+	SentReward      *big.Int
+	LeftReward      *big.Int
+	GasPrice        *big.Int
+	LeftGasCost     *big.Int
+	SenderBalance   *big.Int `json:",omitempty"`
+	SenderCoin      *big.Int `json:",omitempty"`
+	NeedTopUpReward bool
+	NeedTopUpCoin   bool
+}
+
+// GetPendingAccountStats compare input file with partial output file,
+// get input lines which are not sended yet. every output line with txhash
+// is a sended transfer, it's matched with input lines of the same account in order
+func GetPendingAccountStats(inputFile, outputFile string) (accountStats, pending mongodb.AccountStatSlice, titleLine string, err error) {
+	accountStats, titleLine, err = GetAccountsAndRewardsFromFile(inputFile)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	accountStats = nonZeroRewards(accountStats)
+	sentCounts := make(map[string]int)
+	if _, err = os.Stat(outputFile); err == nil {
+		results, _, _, errf := LoadRewardResults(outputFile)
+		if errf != nil {
+			return nil, nil, "", errf
+		}
+		for _, result := range results {
+			if result.TxHash != "" {
+				sentCounts[strings.ToLower(result.Account)]++
+			}
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, nil, "", err
+	}
+	pending = make(mongodb.AccountStatSlice, 0)
+	for _, stat := range accountStats {
+		account := strings.ToLower(stat.Account.String())
+		if sentCounts[account] > 0 {
+			sentCounts[account]--
+			continue
+		}
+		pending = append(pending, stat)
+	}
+	for account, count := range sentCounts {
+		if count > 0 {
+			log.Warn("[pending] sended account is not in input file", "account", account, "count", count)
+		}
+	}
+	return accountStats, pending, titleLine, nil
+}
+
+// GetRunStatus compare input file with partial output file, and estimate
+// gas cost to send the left rewards at the current gas price
+func GetRunStatus(sopt *StatusOption) (*RunStatus, error) {
+	accountStats, pending, _, err := GetPendingAccountStats(sopt.InputFile, sopt.OutputFile)
+	if err != nil {
+		return nil, err
+	}
+
+	gasPrice := sopt.GasPrice
+	if gasPrice == nil {
+		gasPrice, err = capi.SuggestGasPrice()
+		if err != nil {
+			return nil, fmt.Errorf("get gas price failed. %v", err)
+		}
+	}
+	gasLimit := uint64(defaultTxGasLimit)
+	if sopt.GasLimit != nil {
+		gasLimit = *sopt.GasLimit
+	}
+
+	status := &RunStatus{
+		Recipients:  len(accountStats),
+		TotalReward: accountStats.CalcTotalReward(),
+		SentReward:  big.NewInt(0),
+		LeftReward:  big.NewInt(0),
+		GasPrice:    gasPrice,
+		LeftGasCost: big.NewInt(0),
+	}
+	dustRewardThreshold := params.GetDustRewardThreshold()
+	for _, stat := range pending {
+		if stat.Reward.Cmp(dustRewardThreshold) < 0 {
+			status.DustRecipients++
+			continue
+		}
+		status.LeftRecipients++
+		status.LeftReward.Add(status.LeftReward, stat.Reward)
+		txGasLimit, txGasPrice := gasLimit, gasPrice
+		if stat.GasLimit != nil {
+			txGasLimit = *stat.GasLimit
+		}
+		if stat.GasPrice != nil {
+			txGasPrice = stat.GasPrice
+		}
+		status.LeftGasCost.Add(status.LeftGasCost, estimateGasCost(txGasLimit, txGasPrice))
+	}
+	status.SentRecipients = len(accountStats) - len(pending)
+	status.SentReward.Sub(status.TotalReward, pending.CalcTotalReward())
+
+	if sopt.Sender != "" {
+		err = status.checkSenderBalances(sopt)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+func (status *RunStatus) checkSenderBalances(sopt *StatusOption) (err error) {
+	sender := common.HexToAddress(sopt.Sender)
+	status.SenderCoin, err = capi.GetCoinBalance(sender, nil)
+	if err != nil {
+		return fmt.Errorf("get sender coin balance failed. %v", err)
+	}
+	coinNeeded := new(big.Int).Set(status.LeftGasCost)
+	if sopt.RewardToken != "" {
+		status.SenderBalance, err = capi.GetTokenBalance(common.HexToAddress(sopt.RewardToken), sender, nil)
+		if err != nil {
+			return fmt.Errorf("get sender reward token balance failed. %v", err)
+		}
+		status.NeedTopUpReward = status.SenderBalance.Cmp(status.LeftReward) < 0
+	} else {
+		coinNeeded.Add(coinNeeded, status.LeftReward)
+	}
+	status.NeedTopUpCoin = status.SenderCoin.Cmp(coinNeeded) < 0
+	return nil
+}
+
+// PrintRunStatus print status of sending run
+func PrintRunStatus(sopt *StatusOption, status *RunStatus) {
+	fmt.Printf("input file: %v\noutput file: %v\n", sopt.InputFile, sopt.OutputFile)
+	fmt.Printf("recipients: %v, sended: %v, dust: %v, left: %v\n",
+		status.Recipients, status.SentRecipients, status.DustRecipients, status.LeftRecipients)
+	fmt.Printf("total reward: %v, sended: %v, left: %v\n", status.TotalReward, status.SentReward, status.LeftReward)
+	fmt.Printf("gas price: %v, gas cost to complete: %v\n", status.GasPrice, status.LeftGasCost)
+	if sopt.Sender == "" {
+		return
+	}
+	if status.SenderBalance != nil {
+		fmt.Printf("sender %v reward token balance: %v, need top up: %v\n", sopt.Sender, status.SenderBalance, status.NeedTopUpReward)
+	}
+	fmt.Printf("sender %v coin balance: %v, need top up: %v\n", sopt.Sender, status.SenderCoin, status.NeedTopUpCoin)
+}
+
+// ExportPendingInputFile write input lines which are not sended yet to a new input file,
+// title line of the original input file is kept so the new file passes title line check
+func ExportPendingInputFile(inputFile, outputFile, pendingFile string) (pending mongodb.AccountStatSlice, err error) {
+	pendingPath := resolveFilePath(pendingFile)
+	for _, file := range []string{inputFile, outputFile} {
+		if resolveFilePath(file) == pendingPath {
+			return nil, fmt.Errorf("pending file '%v' is the same as '%v'", pendingFile, file)
+		}
+	}
+	_, pending, titleLine, err := GetPendingAccountStats(inputFile, outputFile)
+	if err != nil {
+		return nil, err
+	}
+	file, err := openOutputFile(pendingFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if titleLine != "" {
+		if err = WriteOutputLine(file, titleLine); err != nil {
+			return nil, err
+		}
+	}
+	for _, stat := range pending {
+		line := fmt.Sprintf("%v %v", strings.ToLower(stat.Account.String()), stat.Reward)
+		if stat.GasLimit != nil {
+			line += fmt.Sprintf(" gasLimit=%v", *stat.GasLimit)
+		}
+		if stat.GasPrice != nil {
+			line += fmt.Sprintf(" gasPrice=%v", stat.GasPrice)
+		}
+		if stat.IdempotencyKey != "" {
+			line += " " + idempotencyKeyPrefix + stat.IdempotencyKey
+		}
+		if stat.Chain != "" {
+			line += " " + chainTagPrefix + stat.Chain
+		}
+		if err = WriteOutputLine(file, line); err != nil {
+			return nil, err
+		}
+	}
+	log.Info("[pending] export pending input file success", "input", inputFile, "output", outputFile, "pending", pendingFile,
+		"accounts", len(pending), "totalReward", pending.CalcTotalReward())
+	return pending, nil
+}
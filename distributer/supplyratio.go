@@ -0,0 +1,49 @@
+package distributer
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/anyswap/ANYToken-distribution/tools"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+// checkSupplyRatio warn if total reward exceeds MaxSupplyPercent of reward token's
+// total supply, which is most likely a decimals or units error. abort if AbortOnSupplyRatio
+func (opt *Option) checkSupplyRatio(totalReward *big.Int) error {
+	if opt.RewardToken == "" || opt.MaxSupplyPercent <= 0 {
+		return nil
+	}
+	token := common.HexToAddress(opt.RewardToken)
+	totalSupply, err := capi.GetErc20TotalSupply(token, nil)
+	if err != nil {
+		log.Warn("[supply ratio] get reward token total supply failed", "token", opt.RewardToken, "err", err)
+		return nil
+	}
+	if totalSupply.Sign() <= 0 {
+		log.Warn("[supply ratio] reward token total supply is zero", "token", opt.RewardToken)
+		return nil
+	}
+	ratio := new(big.Float).Quo(new(big.Float).SetInt(totalReward), new(big.Float).SetInt(totalSupply))
+	percent, _ := ratio.Mul(ratio, big.NewFloat(100)).Float64()
+	if percent <= opt.MaxSupplyPercent {
+		return nil
+	}
+
+	decimals, err := capi.GetErc20Decimals(token)
+	if err != nil {
+		decimals = 0
+	}
+	log.Warn("[supply ratio] total reward is suspiciously large relative to token total supply, please check decimals and units",
+		"token", opt.RewardToken, "decimals", decimals,
+		"totalReward", tools.FormatUnits(totalReward, decimals),
+		"totalSupply", tools.FormatUnits(totalSupply, decimals),
+		"percent", fmt.Sprintf("%.4f%%", percent),
+		"maxSupplyPercent", opt.MaxSupplyPercent)
+	if opt.AbortOnSupplyRatio {
+		return fmt.Errorf("total reward %v is %.4f%% of token total supply %v, exceeds %v%%",
+			totalReward, percent, totalSupply, opt.MaxSupplyPercent)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package distributer
+
+import (
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	ethereum "github.com/fsn-dev/fsn-go-sdk/efsn"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+const (
+	sweepWaitInterval = 5 * time.Second
+	sweepWaitTimeout  = 10 * time.Minute
+)
+
+var errSweepPendingTxs = errors.New("sent transactions are not all confirmed before sweep")
+
+// sweepToTreasury send remaining native coin balance of sender to SweepTo,
+// keeping SweepReserve and the gas cost of the sweep tx itself
+func (opt *Option) sweepToTreasury() error {
+	if opt.SweepTo == "" {
+		return nil
+	}
+	args := opt.BuildTxArgs
+	sender := args.fromAddr
+	treasury := common.HexToAddress(opt.SweepTo)
+
+	if !opt.DryRun {
+		// balance is only exact after all sent transactions are mined
+		if err := waitSentTxsConfirmed(sender, *args.Nonce); err != nil {
+			return err
+		}
+	}
+
+	balance, err := capi.BalanceAt(sender, nil)
+	if err != nil {
+		log.Error("[sweep] get sender balance failed", "sender", sender.String(), "err", err)
+		return err
+	}
+
+	gasLimit := uint64(minTxGasLimit)
+	msg := &ethereum.CallMsg{From: sender, To: &treasury, Value: big.NewInt(1)}
+	if estimated, errf := capi.EstimateGas(msg); errf == nil && estimated > gasLimit {
+		gasLimit = estimated
+	}
+	gasCost := estimateGasCost(gasLimit, args.GasPrice)
+
+	amount := new(big.Int).Sub(balance, gasCost)
+	if opt.SweepReserve != nil {
+		amount.Sub(amount, opt.SweepReserve)
+	}
+	if amount.Sign() <= 0 {
+		log.Info("[sweep] nothing to sweep", "sender", sender.String(), "balance", balance, "gasCost", gasCost, "reserve", opt.SweepReserve)
+		return nil
+	}
+
+	log.Info("[sweep] sweep remaining balance to treasury", "sender", sender.String(), "treasury", opt.SweepTo, "balance", balance, "amount", amount, "gasLimit", gasLimit, "gasPrice", args.GasPrice, "reserve", opt.SweepReserve, "dryrun", opt.DryRun)
+	txHash, err := args.sendRewardsTransaction(treasury, amount, common.Address{}, opt.DryRun, &gasLimit, nil)
+	switch {
+	case err == errDustReward:
+		log.Info("[sweep] ignore dust sweep amount", "amount", amount)
+		return nil
+	case err != nil:
+		log.Error("[sweep] sweep failed", "treasury", opt.SweepTo, "amount", amount, "err", err)
+		return err
+	}
+	if txHash != nil {
+		log.Info("[sweep] sweep success", "treasury", opt.SweepTo, "amount", amount, "txHash", txHash.String())
+	}
+	return nil
+}
+
+// waitSentTxsConfirmed wait confirmed nonce of sender catch up with next nonce
+func waitSentTxsConfirmed(sender common.Address, nextNonce uint64) error {
+	deadline := time.Now().Add(sweepWaitTimeout)
+	for {
+		confirmedNonce, err := capi.GetAccountConfirmedNonce(sender)
+		if err == nil && confirmedNonce >= nextNonce {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			log.Error("[sweep] wait sent transactions confirmed timeout", "confirmedNonce", confirmedNonce, "nextNonce", nextNonce, "err", err)
+			return errSweepPendingTxs
+		}
+		log.Info("[sweep] wait sent transactions confirmed", "confirmedNonce", confirmedNonce, "nextNonce", nextNonce, "err", err)
+		time.Sleep(sweepWaitInterval)
+	}
+}
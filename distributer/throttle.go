@@ -0,0 +1,117 @@
+package distributer
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+)
+
+const (
+	throttlePollInterval = 10 * time.Second
+	throttleMinDelay     = 1 * time.Second
+	throttleMaxDelay     = 60 * time.Second
+
+	// pressure is high if observed value is greater than baseline * ratio
+	throttlePendingRatio  = 2.0
+	throttleGasPriceRatio = 1.5
+)
+
+// adaptiveThrottle adjust inter-send delay according to observed
+// mempool pressure (txpool pending count and suggested gas price)
+type adaptiveThrottle struct {
+	delay    time.Duration
+	lastPoll time.Time
+
+	noTxPool        bool // node does not expose txpool RPCs
+	basePending     uint64
+	baseGasPrice    *big.Int
+	hasBasePending  bool
+	hasBaseGasPrice bool
+}
+
+func newAdaptiveThrottle() *adaptiveThrottle {
+	return &adaptiveThrottle{}
+}
+
+// wait poll mempool pressure if needed, then sleep current delay
+func (t *adaptiveThrottle) wait() {
+	if time.Since(t.lastPoll) >= throttlePollInterval {
+		t.lastPoll = time.Now()
+		t.adjust(t.isUnderPressure())
+	}
+	if t.delay > 0 {
+		time.Sleep(t.delay)
+	}
+}
+
+func (t *adaptiveThrottle) adjust(underPressure bool) {
+	oldDelay := t.delay
+	switch {
+	case underPressure && t.delay == 0:
+		t.delay = throttleMinDelay
+	case underPressure:
+		t.delay *= 2
+		if t.delay > throttleMaxDelay {
+			t.delay = throttleMaxDelay
+		}
+	case t.delay > throttleMinDelay:
+		t.delay /= 2
+	default:
+		t.delay = 0
+	}
+	if t.delay != oldDelay {
+		log.Info("[throttle] adjust send delay", "underPressure", underPressure, "oldDelay", oldDelay, "newDelay", t.delay)
+	}
+}
+
+func (t *adaptiveThrottle) isUnderPressure() bool {
+	return t.isTxPoolUnderPressure() || t.isGasPriceUnderPressure()
+}
+
+func (t *adaptiveThrottle) isTxPoolUnderPressure() bool {
+	if t.noTxPool {
+		return false
+	}
+	status, err := capi.GetTxPoolStatus()
+	if err != nil {
+		log.Warn("[throttle] get txpool status failed, fallback to gas price trend only", "err", err)
+		t.noTxPool = true
+		return false
+	}
+	if !t.hasBasePending {
+		t.basePending = status.Pending
+		t.hasBasePending = true
+		log.Info("[throttle] get baseline txpool status", "pending", status.Pending, "queued", status.Queued)
+		return false
+	}
+	threshold := float64(t.basePending) * throttlePendingRatio
+	if threshold < 1 {
+		threshold = 1
+	}
+	if float64(status.Pending) > threshold {
+		log.Info("[throttle] txpool pending surged", "pending", status.Pending, "basePending", t.basePending)
+		return true
+	}
+	return false
+}
+
+func (t *adaptiveThrottle) isGasPriceUnderPressure() bool {
+	gasPrice, err := capi.SuggestGasPrice()
+	if err != nil {
+		log.Warn("[throttle] get gas price failed", "err", err)
+		return false
+	}
+	if !t.hasBaseGasPrice {
+		t.baseGasPrice = gasPrice
+		t.hasBaseGasPrice = true
+		log.Info("[throttle] get baseline gas price", "gasPrice", gasPrice)
+		return false
+	}
+	threshold, _ := new(big.Float).Mul(new(big.Float).SetInt(t.baseGasPrice), big.NewFloat(throttleGasPriceRatio)).Int(nil)
+	if gasPrice.Cmp(threshold) > 0 {
+		log.Info("[throttle] gas price surged", "gasPrice", gasPrice, "baseGasPrice", t.baseGasPrice)
+		return true
+	}
+	return false
+}
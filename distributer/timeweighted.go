@@ -0,0 +1,123 @@
+package distributer
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/anyswap/ANYToken-distribution/mongodb"
+	"github.com/anyswap/ANYToken-distribution/params"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+const defaultSnapshotWorkers = 4
+
+// snapshotSegment sub range [start, end) of the sampling range,
+// start is aligned to sampling interval so that samples never cross segments
+type snapshotSegment struct {
+	start uint64
+	end   uint64
+}
+
+// splitSnapshotRange split [start, end) into at most `count` segments aligned to `interval`
+func splitSnapshotRange(start, end, interval uint64, count int) (segments []*snapshotSegment) {
+	if start >= end || interval == 0 {
+		return nil
+	}
+	samples := (end - start + interval - 1) / interval
+	if count <= 0 {
+		count = 1
+	}
+	samplesPerSegment := (samples + uint64(count) - 1) / uint64(count)
+	segmentLen := samplesPerSegment * interval
+	for segStart := start; segStart < end; segStart += segmentLen {
+		segEnd := segStart + segmentLen
+		if segEnd > end {
+			segEnd = end
+		}
+		segments = append(segments, &snapshotSegment{start: segStart, end: segEnd})
+	}
+	return segments
+}
+
+// calcSegmentWeightedSums calc partial sums of coin balance * block weight of every sample in segment.
+// the weight of a sample is the count of blocks until next sample or the end of whole range.
+func calcSegmentWeightedSums(exchange common.Address, accounts []common.Address, seg *snapshotSegment, interval, rangeEnd uint64) []*big.Int {
+	sums := make([]*big.Int, len(accounts))
+	for i := range sums {
+		sums[i] = big.NewInt(0)
+	}
+	for height := seg.start; height < seg.end; height += interval {
+		weight := interval
+		if height+weight > rangeEnd {
+			weight = rangeEnd - height
+		}
+		blockNumber := new(big.Int).SetUint64(height)
+		totalSupply := capi.LoopGetExchangeLiquidity(exchange, blockNumber)
+		if totalSupply.Sign() <= 0 {
+			continue
+		}
+		exCoinBalance := capi.LoopGetCoinBalance(exchange, blockNumber)
+		bigWeight := new(big.Int).SetUint64(weight)
+		values := batchReadLiquidityBalances(exchange, accounts, blockNumber)
+		for i, value := range values {
+			// convert liquid balance to coin balance
+			coinBalance := new(big.Int).Mul(value, exCoinBalance)
+			coinBalance.Div(coinBalance, totalSupply)
+			sums[i].Add(sums[i], coinBalance.Mul(coinBalance, bigWeight))
+		}
+	}
+	return sums
+}
+
+// getTimeWeightedLiquidityBalancesOfExchange calc time weighted average liquidity (in coin balance)
+// in [StartHeight, EndHeight) by sampling every SampleInterval blocks.
+// the range is splitted into segments and calculated concurrently by worker pool.
+func (opt *Option) getTimeWeightedLiquidityBalancesOfExchange(exchange string, accounts []common.Address) (accountStats mongodb.AccountStatSlice) {
+	exchangeAddr := common.HexToAddress(exchange)
+	workers := opt.SnapshotWorkers
+	if workers <= 0 {
+		workers = defaultSnapshotWorkers
+	}
+	segments := splitSnapshotRange(opt.StartHeight, opt.EndHeight, opt.SampleInterval, workers)
+	log.Info("[byliquid] calc time weighted liquidity start", "exchange", exchange, "start", opt.StartHeight, "end", opt.EndHeight, "interval", opt.SampleInterval, "segments", len(segments), "accounts", len(accounts))
+
+	partialSums := make([][]*big.Int, len(segments))
+	wg := new(sync.WaitGroup)
+	segCh := make(chan int)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range segCh {
+				seg := segments[i]
+				partialSums[i] = calcSegmentWeightedSums(exchangeAddr, accounts, seg, opt.SampleInterval, opt.EndHeight)
+				log.Info("[byliquid] calc segment weighted sums finished", "exchange", exchange, "start", seg.start, "end", seg.end)
+			}
+		}()
+	}
+	for i := range segments {
+		segCh <- i
+	}
+	close(segCh)
+	wg.Wait()
+
+	// combine partial sums, then divide by total blocks of range
+	totalBlocks := new(big.Int).SetUint64(opt.EndHeight - opt.StartHeight)
+	finStatMap := make(map[common.Address]*mongodb.AccountStat)
+	for i, account := range accounts {
+		if params.IsExcludedRewardAccount(account) {
+			continue
+		}
+		sum := big.NewInt(0)
+		for _, sums := range partialSums {
+			sum.Add(sum, sums[i])
+		}
+		finStatMap[account] = &mongodb.AccountStat{
+			Account: account,
+			Share:   sum.Div(sum, totalBlocks),
+			Number:  opt.EndHeight,
+		}
+	}
+	return mongodb.ConvertToSortedSlice(finStatMap)
+}
@@ -0,0 +1,73 @@
+package distributer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common/hexutil"
+)
+
+var (
+	errTokenPaused       = errors.New("reward token is paused")
+	errSenderBlacklisted = errors.New("sender is blacklisted by reward token")
+)
+
+func decodeSelector(name, selectorStr string) ([]byte, error) {
+	selector, err := hexutil.Decode(selectorStr)
+	if err != nil || len(selector) != 4 {
+		return nil, fmt.Errorf("wrong %v selector '%v', must be 4 bytes hex", name, selectorStr)
+	}
+	return selector, nil
+}
+
+// checkTokenState probe reward token's `<PausedSelector>() returns (bool)`
+// and `<BlacklistSelector>(sender) returns (bool)`, abort if token is paused
+// or sender is blacklisted, as every transfer will revert
+func (opt *Option) checkTokenState() (detail string, err error) {
+	if opt.RewardToken == "" || (opt.PausedSelector == "" && opt.BlacklistSelector == "") {
+		return "no token state probes", nil
+	}
+	token := common.HexToAddress(opt.RewardToken)
+	var probes []string
+
+	if opt.PausedSelector != "" {
+		selector, err := decodeSelector("paused", opt.PausedSelector)
+		if err != nil {
+			return "", err
+		}
+		paused, err := capi.CallBool(token, selector)
+		if err != nil {
+			return "", fmt.Errorf("probe paused state of reward token failed. %v", err)
+		}
+		if paused {
+			log.Error("[token state] reward token is paused, all transfers will revert", "token", opt.RewardToken)
+			return "", errTokenPaused
+		}
+		probes = append(probes, "not paused")
+	}
+
+	if opt.BlacklistSelector != "" {
+		selector, err := decodeSelector("blacklist", opt.BlacklistSelector)
+		if err != nil {
+			return "", err
+		}
+		sender := opt.GetSender()
+		data := make([]byte, 36)
+		copy(data[:4], selector)
+		copy(data[4:], sender.Hash().Bytes())
+		blacklisted, err := capi.CallBool(token, data)
+		if err != nil {
+			return "", fmt.Errorf("probe blacklist state of sender failed. %v", err)
+		}
+		if blacklisted {
+			log.Error("[token state] sender is blacklisted by reward token, all transfers will revert", "token", opt.RewardToken, "sender", sender.String())
+			return "", errSenderBlacklisted
+		}
+		probes = append(probes, "sender not blacklisted")
+	}
+
+	return strings.Join(probes, ", "), nil
+}
@@ -0,0 +1,40 @@
+package distributer
+
+import (
+	"github.com/anyswap/ANYToken-distribution/mongodb"
+	"github.com/anyswap/ANYToken-distribution/tracing"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+// startRewardSpan start span of sending reward to recipient,
+// spans of build, sign, broadcast and confirm tx are its children
+func startRewardSpan(stat *mongodb.AccountStat) *tracing.Span {
+	return tracing.StartSpan("send reward", "account", stat.Account.String(), "reward", stat.Reward)
+}
+
+// endRewardSpan end span of sending reward to recipient with outcome
+// (sent, dust, dryrun or failed) and txhash
+func endRewardSpan(span *tracing.Span, txHash *common.Hash, sendErr, verifyErr error) {
+	if span == nil {
+		return
+	}
+	var outcome string
+	switch {
+	case sendErr == errDustReward:
+		outcome, sendErr = "dust", nil
+	case sendErr != nil || verifyErr != nil:
+		outcome = "failed"
+	case txHash != nil:
+		outcome = "sent"
+	default:
+		outcome = "dryrun"
+	}
+	span.SetAttributes("outcome", outcome)
+	if txHash != nil {
+		span.SetAttributes("txhash", txHash.String())
+	}
+	if sendErr == nil {
+		sendErr = verifyErr
+	}
+	span.End(sendErr)
+}
@@ -0,0 +1,45 @@
+package distributer
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+)
+
+var errDuplicateTxHash = errors.New("same txhash is recorded for different recipients")
+
+// checkDuplicateTxHashes every transfer is sent in its own transaction,
+// so a txhash shared by different recipients is an accounting error
+func checkDuplicateTxHashes(results []*RewardResult) error {
+	recipients := make(map[string]map[string]struct{})
+	for _, result := range results {
+		if result.TxHash == "" {
+			continue
+		}
+		txHash := strings.ToLower(result.TxHash)
+		if recipients[txHash] == nil {
+			recipients[txHash] = make(map[string]struct{})
+		}
+		recipients[txHash][strings.ToLower(result.Account)] = struct{}{}
+	}
+	duplicates := 0
+	for txHash, accounts := range recipients {
+		if len(accounts) < 2 {
+			continue
+		}
+		duplicates++
+		accountList := make([]string, 0, len(accounts))
+		for account := range accounts {
+			accountList = append(accountList, account)
+		}
+		sort.Strings(accountList)
+		log.Error("[check txhash] !!! duplicate txhash of different recipients !!!", "txhash", txHash, "recipients", accountList)
+	}
+	if duplicates > 0 {
+		log.Error("[check txhash] found duplicate txhashes, please reconcile the results", "duplicates", duplicates)
+		return errDuplicateTxHash
+	}
+	return nil
+}
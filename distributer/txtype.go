@@ -0,0 +1,59 @@
+package distributer
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+)
+
+// transaction types
+const (
+	TxTypeAuto    = "auto"
+	TxTypeLegacy  = "legacy"
+	TxTypeDynamic = "dynamic"
+)
+
+var errDynamicFeeTxNotSupported = errors.New("dynamic fee (EIP-1559) transaction is not supported by the transaction signer")
+
+// IsValidTxType is valid transaction type
+func IsValidTxType(txType string) bool {
+	switch txType {
+	case "", TxTypeAuto, TxTypeLegacy, TxTypeDynamic:
+		return true
+	default:
+		return false
+	}
+}
+
+// detectTxType detect EIP-1559 support of chain by base fee of latest header.
+// the signer only builds legacy transactions, which are valid on EIP-1559 chains
+// as long as gas price is not lower than base fee, so base fee is the floor of gas price
+func (args *BuildTxArgs) detectTxType(explicitGasPrice bool) error {
+	switch args.TxType {
+	case TxTypeLegacy:
+		return nil
+	case TxTypeDynamic:
+		return errDynamicFeeTxNotSupported
+	}
+	baseFee, err := capi.GetLatestBaseFee()
+	if err != nil {
+		log.Warn("detect transaction type failed, use legacy transaction", "err", err)
+		return nil
+	}
+	if baseFee == nil {
+		log.Info("chain does not support EIP-1559, use legacy transaction")
+		return nil
+	}
+	log.Info("chain supports EIP-1559, use legacy transaction priced not lower than base fee", "baseFee", baseFee, "gasPrice", args.GasPrice)
+	if args.GasPrice.Cmp(baseFee) >= 0 {
+		return nil
+	}
+	if explicitGasPrice {
+		return fmt.Errorf("gas price %v is lower than base fee %v of latest block", args.GasPrice, baseFee)
+	}
+	log.Warn("suggested gas price is lower than base fee, use base fee instead", "suggested", args.GasPrice, "baseFee", baseFee)
+	args.GasPrice = new(big.Int).Set(baseFee)
+	return nil
+}
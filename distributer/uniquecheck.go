@@ -0,0 +1,77 @@
+package distributer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+var errDuplicateRecipient = errors.New("duplicate recipients in input file")
+
+// checkUniqueRecipients every recipient must appear at most once in input file,
+// a duplicate indicates a bug of generating the file, so it's not merged but reported
+func (opt *Option) checkUniqueRecipients(ifile string) error {
+	if !opt.RequireUnique {
+		return nil
+	}
+	accountLines, accounts, err := getRecipientLines(ifile)
+	if err != nil {
+		return err
+	}
+	duplicates := 0
+	for _, account := range accounts {
+		lines := accountLines[account]
+		if len(lines) < 2 {
+			continue
+		}
+		duplicates++
+		log.Error("[check unique] duplicate recipient", "inputfile", ifile, "account", strings.ToLower(account.String()), "lines", lines)
+	}
+	if duplicates > 0 {
+		log.Error("[check unique] input file has duplicate recipients, please fix the generation of it", "inputfile", ifile, "duplicates", duplicates)
+		return fmt.Errorf("%w %v, %v recipients appear more than once", errDuplicateRecipient, ifile, duplicates)
+	}
+	log.Info("[check unique] all recipients are unique", "inputfile", ifile, "recipients", len(accounts))
+	return nil
+}
+
+// getRecipientLines line numbers (starts from 1) of every recipient in input file,
+// and recipients in the order of first appearance
+func getRecipientLines(ifile string) (accountLines map[common.Address][]int, accounts []common.Address, err error) {
+	file, err := os.Open(ifile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open %v failed. %v", ifile, err)
+	}
+	defer file.Close()
+
+	accountLines = make(map[common.Address][]int)
+	reader := newInputReader(file)
+	for lineNum := 1; ; lineNum++ {
+		lineData, _, errf := reader.ReadLine()
+		if errf == io.EOF {
+			break
+		}
+		if errf != nil {
+			return nil, nil, fmt.Errorf("read %v failed at line %v. %v", ifile, lineNum, errf)
+		}
+		line := strings.TrimSpace(string(lineData))
+		if line == "" || isCommentedLine(line) {
+			continue
+		}
+		accountStr := blankOrCommaSepRegexp.Split(line, 2)[0]
+		if !common.IsHexAddress(accountStr) {
+			continue // reported by loading input file
+		}
+		account := common.HexToAddress(accountStr)
+		if _, exist := accountLines[account]; !exist {
+			accounts = append(accounts, account)
+		}
+		accountLines[account] = append(accountLines[account], lineNum)
+	}
+	return accountLines, accounts, nil
+}
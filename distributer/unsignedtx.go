@@ -0,0 +1,62 @@
+package distributer
+
+import (
+	"encoding/json"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common/hexutil"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/core/types"
+)
+
+// UnsignedTx fully formed unsigned transaction emitted in dry run
+type UnsignedTx struct {
+	From     common.Address  `json:"from"`
+	To       common.Address  `json:"to"`
+	Value    *hexutil.Big    `json:"value"`
+	Data     hexutil.Bytes   `json:"data"`
+	Nonce    hexutil.Uint64  `json:"nonce"`
+	Gas      hexutil.Uint64  `json:"gas"`
+	GasPrice *hexutil.Big    `json:"gasPrice"`
+	ChainID  *hexutil.Big    `json:"chainId"`
+	Account  string          `json:"account"`
+	Reward   string          `json:"reward"`
+	Token    *common.Address `json:"token,omitempty"`
+}
+
+// emitUnsignedTx write unsigned tx as one JSON object per line to UnsignedTxFile,
+// and advance nonce as if it's sent
+func (args *BuildTxArgs) emitUnsignedTx(rawTx *types.Transaction, account common.Address, reward *big.Int, rewardToken common.Address) error {
+	if args.unsignedTxEncoder == nil {
+		file, err := os.OpenFile(args.UnsignedTxFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			return err
+		}
+		args.unsignedTxEncoder = json.NewEncoder(file)
+		log.Info("open unsigned tx file success", "file", args.UnsignedTxFile)
+	}
+	utx := &UnsignedTx{
+		From:     args.fromAddr,
+		To:       *rawTx.To(),
+		Value:    (*hexutil.Big)(rawTx.Value()),
+		Data:     rawTx.Data(),
+		Nonce:    hexutil.Uint64(rawTx.Nonce()),
+		Gas:      hexutil.Uint64(rawTx.Gas()),
+		GasPrice: (*hexutil.Big)(rawTx.GasPrice()),
+		ChainID:  (*hexutil.Big)(args.chainID),
+		Account:  strings.ToLower(account.String()),
+		Reward:   reward.String(),
+	}
+	if rewardToken != (common.Address{}) {
+		utx.Token = &rewardToken
+	}
+	if err := args.unsignedTxEncoder.Encode(utx); err != nil {
+		return err
+	}
+	log.Info("sendRewards dry run emit unsigned tx", "account", account.String(), "reward", reward, "nonce", rawTx.Nonce())
+	*args.Nonce++
+	return nil
+}
@@ -1,6 +1,9 @@
 package distributer
 
 import (
+	"bufio"
+	"bytes"
+	"io"
 	"math/big"
 	"regexp"
 	"time"
@@ -13,6 +16,18 @@ import (
 
 var blankOrCommaSepRegexp = regexp.MustCompile(`[\s,]+`) // blank or comma separated
 
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// newInputReader new line reader of input file which skips UTF-8 BOM at the beginning.
+// CRLF line endings are removed by ReadLine, and stray CR is removed by trimming space of line
+func newInputReader(r io.Reader) *bufio.Reader {
+	reader := bufio.NewReader(r)
+	if prefix, err := reader.Peek(len(utf8BOM)); err == nil && bytes.Equal(prefix, utf8BOM) {
+		_, _ = reader.Discard(len(utf8BOM))
+	}
+	return reader
+}
+
 // CalcTotalValue calc the summary
 func CalcTotalValue(shares []*big.Int) *big.Int {
 	sum := big.NewInt(0)
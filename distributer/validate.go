@@ -0,0 +1,145 @@
+package distributer
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/anyswap/ANYToken-distribution/tools"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+// ValidateOption validate reward file option
+type ValidateOption struct {
+	InputFile string
+
+	// check title line is consistent with them if not empty
+	RewardType  string
+	RewardToken string
+
+	// duplicate accounts are intended (to be merged), do not report them
+	AllowDuplicate bool
+}
+
+// ValidateProblem problem found in reward file, line number starts from 1
+type ValidateProblem struct {
+	Line    int
+	Message string
+}
+
+func (p *ValidateProblem) String() string {
+	return fmt.Sprintf("line %v: %v", p.Line, p.Message)
+}
+
+// ValidateResult result of validating reward file
+type ValidateResult struct {
+	TitleLine   string
+	Recipients  int
+	TotalReward *big.Int
+	Problems    []*ValidateProblem
+}
+
+func (res *ValidateResult) addProblem(line int, format string, args ...interface{}) {
+	res.Problems = append(res.Problems, &ValidateProblem{Line: line, Message: fmt.Sprintf(format, args...)})
+}
+
+// ValidateRewardFile check structure of reward file without node connection,
+// every problem is reported with its line number instead of stopping at the first one
+func ValidateRewardFile(vopt *ValidateOption) (*ValidateResult, error) {
+	opt := &Option{RewardToken: vopt.RewardToken}
+	if vopt.RewardType != "" {
+		if err := opt.SetByWhat(vopt.RewardType); err != nil {
+			return nil, err
+		}
+	}
+	if vopt.RewardToken != "" && !common.IsHexAddress(vopt.RewardToken) {
+		return nil, fmt.Errorf("wrong reward token '%v'", vopt.RewardToken)
+	}
+
+	file, err := os.Open(vopt.InputFile)
+	if err != nil {
+		return nil, fmt.Errorf("open %v failed. %v", vopt.InputFile, err)
+	}
+	defer file.Close()
+
+	res := &ValidateResult{TotalReward: big.NewInt(0)}
+	accountLines := make(map[common.Address]int)
+
+	reader := newInputReader(file)
+	for lineNum := 1; ; lineNum++ {
+		lineData, _, errf := reader.ReadLine()
+		if errf == io.EOF {
+			break
+		}
+		if errf != nil {
+			return nil, fmt.Errorf("read %v failed at line %v. %v", vopt.InputFile, lineNum, errf)
+		}
+		line := strings.TrimSpace(string(lineData))
+		if line == "" {
+			continue
+		}
+		if isCommentedLine(line) {
+			if lineNum == 1 {
+				res.TitleLine = line
+				if err = opt.checkTitleLine(line); err != nil {
+					res.addProblem(lineNum, "wrong title line, %v", err)
+				}
+			}
+			continue
+		}
+		parts, _ := parseIdempotencyKey(blankOrCommaSepRegexp.Split(line, -1))
+		parts, _ = parseChainTag(parts)
+		parts, _, _, err := parseGasOverrides(parts)
+		if err != nil {
+			res.addProblem(lineNum, "wrong gas override, %v", err)
+			continue
+		}
+		if len(parts) < 2 {
+			res.addProblem(lineNum, "less than 2 parts")
+			continue
+		}
+		if !common.IsHexAddress(parts[0]) {
+			res.addProblem(lineNum, "wrong address '%v'", parts[0])
+			continue
+		}
+		account := common.HexToAddress(parts[0])
+		if account == (common.Address{}) {
+			res.addProblem(lineNum, "zero address recipient")
+		}
+		reward, err := tools.GetBigIntFromString(parts[1])
+		if err != nil {
+			res.addProblem(lineNum, "wrong reward '%v', %v", parts[1], err)
+			continue
+		}
+		if reward.Sign() < 0 {
+			res.addProblem(lineNum, "negative reward %v", reward)
+			continue
+		}
+		if len(parts) >= 4 && !isTxHashString(parts[2]) {
+			if _, err = tools.GetBigIntFromString(parts[2]); err != nil {
+				res.addProblem(lineNum, "wrong share '%v', %v", parts[2], err)
+			}
+			if _, err = tools.GetBigIntFromString(parts[3]); err != nil {
+				res.addProblem(lineNum, "wrong number '%v', %v", parts[3], err)
+			}
+		}
+		if reward.Sign() == 0 {
+			continue // ignored when sending
+		}
+		if firstLine, exist := accountLines[account]; exist {
+			if !vopt.AllowDuplicate {
+				res.addProblem(lineNum, "duplicate account %v, first seen at line %v", account.String(), firstLine)
+			}
+		} else {
+			accountLines[account] = lineNum
+		}
+		res.Recipients++
+		res.TotalReward.Add(res.TotalReward, reward)
+	}
+	if res.Recipients == 0 {
+		res.addProblem(0, "no recipients with positive reward")
+	}
+	return res, nil
+}
@@ -0,0 +1,223 @@
+package distributer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/anyswap/ANYToken-distribution/callapi"
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/anyswap/ANYToken-distribution/tools"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+var (
+	errVerifyFailed = errors.New("verify sended rewards failed")
+
+	transferLogTopic = common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+)
+
+// VerifyOption verify sended rewards option
+type VerifyOption struct {
+	InputFile      string
+	RewardToken    string
+	Sender         string
+	ByBalanceDelta bool
+
+	// wait confirmations required by policy before verify (nil means no wait)
+	Confirm        *ConfirmPolicy
+	ConfirmTimeout time.Duration
+
+	// write proof of every verified transfer to this directory (see ReceiptProof)
+	ReceiptProofDir string
+
+	proofBlock *blockReceipts
+}
+
+// GetRewardResultsFromFile get send reward results from output file (legacy format)
+// line format: <account>,<reward>[,<share>,<number>][,<txhash>[,<nonce>,<gasPrice>]]
+func GetRewardResultsFromFile(ifile string) (results []*RewardResult, titleLine string, err error) {
+	file, err := os.Open(ifile)
+	if err != nil {
+		return nil, "", fmt.Errorf("open %v failed. %v)", ifile, err)
+	}
+	defer file.Close()
+
+	reader := newInputReader(file)
+	isFirstLine := true
+	for {
+		lineData, _, errf := reader.ReadLine()
+		if errf == io.EOF {
+			break
+		}
+		line := strings.TrimSpace(string(lineData))
+		if isCommentedLine(line) {
+			if isFirstLine {
+				titleLine = line
+			}
+			isFirstLine = false
+			continue
+		}
+		isFirstLine = false
+		parts := blankOrCommaSepRegexp.Split(line, -1)
+		if len(parts) < 2 || !common.IsHexAddress(parts[0]) {
+			return nil, "", fmt.Errorf("wrong line %v", line)
+		}
+		reward, err := tools.GetBigIntFromString(parts[1])
+		if err != nil {
+			return nil, "", fmt.Errorf("wrong reward in line %v, err=%v", line, err)
+		}
+		result := &RewardResult{
+			Account: strings.ToLower(parts[0]),
+			Reward:  reward,
+			line:    line,
+		}
+		// txhash is the last hash column, it's after blockHash of snapshot provenance
+		for _, part := range parts[2:] {
+			if isTxHashString(part) {
+				result.TxHash = part
+			}
+		}
+		results = append(results, result)
+	}
+	return results, titleLine, nil
+}
+
+func isTxHashString(s string) bool {
+	return len(s) == 2+2*common.HashLength && strings.HasPrefix(s, "0x")
+}
+
+// VerifyRewardsFromFile verify every recipient in output file received reward
+func VerifyRewardsFromFile(vopt *VerifyOption) error {
+	results, titleLine, err := GetRewardResultsFromFile(vopt.InputFile)
+	if err != nil {
+		return err
+	}
+	rewardToken := vopt.RewardToken
+	if rewardToken == "" {
+		rewardToken = getTitleExtraInfo(titleLine, "rewardToken")
+	}
+	if rewardToken != "" && !common.IsHexAddress(rewardToken) {
+		return fmt.Errorf("wrong reward token '%v'", rewardToken)
+	}
+	byBalanceDelta := vopt.ByBalanceDelta
+	if rewardToken == "" && !byBalanceDelta {
+		log.Info("[verify] native coin reward can only be verified by balance delta")
+		byBalanceDelta = true
+	}
+	if vopt.ReceiptProofDir != "" {
+		if err = os.MkdirAll(vopt.ReceiptProofDir, 0755); err != nil {
+			return fmt.Errorf("create receipt proof directory failed. %v", err)
+		}
+	}
+	log.Info("[verify] start", "input", vopt.InputFile, "rewardToken", rewardToken, "byBalanceDelta", byBalanceDelta, "records", len(results))
+
+	var verified, failed, skipped int
+	// actual gas cost of receipts having effectiveGasPrice
+	gasSpent, gasSpentCount := big.NewInt(0), 0
+	for _, result := range results {
+		if result.TxHash == "" {
+			log.Warn("[verify] skip record without txhash", "account", result.Account, "reward", result.Reward)
+			skipped++
+			continue
+		}
+		receipt, err := vopt.getVerifyReceipt(common.HexToHash(result.TxHash), result.Reward)
+		if err == nil {
+			if receipt.GasUsed != nil && receipt.EffectiveGasPrice != nil {
+				gasSpent.Add(gasSpent, estimateGasCost(uint64(*receipt.GasUsed), receipt.EffectiveGasPrice.ToInt()))
+				gasSpentCount++
+			}
+			if byBalanceDelta {
+				err = verifyByBalanceDelta(result, rewardToken, receipt)
+			} else {
+				err = verifyByTransferLog(result, rewardToken, vopt.Sender, receipt)
+			}
+		}
+		if err != nil {
+			log.Error("[verify] verify failed", "account", result.Account, "reward", result.Reward, "txhash", result.TxHash, "err", err)
+			failed++
+			continue
+		}
+		verified++
+		if vopt.ReceiptProofDir != "" {
+			if err = vopt.writeReceiptProof(result, rewardToken, receipt); err != nil {
+				log.Error("[verify] write receipt proof failed", "account", result.Account, "txhash", result.TxHash, "err", err)
+				return err
+			}
+		}
+	}
+	log.Info("[verify] finished", "verified", verified, "failed", failed, "skipped", skipped, "gasSpent", gasSpent, "gasSpentOfTxs", gasSpentCount)
+	if err = checkDuplicateTxHashes(results); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return errVerifyFailed
+	}
+	return nil
+}
+
+func (vopt *VerifyOption) getVerifyReceipt(txHash common.Hash, reward *big.Int) (receipt *callapi.RPCReceipt, err error) {
+	if vopt.Confirm != nil {
+		confirmations := vopt.Confirm.RequiredConfirmations(reward)
+		receipt, err = waitTxConfirmations(txHash, confirmations, vopt.ConfirmTimeout)
+	} else {
+		receipt, err = capi.GetTransactionReceipt(txHash)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get receipt failed, %v", err)
+	}
+	if !receipt.IsSuccess() {
+		return nil, fmt.Errorf("transaction failed")
+	}
+	return receipt, nil
+}
+
+func verifyByTransferLog(result *RewardResult, rewardToken, sender string, receipt *callapi.RPCReceipt) error {
+	token := common.HexToAddress(rewardToken)
+	account := common.HexToAddress(result.Account)
+	for _, rlog := range receipt.Logs {
+		if rlog.Address != token || len(rlog.Topics) != 3 || rlog.Topics[0] != transferLogTopic {
+			continue
+		}
+		if common.BytesToAddress(rlog.Topics[2].Bytes()) != account {
+			continue
+		}
+		if sender != "" && common.BytesToAddress(rlog.Topics[1].Bytes()) != common.HexToAddress(sender) {
+			continue
+		}
+		if new(big.Int).SetBytes(rlog.Data).Cmp(result.Reward) == 0 {
+			return nil
+		}
+	}
+	return fmt.Errorf("no matched transfer log")
+}
+
+func verifyByBalanceDelta(result *RewardResult, rewardToken string, receipt *callapi.RPCReceipt) error {
+	account := common.HexToAddress(result.Account)
+	postBlock := receipt.BlockNumber.ToInt()
+	preBlock := new(big.Int).Sub(postBlock, big.NewInt(1))
+	getBalance := func(blockNumber *big.Int) (*big.Int, error) {
+		if rewardToken == "" {
+			return capi.GetCoinBalance(account, blockNumber)
+		}
+		return capi.GetTokenBalance(common.HexToAddress(rewardToken), account, blockNumber)
+	}
+	preBalance, err := getBalance(preBlock)
+	if err != nil {
+		return fmt.Errorf("get balance at block %v failed, %v", preBlock, err)
+	}
+	postBalance, err := getBalance(postBlock)
+	if err != nil {
+		return fmt.Errorf("get balance at block %v failed, %v", postBlock, err)
+	}
+	delta := new(big.Int).Sub(postBalance, preBalance)
+	if delta.Cmp(result.Reward) != 0 {
+		// other transfers to the account in the same block also affect the delta
+		return fmt.Errorf("balance delta mismatch, delta %v (%v -> %v) at block %v", delta, preBalance, postBalance, postBlock)
+	}
+	return nil
+}
@@ -0,0 +1,278 @@
+package distributer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/anyswap/ANYToken-distribution/mongodb"
+	"github.com/anyswap/ANYToken-distribution/params"
+	ethereum "github.com/fsn-dev/fsn-go-sdk/efsn"
+	"github.com/fsn-dev/fsn-go-sdk/efsn/common"
+)
+
+var errSendNotConfirmed = errors.New("send rewards is not confirmed")
+
+// sendInput checked accounts and rewards of an input file
+type sendInput struct {
+	file         string
+	accountStats mongodb.AccountStatSlice
+	titleLine    string
+	totalReward  *big.Int
+	skipped      []*skippedStat
+}
+
+// warmUpReport results of warm up steps
+type warmUpReport struct {
+	tokenSymbol string
+	recipients  int
+	totalReward *big.Int
+
+	// only collected for pre-flight report
+	inputs       []*sendInput
+	coinBalance  *big.Int
+	tokenBalance *big.Int
+	steps        []*PreflightStep
+	warnings     []string
+}
+
+func (report *warmUpReport) reportStep(step, detail string, err error) error {
+	result := &PreflightStep{Step: step}
+	if err != nil {
+		log.Error("[warm up] step failed", "step", step, "err", err)
+		result.Error = err.Error()
+	} else {
+		log.Info("[warm up] step ok", "step", step, "detail", detail)
+		result.Detail = detail
+	}
+	report.steps = append(report.steps, result)
+	return err
+}
+
+// warmUp do all slow and failure-prone checks before sending anything:
+// load input files, decrypt keystore, verify chain ID, resolve token metadata,
+// check balances and simulate a sample transfer.
+// results are saved as pre-flight report if PreflightReportFile is specified
+func (opt *Option) warmUp() (inputs []*sendInput, err error) {
+	report := &warmUpReport{totalReward: big.NewInt(0)}
+	defer func() {
+		if errs := opt.savePreflightReport(report, err); errs != nil && err == nil {
+			inputs, err = nil, errs
+		}
+	}()
+
+	inputs, err = opt.warmUpLoadInputs(report)
+	report.inputs = inputs
+	detail := fmt.Sprintf("%v files, %v recipients, total reward %v", len(inputs), report.recipients, report.totalReward)
+	if err = report.reportStep("load input files", detail, err); err != nil {
+		return nil, err
+	}
+
+	detail, err = opt.warmUpKeystore()
+	if err = report.reportStep("keystore", detail, err); err != nil {
+		return nil, err
+	}
+
+	detail, err = opt.acquireSenderLock()
+	if err = report.reportStep("sender lock", detail, err); err != nil {
+		return nil, err
+	}
+
+	err = opt.checkMinClients()
+	detail = fmt.Sprintf("at least %v healthy clients", opt.MinClients)
+	if err = report.reportStep("min clients", detail, err); err != nil {
+		return nil, err
+	}
+
+	detail, err = opt.warmUpChainID()
+	if err = report.reportStep("chain ID", detail, err); err != nil {
+		return nil, err
+	}
+
+	detail, err = opt.warmUpTokenMetadata(report)
+	if err = report.reportStep("token metadata", detail, err); err != nil {
+		return nil, err
+	}
+
+	detail, err = opt.checkExchangeFactory()
+	if err = report.reportStep("exchange factory", detail, err); err != nil {
+		return nil, err
+	}
+
+	detail, err = opt.checkTokenState()
+	if err = report.reportStep("token state", detail, err); err != nil {
+		return nil, err
+	}
+
+	// assign total value of all input files before check balance
+	opt.TotalValue = report.totalReward
+	if opt.RewardToken != "" {
+		err = opt.CheckSenderRewardTokenBalance()
+		detail = "reward token balance is enough, no allowance needed for direct transfer"
+	} else {
+		err = opt.CheckSenderCoinBalance()
+		detail = "coin balance is enough"
+	}
+	if err = report.reportStep("balance", detail, err); err != nil {
+		return nil, err
+	}
+	opt.collectPreflightBalances(report)
+
+	detail, err = opt.warmUpSimulateTransfer(inputs)
+	if err != nil && opt.DryRun {
+		log.Warn("[warm up] simulate transfer failed, but ignore in dry run", "err", err)
+		report.addWarning("simulate transfer failed, but ignore in dry run. %v", err)
+		detail, err = "failed but ignored in dry run", nil
+	}
+	if err = report.reportStep("simulate transfer", detail, err); err != nil {
+		return nil, err
+	}
+
+	log.Info("[warm up] all steps ok", "sender", opt.GetSender().String(), "token", report.tokenSymbol,
+		"files", len(inputs), "recipients", report.recipients, "totalReward", report.totalReward)
+	opt.warmUpReport = report
+	return inputs, nil
+}
+
+func (opt *Option) warmUpLoadInputs(report *warmUpReport) (inputs []*sendInput, err error) {
+	for i, ifile := range opt.InputFiles {
+		accountStats, titleLine, err := opt.checkSendRewardsFromFile(i)
+		if err != nil {
+			return nil, err
+		}
+		input := &sendInput{
+			file:         ifile,
+			accountStats: accountStats,
+			titleLine:    titleLine,
+			totalReward:  accountStats.CalcTotalReward(),
+			skipped:      opt.takeSkipped(),
+		}
+		report.recipients += len(accountStats)
+		report.totalReward.Add(report.totalReward, input.totalReward)
+		inputs = append(inputs, input)
+	}
+	return inputs, nil
+}
+
+func (opt *Option) warmUpKeystore() (string, error) {
+	args := opt.BuildTxArgs
+	if opt.DryRun {
+		return fmt.Sprintf("skipped in dry run, sender %v", args.Sender), nil
+	}
+	if args.keyWrapper == nil || args.keyWrapper.PrivateKey == nil {
+		return "", fmt.Errorf("private key is not loaded")
+	}
+	if args.keyWrapper.Address != args.fromAddr {
+		return "", fmt.Errorf("key address %v mismatch sender %v", args.keyWrapper.Address.String(), args.fromAddr.String())
+	}
+	return fmt.Sprintf("private key loaded, sender %v", args.fromAddr.String()), nil
+}
+
+func (opt *Option) warmUpChainID() (string, error) {
+	chainID, err := capi.GetChainID()
+	if err != nil {
+		return "", err
+	}
+	if opt.GetChainID() == nil || chainID.Cmp(opt.GetChainID()) != 0 {
+		return "", fmt.Errorf("node chain ID %v mismatch signing chain ID %v", chainID, opt.GetChainID())
+	}
+	return fmt.Sprintf("chain ID %v", chainID), nil
+}
+
+func (opt *Option) warmUpTokenMetadata(report *warmUpReport) (string, error) {
+	if opt.RewardToken == "" {
+		report.tokenSymbol = "native"
+		return "native coin reward", nil
+	}
+	token := common.HexToAddress(opt.RewardToken)
+	metadata, err := capi.BatchGetErc20Metadata(token)
+	if err == nil {
+		report.tokenSymbol = metadata.Symbol
+		return fmt.Sprintf("token %v symbol %v decimals %v", opt.RewardToken, metadata.Symbol, metadata.Decimals), nil
+	}
+	// some tokens have non standard name or symbol, decimals is enough to work with
+	log.Warn("[warm up] get token metadata failed, try decimals only", "token", opt.RewardToken, "err", err)
+	decimals, err := capi.GetErc20Decimals(token)
+	if err != nil {
+		return "", fmt.Errorf("token %v is not erc20, get decimals failed. %v", opt.RewardToken, err)
+	}
+	return fmt.Sprintf("token %v decimals %v", opt.RewardToken, decimals), nil
+}
+
+// warmUpSimulateTransfer estimate gas of the first non dust transfer from sender
+func (opt *Option) warmUpSimulateTransfer(inputs []*sendInput) (string, error) {
+	dustRewardThreshold := params.GetDustRewardThreshold()
+	var sample *mongodb.AccountStat
+	for _, input := range inputs {
+		for _, stat := range input.accountStats {
+			if stat.Reward != nil && stat.Reward.Cmp(dustRewardThreshold) >= 0 {
+				sample = stat
+				break
+			}
+		}
+		if sample != nil {
+			break
+		}
+	}
+	if sample == nil {
+		return "no non dust reward to simulate", nil
+	}
+	return opt.simulateTransfer(sample)
+}
+
+// simulateTransfer estimate gas of transfer from sender, and check it's in gas limit of sending it
+func (opt *Option) simulateTransfer(sample *mongodb.AccountStat) (string, error) {
+	args := opt.BuildTxArgs
+	msg := &ethereum.CallMsg{From: args.fromAddr}
+	if opt.RewardToken != "" {
+		rewardToken := common.HexToAddress(opt.RewardToken)
+		msg.To = &rewardToken
+		msg.Data = packTransferData(sample.Account, sample.Reward)
+	} else {
+		msg.To = &sample.Account
+		msg.Value = sample.Reward
+	}
+	gas, err := capi.EstimateGas(msg)
+	if err != nil {
+		return "", fmt.Errorf("transfer %v to %v will fail. %v", sample.Reward, sample.Account.String(), err)
+	}
+	var gasLimit uint64
+	switch {
+	case sample.GasLimit != nil:
+		gasLimit = *sample.GasLimit
+	case args.EstimateGas:
+		// same as sending, gas limit is the estimated gas plus buffer
+		gasLimit = args.addGasBuffer(gas)
+	default:
+		gasLimit = *args.GasLimit
+	}
+	if gas > gasLimit {
+		return "", fmt.Errorf("estimated gas %v is larger than gas limit %v", gas, gasLimit)
+	}
+	return fmt.Sprintf("transfer %v to %v estimated gas %v (limit %v)", sample.Reward, sample.Account.String(), gas, gasLimit), nil
+}
+
+// confirmSend present summary after warm up and ask operator to confirm
+func (opt *Option) confirmSend(inputs []*sendInput) error {
+	if !opt.ConfirmBeforeSend || opt.DryRun {
+		return nil
+	}
+	report := opt.warmUpReport
+	fmt.Printf("\nsender: %v\nchain ID: %v\nreward token: %v (%v)\nstart nonce: %v\n",
+		opt.GetSender().String(), opt.GetChainID(), opt.RewardToken, report.tokenSymbol, *opt.BuildTxArgs.Nonce)
+	for _, input := range inputs {
+		fmt.Printf("input file: %v, recipients: %v, total reward: %v\n", input.file, len(input.accountStats), input.totalReward)
+	}
+	fmt.Printf("total recipients: %v, total reward: %v\n", report.recipients, report.totalReward)
+	fmt.Print("send rewards? [y/N]: ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	if answer != "y" && answer != "yes" {
+		return errSendNotConfirmed
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package log
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"time"
 
@@ -35,6 +36,11 @@ func SetLogger(logLevel uint32, jsonFormat, colorFormat bool) {
 	}
 }
 
+// SetOutput set log output
+func SetOutput(out io.Writer) {
+	logrus.SetOutput(out)
+}
+
 // SetLogFile set log file path and rotation
 func SetLogFile(logFile string, logRotation, logMaxAge uint64) {
 	if logFile == "" {
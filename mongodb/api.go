@@ -161,6 +161,60 @@ func AddDistributeInfo(ma *MgoDistributeInfo) error {
 	return err
 }
 
+// AddRunSummary add run summary
+func AddRunSummary(ms *MgoRunSummary) error {
+	_, err := collectionRunSummary.UpsertId(ms.Key, ms)
+	switch {
+	case err == nil:
+		log.Info("[mongodb] AddRunSummary success", "summary", ms)
+	default:
+		log.Warn("[mongodb] AddRunSummary failed", "summary", ms, "err", err)
+	}
+	return err
+}
+
+// AddPaidKey add idempotency key of paid recipient
+func AddPaidKey(mp *MgoPaidKey) error {
+	err := collectionPaidKeys.Insert(mp)
+	switch {
+	case err == nil:
+		log.Info("[mongodb] AddPaidKey success", "paid", mp)
+	case mgo.IsDup(err):
+		log.Warn("[mongodb] AddPaidKey key already exist", "paid", mp)
+		return nil
+	default:
+		log.Warn("[mongodb] AddPaidKey failed", "paid", mp, "err", err)
+	}
+	return err
+}
+
+// UpdatePaidKey update reward and txhash of paid idempotency key after paying difference
+func UpdatePaidKey(mp *MgoPaidKey) error {
+	err := collectionPaidKeys.UpdateId(mp.Key, bson.M{"$set": bson.M{
+		"reward":    mp.Reward,
+		"txhash":    mp.TxHash,
+		"runID":     mp.RunID,
+		"timestamp": mp.Timestamp,
+	}})
+	if err == nil {
+		log.Info("[mongodb] UpdatePaidKey success", "paid", mp)
+	} else {
+		log.Warn("[mongodb] UpdatePaidKey failed", "paid", mp, "err", err)
+	}
+	return err
+}
+
+// AddSyncChunk add checkpoint of completed sync chunk
+func AddSyncChunk(mc *MgoSyncChunk) error {
+	_, err := collectionSyncChunks.UpsertId(mc.Key, mc)
+	if err == nil {
+		log.Info("[mongodb] AddSyncChunk success", "from", mc.From, "to", mc.To)
+	} else {
+		log.Warn("[mongodb] AddSyncChunk failed", "from", mc.From, "to", mc.To, "err", err)
+	}
+	return err
+}
+
 func getVolumeRewardUpdateItems(mr *MgoVolumeRewardResult) bson.M {
 	updates := bson.M{}
 	if mr.Reward != "" {
@@ -175,6 +229,9 @@ func getVolumeRewardUpdateItems(mr *MgoVolumeRewardResult) bson.M {
 	if mr.RewardTx != "" {
 		updates["rewardTx"] = mr.RewardTx
 	}
+	if mr.RunID != "" {
+		updates["runID"] = mr.RunID
+	}
 	return updates
 }
 
@@ -210,6 +267,9 @@ func getLiquidRewardUpdateItems(mr *MgoLiquidRewardResult) bson.M {
 	if mr.RewardTx != "" {
 		updates["rewardTx"] = mr.RewardTx
 	}
+	if mr.RunID != "" {
+		updates["runID"] = mr.RunID
+	}
 	return updates
 }
 
@@ -243,8 +303,17 @@ func UpdateSyncInfo(number uint64, hash string, timestamp uint64) error {
 		}})
 }
 
-// UpdateVolumeWithReceipt update volume
-func UpdateVolumeWithReceipt(exr *ExchangeReceipt, blockHash string, blockNumber, timestamp uint64) error {
+// UpdateSyncInfoVersion update sync info version
+func UpdateSyncInfoVersion(version uint64) error {
+	return collectionSyncInfo.UpdateId(KeyOfLatestSyncInfo,
+		bson.M{"$set": bson.M{
+			"version": version,
+		}})
+}
+
+// UpdateVolumeWithReceipt update volume, a receipt already accumulated is ignored,
+// so parse a block again (eg. resume syncing) does not count its volume twice
+func UpdateVolumeWithReceipt(exr *ExchangeReceipt, txHash, blockHash string, blockNumber, timestamp uint64) error {
 	key := GetKeyOfExchangeAndTimestamp(exr.Exchange, timestamp)
 	curVol, err := FindVolume(key)
 
@@ -252,6 +321,19 @@ func UpdateVolumeWithReceipt(exr *ExchangeReceipt, blockHash string, blockNumber
 		return err
 	}
 
+	receiptKey := GetKeyOfVolumeHistory(txHash, exr.LogIndex)
+	var receipts []string
+	if curVol != nil {
+		for _, receipt := range curVol.Receipts {
+			if receipt == receiptKey {
+				log.Debug("[mongodb] update volume ignore accumulated receipt", "key", key, "receipt", receiptKey)
+				return nil
+			}
+		}
+		receipts = curVol.Receipts
+	}
+	receipts = append(receipts, receiptKey)
+
 	tokenFromAmount, _ := tools.GetBigIntFromString(exr.TokenFromAmount)
 	tokenToAmount, _ := tools.GetBigIntFromString(exr.TokenToAmount)
 
@@ -285,6 +367,7 @@ func UpdateVolumeWithReceipt(exr *ExchangeReceipt, blockHash string, blockNumber
 		BlockNumber:    blockNumber,
 		BlockHash:      blockHash,
 		Timestamp:      timestamp,
+		Receipts:       receipts,
 	}, true)
 }
 
@@ -302,6 +385,15 @@ func FindBlocksInRange(start, end uint64) ([]*MgoBlock, error) {
 	return blocks, nil
 }
 
+// IsSyncChunkCompleted is sync chunk [from, to] checkpointed as completed
+func IsSyncChunkCompleted(from, to uint64) (bool, error) {
+	count, err := collectionSyncChunks.FindId(GetKeyOfSyncChunk(from, to)).Count()
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
 // FindLatestSyncInfo find latest sync info
 func FindLatestSyncInfo() (*MgoSyncInfo, error) {
 	var info MgoSyncInfo
@@ -342,6 +434,54 @@ func FindVolumeRewardResult(key string) (*MgoVolumeRewardResult, error) {
 	return &res, nil
 }
 
+// FindLatestCompletedRun find completed run summary with the latest end of byWhat and exchanges
+func FindLatestCompletedRun(byWhat string, exchanges []string, dryRun bool) (*MgoRunSummary, error) {
+	var res MgoRunSummary
+	query := bson.M{
+		"bywhat":    byWhat,
+		"exchanges": exchanges,
+		"dryRun":    dryRun,
+		"outcome":   RunOutcomeCompleted,
+	}
+	err := collectionRunSummary.Find(query).Sort("-end").Limit(1).One(&res)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// FindPaidKey find paid idempotency key
+func FindPaidKey(key string) (*MgoPaidKey, error) {
+	var res MgoPaidKey
+	err := collectionPaidKeys.FindId(key).One(&res)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// IsKeyPaid is idempotency key already paid
+func IsKeyPaid(key string) (bool, error) {
+	_, err := FindPaidKey(key)
+	switch {
+	case err == nil:
+		return true, nil
+	case err == mgo.ErrNotFound:
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// GetPaidKey get idempotency key of paid recipient, return nil if it's not paid
+func GetPaidKey(key string) (*MgoPaidKey, error) {
+	mp, err := FindPaidKey(key)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	return mp, err
+}
+
 // FindLiquidRewardResult find liquid reward result
 func FindLiquidRewardResult(key string) (*MgoLiquidRewardResult, error) {
 	var res MgoLiquidRewardResult
@@ -393,6 +533,45 @@ func FindAllTokenAccounts(token string) (accounts []common.Address) {
 	return accounts
 }
 
+// SumUnsentRewards sum rewards without reward tx of volume and liquid reward results, grouped by reward token
+func SumUnsentRewards() (map[string]*UnsentReward, error) {
+	unsent := make(map[string]*UnsentReward)
+	addUnsent := func(rewardToken, reward string) {
+		value, err := tools.GetBigIntFromString(reward)
+		if err != nil || value == nil || value.Sign() <= 0 {
+			return
+		}
+		rewardToken = strings.ToLower(rewardToken)
+		sum, exist := unsent[rewardToken]
+		if !exist {
+			sum = &UnsentReward{RewardToken: rewardToken, Reward: big.NewInt(0)}
+			unsent[rewardToken] = sum
+		}
+		sum.Records++
+		sum.Reward.Add(sum.Reward, value)
+	}
+	query := bson.M{"rewardTx": bson.M{"$in": []interface{}{"", nil}}}
+
+	var vr MgoVolumeRewardResult
+	iter := collectionVolumeRewardResult.Find(query).Iter()
+	for iter.Next(&vr) {
+		addUnsent(vr.RewardToken, vr.Reward)
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	var lr MgoLiquidRewardResult
+	iter = collectionLiquidRewardResult.Find(query).Iter()
+	for iter.Next(&lr) {
+		addUnsent(lr.RewardToken, lr.Reward)
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+	return unsent, nil
+}
+
 // FindLiquidityBalance find liquidity balance
 func FindLiquidityBalance(exchange, account string, blockNumber uint64) (string, error) {
 	var res MgoLiquidityBalance
@@ -23,6 +23,11 @@ func MongoServerInit(addrs []string, dbname, user, pass string) {
 	go checkMongoSession()
 }
 
+// IsInitialized is mongodb server session initialized
+func IsInitialized() bool {
+	return database != nil
+}
+
 func initDialInfo(addrs []string, db, user, pass string) {
 	dialInfo = &mgo.DialInfo{
 		Addrs:    addrs,
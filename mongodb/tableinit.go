@@ -17,6 +17,9 @@ var (
 	collectionDistributeInfo     *mgo.Collection
 	collectionVolumeRewardResult *mgo.Collection
 	collectionLiquidRewardResult *mgo.Collection
+	collectionRunSummary         *mgo.Collection
+	collectionPaidKeys           *mgo.Collection
+	collectionSyncChunks         *mgo.Collection
 )
 
 // do this when reconnect to the database
@@ -33,6 +36,9 @@ func deinintCollections() {
 	collectionDistributeInfo = database.C(tbDistributeInfo)
 	collectionVolumeRewardResult = database.C(tbVolumeRewardResult)
 	collectionLiquidRewardResult = database.C(tbLiquidRewardResult)
+	collectionRunSummary = database.C(tbRunSummary)
+	collectionPaidKeys = database.C(tbPaidKeys)
+	collectionSyncChunks = database.C(tbSyncChunks)
 }
 
 func initCollections() {
@@ -48,6 +54,9 @@ func initCollections() {
 	initCollection(tbDistributeInfo, &collectionDistributeInfo, "exchange", "bywhat")
 	initCollection(tbVolumeRewardResult, &collectionVolumeRewardResult, "exchange", "start")
 	initCollection(tbLiquidRewardResult, &collectionLiquidRewardResult, "exchange", "start")
+	initCollection(tbRunSummary, &collectionRunSummary, "bywhat", "start")
+	initCollection(tbPaidKeys, &collectionPaidKeys, "account")
+	initCollection(tbSyncChunks, &collectionSyncChunks, "from")
 
 	_ = initLatestSyncInfo()
 }
@@ -66,7 +75,8 @@ func initLatestSyncInfo() error {
 	}
 	return collectionSyncInfo.Insert(
 		&MgoSyncInfo{
-			Key: KeyOfLatestSyncInfo,
+			Key:     KeyOfLatestSyncInfo,
+			Version: SyncInfoVersion,
 		},
 	)
 }
@@ -20,9 +20,16 @@ const (
 	tbDistributeInfo     string = "DistributeInfo"
 	tbVolumeRewardResult string = "VolumeRewardResult"
 	tbLiquidRewardResult string = "LiquidRewardResult"
+	tbRunSummary         string = "RunSummary"
+	tbPaidKeys           string = "PaidKeys"
+	tbSyncChunks         string = "SyncChunks"
 
 	// KeyOfLatestSyncInfo key
 	KeyOfLatestSyncInfo string = "latest"
+
+	// SyncInfoVersion version of persisted sync state,
+	// increase it when stored checkpoint/schema format changes
+	SyncInfoVersion uint64 = 1
 )
 
 // MgoSyncInfo sync info
@@ -31,6 +38,16 @@ type MgoSyncInfo struct {
 	Number    uint64 `bson:"number"`
 	Hash      string `bson:"hash"`
 	Timestamp uint64 `bson:"timestamp"`
+	Version   uint64 `bson:"version"`
+}
+
+// MgoSyncChunk checkpoint of a completed chunk of range syncing,
+// all blocks in [From, To] and their derived records are stored
+type MgoSyncChunk struct {
+	Key       string `bson:"_id"` // from-to
+	From      uint64 `bson:"from"`
+	To        uint64 `bson:"to"`
+	Timestamp int64  `bson:"timestamp"` // completed time
 }
 
 // MgoBlock block
@@ -126,6 +143,8 @@ type MgoVolume struct {
 	BlockNumber    uint64 `bson:"blockNumber"`
 	BlockHash      string `bson:"blockHash"`
 	Timestamp      uint64 `bson:"timestamp"`
+	// keys (tx hash + log index) of receipts accumulated into the volume
+	Receipts []string `bson:"receipts,omitempty"`
 }
 
 // MgoAccount exchange account
@@ -179,6 +198,7 @@ type MgoDistributeInfo struct {
 	RewardToken  string        `bson:"rewardToken"`
 	Rewards      string        `bson:"rewards"`
 	SampleHeight uint64        `bson:"sampleHeight,omitempty"`
+	RunID        string        `bson:"runID,omitempty"`
 	Timestamp    uint64        `bson:"timestamp"`
 }
 
@@ -195,6 +215,7 @@ type MgoVolumeRewardResult struct {
 	Volume      string `bson:"volume"`
 	TxCount     uint64 `bson:"txcount"`
 	RewardTx    string `bson:"rewardTx"`
+	RunID       string `bson:"runID,omitempty"`
 	Timestamp   uint64 `bson:"timestamp"`
 }
 
@@ -211,6 +232,51 @@ type MgoLiquidRewardResult struct {
 	Liquidity   string `bson:"liquidity"`
 	Height      uint64 `bson:"height"`
 	RewardTx    string `bson:"rewardTx"`
+	RunID       string `bson:"runID,omitempty"`
+	Timestamp   uint64 `bson:"timestamp"`
+}
+
+// run summary outcomes
+const (
+	RunOutcomeCompleted = "completed"
+	RunOutcomeAborted   = "aborted"
+)
+
+// MgoRunSummary run level summary of a distribution,
+// reward results of this run have the same RunID as Key
+type MgoRunSummary struct {
+	Key           string   `bson:"_id"` // run ID
+	ByWhat        string   `bson:"bywhat"`
+	Exchanges     []string `bson:"exchanges"`
+	Start         uint64   `bson:"start"`
+	End           uint64   `bson:"end"`
+	SampleHeight  uint64   `bson:"sampleHeight,omitempty"`
+	RewardToken   string   `bson:"rewardToken"`
+	Sender        string   `bson:"sender"`
+	ChainID       string   `bson:"chainID"`
+	DryRun        bool     `bson:"dryRun"`
+	Config        string   `bson:"config"`
+	TotalReward   string   `bson:"totalReward"`
+	RewardsSended string   `bson:"rewardsSended"`
+	DustReward    string   `bson:"dustReward"`
+	SentCount     uint64   `bson:"sentCount"`
+	DustCount     uint64   `bson:"dustCount"`
+	FailedCount   uint64   `bson:"failedCount"`
+	GasSpent      string   `bson:"gasSpent"`
+	Outcome       string   `bson:"outcome"`
+	Error         string   `bson:"error,omitempty"`
+	StartTime     uint64   `bson:"startTime"`
+	EndTime       uint64   `bson:"endTime"`
+}
+
+// MgoPaidKey idempotency key of paid recipient
+type MgoPaidKey struct {
+	Key         string `bson:"_id"` // idempotency key
+	Account     string `bson:"account"`
+	Reward      string `bson:"reward"`
+	RewardToken string `bson:"rewardToken"`
+	TxHash      string `bson:"txhash"`
+	RunID       string `bson:"runID,omitempty"`
 	Timestamp   uint64 `bson:"timestamp"`
 }
 
@@ -243,3 +309,8 @@ func GetKeyOfLiquidityBalance(exchange, account string, blockNumber uint64) stri
 func GetKeyOfVolumeHistory(txhash string, logIndex int) string {
 	return fmt.Sprintf("%s:%d", txhash, logIndex)
 }
+
+// GetKeyOfSyncChunk get key
+func GetKeyOfSyncChunk(from, to uint64) string {
+	return fmt.Sprintf("%d-%d", from, to)
+}
@@ -16,6 +16,19 @@ type AccountStat struct {
 	Reward  *big.Int
 	Share   *big.Int // volume or liquidity
 	Number  uint64   // txcount or height
+
+	// optional hash of block at height Number the share is read at (snapshot provenance)
+	BlockHash *common.Hash
+
+	// per recipient gas overrides from input file, nil means use default
+	GasLimit *uint64
+	GasPrice *big.Int
+
+	// optional idempotency key from input file, recipient is paid at most once per key
+	IdempotencyKey string
+
+	// optional chain name from input file, recipient is paid on this chain in multichain sending
+	Chain string
 }
 
 func (s *AccountStat) String() string {
@@ -202,3 +215,10 @@ func DivideRewards(totalReward *big.Int, totalShareSlice []*big.Int) (rewards []
 	}
 	return rewards
 }
+
+// UnsentReward summary of accrued but not sended rewards of a reward token
+type UnsentReward struct {
+	RewardToken string
+	Records     uint64
+	Reward      *big.Int
+}
@@ -26,6 +26,10 @@ func CheckConfig() (err error) {
 	case config.Exchanges == nil:
 		return errors.New("must config Exchanges")
 	}
+	err = CheckGatewayEndpoints(config.Gateway.Endpoints)
+	if err != nil {
+		return err
+	}
 	err = checkExchangeConfig()
 	if err != nil {
 		return err
@@ -247,3 +251,47 @@ func (dist *DistributeConfig) GetDustRewardThreshold() *big.Int {
 	}
 	return dustThreshold
 }
+
+// CheckGatewayEndpoints check gateway endpoints
+func CheckGatewayEndpoints(endpoints []*GatewayEndpoint) error {
+	for _, endpoint := range endpoints {
+		if endpoint.URL == "" {
+			return errors.New("empty gateway endpoint URL")
+		}
+		switch endpoint.Role {
+		case "", GatewayRolePrimary, GatewayRoleArchive:
+		default:
+			return fmt.Errorf("unknown role '%v' of gateway endpoint %v", endpoint.Role, endpoint.URL)
+		}
+	}
+	return nil
+}
+
+// CheckChains check chains of multichain sending
+func CheckChains(chains []*ChainConfig) error {
+	names := make(map[string]struct{}, len(chains))
+	for _, chain := range chains {
+		if chain.Name == "" {
+			return errors.New("empty chain name")
+		}
+		if _, exist := names[chain.Name]; exist {
+			return fmt.Errorf("duplicate chain name '%v'", chain.Name)
+		}
+		names[chain.Name] = struct{}{}
+		if chain.ChainID == 0 {
+			return fmt.Errorf("must specify chain ID of chain '%v'", chain.Name)
+		}
+		if len(chain.APIAddress) == 0 {
+			return fmt.Errorf("must specify api address of chain '%v'", chain.Name)
+		}
+		if chain.RewardToken != "" && !common.IsHexAddress(chain.RewardToken) {
+			return fmt.Errorf("wrong reward token '%v' of chain '%v'", chain.RewardToken, chain.Name)
+		}
+		if chain.GasPrice != "" {
+			if _, err := tools.GetBigIntFromString(chain.GasPrice); err != nil {
+				return fmt.Errorf("wrong gas price '%v' of chain '%v'", chain.GasPrice, chain.Name)
+			}
+		}
+	}
+	return nil
+}
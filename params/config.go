@@ -1,6 +1,7 @@
 package params
 
 import (
+	"fmt"
 	"math"
 	"math/big"
 	"strings"
@@ -50,6 +51,83 @@ type MongoDBConfig struct {
 type GatewayConfig struct {
 	APIAddress       []string
 	AverageBlockTime uint64
+
+	// gateways with weight and role, used together with APIAddress
+	Endpoints []*GatewayEndpoint
+
+	DropMismatchChainClient bool
+}
+
+// gateway roles
+const (
+	GatewayRolePrimary = "primary"
+	GatewayRoleArchive = "archive"
+)
+
+// GatewayEndpoint gateway with weight in client selection and role (primary or archive)
+type GatewayEndpoint struct {
+	URL    string
+	Weight uint64
+	Role   string
+}
+
+// GatewayFileConfig gateway list file
+type GatewayFileConfig struct {
+	Endpoints []*GatewayEndpoint
+}
+
+// GetAPIAddress get URLs of APIAddress and Endpoints
+func (c *GatewayConfig) GetAPIAddress() []string {
+	urls := make([]string, 0, len(c.APIAddress)+len(c.Endpoints))
+	urls = append(urls, c.APIAddress...)
+	for _, endpoint := range c.Endpoints {
+		urls = append(urls, endpoint.URL)
+	}
+	return urls
+}
+
+// LoadGatewayFile load gateway endpoints from toml file
+func LoadGatewayFile(fileName string) ([]*GatewayEndpoint, error) {
+	gatewayConfig := &GatewayFileConfig{}
+	if _, err := toml.DecodeFile(fileName, gatewayConfig); err != nil {
+		return nil, err
+	}
+	if len(gatewayConfig.Endpoints) == 0 {
+		return nil, fmt.Errorf("no endpoints in gateway file %v", fileName)
+	}
+	if err := CheckGatewayEndpoints(gatewayConfig.Endpoints); err != nil {
+		return nil, err
+	}
+	return gatewayConfig.Endpoints, nil
+}
+
+// ChainConfig a chain to send rewards on in multichain sending
+type ChainConfig struct {
+	Name        string
+	ChainID     uint64 // expected chain ID, checked after dial
+	APIAddress  []string
+	RewardToken string // empty means native coin
+	GasPrice    string // empty means suggested gas price of the chain
+}
+
+// ChainsFileConfig chain list file of multichain sending
+type ChainsFileConfig struct {
+	Chains []*ChainConfig `toml:"Chain"`
+}
+
+// LoadChainsFile load chains of multichain sending from toml file
+func LoadChainsFile(fileName string) ([]*ChainConfig, error) {
+	chainsConfig := &ChainsFileConfig{}
+	if _, err := toml.DecodeFile(fileName, chainsConfig); err != nil {
+		return nil, err
+	}
+	if len(chainsConfig.Chains) == 0 {
+		return nil, fmt.Errorf("no chains in chains file %v", fileName)
+	}
+	if err := CheckChains(chainsConfig.Chains); err != nil {
+		return nil, err
+	}
+	return chainsConfig.Chains, nil
 }
 
 // StakeConfig struct
@@ -80,6 +158,7 @@ type SyncConfig struct {
 	UpdateVolume       bool
 	ScanAllExchange    bool
 	RecordTokenAccount bool
+	ChunkSize          uint64 // blocks of a checkpointed chunk in range syncing
 }
 
 // ExchangeConfig exchange config
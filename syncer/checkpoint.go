@@ -0,0 +1,77 @@
+package syncer
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/anyswap/ANYToken-distribution/mongodb"
+)
+
+const defaultChunkSize uint64 = 10000
+
+var (
+	// blocks of a chunk in range syncing, chunks are aligned to multiples of it
+	chunkSize = defaultChunkSize
+
+	// count of block and derived records failed to store,
+	// a chunk is not checkpointed if any failure happens while syncing it
+	recordFailures uint64
+)
+
+// tryDoRecord store block or derived record of block
+func tryDoRecord(name string, f func() error) {
+	if err := mongodb.TryDoTimes(name, f); err != nil {
+		atomic.AddUint64(&recordFailures, 1)
+	}
+}
+
+// getChunkEnd end (including) of chunk from is in, not greater than end
+func getChunkEnd(from, end uint64) uint64 {
+	to := (from/chunkSize+1)*chunkSize - 1
+	if to > end {
+		to = end
+	}
+	return to
+}
+
+// isChunkCompleted is chunk checkpointed, overwrite always sync again
+func isChunkCompleted(from, to uint64) bool {
+	if overwrite {
+		return false
+	}
+	completed, err := mongodb.IsSyncChunkCompleted(from, to)
+	if err != nil {
+		log.Warn("[syncer] find sync chunk checkpoint failed", "from", from, "to", to, "err", err)
+		return false
+	}
+	return completed
+}
+
+// checkpointChunk wait all blocks of chunk parsed and stored, then checkpoint it.
+// mgo has no multi-document transaction, so the checkpoint is advanced only after
+// all derived records of the chunk are stored. records are keyed by block, tx hash
+// and log index, storing them again when an unfinished chunk is resumed is harmless.
+func (w *worker) checkpointChunk(from, to uint64, failuresBefore uint64) {
+	w.flushParser()
+	if failures := atomic.LoadUint64(&recordFailures) - failuresBefore; failures != 0 {
+		log.Warn("[syncer] store records failed, chunk is not checkpointed and will be synced again", "id", w.id, "from", from, "to", to, "failures", failures)
+		return
+	}
+	mc := &mongodb.MgoSyncChunk{
+		Key:       mongodb.GetKeyOfSyncChunk(from, to),
+		From:      from,
+		To:        to,
+		Timestamp: time.Now().Unix(),
+	}
+	_ = mongodb.TryDoTimes("AddSyncChunk "+mc.Key, func() error {
+		return mongodb.AddSyncChunk(mc)
+	})
+}
+
+// flushParser wait parser finish parsing and storing all sent blocks
+func (w *worker) flushParser() {
+	flushed := make(chan struct{})
+	w.messageChan <- &message{flushed: flushed}
+	<-flushed
+}
@@ -18,7 +18,7 @@ var (
 func dialServer() (err error) {
 	var client *ethclient.Client
 	for _, url := range serverURL {
-		client, err = ethclient.Dial(url)
+		client, err = ethclient.DialContext(cliContext, url)
 		if err != nil {
 			log.Error("[syncer] client connection error", "server", url, "err", err)
 			return err
@@ -33,6 +33,12 @@ func (w *worker) startParser(wg *sync.WaitGroup) {
 		if msg == nil {
 			return
 		}
+		if msg.flushed != nil {
+			wg2.Wait()
+			count = 0
+			close(msg.flushed)
+			continue
+		}
 		count++
 		if !onlySyncAccount {
 			wg2.Add(1)
@@ -66,7 +72,7 @@ func (w *worker) parseBlock(block *types.Block, wg *sync.WaitGroup) {
 	mb.GasUsed = block.GasUsed()
 	mb.Timestamp = block.Time().Uint64()
 
-	_ = mongodb.TryDoTimes("AddBlock "+mb.Key, func() error {
+	tryDoRecord("AddBlock "+mb.Key, func() error {
 		return mongodb.AddBlock(mb, overwrite)
 	})
 
@@ -125,7 +131,7 @@ func (w *worker) parseTx(i int, tx *types.Transaction, block *types.Block, recei
 	}
 
 	if savedb {
-		_ = mongodb.TryDoTimes("AddTransaction "+mt.Key, func() error {
+		tryDoRecord("AddTransaction "+mt.Key, func() error {
 			return mongodb.AddTransaction(mt, overwrite)
 		})
 	}
@@ -161,7 +161,7 @@ func recordAccounts(exchange, pairs, account string) {
 		Pairs:    pairs,
 		Account:  strings.ToLower(account),
 	}
-	_ = mongodb.TryDoTimes("AddAccount "+ma.Key, func() error {
+	tryDoRecord("AddAccount "+ma.Key, func() error {
 		return mongodb.AddAccount(ma)
 	})
 }
@@ -181,7 +181,7 @@ func recordTokenAccounts(token, account string) {
 		Token:   strings.ToLower(token),
 		Account: strings.ToLower(account),
 	}
-	_ = mongodb.TryDoTimes("AddTokenAccount "+ma.Key, func() error {
+	tryDoRecord("AddTokenAccount "+ma.Key, func() error {
 		return mongodb.AddTokenAccount(ma)
 	})
 }
@@ -221,7 +221,7 @@ func recordAccountVoumes(mt *mongodb.MgoTransaction, exReceipt *mongodb.Exchange
 		LogType:     exReceipt.LogType,
 		LogIndex:    exReceipt.LogIndex,
 	}
-	_ = mongodb.TryDoTimes("AddVolumeHistory "+mv.Key, func() error {
+	tryDoRecord("AddVolumeHistory "+mv.Key, func() error {
 		return mongodb.AddVolumeHistory(mv, overwrite)
 	})
 }
@@ -246,8 +246,8 @@ func updateVolumes(mt *mongodb.MgoTransaction, exReceipt *mongodb.ExchangeReceip
 		"tokenToAmount", exReceipt.TokenToAmount,
 		"timestamp", timestampToDate(mt.Timestamp))
 
-	_ = mongodb.TryDoTimes("UpdateVolume "+mt.Hash, func() error {
-		return mongodb.UpdateVolumeWithReceipt(exReceipt, mt.BlockHash, mt.BlockNumber, timestamp)
+	tryDoRecord("UpdateVolume "+mt.Hash, func() error {
+		return mongodb.UpdateVolumeWithReceipt(exReceipt, mt.Hash, mt.BlockHash, mt.BlockNumber, timestamp)
 	})
 }
 
@@ -1,10 +1,12 @@
 package syncer
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"math/big"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/anyswap/ANYToken-distribution/callapi"
@@ -45,6 +47,9 @@ var (
 type message struct {
 	block    *types.Block
 	receipts types.Receipts
+
+	// flush message, closed by parser after all previous blocks are stored
+	flushed chan struct{}
 }
 
 type worker struct {
@@ -81,7 +86,11 @@ func initConfig() {
 		waitDuration = time.Duration(waitInterval) * time.Second
 	}
 
-	serverURL = config.Gateway.APIAddress
+	if syncCfg.ChunkSize != 0 {
+		chunkSize = syncCfg.ChunkSize
+	}
+
+	serverURL = config.Gateway.GetAPIAddress()
 	stableHeight = syncCfg.Stable
 
 	applyArguments()
@@ -94,6 +103,7 @@ func initConfig() {
 		"stableHeight", stableHeight,
 		"startHeight", startHeight,
 		"endHeight", endHeight,
+		"chunkSize", chunkSize,
 	)
 }
 
@@ -108,6 +118,9 @@ func applyArguments() {
 	if args.SyncOverwrite != nil {
 		overwrite = *args.SyncOverwrite
 	}
+	if args.SyncChunkSize != nil && *args.SyncChunkSize != 0 {
+		chunkSize = *args.SyncChunkSize
+	}
 
 	if startHeight != 0 && endHeight == 0 {
 		_ = mongodb.TryDoTimes("UpdateSyncInfo "+fmt.Sprintf("%d", startHeight), func() error {
@@ -117,8 +130,13 @@ func applyArguments() {
 }
 
 // Start start syncer
-func Start(apiCaller *callapi.APICaller, onlySyncAcc bool) {
+// rpc calls of syncer are cancelled and syncing stops when ctx is done.
+func Start(ctx context.Context, apiCaller *callapi.APICaller, onlySyncAcc bool) {
 	capi = apiCaller
+	cliContext = ctx
+	if err := checkSyncInfoVersion(); err != nil {
+		log.Fatalf("[syncer] check sync state version failed. %v", err)
+	}
 	initConfig()
 	initAllExchanges()
 	newSyncer := &syncer{
@@ -154,6 +172,10 @@ func (s *syncer) sync() {
 		if err == nil {
 			break
 		}
+		if cliContext.Err() != nil {
+			log.Warn("[syncer] context is done, stop syncing", "err", cliContext.Err())
+			return
+		}
 		time.Sleep(3 * time.Second)
 	}
 	defer closeClient()
@@ -217,9 +239,14 @@ func (s *syncer) dipatchWork() {
 	}
 	stepCount := blockCount / workerCount
 
+	wend := start
 	for i := uint64(0); i < workerCount; i++ {
-		wstart := start + i*stepCount
-		wend := start + (i+1)*stepCount
+		wstart := wend
+		wend = start + (i+1)*stepCount
+		// align to chunk, so chunks are the same when resume with different workers
+		if aligned := wend / chunkSize * chunkSize; aligned > wstart {
+			wend = aligned
+		}
 		if i == workerCount-1 {
 			wend = last
 		}
@@ -314,6 +341,10 @@ func (w *worker) doSync(wg *sync.WaitGroup) {
 		if w.end > 0 && height >= w.end {
 			break
 		}
+		if cliContext.Err() != nil {
+			log.Warn("[syncer] context is done, stop sync process", "id", w.id, "height", height)
+			break
+		}
 		if height+w.stable > latest {
 			latestHeader, err := getHeaderByNumber(nil)
 			if err != nil {
@@ -358,34 +389,46 @@ func (w *worker) calcSyncPercentage(height uint64) float64 {
 	}
 }
 
+// syncRange sync blocks in chunks. in range syncing every chunk is checkpointed
+// after all its blocks are stored, and checkpointed chunks are skipped when resume.
+// a stored block does not mean its derived records are stored, so all blocks of
+// a chunk which is not checkpointed are synced (again).
 func (w *worker) syncRange(start, end uint64) {
-	step := uint64(10000)
 	height := start
 	for height <= end {
 		from := height
-		to := from + step - 1
-		if to > end {
-			to = end
-		}
-		mblocks, err := mongodb.FindBlocksInRange(from, to)
-		if err != nil {
-			log.Error("[syncer] syncRange error", "from", from, "to", to, "err", err)
-			time.Sleep(retryDuration)
-			continue
-		}
-		if !overwrite && len(mblocks) == int(to-from+1) {
-			log.Info("[syncer] syncRange already synced", "id", w.id, "from", from, "to", to)
+		to := getChunkEnd(from, end)
+		if w.end != 0 && isChunkCompleted(from, to) {
+			log.Info("[syncer] syncRange chunk already completed", "id", w.id, "from", from, "to", to)
 			height = to + 1
 			continue
 		}
-		if w.end != 0 {
-			log.Info("[syncer] syncRange", "id", w.id, "from", from, "to", to, "exist", len(mblocks))
+		failuresBefore := atomic.LoadUint64(&recordFailures)
+		var mblocks []*mongodb.MgoBlock
+		if w.end == 0 {
+			var err error
+			mblocks, err = mongodb.FindBlocksInRange(from, to)
+			if err != nil {
+				log.Error("[syncer] syncRange error", "from", from, "to", to, "err", err)
+				time.Sleep(retryDuration)
+				continue
+			}
+			if !overwrite && len(mblocks) == int(to-from+1) {
+				log.Info("[syncer] syncRange already synced", "id", w.id, "from", from, "to", to)
+				height = to + 1
+				continue
+			}
+		} else {
+			log.Info("[syncer] syncRange", "id", w.id, "from", from, "to", to)
 		}
 		for height <= to {
 			mb := getSynced(mblocks, height)
 			if overwrite || mb == nil {
 				block, err := getBlockByNumber(new(big.Int).SetUint64(height))
 				if err != nil {
+					if cliContext.Err() != nil {
+						return // interrupted, chunk is not checkpointed
+					}
 					log.Warn("[syncer] get block failed", "id", w.id, "number", height, "err", err)
 					time.Sleep(retryDuration)
 					continue
@@ -402,6 +445,7 @@ func (w *worker) syncRange(start, end uint64) {
 			height++
 		}
 		if w.end != 0 {
+			w.checkpointChunk(from, to, failuresBefore)
 			log.Info("[syncer] syncRange completed", "id", w.id, "from", from, "to", to)
 		}
 	}
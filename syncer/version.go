@@ -0,0 +1,59 @@
+package syncer
+
+import (
+	"fmt"
+
+	"github.com/anyswap/ANYToken-distribution/cmd/utils"
+	"github.com/anyswap/ANYToken-distribution/log"
+	"github.com/anyswap/ANYToken-distribution/mongodb"
+)
+
+// syncInfoMigrations migrate stored sync state from version (key) to next version.
+// version 0 is legacy unversioned sync state, which has the same format as version 1.
+var syncInfoMigrations = map[uint64]func() error{
+	0: func() error { return nil },
+}
+
+// checkSyncInfoVersion refuse to resume from stored sync state of unrecognized version
+func checkSyncInfoVersion() error {
+	syncInfo, err := mongodb.FindLatestSyncInfo()
+	if err != nil {
+		return nil // no stored sync state
+	}
+	version := syncInfo.Version
+	if version == mongodb.SyncInfoVersion {
+		return nil
+	}
+	args := utils.SyncArgs
+	switch {
+	case args.SyncReset:
+		log.Warn("[syncer] reset sync state", "storedVersion", version, "currentVersion", mongodb.SyncInfoVersion, "storedNumber", syncInfo.Number)
+		err = mongodb.UpdateSyncInfo(0, "", 0)
+		if err != nil {
+			return err
+		}
+		return mongodb.UpdateSyncInfoVersion(mongodb.SyncInfoVersion)
+	case args.SyncMigrate:
+		if version > mongodb.SyncInfoVersion {
+			return fmt.Errorf("can not migrate sync state of newer version %v to version %v", version, mongodb.SyncInfoVersion)
+		}
+		for ; version < mongodb.SyncInfoVersion; version++ {
+			migrate, exist := syncInfoMigrations[version]
+			if !exist {
+				return fmt.Errorf("no migration of sync state from version %v", version)
+			}
+			if err = migrate(); err != nil {
+				return fmt.Errorf("migrate sync state from version %v failed: %w", version, err)
+			}
+			if err = mongodb.UpdateSyncInfoVersion(version + 1); err != nil {
+				return err
+			}
+			log.Info("[syncer] migrate sync state success", "from", version, "to", version+1)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unrecognized sync state version %v (current version is %v), "+
+			"please use '--%v' to migrate it or '--%v' to reset it",
+			version, mongodb.SyncInfoVersion, utils.SyncMigrateFlag.Name, utils.SyncResetFlag.Name)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/big"
+	"strings"
 )
 
 // ToJSONString to json string
@@ -25,3 +26,21 @@ func GetBigIntFromString(str string) (*big.Int, error) {
 	}
 	return bi, nil
 }
+
+// FormatUnits format amount in smallest unit to decimal string of whole unit
+func FormatUnits(amount *big.Int, decimals uint8) string {
+	if decimals == 0 {
+		return amount.String()
+	}
+	unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	quo, rem := new(big.Int).QuoRem(new(big.Int).Abs(amount), unit, new(big.Int))
+	sign := ""
+	if amount.Sign() < 0 {
+		sign = "-"
+	}
+	if rem.Sign() == 0 {
+		return sign + quo.String()
+	}
+	frac := fmt.Sprintf("%0*s", int(decimals), rem.String())
+	return sign + quo.String() + "." + strings.TrimRight(frac, "0")
+}
@@ -0,0 +1,326 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anyswap/ANYToken-distribution/log"
+)
+
+const (
+	defaultServiceName   = "distribute"
+	defaultExportTimeout = 10 * time.Second
+	tracesPath           = "/v1/traces"
+	protocolHTTPJSON     = "http/json"
+	scopeName            = "github.com/anyswap/ANYToken-distribution"
+
+	// export finished spans when this many are buffered
+	maxExportBatchSize = 512
+)
+
+// exporter OTLP/HTTP json exporter
+type exporter struct {
+	endpoint string
+	headers  map[string]string
+	resource []*attribute
+	client   *http.Client
+
+	mu      sync.Mutex
+	pending []*Span
+	wg      sync.WaitGroup
+}
+
+var traceExporter *exporter
+
+// Enabled is tracing enabled
+func Enabled() bool {
+	return traceExporter != nil
+}
+
+// Init init exporter from standard environment variables:
+// OTEL_SDK_DISABLED, OTEL_TRACES_EXPORTER (otlp or none),
+// OTEL_EXPORTER_OTLP_[TRACES_]ENDPOINT, OTEL_EXPORTER_OTLP_[TRACES_]HEADERS,
+// OTEL_EXPORTER_OTLP_[TRACES_]PROTOCOL (only http/json), OTEL_EXPORTER_OTLP_[TRACES_]TIMEOUT,
+// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES.
+// tracing is disabled (no-op) if no endpoint is configured.
+func Init() error {
+	if strings.EqualFold(os.Getenv("OTEL_SDK_DISABLED"), "true") {
+		return nil
+	}
+	switch exporterName := os.Getenv("OTEL_TRACES_EXPORTER"); exporterName {
+	case "", "otlp":
+	case "none":
+		return nil
+	default:
+		return fmt.Errorf("unsupported traces exporter '%v', only otlp is supported", exporterName)
+	}
+	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	if endpoint == "" {
+		endpoint = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+		if endpoint == "" {
+			return nil
+		}
+		endpoint = strings.TrimSuffix(endpoint, "/") + tracesPath
+	}
+	if u, err := url.Parse(endpoint); err != nil || u.Host == "" {
+		return fmt.Errorf("wrong otlp traces endpoint '%v'", endpoint)
+	}
+	if protocol := getOTLPEnv("PROTOCOL"); protocol != "" && protocol != protocolHTTPJSON {
+		return fmt.Errorf("unsupported otlp protocol '%v', only %v is supported", protocol, protocolHTTPJSON)
+	}
+	headers, err := parseKeyValues(getOTLPEnv("HEADERS"))
+	if err != nil {
+		return fmt.Errorf("wrong otlp headers. %v", err)
+	}
+	timeout := defaultExportTimeout
+	if timeoutStr := getOTLPEnv("TIMEOUT"); timeoutStr != "" {
+		millis, errf := strconv.ParseUint(timeoutStr, 10, 64)
+		if errf != nil {
+			return fmt.Errorf("wrong otlp timeout '%v'", timeoutStr)
+		}
+		timeout = time.Duration(millis) * time.Millisecond
+	}
+	resourceAttrs, err := parseKeyValues(os.Getenv("OTEL_RESOURCE_ATTRIBUTES"))
+	if err != nil {
+		return fmt.Errorf("wrong resource attributes. %v", err)
+	}
+	serviceName := os.Getenv("OTEL_SERVICE_NAME")
+	if serviceName == "" {
+		serviceName = resourceAttrs["service.name"]
+	}
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+	resourceAttrs["service.name"] = serviceName
+	resource := make([]*attribute, 0, len(resourceAttrs))
+	for key, value := range resourceAttrs {
+		resource = append(resource, newAttribute(key, value))
+	}
+
+	traceExporter = &exporter{
+		endpoint: endpoint,
+		headers:  headers,
+		resource: resource,
+		// own transport, so exporting is not traced as rpc call
+		client: &http.Client{Timeout: timeout, Transport: &http.Transport{Proxy: http.ProxyFromEnvironment}},
+	}
+	log.Info("[tracing] enable otlp traces exporter", "endpoint", endpoint, "service", serviceName)
+	return nil
+}
+
+// getOTLPEnv get signal specific OTEL_EXPORTER_OTLP_TRACES_<name>, or general OTEL_EXPORTER_OTLP_<name>
+func getOTLPEnv(name string) string {
+	if value := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_" + name); value != "" {
+		return value
+	}
+	return os.Getenv("OTEL_EXPORTER_OTLP_" + name)
+}
+
+// parseKeyValues parse 'key1=value1,key2=value2' with url encoded values
+func parseKeyValues(s string) (map[string]string, error) {
+	result := make(map[string]string)
+	for _, kv := range strings.Split(s, ",") {
+		if strings.TrimSpace(kv) == "" {
+			continue
+		}
+		pos := strings.Index(kv, "=")
+		if pos <= 0 {
+			return nil, fmt.Errorf("wrong key value pair '%v'", kv)
+		}
+		value, err := url.QueryUnescape(strings.TrimSpace(kv[pos+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("wrong value of key '%v'", kv[:pos])
+		}
+		result[strings.TrimSpace(kv[:pos])] = value
+	}
+	return result, nil
+}
+
+// Shutdown export all finished spans and wait exporting finished
+func Shutdown() {
+	e := traceExporter
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	spans := e.pending
+	e.pending = nil
+	e.mu.Unlock()
+	if len(spans) != 0 {
+		e.wg.Add(1)
+		e.export(spans)
+	}
+	e.wg.Wait()
+}
+
+func exportSpan(span *Span) {
+	e := traceExporter
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	e.pending = append(e.pending, span)
+	if len(e.pending) < maxExportBatchSize {
+		e.mu.Unlock()
+		return
+	}
+	spans := e.pending
+	e.pending = nil
+	e.wg.Add(1)
+	e.mu.Unlock()
+	go e.export(spans)
+}
+
+func (e *exporter) export(spans []*Span) {
+	defer e.wg.Done()
+	data, err := json.Marshal(e.newExportRequest(spans))
+	if err != nil {
+		log.Warn("[tracing] marshal spans failed", "err", err)
+		return
+	}
+	req, err := http.NewRequest(http.MethodPost, e.endpoint, bytes.NewReader(data))
+	if err != nil {
+		log.Warn("[tracing] new export request failed", "err", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range e.headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		log.Warn("[tracing] export spans failed", "spans", len(spans), "err", err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		log.Warn("[tracing] export spans failed", "spans", len(spans), "status", resp.Status)
+		return
+	}
+	log.Debug("[tracing] export spans success", "spans", len(spans))
+}
+
+// otlp json encoding, see https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding
+
+type attribute struct {
+	Key   string         `json:"key"`
+	Value attributeValue `json:"value"`
+}
+
+type attributeValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"` // int64 is encoded as string
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+func newAttributes(kv []interface{}) []*attribute {
+	attrs := make([]*attribute, 0, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		attrs = append(attrs, newAttribute(fmt.Sprint(kv[i]), kv[i+1]))
+	}
+	return attrs
+}
+
+func newAttribute(key string, value interface{}) *attribute {
+	attr := &attribute{Key: key}
+	var intStr, str string
+	switch v := value.(type) {
+	case bool:
+		attr.Value.BoolValue = &v
+		return attr
+	case float64:
+		attr.Value.DoubleValue = &v
+		return attr
+	case int:
+		intStr = strconv.FormatInt(int64(v), 10)
+	case int64:
+		intStr = strconv.FormatInt(v, 10)
+	case uint64:
+		intStr = strconv.FormatUint(v, 10)
+	case *big.Int:
+		str = fmt.Sprint(v) // may overflow int64
+	case fmt.Stringer:
+		str = v.String()
+	default:
+		str = fmt.Sprint(v)
+	}
+	if intStr != "" {
+		attr.Value.IntValue = &intStr
+	} else {
+		attr.Value.StringValue = &str
+	}
+	return attr
+}
+
+type exportRequest struct {
+	ResourceSpans []*resourceSpans `json:"resourceSpans"`
+}
+
+type resourceSpans struct {
+	Resource struct {
+		Attributes []*attribute `json:"attributes"`
+	} `json:"resource"`
+	ScopeSpans []*scopeSpans `json:"scopeSpans"`
+}
+
+type scopeSpans struct {
+	Scope struct {
+		Name string `json:"name"`
+	} `json:"scope"`
+	Spans []*otlpSpan `json:"spans"`
+}
+
+type otlpSpan struct {
+	TraceID           string       `json:"traceId"`
+	SpanID            string       `json:"spanId"`
+	ParentSpanID      string       `json:"parentSpanId,omitempty"`
+	Name              string       `json:"name"`
+	Kind              int          `json:"kind"`
+	StartTimeUnixNano string       `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string       `json:"endTimeUnixNano"`
+	Attributes        []*attribute `json:"attributes,omitempty"`
+	Status            struct {
+		Code    int    `json:"code,omitempty"` // 1 ok, 2 error
+		Message string `json:"message,omitempty"`
+	} `json:"status"`
+}
+
+func (e *exporter) newExportRequest(spans []*Span) *exportRequest {
+	scope := &scopeSpans{Spans: make([]*otlpSpan, 0, len(spans))}
+	scope.Scope.Name = scopeName
+	spanMu.Lock()
+	for _, span := range spans {
+		ospan := &otlpSpan{
+			TraceID:           hex.EncodeToString(span.traceID[:]),
+			SpanID:            hex.EncodeToString(span.spanID[:]),
+			Name:              span.name,
+			Kind:              span.kind,
+			StartTimeUnixNano: strconv.FormatInt(span.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.end.UnixNano(), 10),
+			Attributes:        span.attrs,
+		}
+		if span.parent != nil {
+			ospan.ParentSpanID = hex.EncodeToString(span.parent.spanID[:])
+		}
+		if span.isFailed {
+			ospan.Status.Code = 2
+			ospan.Status.Message = span.errMsg
+		}
+		scope.Spans = append(scope.Spans, ospan)
+	}
+	spanMu.Unlock()
+	rs := &resourceSpans{ScopeSpans: []*scopeSpans{scope}}
+	rs.Resource.Attributes = e.resource
+	return &exportRequest{ResourceSpans: []*resourceSpans{rs}}
+}
@@ -0,0 +1,124 @@
+// Package tracing emit OpenTelemetry traces of runs by OTLP/HTTP json exporter.
+// exporter is configured by standard OTEL_* environment variables,
+// everything is no-op if no exporter endpoint is configured.
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// span kinds
+const (
+	spanKindInternal = 1
+	spanKindClient   = 3
+)
+
+// Span a timed operation of trace, nil span (tracing is disabled) is no-op.
+// spans started by StartSpan become current span until they end,
+// so they must be ended in reverse order (which defer does).
+type Span struct {
+	traceID  [16]byte
+	spanID   [8]byte
+	parent   *Span
+	name     string
+	kind     int
+	start    time.Time
+	end      time.Time
+	attrs    []*attribute
+	errMsg   string
+	isFailed bool
+	current  bool
+}
+
+var (
+	spanMu      sync.Mutex
+	currentSpan *Span
+)
+
+// StartSpan start span as child of current span (or root span of a new trace
+// if there's no current span), it becomes current span until it ends.
+// kv are attributes in key value pairs like logging.
+func StartSpan(name string, kv ...interface{}) *Span {
+	if !Enabled() {
+		return nil
+	}
+	spanMu.Lock()
+	defer spanMu.Unlock()
+	span := newSpan(currentSpan, name, spanKindInternal, kv)
+	span.current = true
+	currentSpan = span
+	return span
+}
+
+// StartClientSpan start client span (eg. rpc call) as child of current span,
+// it does not become current span, so it can be used concurrently.
+// return nil if there's no current span, so calls outside of traced
+// operations (eg. syncing) do not flood the exporter with single span traces.
+func StartClientSpan(name string, kv ...interface{}) *Span {
+	if !Enabled() {
+		return nil
+	}
+	spanMu.Lock()
+	defer spanMu.Unlock()
+	if currentSpan == nil {
+		return nil
+	}
+	return newSpan(currentSpan, name, spanKindClient, kv)
+}
+
+func newSpan(parent *Span, name string, kind int, kv []interface{}) *Span {
+	span := &Span{
+		parent: parent,
+		name:   name,
+		kind:   kind,
+		start:  time.Now(),
+		attrs:  newAttributes(kv),
+	}
+	if parent != nil {
+		span.traceID = parent.traceID
+	} else {
+		_, _ = rand.Read(span.traceID[:])
+	}
+	_, _ = rand.Read(span.spanID[:])
+	return span
+}
+
+// SetAttributes add attributes in key value pairs
+func (s *Span) SetAttributes(kv ...interface{}) {
+	if s == nil {
+		return
+	}
+	spanMu.Lock()
+	defer spanMu.Unlock()
+	s.attrs = append(s.attrs, newAttributes(kv)...)
+}
+
+// End end span, span status is error if err is not nil.
+// the parent of span becomes current span if span is current span.
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	spanMu.Lock()
+	s.end = time.Now()
+	if err != nil {
+		s.isFailed = true
+		s.errMsg = err.Error()
+	}
+	if s.current && currentSpan == s {
+		currentSpan = s.parent
+	}
+	spanMu.Unlock()
+	exportSpan(s)
+}
+
+// TraceID hex trace ID of span, for logging
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return hex.EncodeToString(s.traceID[:])
+}
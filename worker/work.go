@@ -1,6 +1,8 @@
 package worker
 
 import (
+	"context"
+
 	"github.com/anyswap/ANYToken-distribution/callapi"
 	"github.com/anyswap/ANYToken-distribution/distributer"
 	"github.com/anyswap/ANYToken-distribution/syncer"
@@ -8,11 +10,11 @@ import (
 
 var capi *callapi.APICaller
 
-// StartWork start all work
-func StartWork(apiCaller *callapi.APICaller, onlySyncAccount bool) {
+// StartWork start all work, and return when ctx is done
+func StartWork(ctx context.Context, apiCaller *callapi.APICaller, onlySyncAccount bool) {
 	capi = apiCaller
 
-	syncer.Start(capi, onlySyncAccount)
+	syncer.Start(ctx, capi, onlySyncAccount)
 
 	if onlySyncAccount {
 		return
@@ -22,6 +24,5 @@ func StartWork(apiCaller *callapi.APICaller, onlySyncAccount bool) {
 
 	distributer.Start(capi)
 
-	exitCh := make(chan struct{})
-	<-exitCh
+	<-ctx.Done()
 }